@@ -1,6 +1,8 @@
 package flags
 
 import (
+	"time"
+
 	"github.com/urfave/cli"
 )
 
@@ -27,6 +29,40 @@ var (
 		Usage:  "L2 Chain ID",
 		EnvVar: "GAS_PRICE_ORACLE_L2_CHAIN_ID",
 	}
+	L1BaseFeeSmoothingFlag = cli.StringFlag{
+		Name:   "l1-base-fee-smoothing",
+		Value:  "latest",
+		Usage:  "L1 base fee smoothing: latest, ema, median or twap",
+		EnvVar: "GAS_PRICE_ORACLE_L1_BASE_FEE_SMOOTHING",
+	}
+	L1BaseFeeEMAAlphaFlag = cli.Float64Flag{
+		Name:   "l1-base-fee-ema-alpha",
+		Value:  0.2,
+		Usage:  "weight of the latest L1 block in the ema of the base fee",
+		EnvVar: "GAS_PRICE_ORACLE_L1_BASE_FEE_EMA_ALPHA",
+	}
+	L1BaseFeeMedianBlocksFlag = cli.IntFlag{
+		Name:   "l1-base-fee-median-blocks",
+		Value:  10,
+		Usage:  "number of the last L1 blocks of the median of the base fee",
+		EnvVar: "GAS_PRICE_ORACLE_L1_BASE_FEE_MEDIAN_BLOCKS",
+	}
+	L1BaseFeeTWAPWindowFlag = cli.DurationFlag{
+		Name:   "l1-base-fee-twap-window",
+		Value:  2 * time.Minute,
+		Usage:  "time span of the time weighted average of the base fee",
+		EnvVar: "GAS_PRICE_ORACLE_L1_BASE_FEE_TWAP_WINDOW",
+	}
+	L1BaseFeeMaxRiseFlag = cli.Float64Flag{
+		Name:   "l1-base-fee-max-rise",
+		Usage:  "max percent rise of the L1 base fee per update, 0 does not limit it",
+		EnvVar: "GAS_PRICE_ORACLE_L1_BASE_FEE_MAX_RISE",
+	}
+	L1BaseFeeMaxFallFlag = cli.Float64Flag{
+		Name:   "l1-base-fee-max-fall",
+		Usage:  "max percent fall of the L1 base fee per update, 0 does not limit it",
+		EnvVar: "GAS_PRICE_ORACLE_L1_BASE_FEE_MAX_FALL",
+	}
 	GasPriceOracleAddressFlag = cli.StringFlag{
 		Name:   "gas-price-oracle-address",
 		Usage:  "Address of OVM_GasPriceOracle",
@@ -53,6 +89,69 @@ var (
 		Usage:  "Enable updating the L2 gas price",
 		EnvVar: "GAS_PRICE_ORACLE_ENABLE_L2_GAS_PRICE",
 	}
+	EnableFeeCalibrationFlag = cli.BoolFlag{
+		Name:   "enable-l1-fee-calibration",
+		Usage:  "Enable calibrating the L1 fee overhead and scalar from the cost of the batches",
+		EnvVar: "GAS_PRICE_ORACLE_ENABLE_L1_FEE_CALIBRATION",
+	}
+	FeeCalibrationReportOnlyFlag = cli.BoolFlag{
+		Name:   "l1-fee-calibration-report-only",
+		Usage:  "Only report the calibrated overhead and scalar, do not set them",
+		EnvVar: "GAS_PRICE_ORACLE_L1_FEE_CALIBRATION_REPORT_ONLY",
+	}
+	CTCAddressFlag = cli.StringFlag{
+		Name:   "ctc-address",
+		Usage:  "Address of the CanonicalTransactionChain on L1",
+		EnvVar: "GAS_PRICE_ORACLE_CTC_ADDRESS",
+	}
+	FeeCalibrationIntervalFlag = cli.DurationFlag{
+		Name:   "l1-fee-calibration-interval",
+		Value:  time.Hour,
+		Usage:  "time between two calibrations of the L1 fee",
+		EnvVar: "GAS_PRICE_ORACLE_L1_FEE_CALIBRATION_INTERVAL",
+	}
+	FeeCalibrationWindowFlag = cli.Uint64Flag{
+		Name:   "l1-fee-calibration-window",
+		Value:  1000,
+		Usage:  "number of the last L1 blocks whose batches the L1 fee is calibrated on",
+		EnvVar: "GAS_PRICE_ORACLE_L1_FEE_CALIBRATION_WINDOW",
+	}
+	FeeCalibrationMaxChangeFlag = cli.Float64Flag{
+		Name:   "l1-fee-calibration-max-change",
+		Value:  0.2,
+		Usage:  "max percent change of the overhead and the scalar per calibration",
+		EnvVar: "GAS_PRICE_ORACLE_L1_FEE_CALIBRATION_MAX_CHANGE",
+	}
+	FeeCalibrationSignificanceFactorFlag = cli.Float64Flag{
+		Name:   "l1-fee-calibration-significant-factor",
+		Value:  0.05,
+		Usage:  "only update the overhead or the scalar when it changes by more than this factor",
+		EnvVar: "GAS_PRICE_ORACLE_L1_FEE_CALIBRATION_SIGNIFICANT_FACTOR",
+	}
+	MinOverheadFlag = cli.Uint64Flag{
+		Name:   "min-overhead",
+		Value:  0,
+		Usage:  "lowest overhead the calibration sets",
+		EnvVar: "GAS_PRICE_ORACLE_MIN_OVERHEAD",
+	}
+	MaxOverheadFlag = cli.Uint64Flag{
+		Name:   "max-overhead",
+		Value:  10_000,
+		Usage:  "highest overhead the calibration sets",
+		EnvVar: "GAS_PRICE_ORACLE_MAX_OVERHEAD",
+	}
+	MinScalarFlag = cli.Uint64Flag{
+		Name:   "min-scalar",
+		Value:  0,
+		Usage:  "lowest scalar the calibration sets, in units of the decimals of the oracle",
+		EnvVar: "GAS_PRICE_ORACLE_MIN_SCALAR",
+	}
+	MaxScalarFlag = cli.Uint64Flag{
+		Name:   "max-scalar",
+		Value:  10_000_000,
+		Usage:  "highest scalar the calibration sets, in units of the decimals of the oracle",
+		EnvVar: "GAS_PRICE_ORACLE_MAX_SCALAR",
+	}
 	LogLevelFlag = cli.IntFlag{
 		Name:   "loglevel",
 		Value:  3,
@@ -77,6 +176,54 @@ var (
 		Usage:  "max percent change of gas price per second",
 		EnvVar: "GAS_PRICE_ORACLE_MAX_PERCENT_CHANGE_PER_EPOCH",
 	}
+	L2GasPricingStrategyFlag = cli.StringFlag{
+		Name:   "l2-gas-pricing-strategy",
+		Value:  "proportional",
+		Usage:  "L2 gas pricing strategy: proportional, eip1559, pid or percentile",
+		EnvVar: "GAS_PRICE_ORACLE_L2_GAS_PRICING_STRATEGY",
+	}
+	TargetGasPerBlockFlag = cli.Uint64Flag{
+		Name:   "target-gas-per-block",
+		Value:  5_500_000,
+		Usage:  "target gas used per block of the eip1559 and percentile strategies",
+		EnvVar: "GAS_PRICE_ORACLE_TARGET_GAS_PER_BLOCK",
+	}
+	EIP1559DenominatorFlag = cli.Uint64Flag{
+		Name:   "eip1559-change-denominator",
+		Value:  8,
+		Usage:  "inverse of the max change of the gas price per block of the eip1559 strategy",
+		EnvVar: "GAS_PRICE_ORACLE_EIP1559_CHANGE_DENOMINATOR",
+	}
+	PIDKpFlag = cli.Float64Flag{
+		Name:   "pid-kp",
+		Value:  0.5,
+		Usage:  "proportional gain of the pid strategy",
+		EnvVar: "GAS_PRICE_ORACLE_PID_KP",
+	}
+	PIDKiFlag = cli.Float64Flag{
+		Name:   "pid-ki",
+		Value:  0.05,
+		Usage:  "integral gain of the pid strategy",
+		EnvVar: "GAS_PRICE_ORACLE_PID_KI",
+	}
+	PIDKdFlag = cli.Float64Flag{
+		Name:   "pid-kd",
+		Value:  0.1,
+		Usage:  "derivative gain of the pid strategy",
+		EnvVar: "GAS_PRICE_ORACLE_PID_KD",
+	}
+	CongestionPercentileFlag = cli.Float64Flag{
+		Name:   "congestion-percentile",
+		Value:  90,
+		Usage:  "percentile of the gas used per block that the percentile strategy prices on",
+		EnvVar: "GAS_PRICE_ORACLE_CONGESTION_PERCENTILE",
+	}
+	CongestionWindowFlag = cli.IntFlag{
+		Name:   "congestion-window-blocks",
+		Value:  1000,
+		Usage:  "number of the last blocks the percentile strategy looks at",
+		EnvVar: "GAS_PRICE_ORACLE_CONGESTION_WINDOW_BLOCKS",
+	}
 	AverageBlockGasLimitPerEpochFlag = cli.Float64Flag{
 		Name:   "average-block-gas-limit-per-epoch",
 		Value:  11_000_000,
@@ -106,6 +253,29 @@ var (
 		Usage:  "wait for receipts when sending transactions",
 		EnvVar: "GAS_PRICE_ORACLE_WAIT_FOR_RECEIPT",
 	}
+	TxResubmissionTimeoutFlag = cli.DurationFlag{
+		Name:   "tx-resubmission-timeout",
+		Value:  time.Minute,
+		Usage:  "replace a transaction with a higher gas price when it is not mined for this long",
+		EnvVar: "GAS_PRICE_ORACLE_TX_RESUBMISSION_TIMEOUT",
+	}
+	TxPriceBumpFlag = cli.Float64Flag{
+		Name:   "tx-price-bump",
+		Value:  0.15,
+		Usage:  "relative gas price increase of a replacement transaction, at least 0.1",
+		EnvVar: "GAS_PRICE_ORACLE_TX_PRICE_BUMP",
+	}
+	TxMaxGasPriceFlag = cli.Uint64Flag{
+		Name:   "tx-max-gas-price",
+		Usage:  "cap on the gas price of replacement transactions, not setting it does not cap it",
+		EnvVar: "GAS_PRICE_ORACLE_TX_MAX_GAS_PRICE",
+	}
+	TxPollIntervalFlag = cli.DurationFlag{
+		Name:   "tx-poll-interval",
+		Value:  5 * time.Second,
+		Usage:  "time between two checks of the pending transactions",
+		EnvVar: "GAS_PRICE_ORACLE_TX_POLL_INTERVAL",
+	}
 	MetricsEnabledFlag = cli.BoolFlag{
 		Name:   "metrics",
 		Usage:  "Enable metrics collection and reporting",
@@ -154,12 +324,31 @@ var (
 	}
 )
 
+// Flags of the backfill-l1-base-fee command
+var (
+	BackfillBlocksFlag = cli.Uint64Flag{
+		Name:  "blocks",
+		Value: 1000,
+		Usage: "number of the last L1 blocks to backfill",
+	}
+	BackfillOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "file to write the CSV series to, stdout if not set",
+	}
+)
+
 var Flags = []cli.Flag{
 	EthereumHttpUrlFlag,
 	LayerTwoHttpUrlFlag,
 	L1ChainIDFlag,
 	L2ChainIDFlag,
 	L1BaseFeeSignificanceFactorFlag,
+	L1BaseFeeSmoothingFlag,
+	L1BaseFeeEMAAlphaFlag,
+	L1BaseFeeMedianBlocksFlag,
+	L1BaseFeeTWAPWindowFlag,
+	L1BaseFeeMaxRiseFlag,
+	L1BaseFeeMaxFallFlag,
 	GasPriceOracleAddressFlag,
 	PrivateKeyFlag,
 	TransactionGasPriceFlag,
@@ -167,12 +356,35 @@ var Flags = []cli.Flag{
 	FloorPriceFlag,
 	TargetGasPerSecondFlag,
 	MaxPercentChangePerEpochFlag,
+	L2GasPricingStrategyFlag,
+	TargetGasPerBlockFlag,
+	EIP1559DenominatorFlag,
+	PIDKpFlag,
+	PIDKiFlag,
+	PIDKdFlag,
+	CongestionPercentileFlag,
+	CongestionWindowFlag,
 	AverageBlockGasLimitPerEpochFlag,
 	EpochLengthSecondsFlag,
 	L2GasPriceSignificanceFactorFlag,
 	WaitForReceiptFlag,
+	TxResubmissionTimeoutFlag,
+	TxPriceBumpFlag,
+	TxMaxGasPriceFlag,
+	TxPollIntervalFlag,
 	EnableL1BaseFeeFlag,
 	EnableL2GasPriceFlag,
+	EnableFeeCalibrationFlag,
+	FeeCalibrationReportOnlyFlag,
+	CTCAddressFlag,
+	FeeCalibrationIntervalFlag,
+	FeeCalibrationWindowFlag,
+	FeeCalibrationMaxChangeFlag,
+	FeeCalibrationSignificanceFactorFlag,
+	MinOverheadFlag,
+	MaxOverheadFlag,
+	MinScalarFlag,
+	MaxScalarFlag,
 	MetricsEnabledFlag,
 	MetricsHTTPFlag,
 	MetricsPortFlag,
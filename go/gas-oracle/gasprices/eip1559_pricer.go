@@ -0,0 +1,83 @@
+package gasprices
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// EIP1559Pricer moves the price exponentially in the distance of the gas used
+// by each block from a per block target, like the EIP-1559 base fee. A block
+// at twice the target raises the price by e^(1/denominator), an empty block
+// lowers it by as much. The change over an epoch is bounded by
+// maxChangePerEpoch like the other strategies.
+type EIP1559Pricer struct {
+	curPrice          uint64
+	floorPrice        uint64
+	targetGasPerBlock uint64
+	denominator       uint64
+	maxChangePerEpoch float64
+}
+
+// NewEIP1559Pricer creates an EIP1559Pricer and checks its config beforehand
+func NewEIP1559Pricer(curPrice, floorPrice, targetGasPerBlock, denominator uint64, maxPercentChangePerEpoch float64) (*EIP1559Pricer, error) {
+	if err := checkFloorPrice(floorPrice); err != nil {
+		return nil, err
+	}
+	if targetGasPerBlock < 1 {
+		return nil, errors.New("targetGasPerBlock must be greater than or equal to 1")
+	}
+	if denominator < 1 {
+		return nil, errors.New("denominator must be greater than or equal to 1")
+	}
+	if maxPercentChangePerEpoch <= 0 {
+		return nil, errors.New("maxPercentChangePerEpoch must be between (0,100]")
+	}
+	return &EIP1559Pricer{
+		curPrice:          max(curPrice, floorPrice),
+		floorPrice:        floorPrice,
+		targetGasPerBlock: targetGasPerBlock,
+		denominator:       denominator,
+		maxChangePerEpoch: maxPercentChangePerEpoch,
+	}, nil
+}
+
+// Name returns the name of the strategy
+func (p *EIP1559Pricer) Name() string {
+	return StrategyEIP1559
+}
+
+// CurrentPrice returns the gas price of the current epoch
+func (p *EIP1559Pricer) CurrentPrice() uint64 {
+	return p.curPrice
+}
+
+// UpdatePrice applies the change of every block of the epoch, bounded by the
+// max change per epoch so that a long epoch does not compound into a large
+// step. An epoch without blocks counts as one empty block so that the price
+// of an idle chain falls to the floor.
+func (p *EIP1559Pricer) UpdatePrice(load *EpochLoad) (uint64, error) {
+	blocks := load.BlockGasUsed
+	if len(blocks) == 0 {
+		blocks = []uint64{0}
+	}
+	target := float64(p.targetGasPerBlock)
+	exponent := 0.0
+	for _, used := range blocks {
+		// Like EIP-1559 with an elasticity of 2, a block counts for at
+		// most twice the target
+		exponent += math.Min((float64(used)-target)/target, 1)
+	}
+	exponent /= float64(p.denominator)
+	proportionToChangeBy := math.Max(1-p.maxChangePerEpoch, math.Min(math.Exp(exponent), 1+p.maxChangePerEpoch))
+	updated := float64(max(1, p.curPrice)) * proportionToChangeBy
+	result := p.floorPrice
+	if updated > float64(p.floorPrice) {
+		result = uint64(math.Ceil(updated))
+	}
+	log.Debug("Calculated next epoch gas price", "strategy", StrategyEIP1559, "blocks", len(load.BlockGasUsed),
+		"exponent", exponent, "proportionToChangeBy", proportionToChangeBy, "result", result)
+	p.curPrice = result
+	return result, nil
+}
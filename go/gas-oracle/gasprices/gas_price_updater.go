@@ -2,6 +2,7 @@ package gasprices
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -9,15 +10,21 @@ import (
 
 type GetLatestBlockNumberFn func() (uint64, error)
 type UpdateL2GasPriceFn func(uint64) error
+type GetBlockGasUsedFn func(from, to uint64) ([]uint64, error)
+
+// maxEpochBlocks bounds the number of blocks of an epoch whose gas used is
+// fetched, only the last ones are sampled after a long pause
+const maxEpochBlocks = 1024
 
 type GasPriceUpdater struct {
 	mu                     *sync.RWMutex
-	gasPricer              *GasPricer
+	strategy               PricingStrategy
 	epochStartBlockNumber  uint64
 	averageBlockGasLimit   float64
 	epochLengthSeconds     uint64
 	getLatestBlockNumberFn GetLatestBlockNumberFn
 	updateL2GasPriceFn     UpdateL2GasPriceFn
+	getBlockGasUsedFn      GetBlockGasUsedFn
 }
 
 func GetAverageGasPerSecond(
@@ -31,7 +38,7 @@ func GetAverageGasPerSecond(
 }
 
 func NewGasPriceUpdater(
-	gasPricer *GasPricer,
+	strategy PricingStrategy,
 	epochStartBlockNumber uint64,
 	averageBlockGasLimit float64,
 	epochLengthSeconds uint64,
@@ -46,7 +53,7 @@ func NewGasPriceUpdater(
 	}
 	return &GasPriceUpdater{
 		mu:                     new(sync.RWMutex),
-		gasPricer:              gasPricer,
+		strategy:               strategy,
 		epochStartBlockNumber:  epochStartBlockNumber,
 		epochLengthSeconds:     epochLengthSeconds,
 		averageBlockGasLimit:   averageBlockGasLimit,
@@ -72,21 +79,65 @@ func (g *GasPriceUpdater) UpdateGasPrice() error {
 		uint64(g.epochLengthSeconds),
 		uint64(g.averageBlockGasLimit),
 	)
-	log.Debug("UpdateGasPrice", "averageGasPerSecond", averageGasPerSecond, "current-price", g.gasPricer.curPrice)
-	_, err = g.gasPricer.CompleteEpoch(averageGasPerSecond)
+	blockGasUsed, err := g.epochBlockGasUsed(latestBlockNumber)
+	if err != nil {
+		return err
+	}
+	log.Debug("UpdateGasPrice", "strategy", g.strategy.Name(), "averageGasPerSecond", averageGasPerSecond,
+		"blocks", len(blockGasUsed), "current-price", g.strategy.CurrentPrice())
+	gasPrice, err := g.strategy.UpdatePrice(&EpochLoad{
+		GasPerSecond: averageGasPerSecond,
+		BlockGasUsed: blockGasUsed,
+	})
 	if err != nil {
 		return err
 	}
 	g.epochStartBlockNumber = latestBlockNumber
-	err = g.updateL2GasPriceFn(g.gasPricer.curPrice)
+	err = g.updateL2GasPriceFn(gasPrice)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// SetGetBlockGasUsedFn sets how the gas used by a range of blocks is
+// fetched. Without it every block of an epoch is assumed to use the average
+// block gas limit.
+func (g *GasPriceUpdater) SetGetBlockGasUsedFn(fn GetBlockGasUsedFn) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.getBlockGasUsedFn = fn
+}
+
+// epochBlockGasUsed returns the gas used by the blocks after the start of
+// the epoch up to latestBlockNumber
+func (g *GasPriceUpdater) epochBlockGasUsed(latestBlockNumber uint64) ([]uint64, error) {
+	start := g.epochStartBlockNumber + 1
+	if latestBlockNumber-g.epochStartBlockNumber > maxEpochBlocks {
+		start = latestBlockNumber - maxEpochBlocks + 1
+	}
+	if start > latestBlockNumber {
+		return nil, nil
+	}
+	if g.getBlockGasUsedFn == nil {
+		blocks := make([]uint64, latestBlockNumber-start+1)
+		for i := range blocks {
+			blocks[i] = uint64(g.averageBlockGasLimit)
+		}
+		return blocks, nil
+	}
+	blocks, err := g.getBlockGasUsedFn(start, latestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(blocks)) != latestBlockNumber-start+1 {
+		return nil, fmt.Errorf("got the gas used of %d blocks in [%d,%d]", len(blocks), start, latestBlockNumber)
+	}
+	return blocks, nil
+}
+
 func (g *GasPriceUpdater) GetGasPrice() uint64 {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
-	return g.gasPricer.curPrice
+	return g.strategy.CurrentPrice()
 }
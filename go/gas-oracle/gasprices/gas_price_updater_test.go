@@ -113,7 +113,7 @@ func TestUpdateGasPriceFailsIfBlockNumberGoesBackwards(t *testing.T) {
 }
 
 func TestUsageOfGasPriceUpdater(t *testing.T) {
-	_, gasUpdater, incrementCurrentBlock, err := makeTestGasPricerAndUpdater(1000)
+	gasPricer, gasUpdater, incrementCurrentBlock, err := makeTestGasPricerAndUpdater(1000)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -125,7 +125,7 @@ func TestUsageOfGasPriceUpdater(t *testing.T) {
 			repeatCount: 3,
 			// Make sure the gas price is increasing
 			postHook: func(prevGasPrice uint64, gasPriceUpdater *GasPriceUpdater) {
-				curPrice := gasPriceUpdater.gasPricer.curPrice
+				curPrice := gasPriceUpdater.GetGasPrice()
 				if prevGasPrice >= curPrice {
 					t.Fatalf("Expected gas price to increase.")
 				}
@@ -141,7 +141,7 @@ func TestUsageOfGasPriceUpdater(t *testing.T) {
 			numBlocks:   3,
 			repeatCount: 0,
 			postHook: func(prevGasPrice uint64, gasPriceUpdater *GasPriceUpdater) {
-				curPrice := gasPriceUpdater.gasPricer.curPrice
+				curPrice := gasPriceUpdater.GetGasPrice()
 				if prevGasPrice != curPrice {
 					t.Fatalf("Expected gas price to stablize.")
 				}
@@ -152,15 +152,15 @@ func TestUsageOfGasPriceUpdater(t *testing.T) {
 			numBlocks:   1,
 			repeatCount: 5,
 			postHook: func(prevGasPrice uint64, gasPriceUpdater *GasPriceUpdater) {
-				curPrice := gasPriceUpdater.gasPricer.curPrice
-				if prevGasPrice <= curPrice && curPrice != gasPriceUpdater.gasPricer.floorPrice {
+				curPrice := gasPriceUpdater.GetGasPrice()
+				if prevGasPrice <= curPrice && curPrice != gasPricer.floorPrice {
 					t.Fatalf("Expected gas price either reduce or be at the floor.")
 				}
 			},
 		},
 	}
 	loop := func(epoch MockEpoch) {
-		prevGasPrice := gasUpdater.gasPricer.curPrice
+		prevGasPrice := gasUpdater.GetGasPrice()
 		incrementCurrentBlock(epoch.numBlocks)
 		err = gasUpdater.UpdateGasPrice()
 		if err != nil {
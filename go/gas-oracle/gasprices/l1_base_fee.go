@@ -0,0 +1,242 @@
+package gasprices
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// Names of the L1 base fee smoothers
+const (
+	BaseFeeLatest = "latest"
+	BaseFeeEMA    = "ema"
+	BaseFeeMedian = "median"
+	BaseFeeTWAP   = "twap"
+)
+
+// l1BlockTime is the expected time between two L1 blocks, used to size the
+// history of the time weighted average
+const l1BlockTime = 12 * time.Second
+
+// BaseFeeSample is the base fee of an L1 block
+type BaseFeeSample struct {
+	Number  uint64
+	Time    uint64
+	BaseFee *big.Int
+}
+
+// BaseFeeSmoother smooths the base fees of consecutive L1 blocks so that a
+// single block does not move the L1 fee of every L2 user
+type BaseFeeSmoother interface {
+	// Name returns the name of the smoother
+	Name() string
+	// History returns how many of the last L1 blocks the smoother needs to
+	// be fed on start
+	History() uint64
+	// Add feeds the next L1 block, blocks are added in order
+	Add(sample *BaseFeeSample)
+	// Value returns the smoothed base fee, nil before the first block
+	Value() *big.Int
+}
+
+// BaseFeeSmoothingConfig selects and tunes a BaseFeeSmoother and the limits
+// on the change of the base fee per update
+type BaseFeeSmoothingConfig struct {
+	Name string
+	// EMAAlpha is the weight of the newest block in the exponential moving
+	// average
+	EMAAlpha float64
+	// MedianBlocks is the number of the last blocks of the median
+	MedianBlocks int
+	// TWAPWindow is the time span of the time weighted average
+	TWAPWindow time.Duration
+	// MaxRise and MaxFall bound the relative change of the base fee per
+	// update, zero does not bound it
+	MaxRise float64
+	MaxFall float64
+}
+
+// NewBaseFeeSmoother creates the smoother named in the config
+func NewBaseFeeSmoother(cfg *BaseFeeSmoothingConfig) (BaseFeeSmoother, error) {
+	if cfg.MaxRise < 0 || cfg.MaxFall < 0 || cfg.MaxFall > 1 {
+		return nil, errors.New("base fee limits must be positive and the max fall at most 1")
+	}
+	switch cfg.Name {
+	case "", BaseFeeLatest:
+		return &latestBaseFee{}, nil
+	case BaseFeeEMA:
+		if cfg.EMAAlpha <= 0 || cfg.EMAAlpha > 1 {
+			return nil, errors.New("EMA alpha must be between (0,1]")
+		}
+		return &emaBaseFee{alpha: cfg.EMAAlpha}, nil
+	case BaseFeeMedian:
+		if cfg.MedianBlocks < 1 {
+			return nil, errors.New("median must be over at least 1 block")
+		}
+		return &medianBaseFee{window: make([]*big.Int, 0, cfg.MedianBlocks), size: cfg.MedianBlocks}, nil
+	case BaseFeeTWAP:
+		if cfg.TWAPWindow < time.Second {
+			return nil, errors.New("TWAP window must be at least 1 second")
+		}
+		return &twapBaseFee{window: uint64(cfg.TWAPWindow / time.Second)}, nil
+	default:
+		return nil, fmt.Errorf("unknown base fee smoother %q", cfg.Name)
+	}
+}
+
+// LimitBaseFee bounds the change from the current base fee to the next one.
+// A current base fee of zero is not bounded so that the first update goes
+// through.
+func LimitBaseFee(current, next *big.Int, maxRise, maxFall float64) *big.Int {
+	if current == nil || current.Sign() == 0 {
+		return next
+	}
+	cur := new(big.Float).SetInt(current)
+	if maxRise > 0 {
+		high, _ := new(big.Float).Mul(cur, big.NewFloat(1+maxRise)).Int(nil)
+		if next.Cmp(high) > 0 {
+			return high
+		}
+	}
+	if maxFall > 0 {
+		low, _ := new(big.Float).Mul(cur, big.NewFloat(1-maxFall)).Int(nil)
+		if next.Cmp(low) < 0 {
+			return low
+		}
+	}
+	return next
+}
+
+// latestBaseFee does not smooth, it is the base fee of the latest block
+type latestBaseFee struct {
+	value *big.Int
+}
+
+func (s *latestBaseFee) Name() string              { return BaseFeeLatest }
+func (s *latestBaseFee) History() uint64           { return 1 }
+func (s *latestBaseFee) Add(sample *BaseFeeSample) { s.value = sample.BaseFee }
+func (s *latestBaseFee) Value() *big.Int           { return s.value }
+
+// emaBaseFee is the exponential moving average of the base fee per block
+type emaBaseFee struct {
+	alpha float64
+	value *big.Float
+}
+
+func (s *emaBaseFee) Name() string { return BaseFeeEMA }
+
+// History covers the blocks that make up 95% of the average
+func (s *emaBaseFee) History() uint64 {
+	return uint64(math.Ceil(3 / s.alpha))
+}
+
+func (s *emaBaseFee) Add(sample *BaseFeeSample) {
+	fee := new(big.Float).SetInt(sample.BaseFee)
+	if s.value == nil {
+		s.value = fee
+		return
+	}
+	fee.Mul(fee, big.NewFloat(s.alpha))
+	s.value.Mul(s.value, big.NewFloat(1-s.alpha))
+	s.value.Add(s.value, fee)
+}
+
+func (s *emaBaseFee) Value() *big.Int {
+	if s.value == nil {
+		return nil
+	}
+	value, _ := s.value.Int(nil)
+	return value
+}
+
+// medianBaseFee is the median base fee of the last blocks
+type medianBaseFee struct {
+	window []*big.Int
+	size   int
+}
+
+func (s *medianBaseFee) Name() string    { return BaseFeeMedian }
+func (s *medianBaseFee) History() uint64 { return uint64(s.size) }
+
+func (s *medianBaseFee) Add(sample *BaseFeeSample) {
+	if len(s.window) == s.size {
+		s.window = s.window[1:]
+	}
+	s.window = append(s.window, sample.BaseFee)
+}
+
+func (s *medianBaseFee) Value() *big.Int {
+	if len(s.window) == 0 {
+		return nil
+	}
+	sorted := make([]*big.Int, len(s.window))
+	copy(sorted, s.window)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Int).Set(sorted[mid])
+	}
+	sum := new(big.Int).Add(sorted[mid-1], sorted[mid])
+	return sum.Rsh(sum, 1)
+}
+
+// twapBaseFee is the time weighted average of the base fee over a window of
+// time up to the latest block. The base fee of a block counts for the time
+// since the block before it.
+type twapBaseFee struct {
+	window  uint64
+	samples []*BaseFeeSample
+}
+
+func (s *twapBaseFee) Name() string { return BaseFeeTWAP }
+
+func (s *twapBaseFee) History() uint64 {
+	return s.window/uint64(l1BlockTime/time.Second) + 1
+}
+
+func (s *twapBaseFee) Add(sample *BaseFeeSample) {
+	s.samples = append(s.samples, sample)
+	// Keep one block before the window, it bounds the first weight
+	start := s.start()
+	for len(s.samples) > 1 && s.samples[1].Time <= start {
+		s.samples = s.samples[1:]
+	}
+}
+
+func (s *twapBaseFee) start() uint64 {
+	latest := s.samples[len(s.samples)-1].Time
+	if latest < s.window {
+		return 0
+	}
+	return latest - s.window
+}
+
+func (s *twapBaseFee) Value() *big.Int {
+	if len(s.samples) == 0 {
+		return nil
+	}
+	var (
+		start    = s.start()
+		weighted = new(big.Int)
+		total    = uint64(0)
+	)
+	for i := 1; i < len(s.samples); i++ {
+		from := s.samples[i-1].Time
+		if from < start {
+			from = start
+		}
+		if s.samples[i].Time <= from {
+			continue
+		}
+		weight := s.samples[i].Time - from
+		total += weight
+		weighted.Add(weighted, new(big.Int).Mul(s.samples[i].BaseFee, new(big.Int).SetUint64(weight)))
+	}
+	if total == 0 {
+		return new(big.Int).Set(s.samples[len(s.samples)-1].BaseFee)
+	}
+	return weighted.Div(weighted, new(big.Int).SetUint64(total))
+}
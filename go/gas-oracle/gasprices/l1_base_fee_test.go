@@ -0,0 +1,92 @@
+package gasprices
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+// spikySeries is a flat base fee of 100 with a single block at 1000
+func spikySeries() []*BaseFeeSample {
+	var samples []*BaseFeeSample
+	for i := uint64(0); i < 10; i++ {
+		fee := int64(100)
+		if i == 5 {
+			fee = 1000
+		}
+		samples = append(samples, &BaseFeeSample{Number: i, Time: 12 * i, BaseFee: big.NewInt(fee)})
+	}
+	return samples
+}
+
+func TestBaseFeeSmoothers(t *testing.T) {
+	tests := []struct {
+		cfg BaseFeeSmoothingConfig
+		// the smoothed base fee after each block of the spiky series
+		expected []int64
+	}{
+		{BaseFeeSmoothingConfig{Name: BaseFeeLatest}, []int64{100, 100, 100, 100, 100, 1000, 100, 100, 100, 100}},
+		{BaseFeeSmoothingConfig{Name: BaseFeeEMA, EMAAlpha: 0.5}, []int64{100, 100, 100, 100, 100, 550, 325, 212, 156, 128}},
+		{BaseFeeSmoothingConfig{Name: BaseFeeMedian, MedianBlocks: 3}, []int64{100, 100, 100, 100, 100, 100, 100, 100, 100, 100}},
+		// The spike counts for 12 of the 36 seconds of the window while it
+		// is in it
+		{BaseFeeSmoothingConfig{Name: BaseFeeTWAP, TWAPWindow: 36 * time.Second}, []int64{100, 100, 100, 100, 100, 400, 400, 400, 100, 100}},
+	}
+	for _, tt := range tests {
+		smoother, err := NewBaseFeeSmoother(&tt.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if smoother.Value() != nil {
+			t.Fatalf("%s: value before the first block", tt.cfg.Name)
+		}
+		for i, sample := range spikySeries() {
+			smoother.Add(sample)
+			if smoother.Value().Int64() != tt.expected[i] {
+				t.Fatalf("%s: unexpected value %v after block %d", tt.cfg.Name, smoother.Value(), i)
+			}
+		}
+	}
+}
+
+func TestNewBaseFeeSmootherConfig(t *testing.T) {
+	invalid := []BaseFeeSmoothingConfig{
+		{Name: "unknown"},
+		{Name: BaseFeeEMA},
+		{Name: BaseFeeEMA, EMAAlpha: 1.5},
+		{Name: BaseFeeMedian},
+		{Name: BaseFeeTWAP, TWAPWindow: time.Millisecond},
+		{Name: BaseFeeLatest, MaxFall: 2},
+		{Name: BaseFeeLatest, MaxRise: -1},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewBaseFeeSmoother(&cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+	smoother, _ := NewBaseFeeSmoother(&BaseFeeSmoothingConfig{Name: BaseFeeTWAP, TWAPWindow: 2 * time.Minute})
+	if smoother.History() != 11 {
+		t.Fatalf("unexpected TWAP history: %d", smoother.History())
+	}
+}
+
+func TestLimitBaseFee(t *testing.T) {
+	tests := []struct {
+		current, next    int64
+		maxRise, maxFall float64
+		expected         int64
+	}{
+		{0, 1000, 0.1, 0.1, 1000},
+		{100, 1000, 0, 0, 1000},
+		{100, 1000, 0.1, 0.1, 110},
+		{100, 10, 0.1, 0.1, 90},
+		{100, 105, 0.1, 0.1, 105},
+		{100, 10, 0.1, 0, 10},
+	}
+	for _, tt := range tests {
+		limited := LimitBaseFee(big.NewInt(tt.current), big.NewInt(tt.next), tt.maxRise, tt.maxFall)
+		if limited.Int64() != tt.expected {
+			t.Errorf("limit of %d to %d by +%v/-%v: got %v, want %d", tt.current, tt.next, tt.maxRise, tt.maxFall, limited, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package gasprices
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// PercentilePricer prices on a percentile of the gas used per block over a
+// window of the last blocks. The price steps toward the ratio of that
+// percentile to the per block target, so that a single busy block does not
+// move the price but sustained congestion does.
+type PercentilePricer struct {
+	curPrice          uint64
+	floorPrice        uint64
+	targetGasPerBlock uint64
+	maxChangePerEpoch float64
+	percentile        float64
+
+	// window is a ring of the gas used by the last blocks
+	window []uint64
+	next   int
+	filled bool
+}
+
+// NewPercentilePricer creates a PercentilePricer and checks its config
+// beforehand
+func NewPercentilePricer(curPrice, floorPrice, targetGasPerBlock uint64, maxPercentChangePerEpoch, percentile float64, window int) (*PercentilePricer, error) {
+	if err := checkFloorPrice(floorPrice); err != nil {
+		return nil, err
+	}
+	if targetGasPerBlock < 1 {
+		return nil, errors.New("targetGasPerBlock must be greater than or equal to 1")
+	}
+	if maxPercentChangePerEpoch <= 0 {
+		return nil, errors.New("maxPercentChangePerEpoch must be between (0,100]")
+	}
+	if percentile <= 0 || percentile > 100 {
+		return nil, errors.New("percentile must be between (0,100]")
+	}
+	if window < 1 {
+		return nil, errors.New("window must be at least 1 block")
+	}
+	return &PercentilePricer{
+		curPrice:          max(curPrice, floorPrice),
+		floorPrice:        floorPrice,
+		targetGasPerBlock: targetGasPerBlock,
+		maxChangePerEpoch: maxPercentChangePerEpoch,
+		percentile:        percentile,
+		window:            make([]uint64, window),
+	}, nil
+}
+
+// Name returns the name of the strategy
+func (p *PercentilePricer) Name() string {
+	return StrategyPercentile
+}
+
+// CurrentPrice returns the gas price of the current epoch
+func (p *PercentilePricer) CurrentPrice() uint64 {
+	return p.curPrice
+}
+
+// UpdatePrice adds the blocks of the epoch to the window and prices on the
+// percentile of the window. An epoch without blocks counts as one empty
+// block.
+func (p *PercentilePricer) UpdatePrice(load *EpochLoad) (uint64, error) {
+	blocks := load.BlockGasUsed
+	if len(blocks) == 0 {
+		blocks = []uint64{0}
+	}
+	for _, used := range blocks {
+		p.window[p.next] = used
+		p.next = (p.next + 1) % len(p.window)
+		if p.next == 0 {
+			p.filled = true
+		}
+	}
+	gasUsed := p.windowPercentile()
+	proportionOfTarget := float64(gasUsed) / float64(p.targetGasPerBlock)
+	proportionToChangeBy := math.Max(1-p.maxChangePerEpoch, math.Min(proportionOfTarget, 1+p.maxChangePerEpoch))
+
+	updated := float64(max(1, p.curPrice)) * proportionToChangeBy
+	result := max(p.floorPrice, uint64(math.Ceil(updated)))
+	log.Debug("Calculated next epoch gas price", "strategy", StrategyPercentile, "percentile", p.percentile,
+		"gasUsed", gasUsed, "proportionOfTarget", proportionOfTarget, "result", result)
+	p.curPrice = result
+	return result, nil
+}
+
+// windowPercentile returns the nearest rank percentile of the gas used by the
+// blocks in the window
+func (p *PercentilePricer) windowPercentile() uint64 {
+	size := p.next
+	if p.filled {
+		size = len(p.window)
+	}
+	sorted := make([]uint64, size)
+	copy(sorted, p.window[:size])
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p.percentile/100*float64(size))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
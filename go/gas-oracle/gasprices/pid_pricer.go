@@ -0,0 +1,90 @@
+package gasprices
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// pidIntegralLimit bounds the accumulated error of the PIDPricer so that a
+// long period of load does not keep the price up long after it ended
+const pidIntegralLimit = 10
+
+// PIDPricer is a PID controller on the relative distance of the gas per
+// second from its target. Its output is the relative change of the price,
+// capped by maxChangePerEpoch.
+type PIDPricer struct {
+	curPrice              uint64
+	floorPrice            uint64
+	getTargetGasPerSecond GetTargetGasPerSecond
+	maxChangePerEpoch     float64
+	kp, ki, kd            float64
+
+	integral  float64
+	lastError float64
+}
+
+// NewPIDPricer creates a PIDPricer and checks its config beforehand
+func NewPIDPricer(curPrice, floorPrice uint64, getTargetGasPerSecond GetTargetGasPerSecond, maxPercentChangePerEpoch, kp, ki, kd float64) (*PIDPricer, error) {
+	if err := checkFloorPrice(floorPrice); err != nil {
+		return nil, err
+	}
+	if maxPercentChangePerEpoch <= 0 {
+		return nil, errors.New("maxPercentChangePerEpoch must be between (0,100]")
+	}
+	if kp < 0 || ki < 0 || kd < 0 {
+		return nil, errors.New("PID gains cannot be negative")
+	}
+	return &PIDPricer{
+		curPrice:              max(curPrice, floorPrice),
+		floorPrice:            floorPrice,
+		getTargetGasPerSecond: getTargetGasPerSecond,
+		maxChangePerEpoch:     maxPercentChangePerEpoch,
+		kp:                    kp,
+		ki:                    ki,
+		kd:                    kd,
+	}, nil
+}
+
+// Name returns the name of the strategy
+func (p *PIDPricer) Name() string {
+	return StrategyPID
+}
+
+// CurrentPrice returns the gas price of the current epoch
+func (p *PIDPricer) CurrentPrice() uint64 {
+	return p.curPrice
+}
+
+// UpdatePrice feeds the gas per second of the epoch to the controller
+func (p *PIDPricer) UpdatePrice(load *EpochLoad) (uint64, error) {
+	targetGasPerSecond := p.getTargetGasPerSecond()
+	if load.GasPerSecond < 0 {
+		return 0, fmt.Errorf("avgGasPerSecondLastEpoch cannot be negative, got %f", load.GasPerSecond)
+	}
+	if targetGasPerSecond < 1 {
+		return 0, fmt.Errorf("gasPerSecond cannot be less than 1, got %f", targetGasPerSecond)
+	}
+	e := (load.GasPerSecond - targetGasPerSecond) / targetGasPerSecond
+	integral := math.Max(-pidIntegralLimit, math.Min(p.integral+e, pidIntegralLimit))
+	derivative := e - p.lastError
+	output := p.kp*e + p.ki*integral + p.kd*derivative
+	change := math.Max(-p.maxChangePerEpoch, math.Min(output, p.maxChangePerEpoch))
+
+	updated := float64(max(1, p.curPrice)) * (1 + change)
+	result := p.floorPrice
+	if updated > float64(p.floorPrice) {
+		result = uint64(math.Ceil(updated))
+	}
+	// Do not wind the integral up while the price sits at the floor
+	if result > p.floorPrice || e > 0 {
+		p.integral = integral
+	}
+	p.lastError = e
+	log.Debug("Calculated next epoch gas price", "strategy", StrategyPID, "error", e,
+		"integral", p.integral, "derivative", derivative, "change", change, "result", result)
+	p.curPrice = result
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package gasprices
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Names of the pricing strategies
+const (
+	StrategyProportional = "proportional"
+	StrategyEIP1559      = "eip1559"
+	StrategyPID          = "pid"
+	StrategyPercentile   = "percentile"
+)
+
+// EpochLoad is the load of the L2 chain over an epoch
+type EpochLoad struct {
+	// GasPerSecond is the average gas per second over the epoch
+	GasPerSecond float64
+	// BlockGasUsed is the gas used by each block of the epoch, oldest first
+	BlockGasUsed []uint64
+}
+
+// PricingStrategy computes the L2 gas price of the next epoch from the load
+// of the epoch that ended
+type PricingStrategy interface {
+	// Name returns the name of the strategy
+	Name() string
+	// CurrentPrice returns the gas price of the current epoch
+	CurrentPrice() uint64
+	// UpdatePrice ends the current epoch and returns the gas price of the
+	// next one
+	UpdatePrice(load *EpochLoad) (uint64, error)
+}
+
+// StrategyConfig holds the settings of all the pricing strategies, each
+// strategy reads the ones it needs
+type StrategyConfig struct {
+	Name       string
+	FloorPrice uint64
+	// MaxChangePerEpoch bounds the relative change of the price per epoch of
+	// all the strategies
+	MaxChangePerEpoch     float64
+	GetTargetGasPerSecond GetTargetGasPerSecond
+	// TargetGasPerBlock is the per block target of the EIP-1559 and the
+	// percentile strategies
+	TargetGasPerBlock uint64
+	// EIP1559Denominator is the inverse of the largest change of the price
+	// per block of the EIP-1559 strategy
+	EIP1559Denominator uint64
+	PIDKp              float64
+	PIDKi              float64
+	PIDKd              float64
+	// Percentile of the gas used per block over the last CongestionWindow
+	// blocks that the percentile strategy prices on
+	Percentile       float64
+	CongestionWindow int
+}
+
+// NewPricingStrategy creates the strategy named in the config, starting at
+// curPrice
+func NewPricingStrategy(curPrice uint64, cfg *StrategyConfig) (PricingStrategy, error) {
+	switch cfg.Name {
+	case "", StrategyProportional:
+		return NewGasPricer(curPrice, cfg.FloorPrice, cfg.GetTargetGasPerSecond, cfg.MaxChangePerEpoch)
+	case StrategyEIP1559:
+		return NewEIP1559Pricer(curPrice, cfg.FloorPrice, cfg.TargetGasPerBlock, cfg.EIP1559Denominator, cfg.MaxChangePerEpoch)
+	case StrategyPID:
+		return NewPIDPricer(curPrice, cfg.FloorPrice, cfg.GetTargetGasPerSecond, cfg.MaxChangePerEpoch, cfg.PIDKp, cfg.PIDKi, cfg.PIDKd)
+	case StrategyPercentile:
+		return NewPercentilePricer(curPrice, cfg.FloorPrice, cfg.TargetGasPerBlock, cfg.MaxChangePerEpoch, cfg.Percentile, cfg.CongestionWindow)
+	default:
+		return nil, fmt.Errorf("unknown pricing strategy %q", cfg.Name)
+	}
+}
+
+// Name returns the name of the strategy
+func (p *GasPricer) Name() string {
+	return StrategyProportional
+}
+
+// CurrentPrice returns the gas price of the current epoch
+func (p *GasPricer) CurrentPrice() uint64 {
+	return p.curPrice
+}
+
+// UpdatePrice steps the price toward the target gas per second by the
+// average gas per second of the epoch
+func (p *GasPricer) UpdatePrice(load *EpochLoad) (uint64, error) {
+	return p.CompleteEpoch(load.GasPerSecond)
+}
+
+func checkFloorPrice(floorPrice uint64) error {
+	if floorPrice < 1 {
+		return errors.New("floorPrice must be greater than or equal to 1")
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package gasprices
+
+import (
+	"math"
+	"testing"
+)
+
+const (
+	testEpochLength       = 10
+	testTargetGasPerBlock = 1_000_000
+	testBlocksPerEpoch    = 10
+	testStartPrice        = 100
+	testFloorPrice        = 10
+)
+
+// loadCurve returns the gas used by the blocks of each epoch
+type loadCurve func(epoch int) []uint64
+
+func constantLoad(blocks int, gasUsed uint64) loadCurve {
+	return func(int) []uint64 {
+		load := make([]uint64, blocks)
+		for i := range load {
+			load[i] = gasUsed
+		}
+		return load
+	}
+}
+
+// spikeLoad is at the target, except for one very busy block in the first
+// epoch
+func spikeLoad(epoch int) []uint64 {
+	load := constantLoad(testBlocksPerEpoch, testTargetGasPerBlock)(epoch)
+	if epoch == 0 {
+		load[0] = 10 * testTargetGasPerBlock
+	}
+	return load
+}
+
+// rampLoad goes from idle to twice the target over 20 epochs
+func rampLoad(epoch int) []uint64 {
+	return constantLoad(testBlocksPerEpoch, uint64(epoch)*testTargetGasPerBlock/10)(epoch)
+}
+
+func newTestStrategy(t *testing.T, name string) PricingStrategy {
+	strategy, err := NewPricingStrategy(testStartPrice, &StrategyConfig{
+		Name:                  name,
+		FloorPrice:            testFloorPrice,
+		MaxChangePerEpoch:     0.1,
+		GetTargetGasPerSecond: returnConstFn(testBlocksPerEpoch * testTargetGasPerBlock / testEpochLength),
+		TargetGasPerBlock:     testTargetGasPerBlock,
+		EIP1559Denominator:    8,
+		PIDKp:                 0.5,
+		PIDKi:                 0.05,
+		PIDKd:                 0.1,
+		Percentile:            90,
+		CongestionWindow:      100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strategy.Name() != name {
+		t.Fatalf("unexpected strategy: %s", strategy.Name())
+	}
+	return strategy
+}
+
+func runLoadCurve(t *testing.T, strategy PricingStrategy, curve loadCurve, epochs int) []uint64 {
+	prices := []uint64{strategy.CurrentPrice()}
+	for epoch := 0; epoch < epochs; epoch++ {
+		blocks := curve(epoch)
+		gasUsed := uint64(0)
+		for _, used := range blocks {
+			gasUsed += used
+		}
+		price, err := strategy.UpdatePrice(&EpochLoad{
+			GasPerSecond: float64(gasUsed) / testEpochLength,
+			BlockGasUsed: blocks,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if price != strategy.CurrentPrice() {
+			t.Fatalf("current price %d differs from the update %d", strategy.CurrentPrice(), price)
+		}
+		if price < testFloorPrice {
+			t.Fatalf("price %d below the floor", price)
+		}
+		prices = append(prices, price)
+	}
+	return prices
+}
+
+func expectSteady(t *testing.T, prices []uint64) {
+	for _, price := range prices {
+		if price != testStartPrice {
+			t.Fatalf("expected a steady price, got %v", prices)
+		}
+	}
+}
+
+func expectRising(t *testing.T, prices []uint64) {
+	for i := 1; i < len(prices); i++ {
+		if prices[i] <= prices[i-1] {
+			t.Fatalf("expected a rising price, got %v", prices)
+		}
+	}
+}
+
+func expectFloor(t *testing.T, prices []uint64) {
+	for i := 1; i < len(prices); i++ {
+		if prices[i] > prices[i-1] {
+			t.Fatalf("expected a falling price, got %v", prices)
+		}
+	}
+	if prices[len(prices)-1] != testFloorPrice {
+		t.Fatalf("expected the price to reach the floor, got %v", prices)
+	}
+}
+
+// expectRecovery expects the price to end higher than it started after a
+// ramp from idle to above the target
+func expectRecovery(t *testing.T, prices []uint64) {
+	lowest := prices[0]
+	for _, price := range prices {
+		if price < lowest {
+			lowest = price
+		}
+	}
+	if lowest >= prices[0] || prices[len(prices)-1] <= lowest {
+		t.Fatalf("expected the price to fall and rise again, got %v", prices)
+	}
+}
+
+func TestPricingStrategies(t *testing.T) {
+	strategies := []string{StrategyProportional, StrategyEIP1559, StrategyPID, StrategyPercentile}
+	tests := []struct {
+		name   string
+		curve  loadCurve
+		epochs int
+		expect func(*testing.T, []uint64)
+		// only lists the strategies the curve applies to, all if empty
+		only []string
+	}{
+		{"at target", constantLoad(testBlocksPerEpoch, testTargetGasPerBlock), 10, expectSteady, nil},
+		{"congested", constantLoad(testBlocksPerEpoch, 2*testTargetGasPerBlock), 10, expectRising, nil},
+		{"idle", constantLoad(0, 0), 40, expectFloor, nil},
+		{"ramp", rampLoad, 25, expectRecovery, nil},
+		// A single busy block is ignored by the percentile of the window
+		{"spike", spikeLoad, 10, expectSteady, []string{StrategyPercentile}},
+	}
+	for _, tt := range tests {
+		for _, name := range strategies {
+			if len(tt.only) > 0 && !contains(tt.only, name) {
+				continue
+			}
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				prices := runLoadCurve(t, newTestStrategy(t, name), tt.curve, tt.epochs)
+				tt.expect(t, prices)
+			})
+		}
+	}
+}
+
+func TestPricingStrategySpikeResponse(t *testing.T) {
+	// The controllers that look at the whole epoch react to the busy block
+	// of a spike, the EIP-1559 one by at most one block at twice the target
+	// and by at most the max change per epoch
+	for _, name := range []string{StrategyProportional, StrategyEIP1559, StrategyPID} {
+		prices := runLoadCurve(t, newTestStrategy(t, name), spikeLoad, 1)
+		if prices[1] <= prices[0] {
+			t.Fatalf("%s: expected the price to rise, got %v", name, prices)
+		}
+	}
+	prices := runLoadCurve(t, newTestStrategy(t, StrategyEIP1559), spikeLoad, 1)
+	// e^(1/8) is above the max change of 10%
+	maxChange := 0.1
+	if limit := uint64(math.Ceil(float64(prices[0]) * (1 + maxChange))); prices[1] != limit {
+		t.Fatalf("unexpected EIP-1559 price after a spike: %v", prices)
+	}
+}
+
+func TestEIP1559PricerMaxChangePerEpoch(t *testing.T) {
+	// A long epoch of full blocks compounds to far more than the max change
+	// per epoch, the price moves by the max change only
+	pricer, err := NewEIP1559Pricer(1000, 1, testTargetGasPerBlock, 8, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	congested := constantLoad(1000, 2*testTargetGasPerBlock)(0)
+	if price, _ := pricer.UpdatePrice(&EpochLoad{BlockGasUsed: congested}); price != 1500 {
+		t.Fatalf("unexpected price after a congested epoch: %d", price)
+	}
+	idle := constantLoad(1000, 0)(0)
+	if price, _ := pricer.UpdatePrice(&EpochLoad{BlockGasUsed: idle}); price != 750 {
+		t.Fatalf("unexpected price after an idle epoch: %d", price)
+	}
+}
+
+func TestNewPricingStrategyConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  StrategyConfig
+	}{
+		{"unknown", StrategyConfig{Name: "unknown", FloorPrice: 1}},
+		{"eip1559 no target", StrategyConfig{Name: StrategyEIP1559, FloorPrice: 1, EIP1559Denominator: 8}},
+		{"eip1559 no denominator", StrategyConfig{Name: StrategyEIP1559, FloorPrice: 1, TargetGasPerBlock: 1, MaxChangePerEpoch: 0.1}},
+		{"eip1559 no max change", StrategyConfig{Name: StrategyEIP1559, FloorPrice: 1, TargetGasPerBlock: 1, EIP1559Denominator: 8}},
+		{"pid negative gain", StrategyConfig{Name: StrategyPID, FloorPrice: 1, MaxChangePerEpoch: 0.1, PIDKp: -1}},
+		{"percentile out of range", StrategyConfig{Name: StrategyPercentile, FloorPrice: 1, TargetGasPerBlock: 1, MaxChangePerEpoch: 0.1, Percentile: 101, CongestionWindow: 1}},
+		{"percentile no window", StrategyConfig{Name: StrategyPercentile, FloorPrice: 1, TargetGasPerBlock: 1, MaxChangePerEpoch: 0.1, Percentile: 50}},
+		{"no floor", StrategyConfig{Name: StrategyPID, MaxChangePerEpoch: 0.1}},
+	}
+	for _, tt := range tests {
+		if _, err := NewPricingStrategy(1, &tt.cfg); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -37,6 +37,26 @@ func main() {
 		return nil
 	}
 
+	app.Commands = []cli.Command{
+		{
+			Name:  "backfill-l1-base-fee",
+			Usage: "Compute the L1 base fee each smoother would have set over the last L1 blocks",
+			Flags: []cli.Flag{flags.BackfillBlocksFlag, flags.BackfillOutputFlag},
+			Action: func(ctx *cli.Context) error {
+				out := os.Stdout
+				if path := ctx.String(flags.BackfillOutputFlag.Name); path != "" {
+					file, err := os.Create(path)
+					if err != nil {
+						return err
+					}
+					defer file.Close()
+					out = file
+				}
+				return oracle.RunBaseFeeBackfill(ctx, out)
+			},
+		},
+	}
+
 	// Define the functionality of the application
 	app.Action = func(ctx *cli.Context) error {
 		if args := ctx.Args(); len(args) > 0 {
@@ -3,14 +3,17 @@ package oracle
 import (
 	"context"
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum-optimism/optimism/go/gas-oracle/bindings"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/gasprices"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/txmgr"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 )
 
-func wrapUpdateBaseFee(l1Backend bind.ContractTransactor, l2Backend DeployContractBackend, cfg *Config) (func() error, error) {
+func wrapUpdateBaseFee(l1Backend bind.ContractTransactor, l2Backend DeployContractBackend, mgr *txmgr.TxManager, cfg *Config) (func() error, error) {
 	if cfg.privateKey == nil {
 		return nil, errNoPrivateKey
 	}
@@ -18,25 +21,23 @@ func wrapUpdateBaseFee(l1Backend bind.ContractTransactor, l2Backend DeployContra
 		return nil, errNoChainID
 	}
 
-	opts, err := bind.NewKeyedTransactorWithChainID(cfg.privateKey, cfg.l2ChainID)
+	// Create a new contract bindings in scope of the updateL2GasPriceFn
+	// that is returned from this function
+	contract, err := bindings.NewGasPriceOracle(cfg.gasPriceOracleAddress, l2Backend)
 	if err != nil {
 		return nil, err
 	}
-	// Once https://github.com/ethereum/go-ethereum/pull/23062 is released
-	// then we can remove setting the context here
-	if opts.Context == nil {
-		opts.Context = context.Background()
-	}
-	// Don't send the transaction using the `contract` so that we can inspect
-	// it beforehand
-	opts.NoSend = true
 
-	// Create a new contract bindings in scope of the updateL2GasPriceFn
-	// that is returned from this function
-	contract, err := bindings.NewGasPriceOracle(cfg.gasPriceOracleAddress, l2Backend)
+	smoothing := cfg.l1BaseFeeSmoothing
+	if smoothing == nil {
+		smoothing = new(gasprices.BaseFeeSmoothingConfig)
+	}
+	smoother, err := gasprices.NewBaseFeeSmoother(smoothing)
 	if err != nil {
 		return nil, err
 	}
+	// last is the latest L1 block fed to the smoother
+	var last *big.Int
 
 	return func() error {
 		baseFee, err := contract.L1BaseFee(&bind.CallOpts{
@@ -52,44 +53,68 @@ func wrapUpdateBaseFee(l1Backend bind.ContractTransactor, l2Backend DeployContra
 		if tip.BaseFee == nil {
 			return errNoBaseFee
 		}
-		if !isDifferenceSignificant(baseFee.Uint64(), tip.BaseFee.Uint64(), cfg.l1BaseFeeSignificanceFactor) {
-			log.Debug("non significant base fee update", "tip", tip.BaseFee, "current", baseFee)
-			return nil
-		}
-
-		// Use the configured gas price if it is set,
-		// otherwise use gas estimation
-		if cfg.gasPrice != nil {
-			opts.GasPrice = cfg.gasPrice
-		} else {
-			gasPrice, err := l2Backend.SuggestGasPrice(opts.Context)
-			if err != nil {
+		if last == nil || tip.Number.Cmp(last) > 0 {
+			if err := feedBaseFeeSmoother(context.Background(), l1Backend, smoother, tip, last); err != nil {
 				return err
 			}
-			opts.GasPrice = gasPrice
+			last = tip.Number
+		}
+		smoothed := smoother.Value()
+		next := gasprices.LimitBaseFee(baseFee, smoothed, smoothing.MaxRise, smoothing.MaxFall)
+		log.Debug("smoothed l1 base fee", "smoother", smoother.Name(), "tip", tip.BaseFee,
+			"smoothed", smoothed, "next", next)
+		if !isDifferenceSignificant(baseFee.Uint64(), next.Uint64(), cfg.l1BaseFeeSignificanceFactor) {
+			log.Debug("non significant base fee update", "next", next, "current", baseFee)
+			return nil
 		}
 
-		tx, err := contract.SetL1BaseFee(opts, tip.BaseFee)
+		tx, err := sendGasPriceOracleTx(mgr, cfg, txKindL1BaseFee, "setL1BaseFee", next)
 		if err != nil {
-			return err
-		}
-		log.Debug("updating L1 base fee", "tx.gasPrice", tx.GasPrice(), "tx.gasLimit", tx.Gas(),
-			"tx.data", hexutil.Encode(tx.Data()), "tx.to", tx.To().Hex(), "tx.nonce", tx.Nonce())
-		if err := l2Backend.SendTransaction(context.Background(), tx); err != nil {
 			return fmt.Errorf("cannot update base fee: %w", err)
 		}
 		log.Info("L1 base fee transaction sent", "hash", tx.Hash().Hex())
 
 		if cfg.waitForReceipt {
 			// Wait for the receipt
-			receipt, err := waitForReceipt(l2Backend, tx)
+			receipt, err := mgr.WaitMined(context.Background(), tx)
 			if err != nil {
 				return err
 			}
 
-			log.Info("base-fee transaction confirmed", "hash", tx.Hash().Hex(),
+			log.Info("base-fee transaction confirmed", "hash", receipt.TxHash.Hex(),
 				"gas-used", receipt.GasUsed, "blocknumber", receipt.BlockNumber)
 		}
 		return nil
 	}, nil
 }
+
+// feedBaseFeeSmoother adds the L1 blocks after last up to the tip to the
+// smoother, at most as many as the smoother needs. The headers are all
+// fetched before any is added, so that a failed fetch adds nothing and the
+// blocks are not added twice on the next attempt.
+func feedBaseFeeSmoother(ctx context.Context, backend HeaderBackend, smoother gasprices.BaseFeeSmoother, tip *types.Header, last *big.Int) error {
+	number := tip.Number.Uint64()
+	start := uint64(0)
+	if history := smoother.History(); number >= history {
+		start = number - history + 1
+	}
+	if last != nil && last.Uint64() >= start {
+		start = last.Uint64() + 1
+	}
+	var samples []*gasprices.BaseFeeSample
+	for n := start; n < number; n++ {
+		header, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return err
+		}
+		if header.BaseFee == nil {
+			continue
+		}
+		samples = append(samples, &gasprices.BaseFeeSample{Number: n, Time: header.Time, BaseFee: header.BaseFee})
+	}
+	samples = append(samples, &gasprices.BaseFeeSample{Number: number, Time: tip.Time, BaseFee: tip.BaseFee})
+	for _, sample := range samples {
+		smoother.Add(sample)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package oracle
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/flags"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/gasprices"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/urfave/cli"
+)
+
+// HeaderBackend is the L1 client that the headers of the backfill are read
+// from
+type HeaderBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// BaseFeeBackfillResult is how a smoother would have set the L1 base fee
+// over the blocks of a backfill
+type BaseFeeBackfillResult struct {
+	Smoother string
+	// Updates is the number of base fee updates that would have been sent
+	Updates int
+	// MeanDeviation is the mean relative distance of the base fee that
+	// would have been set from the base fee of the block
+	MeanDeviation float64
+}
+
+// baseFeeBackfill replays one smoother over the blocks of a backfill, with
+// the limits and the significance factor of the base fee loop
+type baseFeeBackfill struct {
+	smoother  gasprices.BaseFeeSmoother
+	smoothing *gasprices.BaseFeeSmoothingConfig
+	current   *big.Int
+	updates   int
+	deviation float64
+}
+
+func (b *baseFeeBackfill) add(sample *gasprices.BaseFeeSample, significance float64) *big.Int {
+	b.smoother.Add(sample)
+	next := gasprices.LimitBaseFee(b.current, b.smoother.Value(), b.smoothing.MaxRise, b.smoothing.MaxFall)
+	if b.current == nil || isDifferenceSignificant(b.current.Uint64(), next.Uint64(), significance) {
+		b.current = next
+		b.updates++
+	}
+	if sample.BaseFee.Sign() > 0 {
+		diff, _ := new(big.Float).SetInt(new(big.Int).Sub(b.current, sample.BaseFee)).Float64()
+		fee, _ := new(big.Float).SetInt(sample.BaseFee).Float64()
+		b.deviation += math.Abs(diff) / fee
+	}
+	return b.current
+}
+
+// BackfillBaseFee replays the L1 blocks from start to end through each of
+// the smoothers and writes, per block, the base fee of the block and the
+// base fee each smoother would have set as CSV
+func BackfillBaseFee(ctx context.Context, backend HeaderBackend, smoothings []*gasprices.BaseFeeSmoothingConfig, significance float64, start, end uint64, w io.Writer) ([]*BaseFeeBackfillResult, error) {
+	if end < start {
+		return nil, fmt.Errorf("invalid backfill range %d-%d", start, end)
+	}
+	backfills := make([]*baseFeeBackfill, len(smoothings))
+	header := []string{"number", "time", "base_fee"}
+	warmup := uint64(0)
+	for i, smoothing := range smoothings {
+		smoother, err := gasprices.NewBaseFeeSmoother(smoothing)
+		if err != nil {
+			return nil, err
+		}
+		backfills[i] = &baseFeeBackfill{smoother: smoother, smoothing: smoothing}
+		header = append(header, smoother.Name())
+		if history := smoother.History(); history > warmup {
+			warmup = history
+		}
+	}
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return nil, err
+	}
+
+	// The blocks before the range warm the smoothers up
+	first := uint64(0)
+	if start > warmup {
+		first = start - warmup
+	}
+	blocks := 0
+	for number := first; number <= end; number++ {
+		h, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+		if h.BaseFee == nil {
+			continue
+		}
+		sample := &gasprices.BaseFeeSample{Number: number, Time: h.Time, BaseFee: h.BaseFee}
+		if number < start {
+			for _, backfill := range backfills {
+				backfill.smoother.Add(sample)
+			}
+			continue
+		}
+		blocks++
+		record := []string{strconv.FormatUint(number, 10), strconv.FormatUint(h.Time, 10), h.BaseFee.String()}
+		for _, backfill := range backfills {
+			record = append(record, backfill.add(sample, significance).String())
+		}
+		if err := out.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*BaseFeeBackfillResult, len(backfills))
+	for i, backfill := range backfills {
+		results[i] = &BaseFeeBackfillResult{Smoother: backfill.smoother.Name(), Updates: backfill.updates}
+		if blocks > 0 {
+			results[i].MeanDeviation = backfill.deviation / float64(blocks)
+		}
+	}
+	return results, nil
+}
+
+// RunBaseFeeBackfill backfills the L1 base fee over the last blocks with all
+// the smoothers, as configured by the flags, and writes the series to w
+func RunBaseFeeBackfill(ctx *cli.Context, w io.Writer) error {
+	client, err := ethclient.Dial(ctx.GlobalString(flags.EthereumHttpUrlFlag.Name))
+	if err != nil {
+		return err
+	}
+	tip, err := client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	end := tip.Number.Uint64()
+	start := uint64(0)
+	if blocks := ctx.Uint64(flags.BackfillBlocksFlag.Name); end >= blocks {
+		start = end - blocks + 1
+	}
+
+	base := newBaseFeeSmoothingConfig(ctx)
+	var smoothings []*gasprices.BaseFeeSmoothingConfig
+	for _, name := range []string{gasprices.BaseFeeLatest, gasprices.BaseFeeEMA, gasprices.BaseFeeMedian, gasprices.BaseFeeTWAP} {
+		smoothing := *base
+		smoothing.Name = name
+		smoothings = append(smoothings, &smoothing)
+	}
+	significance := ctx.GlobalFloat64(flags.L1BaseFeeSignificanceFactorFlag.Name)
+	log.Info("Backfilling L1 base fee", "start", start, "end", end)
+	results, err := BackfillBaseFee(context.Background(), client, smoothings, significance, start, end, w)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		log.Info("L1 base fee backfill", "smoother", result.Smoother, "updates", result.Updates,
+			"mean-deviation", fmt.Sprintf("%.4f", result.MeanDeviation))
+	}
+	return nil
+}
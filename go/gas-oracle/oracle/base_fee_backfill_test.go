@@ -0,0 +1,58 @@
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/gasprices"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeHeaders is an L1 chain with a flat base fee and a spike every tenth
+// block
+type fakeHeaders struct{}
+
+func (fakeHeaders) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	fee := int64(100)
+	if number.Uint64()%10 == 5 {
+		fee = 1000
+	}
+	return &types.Header{Number: number, Time: 12 * number.Uint64(), BaseFee: big.NewInt(fee)}, nil
+}
+
+func TestBackfillBaseFee(t *testing.T) {
+	smoothings := []*gasprices.BaseFeeSmoothingConfig{
+		{Name: gasprices.BaseFeeLatest},
+		{Name: gasprices.BaseFeeMedian, MedianBlocks: 5},
+		{Name: gasprices.BaseFeeLatest, MaxRise: 0.5, MaxFall: 0.5},
+	}
+	var out bytes.Buffer
+	results, err := BackfillBaseFee(context.Background(), fakeHeaders{}, smoothings, 0.05, 20, 49, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A header and a row per block
+	if len(records) != 31 || len(records[0]) != 6 || records[1][0] != "20" || records[30][0] != "49" {
+		t.Fatalf("unexpected series: %d rows, %v", len(records), records[0])
+	}
+	// Block 25 is a spike, only the latest base fee follows it
+	if row := records[6]; row[2] != "1000" || row[3] != "1000" || row[4] != "100" || row[5] != "150" {
+		t.Fatalf("unexpected spike row: %v", row)
+	}
+	// The latest base fee is sent on the first block and twice per spike
+	// and tracks the blocks exactly, the median is sent once and is off on
+	// the spikes
+	if results[0].Updates != 7 || results[0].MeanDeviation != 0 || results[1].Updates != 1 || results[1].MeanDeviation <= 0 {
+		t.Fatalf("unexpected results: %+v, %+v", results[0], results[1])
+	}
+	if results[1].Smoother != gasprices.BaseFeeMedian {
+		t.Fatalf("unexpected smoother: %s", results[1].Smoother)
+	}
+}
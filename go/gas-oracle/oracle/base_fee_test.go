@@ -1,12 +1,17 @@
 package oracle
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/go/gas-oracle/bindings"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/gasprices"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -27,9 +32,12 @@ func TestBaseFeeUpdate(t *testing.T) {
 		l2ChainID:             big.NewInt(1337),
 		gasPriceOracleAddress: addr,
 		gasPrice:              big.NewInt(784637584),
+		txPriceBump:           0.1,
+		txResubmissionTimeout: time.Minute,
+		txPollInterval:        time.Second,
 	}
 
-	update, err := wrapUpdateBaseFee(sim, sim, cfg)
+	update, err := wrapUpdateBaseFee(sim, sim, newTestTxManager(t, sim, cfg), cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -69,3 +77,58 @@ func TestBaseFeeUpdate(t *testing.T) {
 		t.Fatal("base fee not updated")
 	}
 }
+
+// failingHeaders are the headers of fakeHeaders, except that the header of
+// failAt cannot be fetched
+type failingHeaders struct {
+	failAt uint64
+}
+
+func (h *failingHeaders) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number.Uint64() == h.failAt {
+		return nil, errors.New("header not available")
+	}
+	return fakeHeaders{}.HeaderByNumber(ctx, number)
+}
+
+// recordingSmoother records the numbers of the blocks it is fed
+type recordingSmoother struct {
+	added []uint64
+}
+
+func (s *recordingSmoother) Name() string    { return "recording" }
+func (s *recordingSmoother) History() uint64 { return 5 }
+func (s *recordingSmoother) Value() *big.Int { return nil }
+
+func (s *recordingSmoother) Add(sample *gasprices.BaseFeeSample) {
+	s.added = append(s.added, sample.Number)
+}
+
+func TestFeedBaseFeeSmootherRetry(t *testing.T) {
+	backend := &failingHeaders{failAt: 18}
+	smoother := new(recordingSmoother)
+	tip, _ := fakeHeaders{}.HeaderByNumber(context.Background(), big.NewInt(20))
+
+	// A failed fetch adds none of the blocks
+	if err := feedBaseFeeSmoother(context.Background(), backend, smoother, tip, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(smoother.added) != 0 {
+		t.Fatalf("blocks added on error: %v", smoother.added)
+	}
+
+	// The next attempt adds every block once, in order
+	backend.failAt = 0
+	if err := feedBaseFeeSmoother(context.Background(), backend, smoother, tip, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{16, 17, 18, 19, 20}
+	if len(smoother.added) != len(want) {
+		t.Fatalf("unexpected blocks: %v", smoother.added)
+	}
+	for i := range want {
+		if smoother.added[i] != want[i] {
+			t.Fatalf("unexpected blocks: %v", smoother.added)
+		}
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/go/gas-oracle/flags"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/gasprices"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/txmgr"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
@@ -23,15 +26,40 @@ type Config struct {
 	privateKey                   *ecdsa.PrivateKey
 	gasPrice                     *big.Int
 	waitForReceipt               bool
+	txResubmissionTimeout        time.Duration
+	txPriceBump                  float64
+	txMaxGasPrice                *big.Int
+	txPollInterval               time.Duration
 	floorPrice                   uint64
 	targetGasPerSecond           uint64
 	maxPercentChangePerEpoch     float64
+	l2GasPricingStrategy         string
+	targetGasPerBlock            uint64
+	eip1559Denominator           uint64
+	pidKp                        float64
+	pidKi                        float64
+	pidKd                        float64
+	congestionPercentile         float64
+	congestionWindow             int
 	averageBlockGasLimitPerEpoch float64
 	epochLengthSeconds           uint64
 	l2GasPriceSignificanceFactor float64
 	l1BaseFeeSignificanceFactor  float64
+	l1BaseFeeSmoothing           *gasprices.BaseFeeSmoothingConfig
 	enableL1BaseFee              bool
 	enableL2GasPrice             bool
+	// L1 fee calibration config
+	enableFeeCalibration             bool
+	feeCalibrationReportOnly         bool
+	ctcAddress                       common.Address
+	feeCalibrationInterval           time.Duration
+	feeCalibrationWindow             uint64
+	feeCalibrationMaxChange          float64
+	feeCalibrationSignificanceFactor float64
+	minOverhead                      uint64
+	maxOverhead                      uint64
+	minScalar                        uint64
+	maxScalar                        uint64
 	// Metrics config
 	MetricsEnabled          bool
 	MetricsHTTP             string
@@ -52,13 +80,33 @@ func NewConfig(ctx *cli.Context) *Config {
 	cfg.gasPriceOracleAddress = common.HexToAddress(addr)
 	cfg.targetGasPerSecond = ctx.GlobalUint64(flags.TargetGasPerSecondFlag.Name)
 	cfg.maxPercentChangePerEpoch = ctx.GlobalFloat64(flags.MaxPercentChangePerEpochFlag.Name)
+	cfg.l2GasPricingStrategy = ctx.GlobalString(flags.L2GasPricingStrategyFlag.Name)
+	cfg.targetGasPerBlock = ctx.GlobalUint64(flags.TargetGasPerBlockFlag.Name)
+	cfg.eip1559Denominator = ctx.GlobalUint64(flags.EIP1559DenominatorFlag.Name)
+	cfg.pidKp = ctx.GlobalFloat64(flags.PIDKpFlag.Name)
+	cfg.pidKi = ctx.GlobalFloat64(flags.PIDKiFlag.Name)
+	cfg.pidKd = ctx.GlobalFloat64(flags.PIDKdFlag.Name)
+	cfg.congestionPercentile = ctx.GlobalFloat64(flags.CongestionPercentileFlag.Name)
+	cfg.congestionWindow = ctx.GlobalInt(flags.CongestionWindowFlag.Name)
 	cfg.averageBlockGasLimitPerEpoch = ctx.GlobalFloat64(flags.AverageBlockGasLimitPerEpochFlag.Name)
 	cfg.epochLengthSeconds = ctx.GlobalUint64(flags.EpochLengthSecondsFlag.Name)
 	cfg.l2GasPriceSignificanceFactor = ctx.GlobalFloat64(flags.L2GasPriceSignificanceFactorFlag.Name)
 	cfg.floorPrice = ctx.GlobalUint64(flags.FloorPriceFlag.Name)
 	cfg.l1BaseFeeSignificanceFactor = ctx.GlobalFloat64(flags.L1BaseFeeSignificanceFactorFlag.Name)
+	cfg.l1BaseFeeSmoothing = newBaseFeeSmoothingConfig(ctx)
 	cfg.enableL1BaseFee = ctx.GlobalBool(flags.EnableL1BaseFeeFlag.Name)
 	cfg.enableL2GasPrice = ctx.GlobalBool(flags.EnableL2GasPriceFlag.Name)
+	cfg.enableFeeCalibration = ctx.GlobalBool(flags.EnableFeeCalibrationFlag.Name)
+	cfg.feeCalibrationReportOnly = ctx.GlobalBool(flags.FeeCalibrationReportOnlyFlag.Name)
+	cfg.ctcAddress = common.HexToAddress(ctx.GlobalString(flags.CTCAddressFlag.Name))
+	cfg.feeCalibrationInterval = ctx.GlobalDuration(flags.FeeCalibrationIntervalFlag.Name)
+	cfg.feeCalibrationWindow = ctx.GlobalUint64(flags.FeeCalibrationWindowFlag.Name)
+	cfg.feeCalibrationMaxChange = ctx.GlobalFloat64(flags.FeeCalibrationMaxChangeFlag.Name)
+	cfg.feeCalibrationSignificanceFactor = ctx.GlobalFloat64(flags.FeeCalibrationSignificanceFactorFlag.Name)
+	cfg.minOverhead = ctx.GlobalUint64(flags.MinOverheadFlag.Name)
+	cfg.maxOverhead = ctx.GlobalUint64(flags.MaxOverheadFlag.Name)
+	cfg.minScalar = ctx.GlobalUint64(flags.MinScalarFlag.Name)
+	cfg.maxScalar = ctx.GlobalUint64(flags.MaxScalarFlag.Name)
 
 	if ctx.GlobalIsSet(flags.PrivateKeyFlag.Name) {
 		hex := ctx.GlobalString(flags.PrivateKeyFlag.Name)
@@ -89,6 +137,13 @@ func NewConfig(ctx *cli.Context) *Config {
 	if ctx.GlobalIsSet(flags.WaitForReceiptFlag.Name) {
 		cfg.waitForReceipt = true
 	}
+	cfg.txResubmissionTimeout = ctx.GlobalDuration(flags.TxResubmissionTimeoutFlag.Name)
+	cfg.txPriceBump = ctx.GlobalFloat64(flags.TxPriceBumpFlag.Name)
+	cfg.txPollInterval = ctx.GlobalDuration(flags.TxPollIntervalFlag.Name)
+	if ctx.GlobalIsSet(flags.TxMaxGasPriceFlag.Name) {
+		maxGasPrice := ctx.GlobalUint64(flags.TxMaxGasPriceFlag.Name)
+		cfg.txMaxGasPrice = new(big.Int).SetUint64(maxGasPrice)
+	}
 
 	cfg.MetricsEnabled = ctx.GlobalBool(flags.MetricsEnabledFlag.Name)
 	cfg.MetricsHTTP = ctx.GlobalString(flags.MetricsHTTPFlag.Name)
@@ -101,3 +156,29 @@ func NewConfig(ctx *cli.Context) *Config {
 
 	return &cfg
 }
+
+// txManagerConfig is the config of the transaction manager that sends the
+// updates
+func (c *Config) txManagerConfig() txmgr.Config {
+	return txmgr.Config{
+		ChainID:             c.l2ChainID,
+		PrivateKey:          c.privateKey,
+		GasPrice:            c.gasPrice,
+		MaxGasPrice:         c.txMaxGasPrice,
+		PriceBump:           c.txPriceBump,
+		ResubmissionTimeout: c.txResubmissionTimeout,
+		PollInterval:        c.txPollInterval,
+	}
+}
+
+// newBaseFeeSmoothingConfig reads the L1 base fee smoothing flags
+func newBaseFeeSmoothingConfig(ctx *cli.Context) *gasprices.BaseFeeSmoothingConfig {
+	return &gasprices.BaseFeeSmoothingConfig{
+		Name:         ctx.GlobalString(flags.L1BaseFeeSmoothingFlag.Name),
+		EMAAlpha:     ctx.GlobalFloat64(flags.L1BaseFeeEMAAlphaFlag.Name),
+		MedianBlocks: ctx.GlobalInt(flags.L1BaseFeeMedianBlocksFlag.Name),
+		TWAPWindow:   ctx.GlobalDuration(flags.L1BaseFeeTWAPWindowFlag.Name),
+		MaxRise:      ctx.GlobalFloat64(flags.L1BaseFeeMaxRiseFlag.Name),
+		MaxFall:      ctx.GlobalFloat64(flags.L1BaseFeeMaxFallFlag.Name),
+	}
+}
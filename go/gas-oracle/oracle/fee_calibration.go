@@ -0,0 +1,460 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/bindings"
+	ometrics "github.com/ethereum-optimism/optimism/go/gas-oracle/metrics"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/txmgr"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// transactionBatchAppendedABI is the event the CTC emits for every batch of
+// L2 transactions appended to it
+const transactionBatchAppendedABI = `[{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint256","name":"_chainId","type":"uint256"},{"indexed":true,"internalType":"uint256","name":"_batchIndex","type":"uint256"},{"indexed":false,"internalType":"bytes32","name":"_batchRoot","type":"bytes32"},{"indexed":false,"internalType":"uint256","name":"_batchSize","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"_prevTotalElements","type":"uint256"},{"indexed":false,"internalType":"bytes","name":"_extraData","type":"bytes"}],"name":"TransactionBatchAppended","type":"event"}]`
+
+var (
+	// errNoCalibrationData represents the error when there are no batches
+	// with L1 fees paid by users in the calibration window
+	errNoCalibrationData = errors.New("no batches to calibrate the L1 fee on")
+
+	l1FeePaidGauge         = metrics.NewRegisteredGauge("l1-fee/paid", ometrics.DefaultRegistry)
+	l1FeeChargedGauge      = metrics.NewRegisteredGauge("l1-fee/charged", ometrics.DefaultRegistry)
+	overheadProposedGauge  = metrics.NewRegisteredGauge("l1-fee/overhead-proposed", ometrics.DefaultRegistry)
+	scalarProposedGauge    = metrics.NewRegisteredGauge("l1-fee/scalar-proposed", ometrics.DefaultRegistry)
+	feeParamsUpdateCounter = metrics.NewRegisteredCounter("tx/fee-params", ometrics.DefaultRegistry)
+)
+
+// L1CalibrationBackend is the L1 client that the batches and their cost are
+// read from
+type L1CalibrationBackend interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+}
+
+// L2ReceiptBackend is the L2 client that the L1 fees charged to users are
+// read from, the receipts of l2geth carry fields that the go-ethereum
+// client does not decode
+type L2ReceiptBackend interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// batchCost is the L1 cost of one batch submission and the L2 blocks that it
+// appended
+type batchCost struct {
+	txHash   common.Hash
+	gasUsed  uint64
+	fee      *big.Int
+	l2Blocks []uint64
+}
+
+// l2FeeCharge is the L1 fee charged to the sender of an L2 transaction, as
+// found in its receipt
+type l2FeeCharge struct {
+	L1GasUsed  *hexutil.Big `json:"l1GasUsed"`
+	L1GasPrice *hexutil.Big `json:"l1GasPrice"`
+	L1Fee      *hexutil.Big `json:"l1Fee"`
+}
+
+// feeParams are the L1 fee parameters of the OVM_GasPriceOracle
+type feeParams struct {
+	overhead uint64
+	scalar   uint64
+	decimals uint64
+}
+
+// calibrationBounds limit the parameters that the calibration proposes
+type calibrationBounds struct {
+	minOverhead, maxOverhead uint64
+	minScalar, maxScalar     uint64
+	// maxChange is the largest relative change of a parameter per update
+	maxChange float64
+}
+
+// FeeCalibration is the result of comparing the L1 fees paid for the batch
+// submissions with the L1 fees charged to the users of the batches
+type FeeCalibration struct {
+	Batches          int
+	Transactions     int
+	Paid             *big.Int
+	Charged          *big.Int
+	Overhead         uint64
+	Scalar           uint64
+	ProposedOverhead uint64
+	ProposedScalar   uint64
+}
+
+// calibrateFeeParams proposes the overhead and the scalar that would have
+// charged the users exactly what the batches cost. The overhead is the L1
+// gas of the batches beyond the calldata gas of their transactions, spread
+// over the transactions, and the scalar then scales the L1 gas price seen
+// by the users to the price the batches paid. The calldata gas of a
+// transaction is taken from its receipt less the current overhead.
+func calibrateFeeParams(params *feeParams, batches []*batchCost, charges map[uint64][]*l2FeeCharge, bounds *calibrationBounds) (*FeeCalibration, error) {
+	var (
+		paid      = new(big.Int)
+		charged   = new(big.Int)
+		batchGas  = uint64(0)
+		dataGas   = uint64(0)
+		txs       = 0
+		batchesIn = 0
+		included  []*l2FeeCharge
+	)
+	for _, batch := range batches {
+		var batchCharges []*l2FeeCharge
+		for _, number := range batch.l2Blocks {
+			for _, charge := range charges[number] {
+				// Transactions from L1 are not charged an L1 fee
+				if charge.L1Fee == nil || charge.L1Fee.ToInt().Sign() == 0 {
+					continue
+				}
+				batchCharges = append(batchCharges, charge)
+			}
+		}
+		if len(batchCharges) == 0 {
+			continue
+		}
+		batchesIn++
+		batchGas += batch.gasUsed
+		paid.Add(paid, batch.fee)
+		for _, charge := range batchCharges {
+			txs++
+			charged.Add(charged, charge.L1Fee.ToInt())
+			if gas := charge.L1GasUsed.ToInt().Uint64(); gas > params.overhead {
+				dataGas += gas - params.overhead
+			}
+		}
+		included = append(included, batchCharges...)
+	}
+	if txs == 0 {
+		return nil, errNoCalibrationData
+	}
+
+	overhead := uint64(0)
+	if batchGas > dataGas {
+		overhead = (batchGas - dataGas) / uint64(txs)
+	}
+	overhead = boundParam(params.overhead, overhead, bounds.minOverhead, bounds.maxOverhead, bounds.maxChange)
+
+	// The fee the users would have been charged at a scalar of one
+	unscaled := new(big.Int)
+	for _, charge := range included {
+		gas := uint64(0)
+		if used := charge.L1GasUsed.ToInt().Uint64(); used > params.overhead {
+			gas = used - params.overhead
+		}
+		gas += overhead
+		unscaled.Add(unscaled, new(big.Int).Mul(new(big.Int).SetUint64(gas), charge.L1GasPrice.ToInt()))
+	}
+	if unscaled.Sign() == 0 {
+		return nil, errNoCalibrationData
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(params.decimals), nil)
+	scalar := new(big.Int).Mul(paid, scale)
+	scalar.Div(scalar, unscaled)
+	proposedScalar := uint64(math.MaxUint64)
+	if scalar.IsUint64() {
+		proposedScalar = scalar.Uint64()
+	}
+	proposedScalar = boundParam(params.scalar, proposedScalar, bounds.minScalar, bounds.maxScalar, bounds.maxChange)
+
+	return &FeeCalibration{
+		Batches:          batchesIn,
+		Transactions:     txs,
+		Paid:             paid,
+		Charged:          charged,
+		Overhead:         params.overhead,
+		Scalar:           params.scalar,
+		ProposedOverhead: overhead,
+		ProposedScalar:   proposedScalar,
+	}, nil
+}
+
+// boundParam limits a proposed parameter to its bounds and to the largest
+// change from its current value. A current value of zero is not limited by
+// the largest change.
+func boundParam(current, proposed, lower, upper uint64, maxChange float64) uint64 {
+	if current > 0 && maxChange > 0 {
+		low := uint64(math.Floor(float64(current) * math.Max(0, 1-maxChange)))
+		high := uint64(math.Ceil(float64(current) * (1 + maxChange)))
+		proposed = min(max(proposed, low), high)
+	}
+	if upper > 0 {
+		proposed = min(proposed, upper)
+	}
+	return max(proposed, lower)
+}
+
+// fetchBatchCosts returns the batches appended to the CTC for the L2 chain
+// in the L1 blocks from start to end, with what their submission cost
+func fetchBatchCosts(ctx context.Context, backend L1CalibrationBackend, ctc common.Address, l2ChainID *big.Int, start, end uint64) ([]*batchCost, error) {
+	ctcABI, err := abi.JSON(strings.NewReader(transactionBatchAppendedABI))
+	if err != nil {
+		return nil, err
+	}
+	event := ctcABI.Events["TransactionBatchAppended"]
+	logs, err := backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(start),
+		ToBlock:   new(big.Int).SetUint64(end),
+		Addresses: []common.Address{ctc},
+		Topics:    [][]common.Hash{{event.ID}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batches := make(map[common.Hash]*batchCost)
+	var order []*batchCost
+	for _, entry := range logs {
+		if entry.Removed {
+			continue
+		}
+		values, err := event.Inputs.NonIndexed().Unpack(entry.Data)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode batch in %s: %w", entry.TxHash.Hex(), err)
+		}
+		chainID, size, prevTotal := values[0].(*big.Int), values[2].(*big.Int), values[3].(*big.Int)
+		if l2ChainID != nil && chainID.Cmp(l2ChainID) != 0 {
+			continue
+		}
+		batch, ok := batches[entry.TxHash]
+		if !ok {
+			batch = &batchCost{txHash: entry.TxHash}
+			batches[entry.TxHash] = batch
+			order = append(order, batch)
+		}
+		// The L2 block of a transaction is its index in the CTC plus one
+		for i := uint64(1); i <= size.Uint64(); i++ {
+			batch.l2Blocks = append(batch.l2Blocks, prevTotal.Uint64()+i)
+		}
+	}
+
+	for _, batch := range order {
+		receipt, err := backend.TransactionReceipt(ctx, batch.txHash)
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch receipt of %s: %w", batch.txHash.Hex(), err)
+		}
+		tx, _, err := backend.TransactionByHash(ctx, batch.txHash)
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch batch %s: %w", batch.txHash.Hex(), err)
+		}
+		header, err := backend.HeaderByNumber(ctx, receipt.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		batch.gasUsed = receipt.GasUsed
+		batch.fee = new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), effectiveGasPrice(tx, header.BaseFee))
+	}
+	return order, nil
+}
+
+// effectiveGasPrice returns the price per gas that a transaction paid in a
+// block with the base fee
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		return tx.GasFeeCap()
+	}
+	return price
+}
+
+// fetchFeeCharges returns the L1 fees charged to the transactions of the L2
+// blocks, by block number. The blocks and the receipts are fetched in
+// batches of at most maxBatchSize.
+func fetchFeeCharges(ctx context.Context, backend L2ReceiptBackend, blocks []uint64) (map[uint64][]*l2FeeCharge, error) {
+	type blockTxs struct {
+		Transactions []common.Hash `json:"transactions"`
+	}
+	charges := make(map[uint64][]*l2FeeCharge)
+	if len(blocks) == 0 {
+		return charges, nil
+	}
+	bodies := make([]blockTxs, len(blocks))
+	reqs := make([]rpc.BatchElem, len(blocks))
+	for i, number := range blocks {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(number), false},
+			Result: &bodies[i],
+		}
+	}
+	if err := batchCall(ctx, backend, reqs); err != nil {
+		return nil, err
+	}
+
+	for i, req := range reqs {
+		if req.Error != nil {
+			return nil, fmt.Errorf("cannot fetch L2 block %d: %w", blocks[i], req.Error)
+		}
+	}
+
+	var (
+		owners   []uint64
+		receipts []*l2FeeCharge
+	)
+	reqs = reqs[:0]
+	for i, body := range bodies {
+		for _, hash := range body.Transactions {
+			charge := new(l2FeeCharge)
+			owners = append(owners, blocks[i])
+			receipts = append(receipts, charge)
+			reqs = append(reqs, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{hash},
+				Result: charge,
+			})
+		}
+	}
+	if len(reqs) == 0 {
+		return charges, nil
+	}
+	if err := batchCall(ctx, backend, reqs); err != nil {
+		return nil, err
+	}
+	for i, req := range reqs {
+		if req.Error != nil {
+			return nil, fmt.Errorf("cannot fetch L2 receipt in block %d: %w", owners[i], req.Error)
+		}
+		if receipts[i].L1GasUsed == nil || receipts[i].L1GasPrice == nil {
+			continue
+		}
+		charges[owners[i]] = append(charges[owners[i]], receipts[i])
+	}
+	return charges, nil
+}
+
+// wrapCalibrateFeeParams is used by the FeeCalibrationLoop to compare the
+// L1 fees paid for the recent batches with the L1 fees charged for them and
+// to propose or set the overhead and the scalar
+func wrapCalibrateFeeParams(l1Backend L1CalibrationBackend, l2Receipts L2ReceiptBackend, l2Backend DeployContractBackend, mgr *txmgr.TxManager, cfg *Config) (func() error, error) {
+	if cfg.privateKey == nil {
+		return nil, errNoPrivateKey
+	}
+	if cfg.l2ChainID == nil {
+		return nil, errNoChainID
+	}
+	if cfg.ctcAddress == (common.Address{}) {
+		return nil, errors.New("no CTC address provided")
+	}
+
+	contract, err := bindings.NewGasPriceOracle(cfg.gasPriceOracleAddress, l2Backend)
+	if err != nil {
+		return nil, err
+	}
+	bounds := &calibrationBounds{
+		minOverhead: cfg.minOverhead,
+		maxOverhead: cfg.maxOverhead,
+		minScalar:   cfg.minScalar,
+		maxScalar:   cfg.maxScalar,
+		maxChange:   cfg.feeCalibrationMaxChange,
+	}
+
+	return func() error {
+		ctx := context.Background()
+		callOpts := &bind.CallOpts{Context: ctx}
+		overhead, err := contract.Overhead(callOpts)
+		if err != nil {
+			return err
+		}
+		scalar, err := contract.Scalar(callOpts)
+		if err != nil {
+			return err
+		}
+		decimals, err := contract.Decimals(callOpts)
+		if err != nil {
+			return err
+		}
+		tip, err := l1Backend.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return err
+		}
+		end := tip.Number.Uint64()
+		start := uint64(0)
+		if end > cfg.feeCalibrationWindow {
+			start = end - cfg.feeCalibrationWindow
+		}
+
+		batches, err := fetchBatchCosts(ctx, l1Backend, cfg.ctcAddress, cfg.l2ChainID, start, end)
+		if err != nil {
+			return err
+		}
+		var blocks []uint64
+		for _, batch := range batches {
+			blocks = append(blocks, batch.l2Blocks...)
+		}
+		charges, err := fetchFeeCharges(ctx, l2Receipts, blocks)
+		if err != nil {
+			return err
+		}
+		params := &feeParams{overhead: overhead.Uint64(), scalar: scalar.Uint64(), decimals: decimals.Uint64()}
+		calibration, err := calibrateFeeParams(params, batches, charges, bounds)
+		if err != nil {
+			return err
+		}
+
+		l1FeePaidGauge.Update(calibration.Paid.Int64())
+		l1FeeChargedGauge.Update(calibration.Charged.Int64())
+		overheadProposedGauge.Update(int64(calibration.ProposedOverhead))
+		scalarProposedGauge.Update(int64(calibration.ProposedScalar))
+		log.Info("L1 fee calibration", "l1-blocks", fmt.Sprintf("%d-%d", start, end),
+			"batches", calibration.Batches, "txs", calibration.Transactions,
+			"paid", calibration.Paid, "charged", calibration.Charged,
+			"overhead", calibration.Overhead, "proposed-overhead", calibration.ProposedOverhead,
+			"scalar", calibration.Scalar, "proposed-scalar", calibration.ProposedScalar,
+			"decimals", params.decimals)
+		if cfg.feeCalibrationReportOnly {
+			return nil
+		}
+
+		if isDifferenceSignificant(calibration.Overhead, calibration.ProposedOverhead, cfg.feeCalibrationSignificanceFactor) {
+			overhead := new(big.Int).SetUint64(calibration.ProposedOverhead)
+			if err := sendFeeParamsTx(mgr, cfg, txKindOverhead, "setOverhead", overhead); err != nil {
+				return fmt.Errorf("cannot update overhead: %w", err)
+			}
+		}
+		if isDifferenceSignificant(calibration.Scalar, calibration.ProposedScalar, cfg.feeCalibrationSignificanceFactor) {
+			scalar := new(big.Int).SetUint64(calibration.ProposedScalar)
+			if err := sendFeeParamsTx(mgr, cfg, txKindScalar, "setScalar", scalar); err != nil {
+				return fmt.Errorf("cannot update scalar: %w", err)
+			}
+		}
+		return nil
+	}, nil
+}
+
+func sendFeeParamsTx(mgr *txmgr.TxManager, cfg *Config, kind, method string, value *big.Int) error {
+	tx, err := sendGasPriceOracleTx(mgr, cfg, kind, method, value)
+	if err != nil {
+		return err
+	}
+	log.Info("L1 fee parameters transaction sent", "hash", tx.Hash().Hex())
+	feeParamsUpdateCounter.Inc(1)
+
+	if cfg.waitForReceipt {
+		receipt, err := mgr.WaitMined(context.Background(), tx)
+		if err != nil {
+			return err
+		}
+		log.Info("L1 fee parameters transaction confirmed", "hash", receipt.TxHash.Hex(),
+			"gas-used", receipt.GasUsed, "blocknumber", receipt.BlockNumber)
+	}
+	return nil
+}
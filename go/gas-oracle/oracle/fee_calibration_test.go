@@ -0,0 +1,228 @@
+package oracle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func testFeeCharge(l1GasUsed, l1GasPrice, l1Fee uint64) *l2FeeCharge {
+	return &l2FeeCharge{
+		L1GasUsed:  (*hexutil.Big)(new(big.Int).SetUint64(l1GasUsed)),
+		L1GasPrice: (*hexutil.Big)(new(big.Int).SetUint64(l1GasPrice)),
+		L1Fee:      (*hexutil.Big)(new(big.Int).SetUint64(l1Fee)),
+	}
+}
+
+func TestCalibrateFeeParams(t *testing.T) {
+	const gwei = 1_000_000_000
+	params := &feeParams{overhead: 2100, scalar: 1_000_000, decimals: 6}
+	// Ten transactions of 5000 calldata gas each, charged at the current
+	// overhead and a scalar of one, and a transaction from L1
+	newCharges := func() map[uint64][]*l2FeeCharge {
+		charges := make(map[uint64][]*l2FeeCharge)
+		for number := uint64(1); number <= 10; number++ {
+			charges[number] = []*l2FeeCharge{testFeeCharge(7100, 20*gwei, 7100*20*gwei)}
+		}
+		charges[11] = []*l2FeeCharge{testFeeCharge(0, 20*gwei, 0)}
+		return charges
+	}
+	newBatch := func(gasUsed uint64) []*batchCost {
+		return []*batchCost{{
+			gasUsed:  gasUsed,
+			fee:      new(big.Int).SetUint64(gasUsed * 20 * gwei),
+			l2Blocks: []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		batchGas uint64
+		bounds   calibrationBounds
+		overhead uint64
+		scalar   uint64
+	}{
+		{"unbounded", 100_000, calibrationBounds{}, 5000, 1_000_000},
+		{"max change", 100_000, calibrationBounds{maxChange: 0.2}, 2520, 1_200_000},
+		{"upper bounds", 100_000, calibrationBounds{maxOverhead: 3000, maxScalar: 1_100_000}, 3000, 1_100_000},
+		{"lower bounds", 60_000, calibrationBounds{minOverhead: 1500}, 1500, 923_076},
+	}
+	for _, tt := range tests {
+		calibration, err := calibrateFeeParams(params, newBatch(tt.batchGas), newCharges(), &tt.bounds)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if calibration.Batches != 1 || calibration.Transactions != 10 {
+			t.Fatalf("%s: unexpected batches %d and transactions %d", tt.name, calibration.Batches, calibration.Transactions)
+		}
+		if calibration.Charged.Uint64() != 10*7100*20*gwei || calibration.Paid.Uint64() != tt.batchGas*20*gwei {
+			t.Fatalf("%s: unexpected charged %v and paid %v", tt.name, calibration.Charged, calibration.Paid)
+		}
+		if calibration.ProposedOverhead != tt.overhead || calibration.ProposedScalar != tt.scalar {
+			t.Errorf("%s: unexpected overhead %d and scalar %d", tt.name, calibration.ProposedOverhead, calibration.ProposedScalar)
+		}
+	}
+
+	// Batches of L1 transactions only are not calibrated on
+	batch := []*batchCost{{gasUsed: 50_000, fee: big.NewInt(1), l2Blocks: []uint64{11}}}
+	if _, err := calibrateFeeParams(params, batch, newCharges(), &calibrationBounds{}); !errors.Is(err, errNoCalibrationData) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// fakeCalibrationL1 holds the batch submissions of an L1 chain
+type fakeCalibrationL1 struct {
+	logs     []types.Log
+	txs      map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	baseFee  *big.Int
+}
+
+func (b *fakeCalibrationL1) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		number = big.NewInt(100)
+	}
+	return &types.Header{Number: number, BaseFee: b.baseFee}, nil
+}
+
+func (b *fakeCalibrationL1) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return b.logs, nil
+}
+
+func (b *fakeCalibrationL1) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return b.txs[hash], false, nil
+}
+
+func (b *fakeCalibrationL1) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	return b.receipts[hash], nil
+}
+
+func TestFetchBatchCosts(t *testing.T) {
+	ctcABI, err := abi.JSON(strings.NewReader(transactionBatchAppendedABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := ctcABI.Events["TransactionBatchAppended"]
+	l1 := &fakeCalibrationL1{
+		txs:      make(map[common.Hash]*types.Transaction),
+		receipts: make(map[common.Hash]*types.Receipt),
+		baseFee:  big.NewInt(10),
+	}
+	addBatch := func(chainID, size, prevTotal int64) common.Hash {
+		data, err := event.Inputs.NonIndexed().Pack(big.NewInt(chainID), [32]byte{}, big.NewInt(size), big.NewInt(prevTotal), []byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := types.NewTx(&types.DynamicFeeTx{Nonce: uint64(len(l1.txs)), GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(100)})
+		l1.txs[tx.Hash()] = tx
+		l1.receipts[tx.Hash()] = &types.Receipt{GasUsed: 1000, BlockNumber: big.NewInt(90)}
+		l1.logs = append(l1.logs, types.Log{TxHash: tx.Hash(), Topics: []common.Hash{event.ID}, Data: data})
+		return tx.Hash()
+	}
+	first := addBatch(1088, 2, 10)
+	addBatch(1089, 5, 0)
+	second := addBatch(1088, 1, 12)
+
+	batches, err := fetchBatchCosts(context.Background(), l1, common.Address{}, big.NewInt(1088), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0].txHash != first || batches[1].txHash != second {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+	if len(batches[0].l2Blocks) != 2 || batches[0].l2Blocks[0] != 11 || batches[0].l2Blocks[1] != 12 || batches[1].l2Blocks[0] != 13 {
+		t.Fatalf("unexpected L2 blocks: %v, %v", batches[0].l2Blocks, batches[1].l2Blocks)
+	}
+	// The batches paid the base fee and the tip
+	if batches[0].gasUsed != 1000 || batches[0].fee.Uint64() != 12_000 {
+		t.Fatalf("unexpected cost: %d, %v", batches[0].gasUsed, batches[0].fee)
+	}
+}
+
+// fakeReceiptRPC answers the block and receipt requests of the L1 fee
+// calibration and records the size of every batch
+type fakeReceiptRPC struct {
+	blocks   map[string][]common.Hash
+	receipts map[common.Hash]*l2FeeCharge
+	batches  []int
+}
+
+func (b *fakeReceiptRPC) BatchCallContext(ctx context.Context, reqs []rpc.BatchElem) error {
+	b.batches = append(b.batches, len(reqs))
+	for i := range reqs {
+		var result interface{}
+		switch reqs[i].Method {
+		case "eth_getBlockByNumber":
+			result = map[string]interface{}{"transactions": b.blocks[reqs[i].Args[0].(string)]}
+		case "eth_getTransactionReceipt":
+			result = b.receipts[reqs[i].Args[0].(common.Hash)]
+		}
+		raw, _ := json.Marshal(result)
+		reqs[i].Error = json.Unmarshal(raw, reqs[i].Result)
+	}
+	return nil
+}
+
+func TestFetchFeeCharges(t *testing.T) {
+	txA, txB, txC := common.Hash{0x0a}, common.Hash{0x0b}, common.Hash{0x0c}
+	backend := &fakeReceiptRPC{
+		blocks: map[string][]common.Hash{"0x1": {txA}, "0x2": {txB, txC}},
+		receipts: map[common.Hash]*l2FeeCharge{
+			txA: testFeeCharge(100, 2, 200),
+			txB: testFeeCharge(300, 2, 600),
+		},
+	}
+	charges, err := fetchFeeCharges(context.Background(), backend, []uint64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The receipt of txC is missing and block 3 is empty
+	if len(charges[1]) != 1 || len(charges[2]) != 1 || len(charges[3]) != 0 {
+		t.Fatalf("unexpected charges: %v", charges)
+	}
+	if charges[2][0].L1Fee.ToInt().Uint64() != 600 || charges[1][0].L1GasUsed.ToInt().Uint64() != 100 {
+		t.Fatalf("unexpected charges: %+v, %+v", charges[1][0], charges[2][0])
+	}
+}
+
+func TestFetchFeeChargesBatches(t *testing.T) {
+	backend := &fakeReceiptRPC{
+		blocks:   make(map[string][]common.Hash),
+		receipts: make(map[common.Hash]*l2FeeCharge),
+	}
+	blocks := make([]uint64, 150)
+	for i := range blocks {
+		blocks[i] = uint64(i + 1)
+		hash := common.BigToHash(big.NewInt(int64(i + 1)))
+		backend.blocks[hexutil.EncodeUint64(blocks[i])] = []common.Hash{hash}
+		backend.receipts[hash] = testFeeCharge(100, 2, 200)
+	}
+	charges, err := fetchFeeCharges(context.Background(), backend, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(charges) != 150 || len(charges[150]) != 1 {
+		t.Fatalf("unexpected charges of %d blocks", len(charges))
+	}
+	// The blocks and then the receipts are fetched in batches of at most
+	// maxBatchSize
+	want := []int{maxBatchSize, 50, maxBatchSize, 50}
+	if len(backend.batches) != len(want) {
+		t.Fatalf("unexpected batches: %v", backend.batches)
+	}
+	for i := range want {
+		if backend.batches[i] != want[i] {
+			t.Fatalf("unexpected batches: %v", backend.batches)
+		}
+	}
+}
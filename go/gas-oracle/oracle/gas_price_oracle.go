@@ -9,11 +9,13 @@ import (
 
 	"github.com/ethereum-optimism/optimism/go/gas-oracle/bindings"
 	"github.com/ethereum-optimism/optimism/go/gas-oracle/gasprices"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/txmgr"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 var (
@@ -43,7 +45,10 @@ type GasPriceOracle struct {
 	contract        *bindings.GasPriceOracle
 	l2Backend       DeployContractBackend
 	l1Backend       bind.ContractTransactor
+	l1Calibration   L1CalibrationBackend
+	l2Receipts      L2ReceiptBackend
 	gasPriceUpdater *gasprices.GasPriceUpdater
+	txManager       *txmgr.TxManager
 	config          *Config
 }
 
@@ -71,17 +76,23 @@ func (g *GasPriceOracle) Start() error {
 	}
 	gasPriceGauge.Update(int64(price.Uint64()))
 
+	// All the loops send their updates through the transaction manager
+	g.txManager.Start()
 	if g.config.enableL1BaseFee {
 		go g.BaseFeeLoop()
 	}
 	if g.config.enableL2GasPrice {
 		go g.Loop()
 	}
+	if g.config.enableFeeCalibration {
+		go g.FeeCalibrationLoop()
+	}
 
 	return nil
 }
 
 func (g *GasPriceOracle) Stop() {
+	g.txManager.Stop()
 	close(g.stop)
 }
 
@@ -129,7 +140,7 @@ func (g *GasPriceOracle) BaseFeeLoop() {
 	timer := time.NewTicker(15 * time.Second)
 	defer timer.Stop()
 
-	updateBaseFee, err := wrapUpdateBaseFee(g.l1Backend, g.l2Backend, g.config)
+	updateBaseFee, err := wrapUpdateBaseFee(g.l1Backend, g.l2Backend, g.txManager, g.config)
 	if err != nil {
 		panic(err)
 	}
@@ -147,6 +158,30 @@ func (g *GasPriceOracle) BaseFeeLoop() {
 	}
 }
 
+// FeeCalibrationLoop compares the L1 fees paid for the batches with the L1
+// fees charged for them and calibrates the overhead and the scalar
+func (g *GasPriceOracle) FeeCalibrationLoop() {
+	timer := time.NewTicker(g.config.feeCalibrationInterval)
+	defer timer.Stop()
+
+	calibrate, err := wrapCalibrateFeeParams(g.l1Calibration, g.l2Receipts, g.l2Backend, g.txManager, g.config)
+	if err != nil {
+		panic(err)
+	}
+
+	for {
+		select {
+		case <-timer.C:
+			if err := calibrate(); err != nil {
+				log.Error("cannot calibrate l1 fee", "message", err)
+			}
+
+		case <-g.ctx.Done():
+			g.Stop()
+		}
+	}
+}
+
 // Update will update the gas price
 func (g *GasPriceOracle) Update() error {
 	l2GasPrice, err := g.contract.GasPrice(&bind.CallOpts{
@@ -174,11 +209,12 @@ func (g *GasPriceOracle) Update() error {
 
 // NewGasPriceOracle creates a new GasPriceOracle based on a Config
 func NewGasPriceOracle(cfg *Config) (*GasPriceOracle, error) {
-	// Create the L2 client
-	l2Client, err := ethclient.Dial(cfg.layerTwoHttpUrl)
+	// Create the L2 client, the rpc client reads the l2geth receipts
+	l2RPC, err := rpc.Dial(cfg.layerTwoHttpUrl)
 	if err != nil {
 		return nil, err
 	}
+	l2Client := ethclient.NewClient(l2RPC)
 
 	l1Client, err := ethclient.Dial(cfg.ethereumHttpUrl)
 	if err != nil {
@@ -207,19 +243,26 @@ func NewGasPriceOracle(cfg *Config) (*GasPriceOracle, error) {
 		return nil, err
 	}
 
-	// Create a gas pricer for the gas price updater
-	log.Info("Creating GasPricer", "currentPrice", currentPrice,
+	// Create a pricing strategy for the gas price updater
+	log.Info("Creating pricing strategy", "strategy", cfg.l2GasPricingStrategy, "currentPrice", currentPrice,
 		"floorPrice", cfg.floorPrice, "targetGasPerSecond", cfg.targetGasPerSecond,
-		"maxPercentChangePerEpoch", cfg.maxPercentChangePerEpoch)
+		"targetGasPerBlock", cfg.targetGasPerBlock, "maxPercentChangePerEpoch", cfg.maxPercentChangePerEpoch)
 
-	gasPricer, err := gasprices.NewGasPricer(
-		currentPrice.Uint64(),
-		cfg.floorPrice,
-		func() float64 {
+	strategy, err := gasprices.NewPricingStrategy(currentPrice.Uint64(), &gasprices.StrategyConfig{
+		Name:       cfg.l2GasPricingStrategy,
+		FloorPrice: cfg.floorPrice,
+		GetTargetGasPerSecond: func() float64 {
 			return float64(cfg.targetGasPerSecond)
 		},
-		cfg.maxPercentChangePerEpoch,
-	)
+		MaxChangePerEpoch:  cfg.maxPercentChangePerEpoch,
+		TargetGasPerBlock:  cfg.targetGasPerBlock,
+		EIP1559Denominator: cfg.eip1559Denominator,
+		PIDKp:              cfg.pidKp,
+		PIDKi:              cfg.pidKi,
+		PIDKd:              cfg.pidKd,
+		Percentile:         cfg.congestionPercentile,
+		CongestionWindow:   cfg.congestionWindow,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -265,9 +308,15 @@ func NewGasPriceOracle(cfg *Config) (*GasPriceOracle, error) {
 	// getLatestBlockNumberFn is used by the GasPriceUpdater
 	// to get the latest block number
 	getLatestBlockNumberFn := wrapGetLatestBlockNumberFn(l2Client)
+	// txManager sends the transactions of all the loops so that they do
+	// not race for the nonces of the key
+	txManager, err := txmgr.NewTxManager(l2Client, cfg.txManagerConfig())
+	if err != nil {
+		return nil, err
+	}
 	// updateL2GasPriceFn is used by the GasPriceUpdater to
 	// update the gas price
-	updateL2GasPriceFn, err := wrapUpdateL2GasPriceFn(l2Client, cfg)
+	updateL2GasPriceFn, err := wrapUpdateL2GasPriceFn(l2Client, txManager, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -277,7 +326,7 @@ func NewGasPriceOracle(cfg *Config) (*GasPriceOracle, error) {
 		"epochLengthSeconds", cfg.epochLengthSeconds)
 
 	gasPriceUpdater, err := gasprices.NewGasPriceUpdater(
-		gasPricer,
+		strategy,
 		epochStartBlockNumber,
 		cfg.averageBlockGasLimitPerEpoch,
 		cfg.epochLengthSeconds,
@@ -288,6 +337,11 @@ func NewGasPriceOracle(cfg *Config) (*GasPriceOracle, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The strategies that price on the blocks of an epoch need the gas they
+	// used, the proportional one only counts them
+	if strategy.Name() != gasprices.StrategyProportional {
+		gasPriceUpdater.SetGetBlockGasUsedFn(wrapGetBlockGasUsedFn(l2RPC))
+	}
 
 	gpo := GasPriceOracle{
 		l2ChainID:       l2ChainID,
@@ -296,9 +350,12 @@ func NewGasPriceOracle(cfg *Config) (*GasPriceOracle, error) {
 		stop:            make(chan struct{}),
 		contract:        contract,
 		gasPriceUpdater: gasPriceUpdater,
+		txManager:       txManager,
 		config:          cfg,
 		l2Backend:       l2Client,
 		l1Backend:       l1Client,
+		l1Calibration:   l1Client,
+		l2Receipts:      l2RPC,
 	}
 
 	if err := gpo.ensure(); err != nil {
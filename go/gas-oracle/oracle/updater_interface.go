@@ -2,18 +2,19 @@ package oracle
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"math/big"
 	"time"
 
 	"github.com/ethereum-optimism/optimism/go/gas-oracle/bindings"
 	ometrics "github.com/ethereum-optimism/optimism/go/gas-oracle/metrics"
-	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/txmgr"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 var (
@@ -38,6 +39,66 @@ func wrapGetLatestBlockNumberFn(backend bind.ContractBackend) func() (uint64, er
 	}
 }
 
+// maxBatchSize is the largest number of requests sent in one json-rpc batch,
+// larger batches are split so that they are not rejected by the node
+const maxBatchSize = 100
+
+// BatchBackend is a client that sends batches of json-rpc requests
+type BatchBackend interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// batchCall sends the requests in batches of at most maxBatchSize, the
+// errors of the single requests are left in their elements
+func batchCall(ctx context.Context, backend BatchBackend, reqs []rpc.BatchElem) error {
+	for start := 0; start < len(reqs); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		if err := backend.BatchCallContext(ctx, reqs[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getBlockGasUsedFn is used by the GasPriceUpdater to get the
+// gas used by a range of blocks, the headers are fetched in batches
+func wrapGetBlockGasUsedFn(backend BatchBackend) func(uint64, uint64) ([]uint64, error) {
+	type blockGasUsed struct {
+		GasUsed hexutil.Uint64 `json:"gasUsed"`
+	}
+	return func(from, to uint64) ([]uint64, error) {
+		if from > to {
+			return nil, nil
+		}
+		headers := make([]*blockGasUsed, to-from+1)
+		reqs := make([]rpc.BatchElem, len(headers))
+		for i := range reqs {
+			reqs[i] = rpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{hexutil.EncodeUint64(from + uint64(i)), false},
+				Result: &headers[i],
+			}
+		}
+		if err := batchCall(context.Background(), backend, reqs); err != nil {
+			return nil, err
+		}
+		gasUsed := make([]uint64, len(headers))
+		for i, req := range reqs {
+			if req.Error != nil {
+				return nil, fmt.Errorf("cannot fetch L2 block %d: %w", from+uint64(i), req.Error)
+			}
+			if headers[i] == nil {
+				return nil, fmt.Errorf("L2 block %d not found", from+uint64(i))
+			}
+			gasUsed[i] = uint64(headers[i].GasUsed)
+		}
+		return gasUsed, nil
+	}
+}
+
 // DeployContractBackend represents the union of the
 // DeployBackend and the ContractBackend
 type DeployContractBackend interface {
@@ -49,7 +110,7 @@ type DeployContractBackend interface {
 // to update the L2 gas price
 // perhaps this should take an options struct along with the backend?
 // how can this continue to be decomposed?
-func wrapUpdateL2GasPriceFn(backend DeployContractBackend, cfg *Config) (func(uint64) error, error) {
+func wrapUpdateL2GasPriceFn(backend DeployContractBackend, mgr *txmgr.TxManager, cfg *Config) (func(uint64) error, error) {
 	if cfg.privateKey == nil {
 		return nil, errNoPrivateKey
 	}
@@ -57,19 +118,6 @@ func wrapUpdateL2GasPriceFn(backend DeployContractBackend, cfg *Config) (func(ui
 		return nil, errNoChainID
 	}
 
-	opts, err := bind.NewKeyedTransactorWithChainID(cfg.privateKey, cfg.l2ChainID)
-	if err != nil {
-		return nil, err
-	}
-	// Once https://github.com/ethereum/go-ethereum/pull/23062 is released
-	// then we can remove setting the context here
-	if opts.Context == nil {
-		opts.Context = context.Background()
-	}
-	// Don't send the transaction using the `contract` so that we can inspect
-	// it beforehand
-	opts.NoSend = true
-
 	// Create a new contract bindings in scope of the updateL2GasPriceFn
 	// that is returned from this function
 	contract, err := bindings.NewGasPriceOracle(cfg.gasPriceOracleAddress, backend)
@@ -79,19 +127,6 @@ func wrapUpdateL2GasPriceFn(backend DeployContractBackend, cfg *Config) (func(ui
 
 	return func(updatedGasPrice uint64) error {
 		log.Trace("UpdateL2GasPriceFn", "gas-price", updatedGasPrice)
-		if cfg.gasPrice == nil {
-			// Set the gas price manually to use legacy transactions
-			gasPrice, err := backend.SuggestGasPrice(context.Background())
-			if err != nil {
-				log.Error("cannot fetch gas price", "message", err)
-				return err
-			}
-			log.Trace("fetched L2 tx.gasPrice", "gas-price", gasPrice)
-			opts.GasPrice = gasPrice
-		} else {
-			// Allow a configurable gas price to be set
-			opts.GasPrice = cfg.gasPrice
-		}
 
 		// Query the current L2 gas price
 		currentPrice, err := contract.GasPrice(&bind.CallOpts{
@@ -118,16 +153,11 @@ func wrapUpdateL2GasPriceFn(backend DeployContractBackend, cfg *Config) (func(ui
 			return nil
 		}
 
-		// Set the gas price by sending a transaction
-		tx, err := contract.SetGasPrice(opts, new(big.Int).SetUint64(updatedGasPrice))
-		if err != nil {
-			return err
-		}
-
-		log.Debug("updating L2 gas price", "tx.gasPrice", tx.GasPrice(), "tx.gasLimit", tx.Gas(),
-			"tx.data", hexutil.Encode(tx.Data()), "tx.to", tx.To().Hex(), "tx.nonce", tx.Nonce())
+		// Set the gas price by sending a transaction, it replaces a
+		// previous update that is still pending
 		pre := time.Now()
-		if err := backend.SendTransaction(context.Background(), tx); err != nil {
+		tx, err := sendGasPriceOracleTx(mgr, cfg, txKindL2GasPrice, "setGasPrice", new(big.Int).SetUint64(updatedGasPrice))
+		if err != nil {
 			return err
 		}
 		txSendTimer.Update(time.Since(pre))
@@ -140,19 +170,48 @@ func wrapUpdateL2GasPriceFn(backend DeployContractBackend, cfg *Config) (func(ui
 			// Keep track of the time it takes to confirm the transaction
 			pre := time.Now()
 			// Wait for the receipt
-			receipt, err := waitForReceipt(backend, tx)
+			receipt, err := mgr.WaitMined(context.Background(), tx)
 			if err != nil {
 				return err
 			}
 			txConfTimer.Update(time.Since(pre))
 
-			log.Info("L2 gas price transaction confirmed", "hash", tx.Hash().Hex(),
+			log.Info("L2 gas price transaction confirmed", "hash", receipt.TxHash.Hex(),
 				"gas-used", receipt.GasUsed, "blocknumber", receipt.BlockNumber)
 		}
 		return nil
 	}, nil
 }
 
+// Kinds of the updates sent through the transaction manager, a pending
+// update is replaced by the next update of its kind
+const (
+	txKindL2GasPrice = "l2-gas-price"
+	txKindL1BaseFee  = "l1-base-fee"
+	txKindOverhead   = "overhead"
+	txKindScalar     = "scalar"
+)
+
+// sendGasPriceOracleTx calls a method of the GasPriceOracle through the
+// transaction manager
+func sendGasPriceOracleTx(mgr *txmgr.TxManager, cfg *Config, kind, method string, args ...interface{}) (*types.Transaction, error) {
+	gpoABI, err := bindings.GasPriceOracleMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data, err := gpoABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := mgr.Send(context.Background(), kind, cfg.gasPriceOracleAddress, data)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("sent GasPriceOracle transaction", "kind", kind, "tx.gasPrice", tx.GasPrice(), "tx.gasLimit", tx.Gas(),
+		"tx.data", hexutil.Encode(tx.Data()), "tx.to", tx.To().Hex(), "tx.nonce", tx.Nonce())
+	return tx, nil
+}
+
 // Only update the gas price when it must be changed by at least
 // a paramaterizable amount. If the param is greater than the result
 // of 1 - (min/max) where min and max are the gas prices then do not
@@ -164,27 +223,6 @@ func isDifferenceSignificant(a, b uint64, c float64) bool {
 	return c <= factor
 }
 
-// Wait for the receipt by polling the backend
-func waitForReceipt(backend DeployContractBackend, tx *types.Transaction) (*types.Receipt, error) {
-	t := time.NewTicker(300 * time.Millisecond)
-	receipt := new(types.Receipt)
-	var err error
-	for range t.C {
-		receipt, err = backend.TransactionReceipt(context.Background(), tx.Hash())
-		if errors.Is(err, ethereum.NotFound) {
-			continue
-		}
-		if err != nil {
-			return nil, err
-		}
-		if receipt != nil {
-			t.Stop()
-			break
-		}
-	}
-	return receipt, nil
-}
-
 func max(a, b uint64) uint64 {
 	if a >= b {
 		return a
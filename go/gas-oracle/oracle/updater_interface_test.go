@@ -3,17 +3,22 @@ package oracle
 import (
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/go/gas-oracle/bindings"
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/txmgr"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 func TestWrapGetLatestBlockNumberFn(t *testing.T) {
@@ -51,6 +56,50 @@ func TestWrapGetLatestBlockNumberFn(t *testing.T) {
 	}
 }
 
+// fakeBlockRPC answers the block requests of the gas used of the blocks up
+// to latest and records the size of every batch
+type fakeBlockRPC struct {
+	latest  uint64
+	batches []int
+}
+
+func (b *fakeBlockRPC) BatchCallContext(ctx context.Context, reqs []rpc.BatchElem) error {
+	b.batches = append(b.batches, len(reqs))
+	for i := range reqs {
+		number, err := hexutil.DecodeUint64(reqs[i].Args[0].(string))
+		if err != nil {
+			return err
+		}
+		var result interface{}
+		if number <= b.latest {
+			result = map[string]interface{}{"gasUsed": hexutil.Uint64(number * 10)}
+		}
+		raw, _ := json.Marshal(result)
+		reqs[i].Error = json.Unmarshal(raw, reqs[i].Result)
+	}
+	return nil
+}
+
+func TestWrapGetBlockGasUsedFn(t *testing.T) {
+	backend := &fakeBlockRPC{latest: 300}
+	getGasUsed := wrapGetBlockGasUsedFn(backend)
+
+	gasUsed, err := getGasUsed(51, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gasUsed) != 250 || gasUsed[0] != 510 || gasUsed[249] != 3000 {
+		t.Fatalf("unexpected gas used: %d blocks, %v", len(gasUsed), gasUsed)
+	}
+	// The headers are fetched in batches of at most maxBatchSize
+	if len(backend.batches) != 3 || backend.batches[0] != maxBatchSize || backend.batches[2] != 50 {
+		t.Fatalf("unexpected batches: %v", backend.batches)
+	}
+	if _, err := getGasUsed(299, 301); err == nil {
+		t.Fatal("expected an error for a missing block")
+	}
+}
+
 func TestWrapUpdateL2GasPriceFn(t *testing.T) {
 	key, _ := crypto.GenerateKey()
 	sim, _ := newSimulatedBackend(key)
@@ -67,9 +116,12 @@ func TestWrapUpdateL2GasPriceFn(t *testing.T) {
 		l2ChainID:             big.NewInt(1337),
 		gasPriceOracleAddress: addr,
 		gasPrice:              big.NewInt(783460975),
+		txPriceBump:           0.1,
+		txResubmissionTimeout: time.Minute,
+		txPollInterval:        time.Second,
 	}
 
-	updateL2GasPriceFn, err := wrapUpdateL2GasPriceFn(sim, cfg)
+	updateL2GasPriceFn, err := wrapUpdateL2GasPriceFn(sim, newTestTxManager(t, sim, cfg), cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -107,10 +159,13 @@ func TestWrapUpdateL2GasPriceFnNoUpdates(t *testing.T) {
 		l2ChainID:             big.NewInt(1337),
 		gasPriceOracleAddress: addr,
 		gasPrice:              big.NewInt(772763153),
+		txPriceBump:           0.1,
+		txResubmissionTimeout: time.Minute,
+		txPollInterval:        time.Second,
 		// the new gas price must change be 50% for it to actually update
 		l2GasPriceSignificanceFactor: 0.5,
 	}
-	updateL2GasPriceFn, err := wrapUpdateL2GasPriceFn(sim, cfg)
+	updateL2GasPriceFn, err := wrapUpdateL2GasPriceFn(sim, newTestTxManager(t, sim, cfg), cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -191,6 +246,16 @@ func TestIsDifferenceSignificant(t *testing.T) {
 	}
 }
 
+// newTestTxManager creates the transaction manager the updates of a test
+// are sent through
+func newTestTxManager(t *testing.T, backend txmgr.Backend, cfg *Config) *txmgr.TxManager {
+	mgr, err := txmgr.NewTxManager(backend, cfg.txManagerConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mgr
+}
+
 func newSimulatedBackend(key *ecdsa.PrivateKey) (*backends.SimulatedBackend, ethdb.Database) {
 	var gasLimit uint64 = 9_000_000
 	auth, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
@@ -0,0 +1,411 @@
+package txmgr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ometrics "github.com/ethereum-optimism/optimism/go/gas-oracle/metrics"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// ErrAbandoned is returned when waiting for a transaction whose nonce
+	// was used by a transaction the manager did not send
+	ErrAbandoned = errors.New("transaction abandoned")
+	// errMinPriceBump represents the error when the price bump is lower than
+	// what nodes require to replace a transaction
+	errMinPriceBump = errors.New("price bump must be at least 0.1")
+
+	sentCounter        = metrics.NewRegisteredCounter("txmgr/sent", ometrics.DefaultRegistry)
+	resubmittedCounter = metrics.NewRegisteredCounter("txmgr/resubmitted", ometrics.DefaultRegistry)
+	supersededCounter  = metrics.NewRegisteredCounter("txmgr/superseded", ometrics.DefaultRegistry)
+	abandonedCounter   = metrics.NewRegisteredCounter("txmgr/abandoned", ometrics.DefaultRegistry)
+	nonceResyncCounter = metrics.NewRegisteredCounter("txmgr/nonce-resync", ometrics.DefaultRegistry)
+	pendingGauge       = metrics.NewRegisteredGauge("txmgr/pending", ometrics.DefaultRegistry)
+	gasPriceGauge      = metrics.NewRegisteredGauge("txmgr/gas-price", ometrics.DefaultRegistry)
+	confirmedTimer     = metrics.NewRegisteredTimer("txmgr/confirmed", ometrics.DefaultRegistry)
+)
+
+// Backend is the chain the transactions are sent to, implemented by the
+// ethclient and the simulated backend
+type Backend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Config is the configuration of a TxManager
+type Config struct {
+	ChainID    *big.Int
+	PrivateKey *ecdsa.PrivateKey
+	// GasPrice is the gas price of new transactions, the suggested gas
+	// price is used if it is nil
+	GasPrice *big.Int
+	// MaxGasPrice caps the bumped gas price, no cap if it is nil
+	MaxGasPrice *big.Int
+	// PriceBump is the relative increase of the gas price of a replacement
+	PriceBump float64
+	// ResubmissionTimeout is how long a transaction may stay unmined before
+	// it is replaced
+	ResubmissionTimeout time.Duration
+	// PollInterval is the time between two checks of the pending
+	// transactions
+	PollInterval time.Duration
+}
+
+// pendingTx is a nonce in use by a transaction that is not mined yet. Every
+// replacement of it shares the nonce, the latest one carries the newest
+// value of its kind.
+type pendingTx struct {
+	kind     string
+	nonce    uint64
+	to       common.Address
+	data     []byte
+	gas      uint64
+	gasPrice *big.Int
+	hashes   []common.Hash
+	created  time.Time
+	sent     time.Time
+
+	done    chan struct{}
+	receipt *types.Receipt
+}
+
+// TxManager sends the transactions of all the loops of the gas-oracle from
+// one key. It assigns the nonces, keeps one pending transaction per kind of
+// update, replaces it with the newest value of its kind, and bumps its gas
+// price when it is not mined in time.
+type TxManager struct {
+	cfg     Config
+	backend Backend
+	signer  types.Signer
+	from    common.Address
+
+	mu      sync.Mutex
+	nonce   uint64
+	synced  bool
+	pending map[uint64]*pendingTx
+	kinds   map[string]*pendingTx
+	now     func() time.Time
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewTxManager creates a TxManager and checks its config beforehand
+func NewTxManager(backend Backend, cfg Config) (*TxManager, error) {
+	if cfg.PrivateKey == nil {
+		return nil, errors.New("no private key provided")
+	}
+	if cfg.ChainID == nil {
+		return nil, errors.New("no chain id provided")
+	}
+	if cfg.PriceBump < 0.1 {
+		return nil, errMinPriceBump
+	}
+	if cfg.ResubmissionTimeout <= 0 || cfg.PollInterval <= 0 {
+		return nil, errors.New("resubmission timeout and poll interval must be positive")
+	}
+	return &TxManager{
+		cfg:     cfg,
+		backend: backend,
+		signer:  types.NewEIP155Signer(cfg.ChainID),
+		from:    crypto.PubkeyToAddress(cfg.PrivateKey.PublicKey),
+		pending: make(map[uint64]*pendingTx),
+		kinds:   make(map[string]*pendingTx),
+		now:     time.Now,
+		quit:    make(chan struct{}),
+	}, nil
+}
+
+// From returns the address the transactions are sent from
+func (m *TxManager) From() common.Address {
+	return m.from
+}
+
+// Start checks the pending transactions every poll interval until Stop is
+// called
+func (m *TxManager) Start() {
+	m.wg.Add(1)
+	go m.loop()
+}
+
+// Stop ends the checks of the pending transactions
+func (m *TxManager) Stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+func (m *TxManager) loop() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.poll(context.Background())
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// Send sends a call of to with data as the newest update of its kind. If an
+// update of the same kind is still pending, it is replaced by this one at
+// the same nonce, so that the superseded value is never applied.
+func (m *TxManager) Send(ctx context.Context, kind string, to common.Address, data []byte) (*types.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gas, err := m.backend.EstimateGas(ctx, ethereum.CallMsg{From: m.from, To: &to, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("cannot estimate gas: %w", err)
+	}
+	if p, ok := m.kinds[kind]; ok {
+		if receipt := m.receipt(ctx, p); receipt != nil {
+			m.confirm(p, receipt)
+		}
+	}
+	if p, ok := m.kinds[kind]; ok {
+		log.Info("Superseding pending transaction", "kind", kind, "nonce", p.nonce, "hash", p.hashes[len(p.hashes)-1].Hex())
+		supersededCounter.Inc(1)
+		p.to, p.data, p.gas = to, data, gas
+		tx, err := m.resubmit(ctx, p)
+		if err != nil {
+			// The newest value stays on the pending transaction and is
+			// sent with the next resubmission
+			log.Warn("Cannot replace pending transaction", "kind", kind, "nonce", p.nonce, "message", err)
+			return nil, err
+		}
+		return tx, nil
+	}
+
+	if !m.synced {
+		if err := m.syncNonce(ctx); err != nil {
+			return nil, err
+		}
+	}
+	gasPrice, err := m.gasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p := &pendingTx{kind: kind, nonce: m.nonce, to: to, data: data, gas: gas, gasPrice: gasPrice, created: m.now(), done: make(chan struct{})}
+	tx, err := m.sign(p)
+	if err != nil {
+		return nil, err
+	}
+	err = m.backend.SendTransaction(ctx, tx)
+	if isNonceError(err) {
+		// Another sender used the key, or a transaction was dropped
+		log.Warn("Resyncing nonce", "nonce", m.nonce, "message", err)
+		if err := m.syncNonce(ctx); err != nil {
+			return nil, err
+		}
+		p.nonce = m.nonce
+		if tx, err = m.sign(p); err != nil {
+			return nil, err
+		}
+		err = m.backend.SendTransaction(ctx, tx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.hashes = append(p.hashes, tx.Hash())
+	p.sent = m.now()
+	m.pending[p.nonce] = p
+	m.kinds[kind] = p
+	m.nonce++
+	sentCounter.Inc(1)
+	pendingGauge.Update(int64(len(m.pending)))
+	gasPriceGauge.Update(gasPrice.Int64())
+	log.Debug("Transaction sent", "kind", kind, "nonce", p.nonce, "hash", tx.Hash().Hex(), "gas-price", gasPrice)
+	return tx, nil
+}
+
+// WaitMined waits until the nonce of tx is mined and returns the receipt of
+// the transaction that used it, tx or one of its replacements
+func (m *TxManager) WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	m.mu.Lock()
+	p, ok := m.pending[tx.Nonce()]
+	m.mu.Unlock()
+	if !ok {
+		return m.backend.TransactionReceipt(ctx, tx.Hash())
+	}
+	select {
+	case <-p.done:
+		if p.receipt == nil {
+			return nil, ErrAbandoned
+		}
+		return p.receipt, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Pending returns the number of nonces in use by unmined transactions
+func (m *TxManager) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+// poll confirms the mined transactions, abandons the ones whose nonce was
+// used by another transaction and resubmits the ones that are not mined in
+// time
+func (m *TxManager) poll(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) == 0 {
+		return
+	}
+	mined, err := m.backend.NonceAt(ctx, m.from, nil)
+	if err != nil {
+		log.Error("Cannot fetch nonce", "message", err)
+		return
+	}
+	nonces := make([]uint64, 0, len(m.pending))
+	for nonce := range m.pending {
+		nonces = append(nonces, nonce)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+
+	for _, nonce := range nonces {
+		p := m.pending[nonce]
+		if receipt := m.receipt(ctx, p); receipt != nil {
+			m.confirm(p, receipt)
+			continue
+		}
+		if nonce < mined {
+			log.Warn("Transaction abandoned, its nonce is used", "kind", p.kind, "nonce", p.nonce)
+			abandonedCounter.Inc(1)
+			m.remove(p)
+			continue
+		}
+		if m.now().Sub(p.sent) >= m.cfg.ResubmissionTimeout {
+			if _, err := m.resubmit(ctx, p); err != nil {
+				log.Warn("Cannot resubmit transaction", "kind", p.kind, "nonce", p.nonce, "message", err)
+			}
+		}
+	}
+	pendingGauge.Update(int64(len(m.pending)))
+}
+
+// receipt returns the receipt of any transaction sent with the nonce of p
+func (m *TxManager) receipt(ctx context.Context, p *pendingTx) *types.Receipt {
+	for i := len(p.hashes) - 1; i >= 0; i-- {
+		receipt, err := m.backend.TransactionReceipt(ctx, p.hashes[i])
+		if err == nil && receipt != nil {
+			return receipt
+		}
+	}
+	return nil
+}
+
+func (m *TxManager) confirm(p *pendingTx, receipt *types.Receipt) {
+	log.Info("Transaction confirmed", "kind", p.kind, "nonce", p.nonce, "hash", receipt.TxHash.Hex(),
+		"gas-used", receipt.GasUsed, "blocknumber", receipt.BlockNumber)
+	confirmedTimer.Update(m.now().Sub(p.created))
+	p.receipt = receipt
+	m.remove(p)
+	pendingGauge.Update(int64(len(m.pending)))
+}
+
+func (m *TxManager) remove(p *pendingTx) {
+	delete(m.pending, p.nonce)
+	if m.kinds[p.kind] == p {
+		delete(m.kinds, p.kind)
+	}
+	close(p.done)
+}
+
+// resubmit sends the latest value of p at its nonce with a bumped gas price
+func (m *TxManager) resubmit(ctx context.Context, p *pendingTx) (*types.Transaction, error) {
+	gasPrice, err := m.gasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bumped, _ := new(big.Float).Mul(new(big.Float).SetInt(p.gasPrice), big.NewFloat(1+m.cfg.PriceBump)).Int(nil)
+	if bumped.Cmp(gasPrice) > 0 {
+		gasPrice = bumped
+	}
+	if m.cfg.MaxGasPrice != nil && gasPrice.Cmp(m.cfg.MaxGasPrice) > 0 {
+		gasPrice = new(big.Int).Set(m.cfg.MaxGasPrice)
+	}
+	previous := p.gasPrice
+	p.gasPrice = gasPrice
+	tx, err := m.sign(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.backend.SendTransaction(ctx, tx); err != nil {
+		p.gasPrice = previous
+		return nil, err
+	}
+	p.hashes = append(p.hashes, tx.Hash())
+	p.sent = m.now()
+	resubmittedCounter.Inc(1)
+	gasPriceGauge.Update(gasPrice.Int64())
+	log.Info("Transaction resubmitted", "kind", p.kind, "nonce", p.nonce, "hash", tx.Hash().Hex(), "gas-price", gasPrice)
+	return tx, nil
+}
+
+func (m *TxManager) gasPrice(ctx context.Context) (*big.Int, error) {
+	if m.cfg.GasPrice != nil {
+		return new(big.Int).Set(m.cfg.GasPrice), nil
+	}
+	gasPrice, err := m.backend.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch gas price: %w", err)
+	}
+	return gasPrice, nil
+}
+
+func (m *TxManager) sign(p *pendingTx) (*types.Transaction, error) {
+	tx := types.NewTransaction(p.nonce, p.to, new(big.Int), p.gas, p.gasPrice, p.data)
+	return types.SignTx(tx, m.signer, m.cfg.PrivateKey)
+}
+
+// syncNonce continues after the pending nonce of the node, or after the
+// pending transactions of the manager if they are ahead
+func (m *TxManager) syncNonce(ctx context.Context) error {
+	nonce, err := m.backend.PendingNonceAt(ctx, m.from)
+	if err != nil {
+		return fmt.Errorf("cannot fetch nonce: %w", err)
+	}
+	for pending := range m.pending {
+		if pending >= nonce {
+			nonce = pending + 1
+		}
+	}
+	if m.synced {
+		nonceResyncCounter.Inc(1)
+	}
+	m.nonce, m.synced = nonce, true
+	return nil
+}
+
+// isNonceError reports whether the node rejected a transaction for its
+// nonce, the messages differ between geth and the simulated backend
+func isNonceError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "nonce too high") ||
+		strings.Contains(msg, "invalid transaction nonce")
+}
@@ -0,0 +1,273 @@
+package txmgr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/go/gas-oracle/bindings"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testManager is a TxManager on a simulated chain with a GasPriceOracle,
+// with a clock that the test moves
+type testManager struct {
+	*TxManager
+	sim  *backends.SimulatedBackend
+	gpo  *bindings.GasPriceOracle
+	addr common.Address
+	opts *bind.TransactOpts
+	time time.Time
+}
+
+func newTestManager(t *testing.T, maxGasPrice *big.Int) *testManager {
+	key, _ := crypto.GenerateKey()
+	opts, _ := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	alloc := core.GenesisAlloc{opts.From: {Balance: big.NewInt(9223372036854775807)}}
+	sim := backends.NewSimulatedBackend(alloc, 9_000_000)
+	addr, _, gpo, err := bindings.DeployGasPriceOracle(opts, sim, opts.From)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+
+	mgr, err := NewTxManager(sim, testConfig(key, maxGasPrice))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := &testManager{TxManager: mgr, sim: sim, gpo: gpo, addr: addr, opts: opts, time: time.Unix(1_000_000, 0)}
+	mgr.now = func() time.Time { return tm.time }
+	return tm
+}
+
+func testConfig(key *ecdsa.PrivateKey, maxGasPrice *big.Int) Config {
+	return Config{
+		ChainID:             big.NewInt(1337),
+		PrivateKey:          key,
+		MaxGasPrice:         maxGasPrice,
+		PriceBump:           0.1,
+		ResubmissionTimeout: time.Minute,
+		PollInterval:        time.Second,
+	}
+}
+
+// setGasPrice sends a setGasPrice call through the manager
+func (tm *testManager) setGasPrice(t *testing.T, price int64) error {
+	gpoABI, err := bindings.GasPriceOracleMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := gpoABI.Pack("setGasPrice", big.NewInt(price))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tm.Send(context.Background(), "gas-price", tm.addr, data)
+	return err
+}
+
+func (tm *testManager) checkGasPrice(t *testing.T, want int64) {
+	t.Helper()
+	price, err := tm.gpo.GasPrice(&bind.CallOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Int64() != want {
+		t.Fatalf("unexpected gas price: want %d, got %d", want, price)
+	}
+}
+
+func (tm *testManager) checkPending(t *testing.T, want int) {
+	t.Helper()
+	if pending := tm.Pending(); pending != want {
+		t.Fatalf("unexpected pending transactions: want %d, got %d", want, pending)
+	}
+}
+
+func TestTxManagerSend(t *testing.T) {
+	tm := newTestManager(t, nil)
+	for i := int64(1); i <= 3; i++ {
+		if err := tm.setGasPrice(t, i); err != nil {
+			t.Fatal(err)
+		}
+		tm.checkPending(t, 1)
+		tm.sim.Commit()
+		tm.checkGasPrice(t, i)
+	}
+	tm.poll(context.Background())
+	tm.checkPending(t, 0)
+	// The deployment used the first nonce
+	if tm.nonce != 4 {
+		t.Fatalf("unexpected nonce %d", tm.nonce)
+	}
+}
+
+func TestTxManagerSupersede(t *testing.T) {
+	tm := newTestManager(t, nil)
+	if err := tm.setGasPrice(t, 10); err != nil {
+		t.Fatal(err)
+	}
+	first := tm.kinds["gas-price"].gasPrice
+	// Drop the first transaction, a newer value is sent at its nonce with a
+	// higher gas price
+	tm.sim.Rollback()
+	if err := tm.setGasPrice(t, 20); err != nil {
+		t.Fatal(err)
+	}
+	p := tm.kinds["gas-price"]
+	if p.nonce != 1 || len(p.hashes) != 2 {
+		t.Fatalf("unexpected replacement: nonce %d, %d hashes", p.nonce, len(p.hashes))
+	}
+	if want := new(big.Int).Div(new(big.Int).Mul(first, big.NewInt(11)), big.NewInt(10)); p.gasPrice.Cmp(want) < 0 {
+		t.Fatalf("gas price not bumped: %v, want at least %v", p.gasPrice, want)
+	}
+	tm.sim.Commit()
+	tm.checkGasPrice(t, 20)
+
+	receipt, err := tm.sim.TransactionReceipt(context.Background(), p.hashes[1])
+	if err != nil || receipt == nil {
+		t.Fatalf("replacement not mined: %v", err)
+	}
+	tm.poll(context.Background())
+	tm.checkPending(t, 0)
+}
+
+func TestTxManagerResubmit(t *testing.T) {
+	tm := newTestManager(t, big.NewInt(1_000_000_000_000))
+	if err := tm.setGasPrice(t, 5); err != nil {
+		t.Fatal(err)
+	}
+	p := tm.kinds["gas-price"]
+	sent := new(big.Int).Set(p.gasPrice)
+
+	// The transaction is stuck, it is left alone until the timeout
+	tm.sim.Rollback()
+	tm.time = tm.time.Add(30 * time.Second)
+	tm.poll(context.Background())
+	if len(p.hashes) != 1 {
+		t.Fatal("resubmitted before the timeout")
+	}
+	tm.time = tm.time.Add(30 * time.Second)
+	tm.poll(context.Background())
+	if len(p.hashes) != 2 || p.gasPrice.Cmp(sent) <= 0 {
+		t.Fatalf("not resubmitted: %d hashes at %v", len(p.hashes), p.gasPrice)
+	}
+
+	// WaitMined returns the receipt of the replacement
+	tx := mustSign(t, tm, p)
+	done := make(chan error, 1)
+	go func() {
+		receipt, err := tm.WaitMined(context.Background(), tx)
+		if err == nil && receipt.TxHash != p.hashes[1] {
+			err = errors.New("unexpected receipt")
+		}
+		done <- err
+	}()
+	tm.sim.Commit()
+	tm.poll(context.Background())
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	tm.checkGasPrice(t, 5)
+	tm.checkPending(t, 0)
+}
+
+func TestTxManagerMaxGasPrice(t *testing.T) {
+	tm := newTestManager(t, nil)
+	if err := tm.setGasPrice(t, 5); err != nil {
+		t.Fatal(err)
+	}
+	p := tm.kinds["gas-price"]
+	tm.cfg.MaxGasPrice = new(big.Int).Add(p.gasPrice, big.NewInt(1))
+	for i := 0; i < 3; i++ {
+		tm.sim.Rollback()
+		tm.time = tm.time.Add(time.Minute)
+		tm.poll(context.Background())
+	}
+	if len(p.hashes) != 4 || p.gasPrice.Cmp(tm.cfg.MaxGasPrice) != 0 {
+		t.Fatalf("gas price not capped: %d hashes at %v", len(p.hashes), p.gasPrice)
+	}
+}
+
+func TestTxManagerNonceResync(t *testing.T) {
+	tm := newTestManager(t, nil)
+	if err := tm.setGasPrice(t, 1); err != nil {
+		t.Fatal(err)
+	}
+	tm.sim.Commit()
+
+	// Another sender uses the key, the manager continues after it
+	if _, err := tm.gpo.SetGasPrice(tm.opts, big.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+	tm.sim.Commit()
+	if err := tm.setGasPrice(t, 3); err != nil {
+		t.Fatal(err)
+	}
+	tm.sim.Commit()
+	tm.checkGasPrice(t, 3)
+	if tm.nonce != 4 {
+		t.Fatalf("unexpected nonce %d", tm.nonce)
+	}
+}
+
+func TestTxManagerAbandon(t *testing.T) {
+	tm := newTestManager(t, nil)
+	if err := tm.setGasPrice(t, 1); err != nil {
+		t.Fatal(err)
+	}
+	p := tm.kinds["gas-price"]
+	tm.sim.Rollback()
+
+	// Another sender uses the nonce of the pending transaction
+	if _, err := tm.gpo.SetGasPrice(tm.opts, big.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+	tm.sim.Commit()
+
+	tm.poll(context.Background())
+	tm.checkPending(t, 0)
+	select {
+	case <-p.done:
+	default:
+		t.Fatal("abandoned transaction is still waited for")
+	}
+	if p.receipt != nil {
+		t.Fatal("abandoned transaction has a receipt")
+	}
+}
+
+func TestNewTxManager(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"no key", func(cfg *Config) { cfg.PrivateKey = nil }},
+		{"no chain id", func(cfg *Config) { cfg.ChainID = nil }},
+		{"low price bump", func(cfg *Config) { cfg.PriceBump = 0.05 }},
+		{"no timeout", func(cfg *Config) { cfg.ResubmissionTimeout = 0 }},
+	}
+	for _, tt := range tests {
+		cfg := testConfig(key, nil)
+		tt.modify(&cfg)
+		if _, err := NewTxManager(nil, cfg); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func mustSign(t *testing.T, tm *testManager, p *pendingTx) *types.Transaction {
+	tx, err := tm.sign(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
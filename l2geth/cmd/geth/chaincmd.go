@@ -268,8 +268,9 @@ func initGenesis(ctx *cli.Context) error {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
 	// Open an initialise both full and light databases
-	stack := makeFullNode(ctx)
+	stack, cfg := makeConfigNode(ctx)
 	defer stack.Close()
+	genesis.UsingOVM = cfg.Eth.OVM.UsingOVM
 
 	for _, name := range []string{"chaindata", "lightchaindata"} {
 		chaindb, err := stack.OpenDatabase(name, 0, 0, "")
@@ -154,10 +154,28 @@ var (
 	optimismFlags = []cli.Flag{
 		utils.Eth1SyncServiceEnable,
 		utils.Eth1CanonicalTransactionChainDeployHeightFlag,
+		utils.RollupUsingOVMFlag,
+		utils.RollupDeSeqBlockFlag,
+		utils.RollupPeerHealthCheckFlag,
+		utils.RollupChainIDFlag,
 		utils.RollupClientHttpFlag,
+		utils.RollupClientTimeoutFlag,
+		utils.RollupClientRetriesFlag,
+		utils.RollupClientMaxLagFlag,
+		utils.RollupClientStreamFlag,
+		utils.RollupClientL1HttpFlag,
+		utils.RollupClientL1ConfirmationsFlag,
+		utils.RollupCTCAddressFlag,
+		utils.RollupSCCAddressFlag,
 		utils.RollupEnableVerifierFlag,
 		utils.RollupTimstampRefreshFlag,
 		utils.RollupPollIntervalFlag,
+		utils.RollupPrefetchDepthFlag,
+		utils.RollupReorgEnableFlag,
+		utils.RollupReorgMaxDepthFlag,
+		utils.RollupReorgAlarmOnlyFlag,
+		utils.RollupMismatchPolicyFlag,
+		utils.RollupForceInclusionWindowFlag,
 		utils.RollupMaxCalldataSizeFlag,
 		utils.RollupBackendFlag,
 		utils.RollupEnforceFeesFlag,
@@ -168,9 +186,22 @@ var (
 		utils.PosClientHttpFlag,
 		utils.LocalL2ClientHttpFlag,
 		utils.SeqsetValidHeightFlag,
+		utils.SeqsetFirstSequencerFlag,
 		utils.SeqsetContractFlag,
+		utils.SeqsetSignHeightFlag,
 		utils.SeqAddressFlag,
 		utils.SeqPrivFlag,
+		utils.SeqKeystoreFlag,
+		utils.SeqPasswordFlag,
+		utils.SeqClefFlag,
+		utils.SeqRemoteSignerFlag,
+		utils.SeqInfoTTLFlag,
+		utils.SeqInfoUrlFlag,
+		utils.SeqStaleThresholdFlag,
+		utils.SeqTakeoverFlag,
+		utils.SeqTakeoverThresholdFlag,
+		utils.SeqRespanPrivFlag,
+		utils.SeqRespanRemoteSignerFlag,
 		utils.SeqBridgeUrlFlag,
 	}
 
@@ -236,6 +267,8 @@ func init() {
 		removedbCommand,
 		dumpCommand,
 		inspectCommand,
+		// See rollupcmd.go:
+		rollupCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -0,0 +1,93 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum-optimism/optimism/l2geth/cmd/utils"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	rollupCommand = cli.Command{
+		Name:      "rollup",
+		Usage:     "Manage the rollup sync state",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+    geth rollup repair-indexes
+
+rebuilds the rollup sync indexes from the head of the chain.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "repair-indexes",
+				Usage:     "Rebuild the rollup sync indexes from the head of the chain",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(repairIndexes),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					utils.RollupUsingOVMFlag,
+					utils.RollupDeSeqBlockFlag,
+				},
+				Description: `
+    geth rollup repair-indexes
+
+compares the CTC, verified and queue indexes of the sync service with the
+TransactionMeta of the head blocks and rewrites the ones that disagree. The
+sync service runs the same check at start up, but only looks for the queue
+index in the recent blocks. The command walks back to genesis if needed and
+runs on a stopped node.`,
+			},
+		},
+	}
+)
+
+// repairIndexes rebuilds the rollup sync indexes of a stopped node
+func repairIndexes(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+	defer chain.Stop()
+
+	repairs, err := rollup.RepairIndexes(chainDb, chain, 0)
+	if err != nil {
+		utils.Fatalf("Failed to repair rollup indexes: %v", err)
+	}
+	if len(repairs) == 0 {
+		fmt.Println("Rollup indexes match the chain")
+		return nil
+	}
+	for _, r := range repairs {
+		fmt.Printf("Repaired %s: %s -> %s\n", r.Name, formatIndex(r.Stored), formatIndex(r.Repaired))
+	}
+	return nil
+}
+
+// formatIndex formats an index that may not be stored
+func formatIndex(index *uint64) string {
+	if index == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%d", *index)
+}
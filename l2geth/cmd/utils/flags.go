@@ -61,6 +61,7 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/p2p/netutil"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
 	"github.com/ethereum-optimism/optimism/l2geth/rollup"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 	"github.com/ethereum-optimism/optimism/l2geth/rpc"
 	whisper "github.com/ethereum-optimism/optimism/l2geth/whisper/whisperv6"
 	pcsclite "github.com/gballet/go-libpcsclite"
@@ -840,12 +841,109 @@ var (
 		Usage:  "Deployment of the canonical transaction chain",
 		EnvVar: "ETH1_CTC_DEPLOYMENT_HEIGHT",
 	}
+	RollupUsingOVMFlag = cli.BoolFlag{
+		Name:   "rollup.usingovm",
+		Usage:  "Enable the functionality necessary for the OVM",
+		EnvVar: "USING_OVM",
+	}
+	RollupDeSeqBlockFlag = cli.Uint64Flag{
+		Name:   "rollup.deseqblock",
+		Usage:  "First block produced by the decentralized sequencers, 0 disables decentralized sequencing",
+		EnvVar: "DESEQBLOCK",
+	}
+	RollupPeerHealthCheckFlag = cli.Int64Flag{
+		Name:   "rollup.peerhealthcheck",
+		Usage:  "Seconds without peer sync after which the peers are reconnected, 0 disables the check",
+		EnvVar: "PEER_HEALTH_CHECK",
+	}
+	RollupChainIDFlag = cli.Uint64Flag{
+		Name:   "rollup.chainid",
+		Usage:  "Chain id of the rollup, must match the genesis",
+		EnvVar: "CHAIN_ID",
+	}
 	RollupClientHttpFlag = cli.StringFlag{
 		Name:   "rollup.clienthttp",
-		Usage:  "HTTP endpoint for the rollup client",
+		Usage:  "HTTP endpoint for the rollup client, a comma separated list of replicas is failed over in order",
 		Value:  "http://localhost:7878",
 		EnvVar: "ROLLUP_CLIENT_HTTP",
 	}
+	RollupClientTimeoutFlag = cli.DurationFlag{
+		Name:   "rollup.clienttimeout",
+		Usage:  "Timeout of a single request of the rollup http client",
+		Value:  time.Second * 10,
+		EnvVar: "ROLLUP_CLIENT_TIMEOUT",
+	}
+	RollupClientRetriesFlag = cli.IntFlag{
+		Name:   "rollup.clientretries",
+		Usage:  "Number of retries of a failed request of the rollup http client",
+		Value:  3,
+		EnvVar: "ROLLUP_CLIENT_RETRIES",
+	}
+	RollupClientMaxLagFlag = cli.Uint64Flag{
+		Name:   "rollup.clientmaxlag",
+		Usage:  "Number of elements a rollup http endpoint may lag behind the index most endpoints reach before it is skipped",
+		Value:  2,
+		EnvVar: "ROLLUP_CLIENT_MAX_LAG",
+	}
+	RollupClientStreamFlag = cli.BoolFlag{
+		Name:   "rollup.clientstream",
+		Usage:  "Subscribe to the server sent events at /stream/{chainId} of the rollup http endpoint, polling continues as a fallback",
+		EnvVar: "ROLLUP_CLIENT_STREAM",
+	}
+	RollupClientL1HttpFlag = cli.StringFlag{
+		Name:   "rollup.clientl1http",
+		Usage:  "L1 JSON-RPC endpoint, when set rollup data is read from the L1 contracts instead of the rollup client",
+		EnvVar: "ROLLUP_CLIENT_L1_HTTP",
+	}
+	RollupClientL1ConfirmationsFlag = cli.Uint64Flag{
+		Name:   "rollup.clientl1confirmations",
+		Usage:  "Number of confirmations an L1 block needs before it is read, used with --rollup.clientl1http",
+		Value:  eth.DefaultConfig.Rollup.RollupClientL1Confirmations,
+		EnvVar: "ROLLUP_CLIENT_L1_CONFIRMATIONS",
+	}
+	RollupCTCAddressFlag = cli.StringFlag{
+		Name:   "rollup.ctcaddress",
+		Usage:  "Address of the L1 canonical transaction chain, used with --rollup.clientl1http",
+		EnvVar: "ROLLUP_CTC_ADDRESS",
+	}
+	RollupSCCAddressFlag = cli.StringFlag{
+		Name:   "rollup.sccaddress",
+		Usage:  "Address of the L1 state commitment chain, used with --rollup.clientl1http",
+		EnvVar: "ROLLUP_SCC_ADDRESS",
+	}
+	RollupReorgEnableFlag = cli.BoolFlag{
+		Name:   "rollup.reorg",
+		Usage:  "Compare the batches posted to L1 with the local chain and rewind on divergence in sequencer mode",
+		EnvVar: "ROLLUP_REORG_ENABLE",
+	}
+	RollupReorgMaxDepthFlag = cli.Uint64Flag{
+		Name:   "rollup.reorgmaxdepth",
+		Usage:  "Maximum number of blocks to rewind on divergence from L1, 0 is unlimited",
+		Value:  0,
+		EnvVar: "ROLLUP_REORG_MAX_DEPTH",
+	}
+	RollupReorgAlarmOnlyFlag = cli.BoolFlag{
+		Name:   "rollup.reorgalarm",
+		Usage:  "Only report divergence from L1 without rewinding the chain",
+		EnvVar: "ROLLUP_REORG_ALARM_ONLY",
+	}
+	RollupMismatchPolicyFlag = cli.StringFlag{
+		Name:   "rollup.mismatchpolicy",
+		Usage:  "Verifier behavior on a state root mismatch: continue, halt or rewind",
+		Value:  "continue",
+		EnvVar: "ROLLUP_STATE_ROOT_MISMATCH_POLICY",
+	}
+	RollupForceInclusionWindowFlag = cli.DurationFlag{
+		Name:   "rollup.forceinclusionwindow",
+		Usage:  "Alert when an enqueue is not included within this time after its L1 timestamp, 0 disables the enqueue watchdog",
+		EnvVar: "ROLLUP_FORCE_INCLUSION_WINDOW",
+	}
+	RollupPrefetchDepthFlag = cli.IntFlag{
+		Name:   "rollup.prefetchdepth",
+		Usage:  "Number of batches downloaded ahead of the one being applied when syncing batches",
+		Value:  4,
+		EnvVar: "ROLLUP_PREFETCH_DEPTH",
+	}
 	RollupPollIntervalFlag = cli.DurationFlag{
 		Name:   "rollup.pollinterval",
 		Usage:  "Interval for polling with the rollup http client",
@@ -930,12 +1028,24 @@ var (
 		EnvVar: "SEQSET_VALID_HEIGHT",
 	}
 
+	SeqsetFirstSequencerFlag = cli.StringFlag{
+		Name:   "seqset.firstsequencer",
+		Usage:  "Sequencer before the seq set is enabled",
+		EnvVar: "SEQSET_FIRST_SEQUENCER",
+	}
+
 	SeqsetContractFlag = cli.StringFlag{
 		Name:   "seqset.contract",
 		Usage:  "seqset contract address ",
 		EnvVar: "SEQSET_CONTRACT",
 	}
 
+	SeqsetSignHeightFlag = cli.Int64Flag{
+		Name:   "seqset.signheight",
+		Usage:  "Block height from which sequencer signatures are bound to the chain id, block number and epoch",
+		EnvVar: "SEQSET_SIGN_HEIGHT",
+	}
+
 	SeqAddressFlag = cli.StringFlag{
 		Name:   "seq.address",
 		Usage:  "sequencer address ",
@@ -948,6 +1058,75 @@ var (
 		EnvVar: "SEQ_PRIV",
 	}
 
+	SeqKeystoreFlag = cli.StringFlag{
+		Name:   "seq.keystore",
+		Usage:  "Encrypted keystore file of the sequencer key",
+		EnvVar: "SEQ_KEYSTORE",
+	}
+
+	SeqPasswordFlag = cli.StringFlag{
+		Name:   "seq.password",
+		Usage:  "Password file of the sequencer keystore, the first line is the password",
+		EnvVar: "SEQ_PASSWORD",
+	}
+
+	SeqClefFlag = cli.StringFlag{
+		Name:   "seq.clef",
+		Usage:  "Endpoint of the clef instance that signs for the sequencer",
+		EnvVar: "SEQ_CLEF",
+	}
+
+	SeqRemoteSignerFlag = cli.StringFlag{
+		Name:   "seq.remotesigner",
+		Usage:  "URL of the remote signer that signs for the sequencer",
+		EnvVar: "SEQ_REMOTE_SIGNER",
+	}
+
+	SeqInfoTTLFlag = cli.DurationFlag{
+		Name:   "seq.infottl",
+		Usage:  "How long the sequencer infos of other sequencers are kept after they were signed, sequencers with seq.infourl re-sign theirs every half of it",
+		Value:  time.Minute * 10,
+		EnvVar: "SEQ_INFO_TTL",
+	}
+
+	SeqInfoUrlFlag = cli.StringFlag{
+		Name:   "seq.infourl",
+		Usage:  "URL of the sequencer that is re-signed and pushed to the known sequencers every half of seq.infottl",
+		EnvVar: "SEQ_INFO_URL",
+	}
+
+	SeqStaleThresholdFlag = cli.DurationFlag{
+		Name:   "seq.stalethreshold",
+		Usage:  "How long the chain may go without a new block before the sequencer is considered stale",
+		Value:  time.Minute,
+		EnvVar: "SEQ_STALE_THRESHOLD",
+	}
+
+	SeqTakeoverFlag = cli.BoolFlag{
+		Name:   "seq.takeover",
+		Usage:  "Take over as a backup sequencer when the active sequencer stalls",
+		EnvVar: "SEQ_TAKEOVER",
+	}
+
+	SeqTakeoverThresholdFlag = cli.DurationFlag{
+		Name:   "seq.takeover.threshold",
+		Usage:  "How long the active sequencer may not add a block and fail its liveness probe before a backup sequencer takes over",
+		Value:  time.Minute * 2,
+		EnvVar: "SEQ_TAKEOVER_THRESHOLD",
+	}
+
+	SeqRespanPrivFlag = cli.StringFlag{
+		Name:   "seq.takeover.priv",
+		Usage:  "Private key of the seqset mpc address that signs the respans of a takeover",
+		EnvVar: "SEQ_TAKEOVER_PRIV",
+	}
+
+	SeqRespanRemoteSignerFlag = cli.StringFlag{
+		Name:   "seq.takeover.remotesigner",
+		Usage:  "URL of the remote signer of the seqset mpc address that signs the respans of a takeover",
+		EnvVar: "SEQ_TAKEOVER_REMOTE_SIGNER",
+	}
+
 	SeqBridgeUrlFlag = cli.StringFlag{
 		Name:   "seq_bridge_url",
 		Usage:  "seq bridge url set to enable RPC only node role",
@@ -1211,6 +1390,33 @@ func setEth1(ctx *cli.Context, cfg *rollup.Config) {
 	}
 }
 
+// setOVM configures the rollup settings of the chain from the command line
+// flags.
+func setOVM(ctx *cli.Context, cfg *rcfg.Config) {
+	if ctx.GlobalIsSet(RollupUsingOVMFlag.Name) {
+		cfg.UsingOVM = ctx.GlobalBool(RollupUsingOVMFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupDeSeqBlockFlag.Name) {
+		cfg.DeSeqBlock = ctx.GlobalUint64(RollupDeSeqBlockFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupPeerHealthCheckFlag.Name) {
+		cfg.PeerHealthCheckSeconds = ctx.GlobalInt64(RollupPeerHealthCheckFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupChainIDFlag.Name) {
+		cfg.ChainID = ctx.GlobalUint64(RollupChainIDFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqsetValidHeightFlag.Name) {
+		cfg.SeqValidHeight = uint64(ctx.GlobalInt64(SeqsetValidHeightFlag.Name))
+	}
+	if ctx.GlobalIsSet(SeqsetFirstSequencerFlag.Name) {
+		addr := ctx.GlobalString(SeqsetFirstSequencerFlag.Name)
+		if !common.IsHexAddress(addr) {
+			Fatalf("Invalid first sequencer address: %s", addr)
+		}
+		cfg.FirstSequencer = common.HexToAddress(addr)
+	}
+}
+
 // UsingOVM
 // setRollup configures the rollup
 func setRollup(ctx *cli.Context, cfg *rollup.Config) {
@@ -1223,6 +1429,50 @@ func setRollup(ctx *cli.Context, cfg *rollup.Config) {
 	if ctx.GlobalIsSet(RollupClientHttpFlag.Name) {
 		cfg.RollupClientHttp = ctx.GlobalString(RollupClientHttpFlag.Name)
 	}
+	if ctx.GlobalIsSet(RollupClientTimeoutFlag.Name) {
+		cfg.RollupClientTimeout = ctx.GlobalDuration(RollupClientTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupClientRetriesFlag.Name) {
+		retries := ctx.GlobalInt(RollupClientRetriesFlag.Name)
+		cfg.RollupClientRetries = &retries
+	}
+	if ctx.GlobalIsSet(RollupClientMaxLagFlag.Name) {
+		maxLag := ctx.GlobalUint64(RollupClientMaxLagFlag.Name)
+		cfg.RollupClientMaxLag = &maxLag
+	}
+	if ctx.GlobalIsSet(RollupClientStreamFlag.Name) {
+		cfg.RollupClientStream = ctx.GlobalBool(RollupClientStreamFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupClientL1HttpFlag.Name) {
+		cfg.RollupClientL1Http = ctx.GlobalString(RollupClientL1HttpFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupClientL1ConfirmationsFlag.Name) {
+		cfg.RollupClientL1Confirmations = ctx.GlobalUint64(RollupClientL1ConfirmationsFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupCTCAddressFlag.Name) {
+		cfg.CanonicalTransactionChainAddress = common.HexToAddress(ctx.GlobalString(RollupCTCAddressFlag.Name))
+	}
+	if ctx.GlobalIsSet(RollupSCCAddressFlag.Name) {
+		cfg.StateCommitmentChainAddress = common.HexToAddress(ctx.GlobalString(RollupSCCAddressFlag.Name))
+	}
+	if ctx.GlobalIsSet(RollupReorgEnableFlag.Name) {
+		cfg.ReorgEnable = ctx.GlobalBool(RollupReorgEnableFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupReorgMaxDepthFlag.Name) {
+		cfg.ReorgMaxDepth = ctx.GlobalUint64(RollupReorgMaxDepthFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupReorgAlarmOnlyFlag.Name) {
+		cfg.ReorgAlarmOnly = ctx.GlobalBool(RollupReorgAlarmOnlyFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupMismatchPolicyFlag.Name) {
+		cfg.StateRootMismatchPolicy = ctx.GlobalString(RollupMismatchPolicyFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupForceInclusionWindowFlag.Name) {
+		cfg.ForceInclusionWindow = ctx.GlobalDuration(RollupForceInclusionWindowFlag.Name)
+	}
+	if ctx.GlobalIsSet(RollupPrefetchDepthFlag.Name) {
+		cfg.BatchPrefetchDepth = ctx.GlobalInt(RollupPrefetchDepthFlag.Name)
+	}
 	if ctx.GlobalIsSet(RollupPollIntervalFlag.Name) {
 		cfg.PollInterval = ctx.GlobalDuration(RollupPollIntervalFlag.Name)
 	}
@@ -1274,12 +1524,48 @@ func setRollup(ctx *cli.Context, cfg *rollup.Config) {
 		cfg.SeqsetValidHeight = uint64(height)
 		params.MetisFallbackRollupConfig.SeqSetHeight = big.NewInt(height)
 	}
+	if ctx.GlobalIsSet(SeqsetSignHeightFlag.Name) {
+		params.MetisFallbackRollupConfig.SeqSignHeight = big.NewInt(ctx.GlobalInt64(SeqsetSignHeightFlag.Name))
+	}
 	if ctx.GlobalIsSet(SeqAddressFlag.Name) {
 		cfg.SeqAddress = ctx.GlobalString(SeqAddressFlag.Name)
 	}
 	if ctx.GlobalIsSet(SeqPrivFlag.Name) {
 		cfg.SeqPriv = ctx.GlobalString(SeqPrivFlag.Name)
 	}
+	if ctx.GlobalIsSet(SeqKeystoreFlag.Name) {
+		cfg.SeqKeystore = ctx.GlobalString(SeqKeystoreFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqPasswordFlag.Name) {
+		cfg.SeqPasswordFile = ctx.GlobalString(SeqPasswordFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqClefFlag.Name) {
+		cfg.SeqClef = ctx.GlobalString(SeqClefFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqRemoteSignerFlag.Name) {
+		cfg.SeqRemoteSigner = ctx.GlobalString(SeqRemoteSignerFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqInfoTTLFlag.Name) {
+		cfg.SeqInfoTTL = ctx.GlobalDuration(SeqInfoTTLFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqInfoUrlFlag.Name) {
+		cfg.SeqInfoUrl = ctx.GlobalString(SeqInfoUrlFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqStaleThresholdFlag.Name) {
+		cfg.SeqStaleThreshold = ctx.GlobalDuration(SeqStaleThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqTakeoverFlag.Name) {
+		cfg.SeqTakeover = ctx.GlobalBool(SeqTakeoverFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqTakeoverThresholdFlag.Name) {
+		cfg.SeqTakeoverThreshold = ctx.GlobalDuration(SeqTakeoverThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqRespanPrivFlag.Name) {
+		cfg.SeqRespanPriv = ctx.GlobalString(SeqRespanPrivFlag.Name)
+	}
+	if ctx.GlobalIsSet(SeqRespanRemoteSignerFlag.Name) {
+		cfg.SeqRespanRemoteSigner = ctx.GlobalString(SeqRespanRemoteSignerFlag.Name)
+	}
 	if ctx.GlobalIsSet(SeqBridgeUrlFlag.Name) {
 		cfg.SeqBridgeUrl = ctx.GlobalString(SeqBridgeUrlFlag.Name)
 	}
@@ -1751,6 +2037,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	setLes(ctx, cfg)
 	setEth1(ctx, &cfg.Rollup)
 	setRollup(ctx, &cfg.Rollup)
+	setOVM(ctx, &cfg.OVM)
 
 	if ctx.GlobalIsSet(SyncModeFlag.Name) {
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
@@ -1993,8 +2280,15 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if err != nil {
 		Fatalf("%v", err)
 	}
+	var ovm rcfg.Config
+	setOVM(ctx, &ovm)
+	if err := ovm.Validate(config.ChainID); err != nil {
+		Fatalf("Invalid rollup configuration: %v", err)
+	}
 	var engine consensus.Engine
-	if config.Clique != nil {
+	if config.Clique != nil && ovm.UsingOVM {
+		engine = clique.NewOVM(config.Clique, chainDb)
+	} else if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
 	} else {
 		engine = ethash.NewFaker()
@@ -2025,7 +2319,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieDirtyLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
-	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)}
+	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name), OVM: ovm}
 	chain, err = core.NewBlockChain(chainDb, cache, config, engine, vmcfg, nil)
 	if err != nil {
 		Fatalf("Can't create BlockChain: %v", err)
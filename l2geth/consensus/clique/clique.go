@@ -39,7 +39,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/log"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
 	"github.com/ethereum-optimism/optimism/l2geth/rlp"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 	"github.com/ethereum-optimism/optimism/l2geth/rpc"
 	lru "github.com/hashicorp/golang-lru"
 	"golang.org/x/crypto/sha3"
@@ -185,6 +184,8 @@ type Clique struct {
 	signFn SignerFn       // Signer function to authorize hashes with
 	lock   sync.RWMutex   // Protects the signer fields
 
+	usingOVM bool // Timestamps are dictated by L1, blocks are sealed without delay
+
 	// The fields below are for testing only
 	fakeDiff bool // Skip difficulty verifications
 }
@@ -210,6 +211,14 @@ func New(config *params.CliqueConfig, db ethdb.Database) *Clique {
 	}
 }
 
+// NewOVM creates a Clique proof-of-authority consensus engine for a chain
+// running the OVM.
+func NewOVM(config *params.CliqueConfig, db ethdb.Database) *Clique {
+	c := New(config, db)
+	c.usingOVM = true
+	return c
+}
+
 // Author implements consensus.Engine, returning the Ethereum address recovered
 // from the signature in the header's extra-data section.
 func (c *Clique) Author(header *types.Header) (common.Address, error) {
@@ -252,7 +261,7 @@ func (c *Clique) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	}
 	number := header.Number.Uint64()
 
-	if !rcfg.UsingOVM {
+	if !c.usingOVM {
 		// Don't waste time checking blocks from the future
 		// NOTE 20210724
 		fmt.Println("verifyHeader in clique, [headerTime, time.Now, allowedFutureBlockTime, expect]", header.Time, time.Now(), allowedFutureBlockTime, uint64(time.Now().Add(allowedFutureBlockTime).Unix()))
@@ -333,7 +342,7 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainReader, header *type
 	// Do not account for timestamps in consensus when running the OVM
 	// changes. The timestamp must be montonic, meaning that it can be the same
 	// or increase. L1 dictates the timestamp.
-	if !rcfg.UsingOVM {
+	if !c.usingOVM {
 		if parent.Time+c.config.Period > header.Time {
 			return ErrInvalidTimestamp
 		}
@@ -560,7 +569,7 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	}
 
 	// Do not manipulate the timestamps when running with the OVM
-	if !rcfg.UsingOVM {
+	if !c.usingOVM {
 		header.Time = parent.Time + c.config.Period
 		if header.Time < uint64(time.Now().Unix()) {
 			header.Time = uint64(time.Now().Unix())
@@ -648,7 +657,7 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, results c
 	// Set the delay to 0 when using the OVM so that blocks are always
 	// produced instantly. When running in a non-OVM network, the delay prevents
 	// the creation of invalid blocks.
-	if rcfg.UsingOVM {
+	if c.usingOVM {
 		delay = 0
 	}
 	// Sign all the things!
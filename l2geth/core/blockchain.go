@@ -203,7 +203,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		cacheConfig:    cacheConfig,
 		db:             db,
 		triegc:         prque.New(nil),
-		stateCache:     state.NewDatabaseWithCache(db, cacheConfig.TrieCleanLimit),
+		stateCache:     state.NewDatabaseWithConfig(db, cacheConfig.TrieCleanLimit, vmConfig.OVM.UsingOVM),
 		quit:           make(chan struct{}),
 		shouldPreserve: shouldPreserve,
 		bodyCache:      bodyCache,
@@ -641,6 +641,9 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
 	rawdb.WriteTxLookupEntries(batch, block)
 	rawdb.WriteHeadBlockHash(batch, block.Hash())
+	if bc.vmConfig.OVM.UsingOVM {
+		rawdb.WriteHeadBlockIndexes(batch, block)
+	}
 
 	// If the block is better than our head or is on a different chain, force update heads
 	if updateHeads {
@@ -787,7 +790,7 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	if number == nil {
 		return nil
 	}
-	receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig)
+	receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig, bc.vmConfig.OVM.UsingOVM)
 	if receipts == nil {
 		return nil
 	}
@@ -1085,7 +1088,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 				}
 				h := rawdb.ReadCanonicalHash(bc.db, frozen)
 				b := rawdb.ReadBlock(bc.db, h, frozen)
-				size += rawdb.WriteAncientBlock(bc.db, b, rawdb.ReadReceipts(bc.db, h, frozen, bc.chainConfig), rawdb.ReadTd(bc.db, h, frozen))
+				size += rawdb.WriteAncientBlock(bc.db, b, rawdb.ReadReceipts(bc.db, h, frozen, bc.chainConfig, bc.vmConfig.OVM.UsingOVM), rawdb.ReadTd(bc.db, h, frozen))
 				count += 1
 
 				// Always keep genesis block in active database.
@@ -1208,9 +1211,9 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			txsCount := len(block.Transactions())
 			for _, tx := range block.Transactions() {
 				if txsCount == 1 {
-					rawdb.WriteTransactionMeta(batch, block.NumberU64(), tx.GetMeta())
+					rawdb.WriteTransactionMeta(batch, block.NumberU64(), tx.GetMeta(), bc.vmConfig.OVM.SeqValidHeight)
 				} else {
-					rawdb.WriteTransactionMetaHash(batch, tx.Hash(), tx.GetMeta())
+					rawdb.WriteTransactionMetaHash(batch, tx.Hash(), tx.GetMeta(), bc.vmConfig.OVM.SeqValidHeight)
 				}
 			}
 
@@ -1285,9 +1288,9 @@ func (bc *BlockChain) writeBlockWithoutState(block *types.Block, td *big.Int) (e
 	txsCount := len(block.Transactions())
 	for _, tx := range block.Transactions() {
 		if txsCount == 1 {
-			rawdb.WriteTransactionMeta(batch, block.NumberU64(), tx.GetMeta())
+			rawdb.WriteTransactionMeta(batch, block.NumberU64(), tx.GetMeta(), bc.vmConfig.OVM.SeqValidHeight)
 		} else {
-			rawdb.WriteTransactionMetaHash(batch, tx.Hash(), tx.GetMeta())
+			rawdb.WriteTransactionMetaHash(batch, tx.Hash(), tx.GetMeta(), bc.vmConfig.OVM.SeqValidHeight)
 		}
 	}
 	if err := batch.Write(); err != nil {
@@ -1326,7 +1329,7 @@ func (bc *BlockChain) restoreBlockMeta(blockNumber uint64, txCount int, meta *ty
 	if txCount != 1 || meta == nil {
 		return
 	}
-	rawdb.WriteTransactionMeta(bc.db, blockNumber, meta)
+	rawdb.WriteTransactionMeta(bc.db, blockNumber, meta, bc.vmConfig.OVM.SeqValidHeight)
 }
 
 // writeBlockWithState writes the block and all associated state to the database,
@@ -1356,9 +1359,9 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	existMeta := rawdb.ReadTransactionMeta(bc.db, block.NumberU64())
 	for _, tx := range block.Transactions() {
 		if txsCount == 1 {
-			rawdb.WriteTransactionMeta(blockBatch, block.NumberU64(), tx.GetMeta())
+			rawdb.WriteTransactionMeta(blockBatch, block.NumberU64(), tx.GetMeta(), bc.vmConfig.OVM.SeqValidHeight)
 		} else {
-			rawdb.WriteTransactionMetaHash(blockBatch, tx.Hash(), tx.GetMeta())
+			rawdb.WriteTransactionMetaHash(blockBatch, tx.Hash(), tx.GetMeta(), bc.vmConfig.OVM.SeqValidHeight)
 		}
 	}
 	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
@@ -1459,6 +1462,7 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	// Set new head.
 	if status == CanonStatTy {
 		bc.writeHeadBlock(block)
+		bc.indexSeqSetEpochs(block, receipts, state)
 	}
 	bc.futureBlocks.Remove(block.Hash())
 
@@ -1597,6 +1601,7 @@ func (bc *BlockChain) insertChainWithFunc(chain types.Blocks, verifySeals bool,
 	}
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+	bc.cacheSeqSenders(chain)
 
 	var (
 		stats     = insertStats{startTime: mclock.Now()}
@@ -1908,6 +1913,7 @@ func (bc *BlockChain) insertChainWithFuncAndCh(chain types.Blocks, verifySeals b
 	}
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+	bc.cacheSeqSenders(chain)
 
 	var (
 		stats     = insertStats{startTime: mclock.Now()}
@@ -2383,7 +2389,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			if number == nil {
 				return
 			}
-			receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig)
+			receipts := rawdb.ReadReceipts(bc.db, hash, *number, bc.chainConfig, bc.vmConfig.OVM.UsingOVM)
 
 			var logs []*types.Log
 			for _, receipt := range receipts {
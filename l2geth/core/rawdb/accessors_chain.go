@@ -368,9 +368,10 @@ func ReadTransactionMetaRaw(db ethdb.Reader, number uint64) []byte {
 }
 
 // UsingOVM
-// WriteTransactionMeta writes the TransactionMeta to disk by hash.
-func WriteTransactionMeta(db ethdb.KeyValueWriter, number uint64, meta *types.TransactionMeta) {
-	data := types.TxMetaEncode(meta)
+// WriteTransactionMeta writes the TransactionMeta to disk by hash. The
+// signature of the sequencer is written from the seqValidHeight on.
+func WriteTransactionMeta(db ethdb.KeyValueWriter, number uint64, meta *types.TransactionMeta, seqValidHeight uint64) {
+	data := types.TxMetaEncode(meta, seqValidHeight)
 	WriteTransactionMetaRaw(db, number, data)
 }
 
@@ -411,8 +412,8 @@ func ReadTransactionMetaRawHash(db ethdb.Reader, hash common.Hash) []byte {
 
 // UsingOVM
 // extend for multiple txs in a block
-func WriteTransactionMetaHash(db ethdb.KeyValueWriter, hash common.Hash, meta *types.TransactionMeta) {
-	data := types.TxMetaEncode(meta)
+func WriteTransactionMetaHash(db ethdb.KeyValueWriter, hash common.Hash, meta *types.TransactionMeta, seqValidHeight uint64) {
+	data := types.TxMetaEncode(meta, seqValidHeight)
 	WriteTransactionMetaRawHash(db, hash, data)
 }
 
@@ -566,8 +567,9 @@ func ReadRawReceipts(db ethdb.Reader, hash common.Hash, number uint64) types.Rec
 //
 // The current implementation populates these metadata fields by reading the receipts'
 // corresponding block body, so if the block body is not found it will return nil even
-// if the receipt itself is stored.
-func ReadReceipts(db ethdb.Reader, hash common.Hash, number uint64, config *params.ChainConfig) types.Receipts {
+// if the receipt itself is stored. The usingOVM flag of the node is needed to
+// derive the contract addresses of system deployments.
+func ReadReceipts(db ethdb.Reader, hash common.Hash, number uint64, config *params.ChainConfig, usingOVM bool) types.Receipts {
 	// We're deriving many fields from the block body, retrieve beside the receipt
 	receipts := ReadRawReceipts(db, hash, number)
 	if receipts == nil {
@@ -578,7 +580,7 @@ func ReadReceipts(db ethdb.Reader, hash common.Hash, number uint64, config *para
 		log.Error("Missing body but have receipt", "hash", hash, "number", number)
 		return nil
 	}
-	if err := receipts.DeriveFields(config, hash, number, body.Transactions); err != nil {
+	if err := receipts.DeriveFields(config, hash, number, body.Transactions, usingOVM); err != nil {
 		log.Error("Failed to derive block receipts fields", "hash", hash, "number", number, "err", err)
 		return nil
 	}
@@ -0,0 +1,195 @@
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+// sequencerEpochKey = sequencerEpochPrefix + id (uint64 big endian)
+func sequencerEpochKey(id uint64) []byte {
+	key := make([]byte, len(sequencerEpochPrefix)+8)
+	copy(key, sequencerEpochPrefix)
+	binary.BigEndian.PutUint64(key[len(sequencerEpochPrefix):], id)
+	return key
+}
+
+// sequencerEpochEndKey = sequencerEpochEndPrefix + end block (uint64 big endian)
+func sequencerEpochEndKey(end uint64) []byte {
+	key := make([]byte, len(sequencerEpochEndPrefix)+8)
+	copy(key, sequencerEpochEndPrefix)
+	binary.BigEndian.PutUint64(key[len(sequencerEpochEndPrefix):], end)
+	return key
+}
+
+// ReadSequencerEpoch reads the sequencer epoch with an id
+func ReadSequencerEpoch(db ethdb.KeyValueReader, id uint64) *types.SequencerEpoch {
+	data, _ := db.Get(sequencerEpochKey(id))
+	if len(data) == 0 {
+		return nil
+	}
+	epoch := new(types.SequencerEpoch)
+	if err := rlp.DecodeBytes(data, epoch); err != nil {
+		log.Error("Invalid sequencer epoch RLP", "id", id, "err", err)
+		return nil
+	}
+	return epoch
+}
+
+// ReadSequencerEpochs reads up to limit sequencer epochs in id order,
+// starting at the given id
+func ReadSequencerEpochs(db ethdb.Iteratee, from uint64, limit int) []*types.SequencerEpoch {
+	it := db.NewIteratorWithStart(sequencerEpochKey(from))
+	defer it.Release()
+
+	var epochs []*types.SequencerEpoch
+	for it.Next() && len(epochs) < limit {
+		key := it.Key()
+		if len(key) != len(sequencerEpochPrefix)+8 || !bytes.HasPrefix(key, sequencerEpochPrefix) {
+			break
+		}
+		epoch := new(types.SequencerEpoch)
+		if err := rlp.DecodeBytes(it.Value(), epoch); err != nil {
+			log.Error("Invalid sequencer epoch RLP", "key", key, "err", err)
+			continue
+		}
+		epochs = append(epochs, epoch)
+	}
+	return epochs
+}
+
+// ReadSequencerEpochAt reads the sequencer epoch that a block number belongs
+// to, nil if no known epoch covers it
+func ReadSequencerEpochAt(db ethdb.KeyValueStore, number uint64) *types.SequencerEpoch {
+	it := db.NewIteratorWithStart(sequencerEpochEndKey(number))
+	defer it.Release()
+
+	if !it.Next() {
+		return nil
+	}
+	key := it.Key()
+	if len(key) != len(sequencerEpochEndPrefix)+8 || !bytes.HasPrefix(key, sequencerEpochEndPrefix) || len(it.Value()) != 8 {
+		return nil
+	}
+	epoch := ReadSequencerEpoch(db, binary.BigEndian.Uint64(it.Value()))
+	if epoch == nil || epoch.StartBlock > number {
+		return nil
+	}
+	return epoch
+}
+
+// WriteSequencerEpoch stores a sequencer epoch and indexes its blocks. A later
+// epoch takes over the blocks from its start on, so epochs with a lower id
+// that overlap the epoch are cut short and the epoch itself ends before the
+// start of a later epoch. A known respan transaction hash is kept when the
+// epoch is written again without one.
+func WriteSequencerEpoch(db ethdb.KeyValueStore, epoch *types.SequencerEpoch) {
+	epoch = &types.SequencerEpoch{
+		ID:         epoch.ID,
+		Signer:     epoch.Signer,
+		StartBlock: epoch.StartBlock,
+		EndBlock:   epoch.EndBlock,
+		RespanTx:   epoch.RespanTx,
+	}
+	// The stored epochs already end before the start of the later ones, so
+	// only the next stored epoch bounds the epoch. Its blocks start after its
+	// end when it was taken over entirely.
+	if next := ReadSequencerEpochs(db, epoch.ID+1, 1); len(next) > 0 {
+		start := next[0].StartBlock
+		if next[0].EndBlock < start {
+			start = next[0].EndBlock + 1
+		}
+		if start <= epoch.EndBlock {
+			epoch.EndBlock = cutEpochEnd(start)
+		}
+	}
+	stored := ReadSequencerEpoch(db, epoch.ID)
+	if stored != nil && epoch.RespanTx == (common.Hash{}) {
+		epoch.RespanTx = stored.RespanTx
+	}
+	if stored != nil && *stored == *epoch {
+		return
+	}
+
+	batch := db.NewBatch()
+	if stored != nil {
+		deleteSequencerEpochEnd(db, batch, stored)
+	}
+	// Cut the earlier epochs that overlap the epoch. The indexed ranges are
+	// disjoint, so the first one that starts after the epoch ends the search.
+	var earlier []*types.SequencerEpoch
+	it := db.NewIteratorWithStart(sequencerEpochEndKey(epoch.StartBlock))
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(sequencerEpochEndPrefix)+8 || !bytes.HasPrefix(key, sequencerEpochEndPrefix) || len(it.Value()) != 8 {
+			break
+		}
+		e := ReadSequencerEpoch(db, binary.BigEndian.Uint64(it.Value()))
+		if e == nil {
+			continue
+		}
+		if e.StartBlock > epoch.EndBlock {
+			break
+		}
+		if e.ID < epoch.ID {
+			earlier = append(earlier, e)
+		}
+	}
+	it.Release()
+	for _, e := range earlier {
+		deleteSequencerEpochEnd(db, batch, e)
+		e.EndBlock = cutEpochEnd(epoch.StartBlock)
+		putSequencerEpoch(batch, e)
+	}
+	putSequencerEpoch(batch, epoch)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to store sequencer epoch", "err", err)
+	}
+}
+
+// cutEpochEnd returns the end block of an epoch that is taken over at start,
+// it is before the start block of the epoch when all its blocks are taken
+// over
+func cutEpochEnd(start uint64) uint64 {
+	if start == 0 {
+		return 0
+	}
+	return start - 1
+}
+
+// putSequencerEpoch writes an epoch and its end index entry, an epoch that
+// was taken over entirely is not indexed
+func putSequencerEpoch(db ethdb.KeyValueWriter, epoch *types.SequencerEpoch) {
+	data, err := rlp.EncodeToBytes(epoch)
+	if err != nil {
+		log.Crit("Failed to encode sequencer epoch", "err", err)
+	}
+	if err := db.Put(sequencerEpochKey(epoch.ID), data); err != nil {
+		log.Crit("Failed to store sequencer epoch", "err", err)
+	}
+	if epoch.EndBlock < epoch.StartBlock {
+		return
+	}
+	id := make([]byte, 8)
+	binary.BigEndian.PutUint64(id, epoch.ID)
+	if err := db.Put(sequencerEpochEndKey(epoch.EndBlock), id); err != nil {
+		log.Crit("Failed to store sequencer epoch end", "err", err)
+	}
+}
+
+// deleteSequencerEpochEnd removes the end index entry of an epoch if it
+// still points to the epoch
+func deleteSequencerEpochEnd(reader ethdb.KeyValueReader, db ethdb.KeyValueWriter, epoch *types.SequencerEpoch) {
+	data, _ := reader.Get(sequencerEpochEndKey(epoch.EndBlock))
+	if len(data) != 8 || binary.BigEndian.Uint64(data) != epoch.ID {
+		return
+	}
+	if err := db.Delete(sequencerEpochEndKey(epoch.EndBlock)); err != nil {
+		log.Crit("Failed to delete sequencer epoch end", "err", err)
+	}
+}
@@ -0,0 +1,92 @@
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+)
+
+func TestSequencerEpochIndex(t *testing.T) {
+	db := NewMemoryDatabase()
+	signerA, signerB, signerC := common.Address{0x0a}, common.Address{0x0b}, common.Address{0x0c}
+
+	WriteSequencerEpoch(db, &types.SequencerEpoch{ID: 1, Signer: signerA, StartBlock: 100, EndBlock: 199})
+	WriteSequencerEpoch(db, &types.SequencerEpoch{ID: 2, Signer: signerB, StartBlock: 200, EndBlock: 299})
+
+	for number, want := range map[uint64]uint64{100: 1, 150: 1, 199: 1, 200: 2, 299: 2} {
+		if epoch := ReadSequencerEpochAt(db, number); epoch == nil || epoch.ID != want {
+			t.Fatalf("unexpected epoch at %d: %v", number, epoch)
+		}
+	}
+	if epoch := ReadSequencerEpochAt(db, 99); epoch != nil {
+		t.Fatalf("unexpected epoch before the first one: %v", epoch)
+	}
+	if epoch := ReadSequencerEpochAt(db, 300); epoch != nil {
+		t.Fatalf("unexpected epoch after the last one: %v", epoch)
+	}
+
+	// A respan takes over the rest of the current epoch
+	respanTx := common.Hash{0x01}
+	WriteSequencerEpoch(db, &types.SequencerEpoch{ID: 3, Signer: signerC, StartBlock: 250, EndBlock: 349, RespanTx: respanTx})
+	if epoch := ReadSequencerEpochAt(db, 249); epoch == nil || epoch.ID != 2 || epoch.EndBlock != 249 {
+		t.Fatalf("unexpected epoch before the respan: %v", epoch)
+	}
+	if epoch := ReadSequencerEpochAt(db, 250); epoch == nil || epoch.ID != 3 || epoch.Signer != signerC {
+		t.Fatalf("unexpected epoch after the respan: %v", epoch)
+	}
+
+	// Writing the epochs again as they are read from the contract keeps the
+	// respan and the cut ranges
+	WriteSequencerEpoch(db, &types.SequencerEpoch{ID: 2, Signer: signerB, StartBlock: 200, EndBlock: 299})
+	WriteSequencerEpoch(db, &types.SequencerEpoch{ID: 3, Signer: signerC, StartBlock: 250, EndBlock: 349})
+	if epoch := ReadSequencerEpochAt(db, 260); epoch == nil || epoch.ID != 3 || epoch.RespanTx != respanTx {
+		t.Fatalf("unexpected epoch after rewriting: %v", epoch)
+	}
+	if epoch := ReadSequencerEpoch(db, 2); epoch == nil || epoch.EndBlock != 249 {
+		t.Fatalf("unexpected respanned epoch: %v", epoch)
+	}
+
+	// A respan at the start of an epoch takes over all of its blocks
+	WriteSequencerEpoch(db, &types.SequencerEpoch{ID: 4, Signer: signerA, StartBlock: 250, EndBlock: 349, RespanTx: common.Hash{0x02}})
+	if epoch := ReadSequencerEpochAt(db, 300); epoch == nil || epoch.ID != 4 {
+		t.Fatalf("unexpected epoch after the second respan: %v", epoch)
+	}
+
+	epochs := ReadSequencerEpochs(db, 2, 2)
+	if len(epochs) != 2 || epochs[0].ID != 2 || epochs[1].ID != 3 {
+		t.Fatalf("unexpected epochs: %v", epochs)
+	}
+	if epochs := ReadSequencerEpochs(db, 0, 10); len(epochs) != 4 {
+		t.Fatalf("unexpected number of epochs: %d", len(epochs))
+	}
+}
+
+func TestSequencerEpochBackfill(t *testing.T) {
+	epochs := []*types.SequencerEpoch{
+		{ID: 0, Signer: common.Address{0x0a}, StartBlock: 0, EndBlock: 99},
+		{ID: 1, Signer: common.Address{0x0b}, StartBlock: 100, EndBlock: 199},
+		{ID: 2, Signer: common.Address{0x0c}, StartBlock: 150, EndBlock: 249},
+		{ID: 3, Signer: common.Address{0x0d}, StartBlock: 150, EndBlock: 249},
+		{ID: 5, Signer: common.Address{0x0e}, StartBlock: 220, EndBlock: 319},
+	}
+	// The chain backfills the epochs from the current one down, the index
+	// must match the one of the epochs written in order
+	ascending, descending := NewMemoryDatabase(), NewMemoryDatabase()
+	for i := range epochs {
+		WriteSequencerEpoch(ascending, epochs[i])
+		WriteSequencerEpoch(descending, epochs[len(epochs)-1-i])
+	}
+	want := map[uint64]uint64{0: 0, 99: 0, 100: 1, 149: 1, 150: 3, 219: 3, 220: 5, 319: 5}
+	for name, db := range map[string]ethdb.KeyValueStore{"ascending": ascending, "descending": descending} {
+		for number, id := range want {
+			if epoch := ReadSequencerEpochAt(db, number); epoch == nil || epoch.ID != id {
+				t.Fatalf("%s: unexpected epoch at %d: %v", name, number, epoch)
+			}
+		}
+		if epoch := ReadSequencerEpoch(db, 2); epoch == nil || epoch.EndBlock != 149 {
+			t.Fatalf("%s: unexpected epoch taken over: %v", name, epoch)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+// equivocationKey = equivocationPrefix + block number (uint64 big endian) + signer
+func equivocationKey(number uint64, signer common.Address) []byte {
+	key := make([]byte, len(equivocationPrefix)+8+common.AddressLength)
+	copy(key, equivocationPrefix)
+	binary.BigEndian.PutUint64(key[len(equivocationPrefix):], number)
+	copy(key[len(equivocationPrefix)+8:], signer.Bytes())
+	return key
+}
+
+// HasEquivocation returns whether an equivocation of a signer in a block is
+// stored
+func HasEquivocation(db ethdb.KeyValueReader, number uint64, signer common.Address) bool {
+	ok, _ := db.Has(equivocationKey(number, signer))
+	return ok
+}
+
+// WriteEquivocation stores the evidence of an equivocation
+func WriteEquivocation(db ethdb.KeyValueWriter, equivocation *types.Equivocation) {
+	data, err := rlp.EncodeToBytes(equivocation)
+	if err != nil {
+		log.Crit("Failed to encode equivocation", "err", err)
+	}
+	if err := db.Put(equivocationKey(equivocation.BlockNumber, equivocation.Signer), data); err != nil {
+		log.Crit("Failed to store equivocation", "err", err)
+	}
+}
+
+// ReadEquivocations reads up to limit equivocations in block number order,
+// starting at the given block number
+func ReadEquivocations(db ethdb.Iteratee, from uint64, limit int) []*types.Equivocation {
+	it := db.NewIteratorWithStart(equivocationKey(from, common.Address{}))
+	defer it.Release()
+
+	var equivocations []*types.Equivocation
+	for it.Next() && len(equivocations) < limit {
+		key := it.Key()
+		if len(key) != len(equivocationPrefix)+8+common.AddressLength || !bytes.HasPrefix(key, equivocationPrefix) {
+			break
+		}
+		equivocation := new(types.Equivocation)
+		if err := rlp.DecodeBytes(it.Value(), equivocation); err != nil {
+			log.Error("Invalid equivocation RLP", "key", key, "err", err)
+			continue
+		}
+		equivocations = append(equivocations, equivocation)
+	}
+	return equivocations
+}
@@ -3,6 +3,7 @@ package rawdb
 import (
 	"math/big"
 
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
 	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
 	"github.com/ethereum-optimism/optimism/l2geth/log"
 )
@@ -28,6 +29,13 @@ func WriteHeadIndex(db ethdb.KeyValueWriter, index uint64) {
 	}
 }
 
+// DeleteHeadIndex removes the known tip of the CTC
+func DeleteHeadIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headIndexKey); err != nil {
+		log.Crit("Failed to delete index", "err", err)
+	}
+}
+
 // ReadHeadIndexTime will read the known tip of the CTC
 func ReadHeadIndexTime(db ethdb.KeyValueReader) *uint64 {
 	data, _ := db.Get(headIndexTimeKey)
@@ -70,6 +78,13 @@ func WriteHeadQueueIndex(db ethdb.KeyValueWriter, index uint64) {
 	}
 }
 
+// DeleteHeadQueueIndex removes the known tip of the queue
+func DeleteHeadQueueIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headQueueIndexKey); err != nil {
+		log.Crit("Failed to delete queue index", "err", err)
+	}
+}
+
 // ReadHeadVerifiedIndex will read the known tip of the batched transactions
 func ReadHeadVerifiedIndex(db ethdb.KeyValueReader) *uint64 {
 	data, _ := db.Get(headVerifiedIndexKey)
@@ -91,6 +106,13 @@ func WriteHeadVerifiedIndex(db ethdb.KeyValueWriter, index uint64) {
 	}
 }
 
+// DeleteHeadVerifiedIndex removes the known tip of the batched transactions
+func DeleteHeadVerifiedIndex(db ethdb.KeyValueWriter) {
+	if err := db.Delete(headVerifiedIndexKey); err != nil {
+		log.Crit("Failed to delete verifier index", "err", err)
+	}
+}
+
 // ReadHeadBatchIndex will read the known tip of the processed batches
 func ReadHeadBatchIndex(db ethdb.KeyValueReader) *uint64 {
 	data, _ := db.Get(headBatchKey)
@@ -111,3 +133,30 @@ func WriteHeadBatchIndex(db ethdb.KeyValueWriter, index uint64) {
 		log.Crit("Failed to store head batch index", "err", err)
 	}
 }
+
+// WriteHeadBlockIndexes writes the CTC and queue indexes described by a new
+// head block. It is meant to be called with the batch that marks the block
+// as the head so that the indexes can not get out of step with the chain.
+// The queue index is only written when the block includes an enqueue. The
+// index time tracks the liveness of the sequencer rather than the chain and
+// is left to the sync service.
+func WriteHeadBlockIndexes(db ethdb.KeyValueWriter, block *types.Block) {
+	number := block.NumberU64()
+	if number == 0 {
+		DeleteHeadIndex(db)
+		DeleteHeadVerifiedIndex(db)
+		return
+	}
+	// The CTC index is the block number minus one
+	WriteHeadIndex(db, number-1)
+	WriteHeadVerifiedIndex(db, number-1)
+
+	txs := block.Transactions()
+	for i := len(txs) - 1; i >= 0; i-- {
+		meta := txs[i].GetMeta()
+		if txs[i].QueueOrigin() == types.QueueOriginL1ToL2 && meta.QueueIndex != nil {
+			WriteHeadQueueIndex(db, *meta.QueueIndex)
+			return
+		}
+	}
+}
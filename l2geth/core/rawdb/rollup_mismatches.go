@@ -0,0 +1,66 @@
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+// stateRootMismatchKey = stateRootMismatchPrefix + index (uint64 big endian)
+func stateRootMismatchKey(index uint64) []byte {
+	key := make([]byte, len(stateRootMismatchPrefix)+8)
+	copy(key, stateRootMismatchPrefix)
+	binary.BigEndian.PutUint64(key[len(stateRootMismatchPrefix):], index)
+	return key
+}
+
+// WriteStateRootMismatch stores a state root mismatch, a later mismatch at
+// the same index replaces the earlier one
+func WriteStateRootMismatch(db ethdb.KeyValueWriter, mismatch *types.StateRootMismatch) {
+	data, err := rlp.EncodeToBytes(mismatch)
+	if err != nil {
+		log.Crit("Failed to encode state root mismatch", "err", err)
+	}
+	if err := db.Put(stateRootMismatchKey(mismatch.Index), data); err != nil {
+		log.Crit("Failed to store state root mismatch", "err", err)
+	}
+}
+
+// ReadStateRootMismatch reads the state root mismatch at an index
+func ReadStateRootMismatch(db ethdb.KeyValueReader, index uint64) *types.StateRootMismatch {
+	data, _ := db.Get(stateRootMismatchKey(index))
+	if len(data) == 0 {
+		return nil
+	}
+	mismatch := new(types.StateRootMismatch)
+	if err := rlp.DecodeBytes(data, mismatch); err != nil {
+		log.Error("Invalid state root mismatch RLP", "index", index, "err", err)
+		return nil
+	}
+	return mismatch
+}
+
+// ReadStateRootMismatches reads up to limit state root mismatches in index
+// order, starting at the given index
+func ReadStateRootMismatches(db ethdb.Iteratee, start uint64, limit int) []*types.StateRootMismatch {
+	it := db.NewIteratorWithStart(stateRootMismatchKey(start))
+	defer it.Release()
+
+	var mismatches []*types.StateRootMismatch
+	for it.Next() && len(mismatches) < limit {
+		key := it.Key()
+		if len(key) != len(stateRootMismatchPrefix)+8 || string(key[:len(stateRootMismatchPrefix)]) != string(stateRootMismatchPrefix) {
+			break
+		}
+		mismatch := new(types.StateRootMismatch)
+		if err := rlp.DecodeBytes(it.Value(), mismatch); err != nil {
+			log.Error("Invalid state root mismatch RLP", "key", key, "err", err)
+			continue
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches
+}
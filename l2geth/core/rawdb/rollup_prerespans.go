@@ -0,0 +1,59 @@
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+// preRespanKey = preRespanPrefix + start block (uint64 big endian)
+func preRespanKey(start uint64) []byte {
+	key := make([]byte, len(preRespanPrefix)+8)
+	copy(key, preRespanPrefix)
+	binary.BigEndian.PutUint64(key[len(preRespanPrefix):], start)
+	return key
+}
+
+// WritePreRespan stores a pending pre-respan, replacing the one with the same
+// start block
+func WritePreRespan(db ethdb.KeyValueWriter, respan *types.PreRespan) {
+	data, err := rlp.EncodeToBytes(respan)
+	if err != nil {
+		log.Crit("Failed to encode pre-respan", "err", err)
+	}
+	if err := db.Put(preRespanKey(respan.StartBlock), data); err != nil {
+		log.Crit("Failed to store pre-respan", "err", err)
+	}
+}
+
+// DeletePreRespan removes the pending pre-respan with a start block
+func DeletePreRespan(db ethdb.KeyValueWriter, start uint64) {
+	if err := db.Delete(preRespanKey(start)); err != nil {
+		log.Crit("Failed to delete pre-respan", "err", err)
+	}
+}
+
+// ReadPreRespans reads the pending pre-respans ordered by start block
+func ReadPreRespans(db ethdb.Iteratee) []*types.PreRespan {
+	it := db.NewIteratorWithPrefix(preRespanPrefix)
+	defer it.Release()
+
+	var respans []*types.PreRespan
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(preRespanPrefix)+8 || !bytes.HasPrefix(key, preRespanPrefix) {
+			continue
+		}
+		respan := new(types.PreRespan)
+		if err := rlp.DecodeBytes(it.Value(), respan); err != nil {
+			log.Error("Invalid pre-respan RLP", "key", key, "err", err)
+			continue
+		}
+		respans = append(respans, respan)
+	}
+	return respans
+}
@@ -0,0 +1,56 @@
+package rawdb
+
+import (
+	"bytes"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+// sequencerInfoKey = sequencerInfoPrefix + address
+func sequencerInfoKey(address common.Address) []byte {
+	return append(append([]byte{}, sequencerInfoPrefix...), address.Bytes()...)
+}
+
+// WriteSequencerInfo stores the signed info of a sequencer, replacing the
+// earlier one
+func WriteSequencerInfo(db ethdb.KeyValueWriter, info *types.SequencerInfo) {
+	data, err := rlp.EncodeToBytes(info)
+	if err != nil {
+		log.Crit("Failed to encode sequencer info", "err", err)
+	}
+	if err := db.Put(sequencerInfoKey(info.SequencerAddress), data); err != nil {
+		log.Crit("Failed to store sequencer info", "err", err)
+	}
+}
+
+// DeleteSequencerInfo removes the info of a sequencer
+func DeleteSequencerInfo(db ethdb.KeyValueWriter, address common.Address) {
+	if err := db.Delete(sequencerInfoKey(address)); err != nil {
+		log.Crit("Failed to delete sequencer info", "err", err)
+	}
+}
+
+// ReadSequencerInfos reads the stored infos of all sequencers
+func ReadSequencerInfos(db ethdb.Iteratee) []*types.SequencerInfo {
+	it := db.NewIteratorWithPrefix(sequencerInfoPrefix)
+	defer it.Release()
+
+	var infos []*types.SequencerInfo
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(sequencerInfoPrefix)+common.AddressLength || !bytes.HasPrefix(key, sequencerInfoPrefix) {
+			continue
+		}
+		info := new(types.SequencerInfo)
+		if err := rlp.DecodeBytes(it.Value(), info); err != nil {
+			log.Error("Invalid sequencer info RLP", "key", key, "err", err)
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
@@ -67,6 +67,18 @@ var (
 	headBatchKey = []byte("LastBatch")
 	// headIndexTimeKey tracks the last processed ctc index time
 	headIndexTimeKey = []byte("LastIndexTime")
+	// stateRootMismatchPrefix + index (uint64 big endian) -> state root mismatch
+	stateRootMismatchPrefix = []byte("StateRootMismatch-")
+	// sequencerEpochPrefix + epoch id (uint64 big endian) -> sequencer epoch
+	sequencerEpochPrefix = []byte("SeqEpoch-")
+	// sequencerEpochEndPrefix + end block (uint64 big endian) -> epoch id
+	sequencerEpochEndPrefix = []byte("SeqEpochEnd-")
+	// sequencerInfoPrefix + address -> signed sequencer info
+	sequencerInfoPrefix = []byte("SeqInfo-")
+	// preRespanPrefix + start block (uint64 big endian) -> pending pre-respan
+	preRespanPrefix = []byte("PreRespan-")
+	// equivocationPrefix + block number (uint64 big endian) + signer -> equivocation evidence
+	equivocationPrefix = []byte("SeqEquivocation-")
 
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
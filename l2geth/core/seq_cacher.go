@@ -0,0 +1,183 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+// seqSenderCacher is a concurrent sequencer recoverer and cacher.
+var seqSenderCacher = newTxSeqCacher(runtime.NumCPU())
+
+var errUnknownSeqEpoch = errors.New("unknown sequencer epoch")
+
+// SeqSignEpochFunc returns the epoch that the sequencer signature of a tx in
+// the block number is bound to
+type SeqSignEpochFunc func(tx *types.Transaction, number uint64) (*big.Int, error)
+
+// txSeqCacherRequest is a request for recovering the sequencers of
+// transactions from their signatures over the digests and caching them into
+// the transactions themselves.
+//
+// The inc field defines the number of transactions to skip after each
+// recovery, like txSenderCacherRequest.
+type txSeqCacherRequest struct {
+	txs     []*types.Transaction
+	digests []common.Hash
+	inc     int
+	done    *sync.WaitGroup
+}
+
+// txSeqCacher is a helper structure to concurrently ecrecover the
+// sequencers of transactions on background threads.
+type txSeqCacher struct {
+	threads int
+	tasks   chan *txSeqCacherRequest
+}
+
+// newTxSeqCacher creates a new sequencer background cacher and starts as
+// many processing goroutines as threads.
+func newTxSeqCacher(threads int) *txSeqCacher {
+	cacher := &txSeqCacher{
+		tasks:   make(chan *txSeqCacherRequest, threads),
+		threads: threads,
+	}
+	for i := 0; i < threads; i++ {
+		go cacher.cache()
+	}
+	return cacher
+}
+
+// cache is an infinite loop, caching the sequencers of the requests.
+func (cacher *txSeqCacher) cache() {
+	for task := range cacher.tasks {
+		for i := 0; i < len(task.txs); i += task.inc {
+			recoverSeqSigner(task.txs[i], task.digests[i])
+		}
+		task.done.Done()
+	}
+}
+
+// recover recovers the sequencers of transactions from their signatures over
+// the digests. The returned wait group is done when all of them are cached.
+func (cacher *txSeqCacher) recover(txs []*types.Transaction, digests []common.Hash) *sync.WaitGroup {
+	done := new(sync.WaitGroup)
+	if len(txs) == 0 {
+		return done
+	}
+	tasks := cacher.threads
+	if len(txs) < tasks*4 {
+		tasks = (len(txs) + 3) / 4
+	}
+	done.Add(tasks)
+	for i := 0; i < tasks; i++ {
+		cacher.tasks <- &txSeqCacherRequest{
+			txs:     txs[i:],
+			digests: digests[i:],
+			inc:     tasks,
+			done:    done,
+		}
+	}
+	return done
+}
+
+// seqSignDigests appends the sequencer signed transactions of the block
+// number and the digests their sequencers signed. Transactions whose epoch is
+// not known are left out.
+func seqSignDigests(config *params.ChainConfig, number uint64, txs []*types.Transaction, epochOf SeqSignEpochFunc, signed []*types.Transaction, digests []common.Hash) ([]*types.Transaction, []common.Hash) {
+	seqSign := config.IsSeqSignEnabled(new(big.Int).SetUint64(number))
+	for _, tx := range txs {
+		if tx.QueueOrigin() == types.QueueOriginL1ToL2 || tx.GetSeqSign() == nil {
+			continue
+		}
+		digest := tx.Hash()
+		if seqSign {
+			epoch, err := epochOf(tx, number)
+			if err != nil {
+				continue
+			}
+			digest = SeqSignHash(config.ChainID, number, epoch, tx.Hash())
+		}
+		signed = append(signed, tx)
+		digests = append(digests, digest)
+	}
+	return signed, digests
+}
+
+// CacheSeqSenders recovers the senders and the sequencers of the transactions
+// of blocks concurrently and caches them into the transactions. It returns
+// when the sequencers are cached, the senders are recovered in the
+// background.
+func CacheSeqSenders(config *params.ChainConfig, blocks []*types.Block, epochOf SeqSignEpochFunc) {
+	if len(blocks) == 0 {
+		return
+	}
+	senderCacher.recoverFromBlocks(types.MakeSigner(config, blocks[0].Number()), blocks)
+
+	var (
+		txs     []*types.Transaction
+		digests []common.Hash
+	)
+	for _, block := range blocks {
+		txs, digests = seqSignDigests(config, block.NumberU64(), block.Transactions(), epochOf, txs, digests)
+	}
+	seqSenderCacher.recover(txs, digests).Wait()
+}
+
+// CacheBatchSeqSenders is CacheSeqSenders for a transaction batch, every
+// transaction is applied in the block after its index.
+func CacheBatchSeqSenders(config *params.ChainConfig, batch []*types.Transaction, epochOf SeqSignEpochFunc) {
+	var (
+		txs     []*types.Transaction
+		digests []common.Hash
+	)
+	for _, tx := range batch {
+		index := tx.GetMeta().Index
+		if index == nil {
+			continue
+		}
+		if tx.QueueOrigin() != types.QueueOriginL1ToL2 {
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+	senderCacher.recover(types.MakeSigner(config, new(big.Int).SetUint64(*txs[0].GetMeta().Index+1)), txs)
+
+	var signed []*types.Transaction
+	for _, tx := range txs {
+		signed, digests = seqSignDigests(config, *tx.GetMeta().Index+1, []*types.Transaction{tx}, epochOf, signed, digests)
+	}
+	seqSenderCacher.recover(signed, digests).Wait()
+}
+
+// cacheSeqSenders starts the recovery of the sequencers of a chain that is
+// inserted. The epochs are read from the epoch index, so blocks of unknown
+// epochs are recovered during their processing.
+func (bc *BlockChain) cacheSeqSenders(chain types.Blocks) {
+	epochOf := func(tx *types.Transaction, number uint64) (*big.Int, error) {
+		if isRecommit, epoch := DecodeReCommitEpoch(tx.Data()); isRecommit {
+			return epoch, nil
+		}
+		epoch := rawdb.ReadSequencerEpochAt(bc.db, number)
+		if epoch == nil {
+			return nil, errUnknownSeqEpoch
+		}
+		return new(big.Int).SetUint64(epoch.ID), nil
+	}
+	var (
+		txs     []*types.Transaction
+		digests []common.Hash
+	)
+	for _, block := range chain {
+		txs, digests = seqSignDigests(bc.chainConfig, block.NumberU64(), block.Transactions(), epochOf, txs, digests)
+	}
+	seqSenderCacher.recover(txs, digests)
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+// seqSignedBlocks returns blocks from number 1 on with txs signed by key and
+// sequencer signed by seqKey in the epoch
+func seqSignedBlocks(tb testing.TB, config *params.ChainConfig, key, seqKey *ecdsa.PrivateKey, blocks, txs int, epoch *big.Int) []*types.Block {
+	signer := types.NewEIP155Signer(config.ChainID)
+	var (
+		chain = make([]*types.Block, blocks)
+		nonce uint64
+	)
+	for i := range chain {
+		number := uint64(i + 1)
+		body := make([]*types.Transaction, txs)
+		for j := range body {
+			tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1), 21000, common.Big1, nil), signer, key)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			nonce++
+			digest := tx.Hash()
+			if config.IsSeqSignEnabled(new(big.Int).SetUint64(number)) {
+				digest = SeqSignHash(config.ChainID, number, epoch, tx.Hash())
+			}
+			signature, err := crypto.Sign(digest.Bytes(), seqKey)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			tx.SetSeqSign(&types.SeqSign{
+				R: new(big.Int).SetBytes(signature[0:32]),
+				S: new(big.Int).SetBytes(signature[32:64]),
+				V: new(big.Int).SetBytes(signature[64:65]),
+			})
+			body[j] = tx
+		}
+		chain[i] = types.NewBlock(&types.Header{Number: new(big.Int).SetUint64(number)}, body, nil, nil)
+	}
+	return chain
+}
+
+func TestCacheSeqSenders(t *testing.T) {
+	defer func(height *big.Int) { params.MetisFallbackRollupConfig.SeqSignHeight = height }(params.MetisFallbackRollupConfig.SeqSignHeight)
+	params.MetisFallbackRollupConfig.SeqSignHeight = big.NewInt(3)
+
+	config := params.TestChainConfig
+	key, _ := crypto.GenerateKey()
+	seqKey, _ := crypto.GenerateKey()
+	seq := crypto.PubkeyToAddress(seqKey.PublicKey)
+	epoch := big.NewInt(2)
+	blocks := seqSignedBlocks(t, config, key, seqKey, 4, 8, epoch)
+
+	CacheSeqSenders(config, blocks, func(tx *types.Transaction, number uint64) (*big.Int, error) {
+		if number == 4 {
+			return nil, errUnknownSeqEpoch
+		}
+		return epoch, nil
+	})
+	for _, block := range blocks {
+		number := block.NumberU64()
+		for _, tx := range block.Transactions() {
+			digest := tx.Hash()
+			if number >= 3 {
+				digest = SeqSignHash(config.ChainID, number, epoch, tx.Hash())
+			}
+			from, ok := tx.CachedSeqSender(digest)
+			if number == 4 {
+				// The epoch of the block is not known
+				if ok {
+					t.Fatalf("sequencer of block %d cached without epoch", number)
+				}
+				continue
+			}
+			if !ok || from != seq {
+				t.Fatalf("unexpected cached sequencer of block %d: %s, %v", number, from.Hex(), ok)
+			}
+			// The cache is bound to the digest
+			if _, ok := tx.CachedSeqSender(common.Hash{0x01}); ok {
+				t.Fatal("sequencer cached for another digest")
+			}
+		}
+	}
+	// A new signature invalidates the cache
+	tx := blocks[0].Transactions()[0]
+	signSeq(t, tx, key, tx.Hash())
+	if _, ok := tx.CachedSeqSender(tx.Hash()); ok {
+		t.Fatal("cached sequencer of a replaced signature")
+	}
+	if from, err := RecoverSeqAddress(tx); err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("unexpected sequencer after a new signature: %s, %v", from.Hex(), err)
+	}
+}
+
+func BenchmarkRecoverSeqSendersSerial(b *testing.B) {
+	benchmarkRecoverSeqSenders(b, false)
+}
+
+func BenchmarkRecoverSeqSendersParallel(b *testing.B) {
+	benchmarkRecoverSeqSenders(b, true)
+}
+
+// benchmarkRecoverSeqSenders recovers the senders and the sequencers of a
+// batch of blocks one by one like the block insertion does, with or without
+// caching them on the worker pools first
+func benchmarkRecoverSeqSenders(b *testing.B, parallel bool) {
+	defer func(height *big.Int) { params.MetisFallbackRollupConfig.SeqSignHeight = height }(params.MetisFallbackRollupConfig.SeqSignHeight)
+	params.MetisFallbackRollupConfig.SeqSignHeight = common.Big0
+
+	config := params.TestChainConfig
+	key, _ := crypto.GenerateKey()
+	seqKey, _ := crypto.GenerateKey()
+	epoch := big.NewInt(1)
+	epochOf := func(tx *types.Transaction, number uint64) (*big.Int, error) { return epoch, nil }
+	signer := types.NewEIP155Signer(config.ChainID)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		blocks := seqSignedBlocks(b, config, key, seqKey, 64, 16, epoch)
+		b.StartTimer()
+
+		if parallel {
+			CacheSeqSenders(config, blocks, epochOf)
+		}
+		for _, block := range blocks {
+			for _, tx := range block.Transactions() {
+				if _, err := types.Sender(signer, tx); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := RecoverSeqAddressAt(config, tx, block.NumberU64(), epoch); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
@@ -2,10 +2,10 @@ package core
 
 import (
 	"encoding/hex"
-	"errors"
 	"math/big"
 
 	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
 	"github.com/ethereum-optimism/optimism/l2geth/core/state"
 	"github.com/ethereum-optimism/optimism/l2geth/core/types"
 	"github.com/ethereum-optimism/optimism/l2geth/crypto"
@@ -41,26 +41,21 @@ func DecodeReCommitData(data []byte) (bool, common.Address, *big.Int, *big.Int)
 	return false, common.HexToAddress("0x0"), zeroBigInt, zeroBigInt
 }
 
-func RecoverSeqAddress(tx *types.Transaction) (common.Address, error) {
-	// enqueue tx no sign
-	if tx.QueueOrigin() == types.QueueOriginL1ToL2 {
-		return common.Address{}, errors.New("enqueue seq sign is null")
-	}
-	seqSign := tx.GetSeqSign()
-	if seqSign == nil {
-		return common.Address{}, errors.New("seq sign is null")
-	}
-
-	var signBytes []byte
-	signBytes = append(signBytes, seqSign.R.FillBytes(make([]byte, 32))...)
-	signBytes = append(signBytes, seqSign.S.FillBytes(make([]byte, 32))...)
-	signBytes = append(signBytes, byte(seqSign.V.Int64()))
+// EncodeReCommitData returns the call data of a seqset recommitEpoch call
+// that hands the blocks from startBlock to endBlock to a new signer
+func EncodeReCommitData(oldEpochId, newEpochId, startBlock, endBlock *big.Int, signer common.Address) []byte {
+	data := common.FromHex(seqsetRecommitMethod)
+	data = append(data, common.BigToHash(oldEpochId).Bytes()...)
+	data = append(data, common.BigToHash(newEpochId).Bytes()...)
+	data = append(data, common.BigToHash(startBlock).Bytes()...)
+	data = append(data, common.BigToHash(endBlock).Bytes()...)
+	return append(data, common.LeftPadBytes(signer.Bytes(), 32)...)
+}
 
-	signer, err := crypto.SigToPub(tx.Hash().Bytes(), signBytes)
-	if err != nil {
-		return common.Address{}, err
-	}
-	return crypto.PubkeyToAddress(*signer), nil
+// RecoverSeqAddress recovers the sequencer of a transaction from a signature
+// over the transaction hash, the scheme before the SeqSign fork
+func RecoverSeqAddress(tx *types.Transaction) (common.Address, error) {
+	return recoverSeqSigner(tx, tx.Hash())
 }
 
 func updateEpochCache(currentEpochId *big.Int, epoch *Epoch, prependBeginning bool) {
@@ -102,7 +97,8 @@ func processSeqSetBlock(bc *BlockChain, statedb *state.StateDB, block *types.Blo
 		if to != nil && *to == seqsetAddr {
 			// decode tx data
 			isRecommit, newSequencer, _, _ := DecodeReCommitData(currentTx.Data())
-			recoverSigner, err := RecoverSeqAddress(currentTx)
+			_, newEpoch := DecodeReCommitEpoch(currentTx.Data())
+			recoverSigner, err := RecoverSeqAddressAt(bc.Config(), currentTx, currentBN.Uint64(), newEpoch)
 			if err != nil {
 				return err
 			}
@@ -115,9 +111,7 @@ func processSeqSetBlock(bc *BlockChain, statedb *state.StateDB, block *types.Blo
 		}
 	}
 
-	// get currentEpochId, slot index is 104
-	currentEpochIdSlot := big.NewInt(104)
-	currentEpochId := statedb.GetState(seqsetAddr, common.BytesToHash(currentEpochIdSlot.Bytes())).Big()
+	currentEpochId := readSeqSetCurrentEpochId(statedb, seqsetAddr)
 
 	// epoch id slice
 	prependBeginning := true
@@ -133,26 +127,8 @@ func processSeqSetBlock(bc *BlockChain, statedb *state.StateDB, block *types.Blo
 		}
 	}
 
-	// get epoch, base slot index is 103
 	for _, epochId := range epochIds {
-		epochsSlot := big.NewInt(103)
-		numberSlot := crypto.Keccak256Hash(append(common.LeftPadBytes(epochId.Bytes(), 32), common.LeftPadBytes(epochsSlot.Bytes(), 32)...)).Big()
-		signerSlot := new(big.Int).Add(numberSlot, common.Big1)
-		startBlockSlot := new(big.Int).Add(signerSlot, common.Big1)
-		endBlockSlot := new(big.Int).Add(startBlockSlot, common.Big1)
-
-		numberData := statedb.GetState(seqsetAddr, common.BytesToHash(numberSlot.Bytes())).Bytes()
-		signerData := statedb.GetState(seqsetAddr, common.BytesToHash(signerSlot.Bytes())).Bytes()
-		startBlockData := statedb.GetState(seqsetAddr, common.BytesToHash(startBlockSlot.Bytes())).Bytes()
-		endBlockData := statedb.GetState(seqsetAddr, common.BytesToHash(endBlockSlot.Bytes())).Bytes()
-
-		epoch := &Epoch{
-			Number:     new(big.Int).SetBytes(numberData),
-			Signer:     common.BytesToAddress(signerData),
-			StartBlock: new(big.Int).SetBytes(startBlockData),
-			EndBlock:   new(big.Int).SetBytes(endBlockData),
-		}
-
+		epoch := readSeqSetEpoch(statedb, seqsetAddr, epochId)
 		log.Debug("Read epoch from slot", "epoch", epoch)
 
 		updateEpochCache(currentEpochId, epoch, prependBeginning)
@@ -160,15 +136,29 @@ func processSeqSetBlock(bc *BlockChain, statedb *state.StateDB, block *types.Blo
 
 	// check first tx is enough
 	currentTx := block.Transactions()[0]
-	recoverSigner, err := RecoverSeqAddress(currentTx)
-	if err != nil {
-		return err
-	}
 	found := false
-	for _, epoch := range epochCache {
-		if epoch.Signer == recoverSigner {
-			found = true
-			break
+	if bc.Config().IsSeqSignEnabled(currentBN) {
+		// the signature is bound to the epoch of its signer
+		for _, epoch := range epochCache {
+			recoverSigner, err := RecoverSeqAddressAt(bc.Config(), currentTx, currentBN.Uint64(), epoch.Number)
+			if err != nil {
+				return err
+			}
+			if epoch.Signer == recoverSigner {
+				found = true
+				break
+			}
+		}
+	} else {
+		recoverSigner, err := RecoverSeqAddress(currentTx)
+		if err != nil {
+			return err
+		}
+		for _, epoch := range epochCache {
+			if epoch.Signer == recoverSigner {
+				found = true
+				break
+			}
 		}
 	}
 	if !found {
@@ -176,3 +166,79 @@ func processSeqSetBlock(bc *BlockChain, statedb *state.StateDB, block *types.Blo
 	}
 	return nil
 }
+
+// readSeqSetCurrentEpochId reads the current epoch id from the storage of the
+// seqset contract, slot index is 104
+func readSeqSetCurrentEpochId(statedb *state.StateDB, seqsetAddr common.Address) *big.Int {
+	currentEpochIdSlot := big.NewInt(104)
+	return statedb.GetState(seqsetAddr, common.BytesToHash(currentEpochIdSlot.Bytes())).Big()
+}
+
+// readSeqSetEpoch reads an epoch from the storage of the seqset contract, base
+// slot index of the epochs is 103
+func readSeqSetEpoch(statedb *state.StateDB, seqsetAddr common.Address, epochId *big.Int) *Epoch {
+	epochsSlot := big.NewInt(103)
+	numberSlot := crypto.Keccak256Hash(append(common.LeftPadBytes(epochId.Bytes(), 32), common.LeftPadBytes(epochsSlot.Bytes(), 32)...)).Big()
+	signerSlot := new(big.Int).Add(numberSlot, common.Big1)
+	startBlockSlot := new(big.Int).Add(signerSlot, common.Big1)
+	endBlockSlot := new(big.Int).Add(startBlockSlot, common.Big1)
+
+	numberData := statedb.GetState(seqsetAddr, common.BytesToHash(numberSlot.Bytes())).Bytes()
+	signerData := statedb.GetState(seqsetAddr, common.BytesToHash(signerSlot.Bytes())).Bytes()
+	startBlockData := statedb.GetState(seqsetAddr, common.BytesToHash(startBlockSlot.Bytes())).Bytes()
+	endBlockData := statedb.GetState(seqsetAddr, common.BytesToHash(endBlockSlot.Bytes())).Bytes()
+
+	return &Epoch{
+		Number:     new(big.Int).SetBytes(numberData),
+		Signer:     common.BytesToAddress(signerData),
+		StartBlock: new(big.Int).SetBytes(startBlockData),
+		EndBlock:   new(big.Int).SetBytes(endBlockData),
+	}
+}
+
+// indexSeqSetEpochs records the epochs of the seqset contract after a
+// canonical block, the epoch of a successful respan in the block and the
+// current epoch. The epochs before the current one are read once when they
+// are not yet known.
+func (bc *BlockChain) indexSeqSetEpochs(block *types.Block, receipts []*types.Receipt, statedb *state.StateDB) {
+	if !bc.Config().IsSeqSetPeerEnabled(block.Number()) {
+		return
+	}
+	seqsetAddr := bc.Config().MetisSeqSetContract()
+	if seqsetAddr.IsZero() {
+		return
+	}
+	for i, tx := range block.Transactions() {
+		if tx.To() == nil || *tx.To() != seqsetAddr || i >= len(receipts) || receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		isRecommit, signer, startBlock, endBlock := DecodeReCommitData(tx.Data())
+		if !isRecommit {
+			continue
+		}
+		_, epochId := DecodeReCommitEpoch(tx.Data())
+		rawdb.WriteSequencerEpoch(bc.db, &types.SequencerEpoch{
+			ID:         epochId.Uint64(),
+			Signer:     signer,
+			StartBlock: startBlock.Uint64(),
+			EndBlock:   endBlock.Uint64(),
+			RespanTx:   tx.Hash(),
+		})
+	}
+	currentEpochId := readSeqSetCurrentEpochId(statedb, seqsetAddr)
+	for id := new(big.Int).Set(currentEpochId); id.Sign() >= 0; id.Sub(id, common.Big1) {
+		if id.Cmp(currentEpochId) != 0 && rawdb.ReadSequencerEpoch(bc.db, id.Uint64()) != nil {
+			break
+		}
+		epoch := readSeqSetEpoch(statedb, seqsetAddr, id)
+		if epoch.Signer == (common.Address{}) {
+			break
+		}
+		rawdb.WriteSequencerEpoch(bc.db, &types.SequencerEpoch{
+			ID:         id.Uint64(),
+			Signer:     epoch.Signer,
+			StartBlock: epoch.StartBlock.Uint64(),
+			EndBlock:   epoch.EndBlock.Uint64(),
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/state"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+// setSeqSetEpoch writes an epoch to the storage of the seqset contract
+func setSeqSetEpoch(statedb *state.StateDB, seqsetAddr common.Address, id int64, signer common.Address, start, end int64) {
+	numberSlot := crypto.Keccak256Hash(common.BigToHash(big.NewInt(id)).Bytes(), common.BigToHash(big.NewInt(103)).Bytes()).Big()
+	for i, value := range []common.Hash{
+		common.BigToHash(big.NewInt(id)),
+		signer.Hash(),
+		common.BigToHash(big.NewInt(start)),
+		common.BigToHash(big.NewInt(end)),
+	} {
+		statedb.SetState(seqsetAddr, common.BigToHash(new(big.Int).Add(numberSlot, big.NewInt(int64(i)))), value)
+	}
+	statedb.SetState(seqsetAddr, common.BigToHash(big.NewInt(104)), common.BigToHash(big.NewInt(id)))
+}
+
+func TestIndexSeqSetEpochs(t *testing.T) {
+	defer func(cfg params.MVMRollupConfig) { *params.MetisFallbackRollupConfig = cfg }(*params.MetisFallbackRollupConfig)
+	seqsetAddr := common.Address{0x5e}
+	params.MetisFallbackRollupConfig.SeqSetContract = seqsetAddr
+	params.MetisFallbackRollupConfig.SeqSetPeerHeight = big.NewInt(0)
+
+	db := rawdb.NewMemoryDatabase()
+	bc := &BlockChain{chainConfig: params.TestChainConfig, db: db}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	signerA, signerB, signerC := common.Address{0x0a}, common.Address{0x0b}, common.Address{0x0c}
+
+	// The epochs before the current one are read when the index is empty
+	setSeqSetEpoch(statedb, seqsetAddr, 0, signerA, 0, 99)
+	setSeqSetEpoch(statedb, seqsetAddr, 1, signerB, 100, 199)
+	header := &types.Header{Number: big.NewInt(150)}
+	bc.indexSeqSetEpochs(types.NewBlockWithHeader(header), nil, statedb)
+	if epochs := rawdb.ReadSequencerEpochs(db, 0, 10); len(epochs) != 2 || epochs[0].Signer != signerA || epochs[1].Signer != signerB {
+		t.Fatalf("unexpected epochs: %v", epochs)
+	}
+
+	// A successful respan is recorded with its transaction
+	data := common.FromHex(seqsetRecommitMethod)
+	for _, word := range []common.Hash{
+		common.BigToHash(big.NewInt(1)),
+		common.BigToHash(big.NewInt(2)),
+		common.BigToHash(big.NewInt(160)),
+		common.BigToHash(big.NewInt(259)),
+		signerC.Hash(),
+	} {
+		data = append(data, word.Bytes()...)
+	}
+	tx := types.NewTransaction(0, seqsetAddr, common.Big0, 100000, common.Big1, data)
+	header = &types.Header{Number: big.NewInt(151)}
+	block := types.NewBlock(header, []*types.Transaction{tx}, nil, nil)
+	setSeqSetEpoch(statedb, seqsetAddr, 2, signerC, 160, 259)
+	bc.indexSeqSetEpochs(block, []*types.Receipt{{Status: types.ReceiptStatusSuccessful}}, statedb)
+
+	if epoch := rawdb.ReadSequencerEpochAt(db, 159); epoch == nil || epoch.Signer != signerB {
+		t.Fatalf("unexpected epoch before the respan: %v", epoch)
+	}
+	if epoch := rawdb.ReadSequencerEpochAt(db, 160); epoch == nil || epoch.Signer != signerC || epoch.RespanTx != tx.Hash() {
+		t.Fatalf("unexpected respan epoch: %v", epoch)
+	}
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+// From the SeqSign fork on the sequencer signs an EIP-712 typed payload that
+// binds a transaction to the L2 chain, the block number and the sequencer set
+// epoch, so that a signature cannot be replayed into another network or at
+// another height. Blocks before the fork keep the signatures over the plain
+// transaction hash.
+var (
+	seqSignDomainTypeHash = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId)"))
+	seqSignTypeHash       = crypto.Keccak256Hash([]byte("SequencerSignature(uint256 blockNumber,uint256 epochId,bytes32 txHash)"))
+	seqSignNameHash       = crypto.Keccak256Hash([]byte("Metis Sequencer"))
+	seqSignVersionHash    = crypto.Keccak256Hash([]byte("1"))
+)
+
+// SeqSignDomainSeparator returns the EIP-712 domain separator of the
+// sequencer signatures of a chain
+func SeqSignDomainSeparator(chainID *big.Int) common.Hash {
+	return crypto.Keccak256Hash(
+		seqSignDomainTypeHash.Bytes(),
+		seqSignNameHash.Bytes(),
+		seqSignVersionHash.Bytes(),
+		common.BigToHash(chainID).Bytes(),
+	)
+}
+
+// SeqSignData returns the EIP-712 encoding of a sequencer signature payload,
+// the sequencer signs its keccak256 hash
+func SeqSignData(chainID *big.Int, number uint64, epoch *big.Int, txHash common.Hash) []byte {
+	if epoch == nil {
+		epoch = common.Big0
+	}
+	structHash := crypto.Keccak256(
+		seqSignTypeHash.Bytes(),
+		common.BigToHash(new(big.Int).SetUint64(number)).Bytes(),
+		common.BigToHash(epoch).Bytes(),
+		txHash.Bytes(),
+	)
+	data := make([]byte, 0, 2+2*common.HashLength)
+	data = append(data, 0x19, 0x01)
+	data = append(data, SeqSignDomainSeparator(chainID).Bytes()...)
+	return append(data, structHash...)
+}
+
+// SeqSignHash returns the hash that the sequencer signs for a transaction in
+// a block from the SeqSign fork on
+func SeqSignHash(chainID *big.Int, number uint64, epoch *big.Int, txHash common.Hash) common.Hash {
+	return crypto.Keccak256Hash(SeqSignData(chainID, number, epoch, txHash))
+}
+
+// RecoverSeqAddressAt recovers the sequencer of a transaction in the block
+// number, bound to the epoch once the SeqSign fork is active. Before the fork
+// the epoch is ignored and the signature is over the transaction hash.
+func RecoverSeqAddressAt(config *params.ChainConfig, tx *types.Transaction, number uint64, epoch *big.Int) (common.Address, error) {
+	if !config.IsSeqSignEnabled(new(big.Int).SetUint64(number)) {
+		return RecoverSeqAddress(tx)
+	}
+	return recoverSeqSigner(tx, SeqSignHash(config.ChainID, number, epoch, tx.Hash()))
+}
+
+// recoverSeqSigner recovers the signer of the sequencer signature of a
+// transaction over a hash
+func recoverSeqSigner(tx *types.Transaction, hash common.Hash) (common.Address, error) {
+	// enqueue tx no sign
+	if tx.QueueOrigin() == types.QueueOriginL1ToL2 {
+		return common.Address{}, errors.New("enqueue seq sign is null")
+	}
+	seqSign := tx.GetSeqSign()
+	if seqSign == nil {
+		return common.Address{}, errors.New("seq sign is null")
+	}
+	if from, ok := tx.CachedSeqSender(hash); ok {
+		return from, nil
+	}
+
+	var signBytes []byte
+	signBytes = append(signBytes, seqSign.R.FillBytes(make([]byte, 32))...)
+	signBytes = append(signBytes, seqSign.S.FillBytes(make([]byte, 32))...)
+	signBytes = append(signBytes, byte(seqSign.V.Int64()))
+
+	signer, err := crypto.SigToPub(hash.Bytes(), signBytes)
+	if err != nil {
+		return common.Address{}, err
+	}
+	from := crypto.PubkeyToAddress(*signer)
+	tx.CacheSeqSender(seqSign, hash, from)
+	return from, nil
+}
+
+// DecodeReCommitEpoch returns the new epoch id of a recommit call, a
+// recommit is signed by the new sequencer in the new epoch
+func DecodeReCommitEpoch(data []byte) (bool, *big.Int) {
+	isRecommit, _, _, _ := DecodeReCommitData(data)
+	if !isRecommit {
+		return false, nil
+	}
+	return true, new(big.Int).SetBytes(data[4+32 : 4+2*32])
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+// signSeq sets the sequencer signature of a transaction over a hash
+func signSeq(t *testing.T, tx *types.Transaction, key *ecdsa.PrivateKey, hash common.Hash) {
+	signature, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetSeqSign(&types.SeqSign{
+		R: new(big.Int).SetBytes(signature[0:32]),
+		S: new(big.Int).SetBytes(signature[32:64]),
+		V: new(big.Int).SetBytes(signature[64:65]),
+	})
+}
+
+func TestRecoverSeqAddressAt(t *testing.T) {
+	defer func(height *big.Int) { params.MetisFallbackRollupConfig.SeqSignHeight = height }(params.MetisFallbackRollupConfig.SeqSignHeight)
+	params.MetisFallbackRollupConfig.SeqSignHeight = big.NewInt(10)
+
+	config := params.TestChainConfig
+	seqKey, _ := crypto.GenerateKey()
+	seq := crypto.PubkeyToAddress(seqKey.PublicKey)
+	epoch := big.NewInt(3)
+	tx := types.NewTransaction(0, common.Address{0xaa}, big.NewInt(1), 21000, common.Big1, nil)
+
+	// Before the fork the signature is over the transaction hash and the
+	// epoch is ignored
+	signSeq(t, tx, seqKey, tx.Hash())
+	if addr, err := RecoverSeqAddressAt(config, tx, 9, epoch); err != nil || addr != seq {
+		t.Fatalf("unexpected legacy seq address: %s, %v", addr.Hex(), err)
+	}
+	if addr, _ := RecoverSeqAddressAt(config, tx, 10, epoch); addr == seq {
+		t.Fatal("legacy signature accepted after the fork")
+	}
+
+	signSeq(t, tx, seqKey, SeqSignHash(config.ChainID, 10, epoch, tx.Hash()))
+	if addr, err := RecoverSeqAddressAt(config, tx, 10, epoch); err != nil || addr != seq {
+		t.Fatalf("unexpected seq address: %s, %v", addr.Hex(), err)
+	}
+	// The signature does not recover to the sequencer at another height, in
+	// another epoch or on another chain
+	if addr, _ := RecoverSeqAddressAt(config, tx, 11, epoch); addr == seq {
+		t.Fatal("signature replayed at another height")
+	}
+	if addr, _ := RecoverSeqAddressAt(config, tx, 10, big.NewInt(4)); addr == seq {
+		t.Fatal("signature replayed in another epoch")
+	}
+	if SeqSignHash(params.MetisSepoliaChainID, 10, epoch, tx.Hash()) == SeqSignHash(config.ChainID, 10, epoch, tx.Hash()) {
+		t.Fatal("signature hash not bound to the chain id")
+	}
+	if SeqSignHash(config.ChainID, 10, nil, tx.Hash()) != SeqSignHash(config.ChainID, 10, common.Big0, tx.Hash()) {
+		t.Fatal("nil epoch not encoded as zero")
+	}
+}
+
+func TestDecodeReCommitEpoch(t *testing.T) {
+	data := EncodeReCommitData(big.NewInt(6), big.NewInt(7), big.NewInt(100), big.NewInt(200), common.Address{0x01})
+
+	if isRecommit, epoch := DecodeReCommitEpoch(data); !isRecommit || epoch.Uint64() != 7 {
+		t.Fatalf("unexpected recommit epoch: %v, %v", isRecommit, epoch)
+	}
+	if isRecommit, signer, start, end := DecodeReCommitData(data); !isRecommit || signer != (common.Address{0x01}) || start.Uint64() != 100 || end.Uint64() != 200 {
+		t.Fatalf("unexpected recommit data: %v, %s, %v, %v", isRecommit, signer.Hex(), start, end)
+	}
+	if isRecommit, _ := DecodeReCommitEpoch(data[:len(data)-1]); isRecommit {
+		t.Fatal("short data decoded as recommit")
+	}
+}
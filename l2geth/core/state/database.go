@@ -49,6 +49,9 @@ type Database interface {
 
 	// TrieDB retrieves the low level trie database used for data storage.
 	TrieDB() *trie.Database
+
+	// UsingOVM returns true when balances are kept in the OVM_ETH contract.
+	UsingOVM() bool
 }
 
 // Trie is a Ethereum Merkle Patricia trie.
@@ -107,16 +110,25 @@ func NewDatabase(db ethdb.Database) Database {
 // is safe for concurrent use and retains a lot of collapsed RLP trie nodes in a
 // large memory cache.
 func NewDatabaseWithCache(db ethdb.Database, cache int) Database {
+	return NewDatabaseWithConfig(db, cache, false)
+}
+
+// NewDatabaseWithConfig creates a backing store for state like
+// NewDatabaseWithCache. With usingOVM set the balances are kept in the
+// OVM_ETH contract.
+func NewDatabaseWithConfig(db ethdb.Database, cache int, usingOVM bool) Database {
 	csc, _ := lru.New(codeSizeCacheSize)
 	return &cachingDB{
 		db:            trie.NewDatabaseWithCache(db, cache),
 		codeSizeCache: csc,
+		usingOVM:      usingOVM,
 	}
 }
 
 type cachingDB struct {
 	db            *trie.Database
 	codeSizeCache *lru.Cache
+	usingOVM      bool
 }
 
 // OpenTrie opens the main account trie at a specific root hash.
@@ -161,3 +173,8 @@ func (db *cachingDB) ContractCodeSize(addrHash, codeHash common.Hash) (int, erro
 func (db *cachingDB) TrieDB() *trie.Database {
 	return db.db
 }
+
+// UsingOVM returns true when balances are kept in the OVM_ETH contract.
+func (db *cachingDB) UsingOVM() bool {
+	return db.usingOVM
+}
@@ -31,7 +31,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/metrics"
 	"github.com/ethereum-optimism/optimism/l2geth/rlp"
 	"github.com/ethereum-optimism/optimism/l2geth/rollup/dump"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 	"github.com/ethereum-optimism/optimism/l2geth/trie"
 	"golang.org/x/crypto/sha3"
 )
@@ -241,7 +240,7 @@ func (s *StateDB) Empty(addr common.Address) bool {
 
 // Retrieve the balance from the given address or 0 if object not found
 func (s *StateDB) GetBalance(addr common.Address) *big.Int {
-	if rcfg.UsingOVM {
+	if s.db.UsingOVM() {
 		// Get balance from the OVM_ETH contract.
 		// NOTE: We may remove this feature in a future release.
 		key := GetOVMBalanceKey(addr)
@@ -372,7 +371,7 @@ func (s *StateDB) HasSuicided(addr common.Address) bool {
 
 // AddBalance adds amount to the account associated with addr.
 func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
-	if rcfg.UsingOVM {
+	if s.db.UsingOVM() {
 		// Mutate the storage slot inside of OVM_ETH to change balances.
 		// Note that we don't need to check for overflows or underflows here because the code that
 		// uses this codepath already checks for them. You can follow the original codepath below
@@ -392,7 +391,7 @@ func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
 
 // SubBalance subtracts amount from the account associated with addr.
 func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
-	if rcfg.UsingOVM {
+	if s.db.UsingOVM() {
 		// Mutate the storage slot inside of OVM_ETH to change balances.
 		// Note that we don't need to check for overflows or underflows here because the code that
 		// uses this codepath already checks for them. You can follow the original codepath below
@@ -411,7 +410,7 @@ func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
 }
 
 func (s *StateDB) SetBalance(addr common.Address, amount *big.Int) {
-	if rcfg.UsingOVM {
+	if s.db.UsingOVM() {
 		// Mutate the storage slot inside of OVM_ETH to change balances.
 		key := GetOVMBalanceKey(addr)
 		s.SetState(dump.OvmEthAddress, key, common.BigToHash(amount))
@@ -72,12 +72,12 @@ func (p *statePrefetcher) Prefetch(block *types.Block, statedb *state.StateDB, c
 // the transaction successfully, rather to warm up touched data slots.
 func precacheTransaction(config *params.ChainConfig, bc ChainContext, author *common.Address, gaspool *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, cfg vm.Config) error {
 	// Convert the transaction into an executable message and pre-cache its sender
-	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
+	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number), cfg.OVM.UsingOVM)
 	if err != nil {
 		return err
 	}
 	// Create the EVM and execute the transaction
-	context := NewEVMContext(msg, header, bc, author)
+	context := NewEVMContext(msg, header, bc, author, cfg.OVM.UsingOVM)
 	vm := vm.NewEVM(context, statedb, config, cfg)
 
 	_, _, _, err = ApplyMessage(vm, msg, gaspool)
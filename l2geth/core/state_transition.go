@@ -28,7 +28,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/log"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
 	"github.com/ethereum-optimism/optimism/l2geth/rollup/fees"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 )
 
 var (
@@ -134,7 +133,7 @@ func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition
 	)
 	l1Fee := new(big.Int)
 
-	if rcfg.UsingOVM {
+	if evm.RollupConfig().UsingOVM {
 		if msg.QueueOrigin() == types.QueueOriginSequencer {
 			// Compute the L1 fee before the state transition
 			// so it only has to be read from state one time.
@@ -194,7 +193,7 @@ func (st *StateTransition) useGas(amount uint64) error {
 func (st *StateTransition) buyGas() error {
 
 	mgval := new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasPrice)
-	if rcfg.UsingOVM {
+	if st.evm.RollupConfig().UsingOVM {
 		// Only charge the L1 fee for QueueOrigin sequencer transactions
 		if st.evm.ChainConfig().IsShanghai(st.evm.Context.BlockNumber) && st.msg.QueueOrigin() == types.QueueOriginSequencer {
 			mgval = mgval.Add(mgval, st.l1Fee)
@@ -208,7 +207,7 @@ func (st *StateTransition) buyGas() error {
 		if st.evm.ChainConfig().IsShanghai(st.evm.Context.BlockNumber) {
 			return errInsufficientBalanceForGas
 		}
-		if rcfg.UsingOVM {
+		if st.evm.RollupConfig().UsingOVM {
 			// Hack to prevent race conditions with the `gas-oracle`
 			// where policy level balance checks pass and then fail
 			// during consensus. The user gets some free gas
@@ -233,7 +232,7 @@ func (st *StateTransition) preCheck() error {
 	// log.Debug("preCheck", "checknonce", st.msg.CheckNonce(), "gas", st.msg.Gas())
 	// Make sure this transaction's nonce is correct.
 	if st.msg.CheckNonce() {
-		if rcfg.UsingOVM {
+		if st.evm.RollupConfig().UsingOVM {
 			if st.msg.QueueOrigin() == types.QueueOriginL1ToL2 {
 				return st.buyGas()
 			}
@@ -310,7 +309,7 @@ func (st *StateTransition) TransitionDbWithBlockNumber(blockNumber uint64) (ret
 			log.Debug("zero address with value called. skipping vm execution")
 		} else {
 			// NOTE: andromeda peer & replica
-			if rcfg.ChainID == 1088 && (blockNumber == 3247675 || blockNumber == 3247681) {
+			if st.evm.RollupConfig().ChainID == 1088 && (blockNumber == 3247675 || blockNumber == 3247681) {
 				_ = st.useGas(100000)
 			}
 			// log.Debug("getting in vm", "gas", st.gas, "value", st.value, "sender", msg.From(), "gasprice", st.gasPrice)
@@ -347,7 +346,7 @@ func (st *StateTransition) TransitionDbWithBlockNumber(blockNumber uint64) (ret
 	if !st.evm.ChainConfig().IsShanghai(st.evm.Context.BlockNumber) {
 		st.state.AddBalance(evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 	} else {
-		if rcfg.UsingOVM {
+		if st.evm.RollupConfig().UsingOVM {
 			// The L2 Fee is the same as the fee that is charged in the normal geth
 			// codepath. Add the L1 fee to the L2 fee for the total fee that is sent
 			// to the sequencer.
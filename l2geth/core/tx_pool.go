@@ -157,6 +157,8 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	OVM rcfg.Config `toml:"-"` // Rollup configuration of the node
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -555,8 +557,8 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	// 	return ErrUnderpriced
 	// }
 	// Ensure the transaction adheres to nonce ordering
-	if rcfg.UsingOVM {
-		if rcfg.DeSeqBlock > 0 && pool.chain.CurrentBlock().NumberU64()+1 >= rcfg.DeSeqBlock {
+	if pool.config.OVM.UsingOVM {
+		if pool.config.OVM.IsDeSeq(pool.chain.CurrentBlock().NumberU64() + 1) {
 			if pool.currentState.GetNonce(from) > tx.Nonce() {
 				return ErrNonceTooLow
 			}
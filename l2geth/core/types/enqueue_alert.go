@@ -0,0 +1,16 @@
+package types
+
+import (
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+)
+
+// EnqueueAlert is raised when an enqueue transaction is not included by the
+// sequencer within the force inclusion window after its L1 timestamp
+type EnqueueAlert struct {
+	QueueIndex    uint64      `json:"queueIndex"`
+	TxHash        common.Hash `json:"txHash"`
+	L1BlockNumber uint64      `json:"l1BlockNumber"`
+	L1Timestamp   uint64      `json:"l1Timestamp"`
+	// Seconds the enqueue has waited when the alert was last updated
+	Age uint64 `json:"age"`
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+)
+
+// SequencerSignedTx is a transaction that a sequencer signed for a block
+type SequencerSignedTx struct {
+	TxHash common.Hash `json:"txHash"`
+	// Epoch that the signature is bound to, zero before the SeqSign fork
+	EpochID uint64 `json:"epochId"`
+	// Hash that the sequencer signed, the transaction hash before the SeqSign
+	// fork and the EIP-712 hash of the block number, epoch and transaction
+	// hash after it
+	Digest common.Hash `json:"digest"`
+	// Signature r || s || v with v 27 or 28, as ecrecover takes it
+	Signature hexutil.Bytes `json:"signature"`
+	// Where the node saw the transaction: local, p2p, dtl or chain
+	Source string `json:"source"`
+}
+
+// Equivocation is the evidence of a sequencer that signed two different
+// transactions for the same block
+type Equivocation struct {
+	Signer      common.Address    `json:"signer"`
+	BlockNumber uint64            `json:"blockNumber"`
+	First       SequencerSignedTx `json:"first"`
+	Second      SequencerSignedTx `json:"second"`
+	// ABI encoding of (address signer, uint256 blockNumber, uint256 epochId1,
+	// bytes32 txHash1, bytes signature1, uint256 epochId2, bytes32 txHash2,
+	// bytes signature2) for the slashing contracts
+	Evidence hexutil.Bytes `json:"evidence"`
+	// Unix time at which the equivocation was detected
+	Detected uint64 `json:"detected"`
+}
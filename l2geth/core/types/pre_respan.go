@@ -0,0 +1,27 @@
+package types
+
+import (
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+)
+
+const (
+	// PreRespanSourceBridge marks a pre-respan that the bridge announced
+	PreRespanSourceBridge = "bridge"
+	// PreRespanSourceTx marks a pre-respan of a respan transaction that is in
+	// the chain before its start block
+	PreRespanSourceTx = "tx"
+)
+
+// PreRespan is a respan that is known before the chain reaches its start
+// block. Until then, blocks from the start block on that are signed by the
+// previous signer are not saved.
+type PreRespan struct {
+	PreSigner  common.Address `json:"preSigner"`
+	NewSigner  common.Address `json:"newSigner"`
+	StartBlock uint64         `json:"startBlock"`
+	Source     string         `json:"source"`
+	// Hash of the respan transaction, zero for pre-respans of the bridge
+	TxHash common.Hash `json:"txHash"`
+	// Unix time at which the pre-respan was created
+	Created uint64 `json:"created"`
+}
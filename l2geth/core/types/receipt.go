@@ -334,7 +334,9 @@ func (r Receipts) GetRlp(i int) []byte {
 
 // DeriveFields fills the receipts with their computed fields based on consensus
 // data and contextual infos like containing block and transactions.
-func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, number uint64, txs Transactions) error {
+// When using the OVM the contract address of system deployments is derived
+// from the deployer.
+func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, number uint64, txs Transactions, usingOVM bool) error {
 	signer := MakeSigner(config, new(big.Int).SetUint64(number))
 
 	logIndex := uint(0)
@@ -356,7 +358,7 @@ func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, num
 			from, _ := Sender(signer, txs[i])
 			nonce := txs[i].Nonce()
 
-			if rcfg.UsingOVM {
+			if usingOVM {
 				sysAddress := rcfg.SystemAddressFor(config.ChainID, from)
 				// If nonce is zero, and the deployer is a system address deployer,
 				// set the provided system contract address.
@@ -1,13 +1,46 @@
 package types
 
 import (
+	"math/big"
+
 	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
 )
 
+// sequencerInfoDomain separates the signatures of sequencer info entries from
+// the other signatures of the sequencer key
+const sequencerInfoDomain = "metis-sequencer-info"
+
 type SequencerInfo struct {
 	SequencerAddress common.Address `json:"sequencerAddress"`
 	SequencerUrl     string         `json:"sequencerUrl"`
-	SequencerHeight  uint64         `json:"sequencerHeight"`
+	SequencerHeight  uint64         `json:"sequencerHeight" rlp:"-"`
+	// Unix time at which the entry was signed, it expires a TTL after it
+	Timestamp uint64 `json:"timestamp"`
+	// Signature of the sequencer key over the hash of the sign data
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+	// Whether the sequencer answered when the list was made
+	Alive bool `json:"alive" rlp:"-"`
+}
+
+// SignData returns the data whose keccak256 hash the sequencer signs for an
+// entry on a chain
+func (s *SequencerInfo) SignData(chainID *big.Int) []byte {
+	data, _ := rlp.EncodeToBytes([]interface{}{
+		sequencerInfoDomain,
+		chainID,
+		s.SequencerAddress,
+		s.SequencerUrl,
+		s.Timestamp,
+	})
+	return data
+}
+
+// SigHash returns the hash that the sequencer signs for an entry on a chain
+func (s *SequencerInfo) SigHash(chainID *big.Int) common.Hash {
+	return crypto.Keccak256Hash(s.SignData(chainID))
 }
 
 type SequencerInfoList struct {
@@ -0,0 +1,18 @@
+package types
+
+import (
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+)
+
+// SequencerEpoch is an epoch of the sequencer set, the range of blocks that
+// a signer sequences. An epoch that is created by a respan takes over the
+// remaining blocks of the epochs before it.
+type SequencerEpoch struct {
+	ID         uint64         `json:"id"`
+	Signer     common.Address `json:"signer"`
+	StartBlock uint64         `json:"startBlock"`
+	EndBlock   uint64         `json:"endBlock"`
+	// Hash of the recommit transaction of a respan, zero for epochs that
+	// were not created by a respan
+	RespanTx common.Hash `json:"respanTx"`
+}
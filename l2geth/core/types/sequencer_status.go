@@ -0,0 +1,48 @@
+package types
+
+// Roles of a node in the sequencer set
+const (
+	SequencerRoleActive   = "sequencer"
+	SequencerRoleBackup   = "backup"
+	SequencerRoleVerifier = "verifier"
+)
+
+// Kinds of SequencerChange
+const (
+	SequencerChangeEpoch  = "epoch"
+	SequencerChangeRespan = "respan"
+)
+
+// SequencerStatus is the sequencer schedule at the head of the chain as the
+// node sees it
+type SequencerStatus struct {
+	BlockNumber uint64 `json:"blockNumber"`
+	// Epoch of the head block, nil before the sequencer set is valid
+	Epoch *SequencerEpoch `json:"epoch"`
+	// Number of blocks of the epoch after the head block
+	BlocksLeft uint64 `json:"blocksLeft"`
+	// The epoch after the current one, nil until it is known
+	NextEpoch      *SequencerEpoch `json:"nextEpoch"`
+	PendingRespans []*PreRespan    `json:"pendingRespans"`
+	Role           string          `json:"role"`
+	LastBlockTime  uint64          `json:"lastBlockTime"`
+	// Local unix time at which the last index was added, zero before the
+	// first one. The head is stale when no index was added for
+	// StaleThreshold seconds.
+	LastIndexTime  uint64 `json:"lastIndexTime"`
+	StaleThreshold uint64 `json:"staleThreshold"`
+	Stale          bool   `json:"stale"`
+}
+
+// SequencerChange is a change of the sequencer schedule, the rollover to a
+// new epoch or a queued, fired or cleared respan
+type SequencerChange struct {
+	Type        string `json:"type"`
+	BlockNumber uint64 `json:"blockNumber"`
+	// The new and the previous epoch of a rollover
+	Epoch    *SequencerEpoch `json:"epoch,omitempty"`
+	Previous *SequencerEpoch `json:"previous,omitempty"`
+	// The action on the respan and the respan itself
+	Action string     `json:"action,omitempty"`
+	Respan *PreRespan `json:"respan,omitempty"`
+}
@@ -0,0 +1,16 @@
+package types
+
+import (
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+)
+
+// StateRootMismatch records a state root computed by a verifier that does
+// not match the state root posted to L1
+type StateRootMismatch struct {
+	Index      uint64      `json:"index"`
+	LocalRoot  common.Hash `json:"localRoot"`
+	RemoteRoot common.Hash `json:"remoteRoot"`
+	BatchIndex uint64      `json:"batchIndex"`
+	BatchRoot  common.Hash `json:"batchRoot"`
+	Timestamp  uint64      `json:"timestamp"`
+}
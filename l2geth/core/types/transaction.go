@@ -27,7 +27,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
 	"github.com/ethereum-optimism/optimism/l2geth/crypto"
 	"github.com/ethereum-optimism/optimism/l2geth/rlp"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 )
 
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
@@ -48,6 +47,8 @@ type Transaction struct {
 	hash atomic.Value
 	size atomic.Value
 	from atomic.Value
+	// sequencer of the sequencer signature
+	seqFrom atomic.Value
 
 	// l2 tx tag
 	l2tx uint
@@ -209,12 +210,41 @@ func (t *Transaction) SetL2Tx(l2tx uint) {
 	t.l2tx = l2tx
 }
 
+// seqSigCache is the sequencer that a sequencer signature over a digest
+// recovers to
+type seqSigCache struct {
+	sign   *SeqSign
+	digest common.Hash
+	from   common.Address
+}
+
 func (t *Transaction) SetSeqSign(signResult *SeqSign) {
 	t.meta.R = signResult.R
 	t.meta.S = signResult.S
 	t.meta.V = signResult.V
 }
 
+// CachedSeqSender returns the sequencer that the current sequencer signature
+// over a digest was recovered to before, false if it was not
+func (t *Transaction) CachedSeqSender(digest common.Hash) (common.Address, bool) {
+	sc := t.seqFrom.Load()
+	if sc == nil {
+		return common.Address{}, false
+	}
+	cache := sc.(seqSigCache)
+	sign := t.GetSeqSign()
+	if cache.digest != digest || sign == nil || cache.sign.R.Cmp(sign.R) != 0 || cache.sign.S.Cmp(sign.S) != 0 || cache.sign.V.Cmp(sign.V) != 0 {
+		return common.Address{}, false
+	}
+	return cache.from, true
+}
+
+// CacheSeqSender stores the sequencer that a sequencer signature of the
+// transaction over a digest recovers to
+func (t *Transaction) CacheSeqSender(sign *SeqSign, digest common.Hash, from common.Address) {
+	t.seqFrom.Store(seqSigCache{sign: sign, digest: digest, from: from})
+}
+
 func (t *Transaction) GetSeqSign() *SeqSign {
 	if t.meta.R == nil || t.meta.R.Sign() == 0 {
 		return nil
@@ -323,10 +353,11 @@ func (tx *Transaction) Size() common.StorageSize {
 
 // AsMessage returns the transaction as a core.Message.
 //
-// AsMessage requires a signer to derive the sender.
+// AsMessage requires a signer to derive the sender. When using the OVM the
+// sender of L1 to L2 transactions is the L1 message sender.
 //
 // XXX Rename message to something less arbitrary?
-func (tx *Transaction) AsMessage(s Signer) (Message, error) {
+func (tx *Transaction) AsMessage(s Signer, usingOVM bool) (Message, error) {
 	// TOOD 20210724
 	txMeta := tx.GetMeta()
 	if txMeta.L1MessageSender == nil {
@@ -356,7 +387,7 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 	}
 
 	var err error
-	if rcfg.UsingOVM {
+	if usingOVM {
 		if tx.meta.QueueOrigin == QueueOriginL1ToL2 && tx.meta.L1MessageSender != nil {
 			msg.from = *tx.meta.L1MessageSender
 		} else {
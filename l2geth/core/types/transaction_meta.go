@@ -13,7 +13,6 @@ import (
 
 	"github.com/ethereum-optimism/optimism/l2geth/common"
 	"github.com/ethereum-optimism/optimism/l2geth/rlp"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 )
 
 type QueueOrigin uint8
@@ -156,6 +155,9 @@ func NewTransactionMeta(l1BlockNumber *big.Int, l1timestamp uint64, l1MessageSen
 //	varbytes(L1MessageSender) ||
 //	varbytes(QueueOrigin) ||
 //	varbytes(L1Timestamp)
+//
+// The signature of the sequencer follows when it was encoded, see
+// TxMetaEncode.
 func TxMetaDecode(input []byte) (*TransactionMeta, error) {
 	var err error
 	meta := TransactionMeta{}
@@ -223,7 +225,7 @@ func TxMetaDecode(input []byte) (*TransactionMeta, error) {
 		meta.RawTransaction = raw
 	}
 
-	if rcfg.SeqValidHeight > 0 && *meta.Index+1 >= rcfg.SeqValidHeight {
+	if b.Len() > 0 {
 		// sequencer sign after mpc enabled height
 		r, err := common.ReadVarBytes(b, 0, 1024, "R")
 		if err != nil {
@@ -256,8 +258,10 @@ func TxMetaDecode(input []byte) (*TransactionMeta, error) {
 	return &meta, nil
 }
 
-// TxMetaEncode serializes the TransactionMeta as bytes.
-func TxMetaEncode(meta *TransactionMeta) []byte {
+// TxMetaEncode serializes the TransactionMeta as bytes. The signature of the
+// sequencer is appended from the seqValidHeight on, a zero signature when the
+// transaction has none. A zero seqValidHeight never appends it.
+func TxMetaEncode(meta *TransactionMeta, seqValidHeight uint64) []byte {
 	b := new(bytes.Buffer)
 
 	L1BlockNumber := meta.L1BlockNumber
@@ -312,7 +316,7 @@ func TxMetaEncode(meta *TransactionMeta) []byte {
 		common.WriteVarBytes(b, 0, rawTransaction)
 	}
 
-	if rcfg.SeqValidHeight > 0 && *meta.Index+1 >= rcfg.SeqValidHeight {
+	if seqValidHeight > 0 && meta.Index != nil && *meta.Index+1 >= seqValidHeight {
 		// sequencer sign after mpc enabled height
 		rSeq := meta.R
 		if rSeq == nil {
@@ -69,7 +69,7 @@ func TestTransactionMetaEncode(t *testing.T) {
 	for _, test := range txMetaSerializationTests {
 		txmeta := NewTransactionMeta(test.l1BlockNumber, test.l1Timestamp, test.msgSender, test.queueOrigin, nil, nil, test.rawTransaction)
 
-		encoded := TxMetaEncode(txmeta)
+		encoded := TxMetaEncode(txmeta, 0)
 		decoded, err := TxMetaDecode(encoded)
 
 		if err != nil {
@@ -82,6 +82,42 @@ func TestTransactionMetaEncode(t *testing.T) {
 	}
 }
 
+func TestTransactionMetaEncodeSignature(t *testing.T) {
+	index := uint64(9)
+	txmeta := NewTransactionMeta(l1BlockNumber, 0, &addr, QueueOriginSequencer, &index, nil, []byte{0, 0, 0, 0})
+	unsigned := TxMetaEncode(txmeta, 0)
+
+	// From the valid height on a zero signature is written for unsigned
+	// transactions, older nodes expect it
+	for _, height := range []uint64{1, 10} {
+		encoded := TxMetaEncode(txmeta, height)
+		if len(encoded) != len(unsigned)+3*(1+len(getNullValue())) {
+			t.Fatalf("height %d: no zero signature written", height)
+		}
+		decoded, err := TxMetaDecode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !isTxMetaEqual(txmeta, decoded) {
+			t.Fatalf("height %d: encoding/decoding mismatch", height)
+		}
+	}
+
+	// Below the valid height the signature is left out
+	txmeta.R, txmeta.S, txmeta.V = big.NewInt(1), big.NewInt(2), big.NewInt(27)
+	if encoded := TxMetaEncode(txmeta, 11); !bytes.Equal(encoded, unsigned) {
+		t.Fatal("signature written below the valid height")
+	}
+
+	decoded, err := TxMetaDecode(TxMetaEncode(txmeta, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.R.Cmp(txmeta.R) != 0 || decoded.S.Cmp(txmeta.S) != 0 || decoded.V.Cmp(txmeta.V) != 0 {
+		t.Fatal("signature mismatch")
+	}
+}
+
 func isTxMetaEqual(meta1 *TransactionMeta, meta2 *TransactionMeta) bool {
 	// Maybe can just return this
 	if !reflect.DeepEqual(meta1, meta2) {
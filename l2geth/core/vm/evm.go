@@ -403,7 +403,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, common.Address{}, gas, ErrInsufficientBalance
 	}
-	if rcfg.UsingOVM {
+	if evm.vmConfig.OVM.UsingOVM {
 		// Make sure the creator address should be able to deploy.
 		if !evm.AddressWhitelisted(caller.Address()) {
 			// Try to encode this error as a Solidity error message so it's more clear to end-users
@@ -517,6 +517,9 @@ func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *
 // ChainConfig returns the environment's chain configuration
 func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
 
+// RollupConfig returns the rollup configuration of the node
+func (evm *EVM) RollupConfig() *rcfg.Config { return &evm.vmConfig.OVM }
+
 func (evm *EVM) AddressWhitelisted(addr common.Address) bool {
 	// First check if the owner is address(0), which implicitly disables the whitelist.
 	ownerKey := common.Hash{}
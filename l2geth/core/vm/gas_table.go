@@ -23,7 +23,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/common/math"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
 	"github.com/ethereum-optimism/optimism/l2geth/rollup/dump"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 )
 
 // memoryGasCost calculates the quadratic gas for memory expansion. It does so
@@ -100,7 +99,7 @@ func gasSStore(evm *EVM, contract *Contract, stack *Stack, mem *Memory, memorySi
 		y, x    = stack.Back(1), stack.Back(0)
 		current = evm.StateDB.GetState(contract.Address(), common.BigToHash(x))
 	)
-	isOvmRefund := rcfg.UsingOVM && evm.chainConfig.IsRFDUpdate(evm.BlockNumber) && dump.OvmEthAddress == contract.Address()
+	isOvmRefund := evm.vmConfig.OVM.UsingOVM && evm.chainConfig.IsRFDUpdate(evm.BlockNumber) && dump.OvmEthAddress == contract.Address()
 	// The legacy gas metering only takes into consideration the current state
 	// Legacy rules should be applied if we are in Petersburg (removal of EIP-1283)
 	// OR Constantinople is not active
@@ -191,7 +190,7 @@ func gasSStoreEIP2200(evm *EVM, contract *Contract, stack *Stack, mem *Memory, m
 		current = evm.StateDB.GetState(contract.Address(), common.BigToHash(x))
 	)
 	value := common.BigToHash(y)
-	isOvmRefund := rcfg.UsingOVM && evm.chainConfig.IsRFDUpdate(evm.BlockNumber) && dump.OvmEthAddress == contract.Address()
+	isOvmRefund := evm.vmConfig.OVM.UsingOVM && evm.chainConfig.IsRFDUpdate(evm.BlockNumber) && dump.OvmEthAddress == contract.Address()
 	if current == value { // noop (1)
 		return params.SstoreNoopGasEIP2200, nil
 	}
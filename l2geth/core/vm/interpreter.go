@@ -40,6 +40,8 @@ type Config struct {
 	EVMInterpreter   string // External EVM interpreter options
 
 	ExtraEips []int // Additional EIPS that are to be enabled
+
+	OVM rcfg.Config // Rollup configuration of the node
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the
@@ -101,7 +103,7 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 			// log.Info("Use berlin InstructionSet")
 		case evm.chainRules.IsIstanbul:
 			jt = istanbulInstructionSet
-			if rcfg.UsingOVM {
+			if cfg.OVM.UsingOVM {
 				enableMinimal2929(&jt)
 			}
 		case evm.chainRules.IsConstantinople:
@@ -23,7 +23,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/common/math"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
 	"github.com/ethereum-optimism/optimism/l2geth/rollup/dump"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 )
 
 func makeGasSStoreFunc(clearingRefund uint64) gasFunc {
@@ -58,7 +57,7 @@ func makeGasSStoreFunc(clearingRefund uint64) gasFunc {
 			//		return params.SloadGasEIP2200, nil
 			return cost + params.WarmStorageReadCostEIP2929, nil // SLOAD_GAS
 		}
-		isOvmRefund := rcfg.UsingOVM && evm.chainConfig.IsRFDUpdate(evm.BlockNumber) && dump.OvmEthAddress == contract.Address()
+		isOvmRefund := evm.vmConfig.OVM.UsingOVM && evm.chainConfig.IsRFDUpdate(evm.BlockNumber) && dump.OvmEthAddress == contract.Address()
 		original := evm.StateDB.GetCommittedState(contract.Address(), common.BigToHash(x))
 		if original == current {
 			if original == (common.Hash{}) { // create slot (2.1.1)
@@ -21,7 +21,6 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"strings"
 	"sync"
 	"time"
 
@@ -36,7 +35,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/core/vm"
 	"github.com/ethereum-optimism/optimism/l2geth/eth/downloader"
 	"github.com/ethereum-optimism/optimism/l2geth/eth/gasprice"
-	"github.com/ethereum-optimism/optimism/l2geth/ethclient"
 	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
 	"github.com/ethereum-optimism/optimism/l2geth/event"
 	"github.com/ethereum-optimism/optimism/l2geth/log"
@@ -55,10 +53,7 @@ type EthAPIBackend struct {
 	gasLimit        uint64
 	UsingOVM        bool
 	MaxCallDataSize int
-	seqInfos        map[common.Address]string
 	seqRwMutex      sync.RWMutex
-	seqRpcClient    *ethclient.Client
-	seqRpcUrl       string
 }
 
 func NewEthAPIBackend(extRPCEnabled bool, eth *Ethereum, gpo *gasprice.Oracle, rollupGpo *gasprice.RollupOracle, verifier bool, gasLimit uint64, UsingOVM bool, MaxCallDataSize int) *EthAPIBackend {
@@ -71,7 +66,6 @@ func NewEthAPIBackend(extRPCEnabled bool, eth *Ethereum, gpo *gasprice.Oracle, r
 	b.gasLimit = gasLimit
 	b.UsingOVM = UsingOVM
 	b.MaxCallDataSize = MaxCallDataSize
-	b.seqInfos = make(map[common.Address]string)
 	return b
 }
 func (b *EthAPIBackend) IsVerifier() bool {
@@ -126,6 +120,9 @@ func (b *EthAPIBackend) SetHead(number uint64) {
 	if !b.UsingOVM {
 		b.eth.protocolManager.downloader.Cancel()
 	}
+	if b.eth.syncService != nil {
+		b.eth.syncService.RollupAdapter().RewindSequencerTxs(number)
+	}
 	b.eth.blockchain.SetHead(number)
 
 	// Make sure to reset the LatestL1{Timestamp,BlockNumber}
@@ -226,6 +223,10 @@ func (b *EthAPIBackend) SequencerClientHttp() string {
 	return b.eth.config.Rollup.SequencerClientHttp
 }
 
+func (b *EthAPIBackend) RollupConfig() *rcfg.Config {
+	return &b.eth.config.OVM
+}
+
 func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
 	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
 		return b.eth.blockchain.CurrentBlock().Header(), nil
@@ -358,14 +359,14 @@ func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *sta
 	// https://github.com/ethereum-optimism/optimism/l2geth/commit/39f502329fac4640cfb71959c3496f19ea88bc85#diff-9886da3412b43831145f62cec6e895eb3613a175b945e5b026543b7463454603
 	// We're throwing this behind a UsingOVM flag for now as to not break
 	// any tests that may depend on this behavior.
-	if !rcfg.UsingOVM {
+	if !b.UsingOVM {
 		state.SetBalance(msg.From(), math.MaxBig256)
 	}
 	vmError := func() error { return nil }
 	if vmCfg == nil {
 		vmCfg = b.eth.blockchain.GetVMConfig()
 	}
-	context := core.NewEVMContext(msg, header, b.eth.BlockChain(), nil)
+	context := core.NewEVMContext(msg, header, b.eth.BlockChain(), nil, b.UsingOVM)
 	return vm.NewEVM(context, state, b.eth.blockchain.Config(), *vmCfg), vmError, nil
 }
 
@@ -533,7 +534,7 @@ func (b *EthAPIBackend) ProxyTransaction(ctx context.Context, tx *types.Transact
 	if !b.IsRpcProxySupport() {
 		return nil
 	}
-	if rcfg.UsingOVM {
+	if b.UsingOVM {
 		err := b.validateTx(ctx, tx)
 		if err != nil {
 			return fmt.Errorf("invalid transaction: %w", err)
@@ -565,8 +566,8 @@ func (b *EthAPIBackend) validateTx(ctx context.Context, tx *types.Transaction) e
 	if err != nil {
 		return core.ErrInvalidSender
 	}
-	if rcfg.UsingOVM {
-		if rcfg.DeSeqBlock > 0 && header.Number.Uint64()+1 >= rcfg.DeSeqBlock {
+	if b.UsingOVM {
+		if b.eth.config.OVM.IsDeSeq(header.Number.Uint64() + 1) {
 			if state.GetNonce(from) > tx.Nonce() {
 				return core.ErrNonceTooLow
 			}
@@ -609,92 +610,43 @@ func (b *EthAPIBackend) ProxyEstimateGas(ctx context.Context, arg interface{}) (
 	return b.eth.rpcClient.EstimateGasByArg(ctx, arg)
 }
 
-// Cache a rpc client until sequencer rpc url changed
-func (b *EthAPIBackend) EnsureSeqRpcClient(ctx context.Context, url string) error {
-	if b.seqRpcClient == nil || b.seqRpcUrl != url {
-		if b.seqRpcClient != nil {
-			b.seqRpcClient.Close()
-		}
-		ctxt, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
-		rpcClient, err := ethclient.DialContext(ctxt, url)
-		if err != nil {
-			return err
-		}
-		b.seqRpcClient = rpcClient
-		b.seqRpcUrl = url
-		return nil
-	}
-	return nil
-}
-
 func (b *EthAPIBackend) IsSequencerWorking() bool {
-	indexTime := b.eth.syncService.GetLatestIndexTime()
-	if indexTime == nil {
-		return false
-	}
-	if time.Now().Unix()-int64(*indexTime) > 60 {
-		return false
-	}
-	return true
+	return !b.eth.syncService.SeqStale()
 }
 
 func (b *EthAPIBackend) AddSequencerInfo(ctx context.Context, seq *types.SequencerInfo) error {
-	if strings.EqualFold(seq.SequencerAddress.String(), b.eth.syncService.SeqAddress) {
-		return errors.New("no need to add self address")
-	}
-	b.seqRwMutex.Lock()
-	defer b.seqRwMutex.Unlock()
-	b.seqInfos[seq.SequencerAddress] = seq.SequencerUrl
-	return nil
+	return b.eth.syncService.AddSequencerInfo(seq)
+}
+
+func (b *EthAPIBackend) SignSequencerInfo(ctx context.Context, url string) (*types.SequencerInfo, error) {
+	return b.eth.syncService.SignSequencerInfo(url)
 }
 
 func (b *EthAPIBackend) GetSeqUrl(seqAddr common.Address) string {
-	b.seqRwMutex.RLock()
-	defer b.seqRwMutex.RUnlock()
-	url, ok := b.seqInfos[seqAddr]
-	if ok {
-		return url
-	}
-	for _, v := range b.seqInfos {
-		return v
-	}
-	return ""
+	return b.eth.syncService.SequencerUrl(seqAddr)
 }
 
-func (b *EthAPIBackend) ListSequencerInfo(ctx context.Context) *types.SequencerInfoList {
-	var list types.SequencerInfoList
-	b.seqRwMutex.RLock()
-	for k, v := range b.seqInfos {
-		seq := types.SequencerInfo{
-			SequencerAddress: k,
-			SequencerUrl:     v,
-		}
-		list.SeqList = append(list.SeqList, seq)
-	}
-	b.seqRwMutex.RUnlock()
+func (b *EthAPIBackend) StateRootMismatches(ctx context.Context, start uint64, limit int) ([]*types.StateRootMismatch, error) {
+	return b.eth.syncService.StateRootMismatches(start, limit), nil
+}
 
-	for i, seq := range list.SeqList {
-		l2Url := seq.SequencerUrl
-		err := b.EnsureSeqRpcClient(ctx, l2Url)
-		if err != nil {
-			log.Warn("Dial to a new proxy rpc client failed", "url", l2Url, "err", err)
-			// return err
-			continue
-		}
-		header, err := b.seqRpcClient.HeaderByNumber(context.TODO(), nil)
-		if err != nil {
-			log.Warn("HeaderByNumber ", "url", l2Url, "err", err)
-			// return err
-			continue
-		}
-		list.SeqList[i].SequencerHeight = header.Number.Uint64()
+func (b *EthAPIBackend) EnqueueAlerts(ctx context.Context) ([]*types.EnqueueAlert, error) {
+	return b.eth.syncService.EnqueueAlerts()
+}
+
+func (b *EthAPIBackend) FeeThresholds() (*big.Float, *big.Float) {
+	return b.eth.syncService.FeeThresholds()
+}
+
+func (b *EthAPIBackend) ListSequencerInfo(ctx context.Context) *types.SequencerInfoList {
+	list := types.SequencerInfoList{
+		SeqList: b.eth.syncService.SequencerInfos(ctx),
 	}
-	ownerHeight := b.eth.blockchain.CurrentBlock().Header().Number.Uint64()
 	seqOwner := types.SequencerInfo{
 		SequencerAddress: common.HexToAddress(b.eth.config.Rollup.SeqAddress),
 		SequencerUrl:     "localhost",
-		SequencerHeight:  ownerHeight,
+		SequencerHeight:  b.eth.blockchain.CurrentBlock().Header().Number.Uint64(),
+		Alive:            true,
 	}
 	list.SeqList = append(list.SeqList, seqOwner)
 	return &list
@@ -706,10 +658,38 @@ func (b *EthAPIBackend) SetPreRespan(ctx context.Context, oldAddress common.Addr
 	return b.eth.syncService.RollupAdapter().SetPreRespan(oldAddress, newAddress, number)
 }
 
+func (b *EthAPIBackend) PreRespans(ctx context.Context) ([]*types.PreRespan, error) {
+	return b.eth.syncService.RollupAdapter().PreRespans(), nil
+}
+
+func (b *EthAPIBackend) CancelPreRespan(ctx context.Context, number uint64) error {
+	return b.eth.syncService.RollupAdapter().CancelPreRespan(number)
+}
+
 func (b *EthAPIBackend) FinalizedBlockNumber() (uint64, error) {
 	return b.eth.syncService.RollupAdapter().GetFinalizedBlock()
 }
 
+func (b *EthAPIBackend) SequencerEpochAt(ctx context.Context, number uint64) (*types.SequencerEpoch, error) {
+	return rawdb.ReadSequencerEpochAt(b.eth.ChainDb(), number), nil
+}
+
+func (b *EthAPIBackend) SequencerEpochs(ctx context.Context, from uint64, count int) ([]*types.SequencerEpoch, error) {
+	return rawdb.ReadSequencerEpochs(b.eth.ChainDb(), from, count), nil
+}
+
+func (b *EthAPIBackend) Equivocations(ctx context.Context, from uint64, count int) ([]*types.Equivocation, error) {
+	return rawdb.ReadEquivocations(b.eth.ChainDb(), from, count), nil
+}
+
+func (b *EthAPIBackend) SequencerStatus(ctx context.Context) (*types.SequencerStatus, error) {
+	return b.eth.syncService.SequencerStatus()
+}
+
+func (b *EthAPIBackend) SubscribeSequencerChangeEvent(ch chan<- types.SequencerChange) event.Subscription {
+	return b.eth.syncService.SubscribeSequencerChangeEvent(ch)
+}
+
 func (b *EthAPIBackend) SyncStatus() (*types.SyncStatus, error) {
 	return b.eth.syncService.RollupClient().SyncStatusV2()
 }
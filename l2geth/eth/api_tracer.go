@@ -205,8 +205,8 @@ func (api *PrivateDebugAPI) traceChain(ctx context.Context, start, end *types.Bl
 
 				// Trace all the transactions contained within
 				for i, tx := range task.block.Transactions() {
-					msg, _ := tx.AsMessage(signer)
-					vmctx := core.NewEVMContext(msg, task.block.Header(), api.eth.blockchain, nil)
+					msg, _ := tx.AsMessage(signer, api.eth.config.OVM.UsingOVM)
+					vmctx := core.NewEVMContext(msg, task.block.Header(), api.eth.blockchain, nil, api.eth.config.OVM.UsingOVM)
 
 					res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
 					if err != nil {
@@ -479,8 +479,8 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 			// Fetch and execute the next transaction trace tasks
 			for task := range jobs {
 				tx := txs[task.index]
-				msg, _ := tx.AsMessage(signer)
-				vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+				msg, _ := tx.AsMessage(signer, api.eth.config.OVM.UsingOVM)
+				vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil, api.eth.config.OVM.UsingOVM)
 
 				res, err := api.traceTx(ctx, msg, vmctx, task.statedb, config)
 				if err != nil {
@@ -498,8 +498,8 @@ func (api *PrivateDebugAPI) traceBlock(ctx context.Context, block *types.Block,
 		jobs <- &txTraceTask{statedb: statedb.Copy(), index: i}
 
 		// Generate the next state snapshot fast without tracing
-		msg, _ := tx.AsMessage(signer)
-		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+		msg, _ := tx.AsMessage(signer, api.eth.config.OVM.UsingOVM)
+		vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil, api.eth.config.OVM.UsingOVM)
 
 		vmenv := vm.NewEVM(vmctx, statedb, api.eth.blockchain.Config(), vm.Config{})
 		if _, _, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
@@ -588,8 +588,8 @@ func (api *PrivateDebugAPI) standardTraceBlockToFile(ctx context.Context, block
 	for i, tx := range block.Transactions() {
 		// Prepare the trasaction for un-traced execution
 		var (
-			msg, _ = tx.AsMessage(signer)
-			vmctx  = core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+			msg, _ = tx.AsMessage(signer, api.eth.config.OVM.UsingOVM)
+			vmctx  = core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil, api.eth.config.OVM.UsingOVM)
 
 			vmConf vm.Config
 			dump   *os.File
@@ -839,8 +839,8 @@ func (api *PrivateDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int, ree
 
 	for idx, tx := range block.Transactions() {
 		// Assemble the transaction call message and return if the requested offset
-		msg, _ := tx.AsMessage(signer)
-		context := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+		msg, _ := tx.AsMessage(signer, api.eth.config.OVM.UsingOVM)
+		context := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil, api.eth.config.OVM.UsingOVM)
 		if idx == txIndex {
 			return msg, context, statedb, nil
 		}
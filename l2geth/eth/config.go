@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/miner"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
 	"github.com/ethereum-optimism/optimism/l2geth/rollup"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 )
 
 // DefaultConfig contains default settings for use on the Ethereum main net.
@@ -80,6 +81,8 @@ var DefaultConfig = Config{
 		// is additional overhead that is unaccounted. Round down to 127000 for
 		// safety.
 		MaxCallDataSize: 127000,
+		// Blocks the L1 rollup client stays behind the L1 tip
+		RollupClientL1Confirmations: 12,
 	},
 }
 
@@ -175,6 +178,10 @@ type Config struct {
 	// CheckpointOracle is the configuration for checkpoint oracle.
 	CheckpointOracle *params.CheckpointOracleConfig `toml:",omitempty"`
 
+	// OVM is the rollup configuration of the node, it is passed to the
+	// blockchain, transaction pool, miner and sync service.
+	OVM rcfg.Config
+
 	// Istanbul block override (TODO: remove after the fork)
 	OverrideIstanbul *big.Int
 
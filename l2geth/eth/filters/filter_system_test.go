@@ -82,7 +82,7 @@ func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*type
 
 func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
 	if number := rawdb.ReadHeaderNumber(b.db, hash); number != nil {
-		return rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig), nil
+		return rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig, false), nil
 	}
 	return nil, nil
 }
@@ -92,7 +92,7 @@ func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types
 	if number == nil {
 		return nil, nil
 	}
-	receipts := rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig)
+	receipts := rawdb.ReadReceipts(b.db, hash, *number, params.TestChainConfig, false)
 
 	logs := make([][]*types.Log, len(receipts))
 	for i, receipt := range receipts {
@@ -183,7 +183,7 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 			return nil
 		}
 		seqAdapter := manager.syncService.RollupAdapter()
-		if seqAdapter == nil || rcfg.SeqValidHeight == 0 {
+		if seqAdapter == nil || seqAdapter.GetSeqValidHeight() == 0 {
 			return nil
 		}
 		rollupClient := manager.syncService.RollupClient()
@@ -209,6 +209,9 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 				return errEpoch
 			}
 		}
+		// Recover the signatures of all blocks concurrently before they are
+		// checked one by one
+		seqAdapter.CacheSeqSenders(blocks)
 		var respanBN *big.Int
 		currentSelfSeq := manager.syncService.IsSelfSeqAddress(currentEpoch.Signer)
 		for _, block := range blocks {
@@ -266,12 +269,13 @@ func NewProtocolManager(config *params.ChainConfig, checkpoint *params.TrustedCh
 					log.Error(errInfo)
 					return errors.New(errInfo)
 				}
-				recoverSeq, err := seqAdapter.RecoverSeqAddress(tx)
+				recoverSeq, err := seqAdapter.RecoverSeqAddress(tx, blockNumber)
 				if err != nil {
 					log.Error("handler blocksBeforeInsert RecoverSeqAddress err", err)
 					return err
 				}
 				log.Debug(fmt.Sprintf("handler blocksBeforeInsert tx seq %v", recoverSeq), "number", blockNumber)
+				seqAdapter.ObserveSequencerTx(tx, blockNumber, rollup.SeqTxSourceP2P)
 				// check prevent sequencer signer and height of PoS
 				shouldPrevent := seqAdapter.IsPreRespanSequencer(recoverSeq, blockNumber)
 				if shouldPrevent {
@@ -402,7 +406,8 @@ func (pm *ProtocolManager) startFetcherTicker() {
 		select {
 		case <-pm.tickerFetcherSync.C:
 			ts := time.Now().Unix()
-			if rcfg.PeerHealthCheckSeconds > 0 && pm.peerSyncTime > 0 && ts-pm.peerSyncTime > rcfg.PeerHealthCheckSeconds {
+			healthCheck := pm.blockchain.GetVMConfig().OVM.PeerHealthCheckSeconds
+			if healthCheck > 0 && pm.peerSyncTime > 0 && ts-pm.peerSyncTime > healthCheck {
 				// restart peer connection
 				log.Info("Need reconnect peers", "seconds", ts-pm.peerSyncTime, "peers len", pm.peers.Len())
 
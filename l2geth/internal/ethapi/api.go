@@ -47,6 +47,7 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/p2p"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
 	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/fees"
 	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 	"github.com/ethereum-optimism/optimism/l2geth/rpc"
 	"github.com/ethereum-optimism/optimism/l2geth/trie"
@@ -549,6 +550,36 @@ func NewPublicBlockChainAPI(b Backend) *PublicBlockChainAPI {
 	return &PublicBlockChainAPI{b}
 }
 
+// SequencerChanges sends a notification on every rollover to a new sequencer
+// epoch and every queued, fired or cleared respan.
+func (s *PublicBlockChainAPI) SequencerChanges(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		changes := make(chan types.SequencerChange, 16)
+		changesSub := s.b.SubscribeSequencerChangeEvent(changes)
+		defer changesSub.Unsubscribe()
+
+		for {
+			select {
+			case change := <-changes:
+				notifier.Notify(rpcSub.ID, change)
+			case <-changesSub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // ChainId returns the chainID value for transaction replay protection.
 func (s *PublicBlockChainAPI) ChainId() *hexutil.Big {
 	return (*hexutil.Big)(s.b.ChainConfig().ChainID)
@@ -899,7 +930,7 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 	// Set sender address or use a default if none specified
 	var addr common.Address
 	if args.From == nil {
-		if !rcfg.UsingOVM {
+		if !b.RollupConfig().UsingOVM {
 			if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
 				if accounts := wallets[0].Accounts(); len(accounts) > 0 {
 					addr = accounts[0].Address
@@ -968,7 +999,7 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 	// or else the result of `eth_call` will not be correct.
 	blockNumber := header.Number
 	timestamp := header.Time
-	if rcfg.UsingOVM {
+	if b.RollupConfig().UsingOVM {
 		block, err := b.BlockByNumber(ctx, rpc.BlockNumber(header.Number.Uint64()))
 		if err != nil {
 			return nil, 0, false, err
@@ -977,7 +1008,7 @@ func DoCall(ctx context.Context, b Backend, args CallArgs, blockNrOrHash rpc.Blo
 		if block != nil {
 			txs := block.Transactions()
 			if header.Number.Uint64() != 0 {
-				if (rcfg.DeSeqBlock == 0 || header.Number.Uint64() < rcfg.DeSeqBlock) && len(txs) != 1 {
+				if !b.RollupConfig().IsDeSeq(header.Number.Uint64()) && len(txs) != 1 {
 					return nil, 0, false, fmt.Errorf("block %d has more than 1 transaction", header.Number.Uint64())
 				}
 				tx := txs[0]
@@ -1869,7 +1900,7 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 // SendTransaction creates a transaction for the given argument, sign it and submit it to the
 // transaction pool.
 func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args SendTxArgs) (common.Hash, error) {
-	if rcfg.UsingOVM {
+	if s.b.RollupConfig().UsingOVM {
 		return common.Hash{}, errOVMUnsupported
 	}
 	// Look up the wallet containing the requested signer
@@ -1904,7 +1935,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 // FillTransaction fills the defaults (nonce, gas, gasPrice) on a given unsigned transaction,
 // and returns it to the caller for further processing (signing + broadcast)
 func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args SendTxArgs) (*SignTransactionResult, error) {
-	if rcfg.UsingOVM {
+	if s.b.RollupConfig().UsingOVM {
 		return nil, errOVMUnsupported
 	}
 	// Set some sanity defaults and terminate on failure
@@ -1952,7 +1983,7 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_sign
 func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
-	if rcfg.UsingOVM {
+	if s.b.RollupConfig().UsingOVM {
 		return nil, errOVMUnsupported
 	}
 	// Look up the wallet containing the requested signer
@@ -2154,18 +2185,206 @@ func (api *PublicRollupAPI) GasPrices(ctx context.Context) (*gasPrices, error) {
 	}, nil
 }
 
+// EstimateFeeArgs are the arguments of rollup_estimateFee, either the
+// fields of a call or a signed raw transaction
+type EstimateFeeArgs struct {
+	CallArgs
+	RawTransaction *hexutil.Bytes `json:"rawTransaction"`
+}
+
+type feeEstimate struct {
+	GasLimit      hexutil.Uint64 `json:"gasLimit"`
+	L2GasUsed     hexutil.Uint64 `json:"l2GasUsed"`
+	L2GasPrice    *hexutil.Big   `json:"l2GasPrice"`
+	L1GasUsed     *hexutil.Big   `json:"l1GasUsed"`
+	L1GasPrice    *hexutil.Big   `json:"l1GasPrice"`
+	Overhead      *hexutil.Big   `json:"overhead"`
+	Scalar        string         `json:"scalar"`
+	L1Fee         *hexutil.Big   `json:"l1Fee"`
+	L2Fee         *hexutil.Big   `json:"l2Fee"`
+	TotalFee      *hexutil.Big   `json:"totalFee"`
+	MinGasPrice   *hexutil.Big   `json:"minGasPrice"`
+	MaxGasPrice   *hexutil.Big   `json:"maxGasPrice"`
+	ThresholdUp   *string        `json:"feeThresholdUp"`
+	ThresholdDown *string        `json:"feeThresholdDown"`
+}
+
+// EstimateFee estimates the fee of a transaction split into its L2
+// execution and its L1 data parts against the given block, the pending block
+// by default. It also returns the range of gas prices the sequencer accepts.
+// The gas price of the oracle at the block is used when the transaction does
+// not set one.
+func (api *PublicRollupAPI) EstimateFee(ctx context.Context, args EstimateFeeArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*feeEstimate, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	callArgs := args.CallArgs
+	var nonce *uint64
+	if args.RawTransaction != nil {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(*args.RawTransaction, tx); err != nil {
+			return nil, err
+		}
+		var signer types.Signer = types.HomesteadSigner{}
+		if tx.Protected() {
+			signer = types.NewEIP155Signer(tx.ChainId())
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, err
+		}
+		data := hexutil.Bytes(tx.Data())
+		gas := hexutil.Uint64(tx.Gas())
+		txNonce := tx.Nonce()
+		callArgs = CallArgs{
+			From:     &from,
+			To:       tx.To(),
+			Gas:      &gas,
+			GasPrice: (*hexutil.Big)(tx.GasPrice()),
+			Value:    (*hexutil.Big)(tx.Value()),
+			Data:     &data,
+		}
+		nonce = &txNonce
+	}
+
+	state, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, bNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// The gas price the sequencer expects, the one of the oracle at the block
+	expected := state.GetState(rcfg.L2GasPriceOracleAddress, rcfg.L2GasPriceSlot).Big()
+	if callArgs.GasPrice == nil {
+		callArgs.GasPrice = (*hexutil.Big)(expected)
+	}
+	gasPrice := callArgs.GasPrice.ToInt()
+	from := common.Address{}
+	if callArgs.From != nil {
+		from = *callArgs.From
+	}
+	if nonce == nil {
+		pending := state.GetNonce(from)
+		nonce = &pending
+	}
+
+	// A raw transaction keeps its gas limit, it only has to cover the
+	// estimate
+	var gasLimit hexutil.Uint64
+	if callArgs.Gas != nil {
+		gasLimit = *callArgs.Gas
+	}
+	estimated, err := DoEstimateGas(ctx, api.b, callArgs, bNrOrHash, api.b.RPCGasCap())
+	if err != nil {
+		return nil, err
+	}
+	if args.RawTransaction == nil {
+		gasLimit = estimated
+	}
+
+	var data []byte
+	if callArgs.Data != nil {
+		data = *callArgs.Data
+	} else if callArgs.Input != nil {
+		data = *callArgs.Input
+	}
+	value := new(big.Int)
+	if callArgs.Value != nil {
+		value = callArgs.Value.ToInt()
+	}
+	// The L1 fee of the transaction as it would be sent
+	msg := types.NewMessage(from, callArgs.To, *nonce, value, uint64(gasLimit), gasPrice, data, true, nil, 0, types.QueueOriginSequencer)
+	l1Fee, l1GasPrice, l1GasUsed, scalar, err := fees.DeriveL1GasInfo(msg, state)
+	if err != nil {
+		return nil, err
+	}
+	overhead := state.GetState(rcfg.L2GasPriceOracleAddress, rcfg.OverheadSlot).Big()
+
+	// Before Shanghai the L1 fee is paid as gas at the gas price of the
+	// transaction and is part of the estimated gas, after it the L1 fee is
+	// charged on top of the L2 fee. Either way no L1 fee is charged at a
+	// zero gas price.
+	l2GasUsed := uint64(estimated)
+	if !api.b.ChainConfig().IsShanghai(header.Number) {
+		// The estimate includes the L1 fee as gas, charged as in the
+		// calls of the estimate
+		estimate := types.NewMessage(from, callArgs.To, 0, value, l2GasUsed, gasPrice, data, false, nil, 0, types.QueueOriginSequencer)
+		l1FeeInL2, err := fees.CalculateL1MsgFeeInL2(estimate, state, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		if l1FeeInL2 < l2GasUsed {
+			l2GasUsed -= l1FeeInL2
+		}
+	}
+	if gasPrice.Sign() == 0 {
+		l1Fee = new(big.Int)
+	}
+	l2Fee := new(big.Int).Mul(new(big.Int).SetUint64(l2GasUsed), gasPrice)
+
+	thresholdUp, thresholdDown := api.b.FeeThresholds()
+	minGasPrice, maxGasPrice := fees.PaysEnoughBounds(expected, thresholdUp, thresholdDown)
+	estimate := &feeEstimate{
+		GasLimit:    gasLimit,
+		L2GasUsed:   hexutil.Uint64(l2GasUsed),
+		L2GasPrice:  (*hexutil.Big)(gasPrice),
+		L1GasUsed:   (*hexutil.Big)(l1GasUsed),
+		L1GasPrice:  (*hexutil.Big)(l1GasPrice),
+		Overhead:    (*hexutil.Big)(overhead),
+		Scalar:      scalar.String(),
+		L1Fee:       (*hexutil.Big)(l1Fee),
+		L2Fee:       (*hexutil.Big)(l2Fee),
+		TotalFee:    (*hexutil.Big)(new(big.Int).Add(l1Fee, l2Fee)),
+		MinGasPrice: (*hexutil.Big)(minGasPrice),
+		MaxGasPrice: (*hexutil.Big)(maxGasPrice),
+	}
+	if thresholdUp != nil {
+		up := thresholdUp.String()
+		estimate.ThresholdUp = &up
+	}
+	if thresholdDown != nil {
+		down := thresholdDown.String()
+		estimate.ThresholdDown = &down
+	}
+	return estimate, nil
+}
+
 func (api *PublicRollupAPI) CheckIsSeqWorking() bool {
 	return api.b.IsSequencerWorking()
 }
 
+// AddSequencerInfo adds the url of another sequencer. The entry must be signed
+// by the sequencer key of a signer of the sequencer set, it expires a TTL
+// after it was signed.
 func (api *PublicRollupAPI) AddSequencerInfo(ctx context.Context, seq *types.SequencerInfo) error {
 	return api.b.AddSequencerInfo(ctx, seq)
 }
 
+// ListSequencerInfo returns the known sequencers with their liveness and head
+// height, the local sequencer is the last entry
 func (api *PublicRollupAPI) ListSequencerInfo(ctx context.Context) *types.SequencerInfoList {
 	return api.b.ListSequencerInfo(ctx)
 }
 
+// GetStateRootMismatches returns the state root mismatches recorded by the
+// verifier, starting at the given L2 index. At most 100 mismatches are
+// returned unless a different limit is given.
+func (api *PublicRollupAPI) GetStateRootMismatches(ctx context.Context, start hexutil.Uint64, limit *int) ([]*types.StateRootMismatch, error) {
+	n := 100
+	if limit != nil {
+		n = *limit
+	}
+	if n <= 0 {
+		return nil, errors.New("limit must be positive")
+	}
+	return api.b.StateRootMismatches(ctx, uint64(start), n)
+}
+
+// GetEnqueueAlerts returns the enqueues that the sequencer did not include
+// within the force inclusion window
+func (api *PublicRollupAPI) GetEnqueueAlerts(ctx context.Context) ([]*types.EnqueueAlert, error) {
+	return api.b.EnqueueAlerts(ctx)
+}
+
 // PrivatelRollupAPI provides private RPC methods to control the sequencer.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type PrivateRollupAPI struct {
@@ -2189,6 +2408,12 @@ func (api *PrivateRollupAPI) SetL2GasPrice(ctx context.Context, gasPrice hexutil
 	return api.b.SetL2GasPrice(ctx, (*big.Int)(&gasPrice))
 }
 
+// SignSequencerInfo returns the info entry of the local sequencer at a url,
+// signed with the sequencer key, to be added on the other sequencers
+func (api *PrivateRollupAPI) SignSequencerInfo(ctx context.Context, url string) (*types.SequencerInfo, error) {
+	return api.b.SignSequencerInfo(ctx, url)
+}
+
 // BridgeRollupAPI provides private RPC methods to control the sequencer with bridge.
 // These methods can be abused by external users and must be considered insecure for use by untrusted users.
 type BridgeRollupAPI struct {
@@ -2203,6 +2428,16 @@ func (api *BridgeRollupAPI) SetPreRespan(ctx context.Context, oldAddress common.
 	return api.b.SetPreRespan(ctx, oldAddress, newAddress, number)
 }
 
+// ListPreRespans returns the pending pre-respans ordered by start block
+func (api *BridgeRollupAPI) ListPreRespans(ctx context.Context) ([]*types.PreRespan, error) {
+	return api.b.PreRespans(ctx)
+}
+
+// CancelPreRespan removes the pending pre-respan that starts at the number
+func (api *BridgeRollupAPI) CancelPreRespan(ctx context.Context, number uint64) error {
+	return api.b.CancelPreRespan(ctx, number)
+}
+
 type OutputResponse struct {
 	Version               common.Hash       `json:"version"`
 	OutputRoot            common.Hash       `json:"outputRoot"`
@@ -2326,6 +2561,47 @@ func (api *PublicMvmAPI) FinalizedBlockNumber(ctx context.Context) (hexutil.Uint
 	return hexutil.Uint64(finalizedBlockNumber), nil
 }
 
+// GetSequencerAt returns the sequencer epoch that a block number belongs to,
+// nil if the epoch is not known
+func (api *PublicMvmAPI) GetSequencerAt(ctx context.Context, blockNumber hexutil.Uint64) (*types.SequencerEpoch, error) {
+	return api.b.SequencerEpochAt(ctx, uint64(blockNumber))
+}
+
+// GetEpochs returns the known sequencer epochs in id order, starting at the
+// given id. At most 100 epochs are returned unless a different count is
+// given.
+func (api *PublicMvmAPI) GetEpochs(ctx context.Context, fromId hexutil.Uint64, count *int) ([]*types.SequencerEpoch, error) {
+	n := 100
+	if count != nil {
+		n = *count
+	}
+	if n <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+	return api.b.SequencerEpochs(ctx, uint64(fromId), n)
+}
+
+// GetEquivocations returns the detected sequencer equivocations in block
+// number order, starting at the given block number. At most 100 equivocations
+// are returned unless a different count is given.
+func (api *PublicMvmAPI) GetEquivocations(ctx context.Context, fromBlock hexutil.Uint64, count *int) ([]*types.Equivocation, error) {
+	n := 100
+	if count != nil {
+		n = *count
+	}
+	if n <= 0 {
+		return nil, errors.New("count must be positive")
+	}
+	return api.b.Equivocations(ctx, uint64(fromBlock), n)
+}
+
+// SequencerStatus returns the sequencer epoch of the head block with the
+// blocks left in it, the next epoch if it is known, the pending respans, the
+// role of the node and whether the head is stale
+func (api *PublicMvmAPI) SequencerStatus(ctx context.Context) (*types.SequencerStatus, error) {
+	return api.b.SequencerStatus(ctx)
+}
+
 // PublicDebugAPI is the collection of Ethereum APIs exposed over the public
 // debugging endpoint.
 type PublicDebugAPI struct {
@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
 	"github.com/ethereum-optimism/optimism/l2geth/event"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 	"github.com/ethereum-optimism/optimism/l2geth/rpc"
 )
 
@@ -97,6 +98,7 @@ type Backend interface {
 	SetL2GasPrice(context.Context, *big.Int) error
 	IngestTransactions([]*types.Transaction) error
 	SequencerClientHttp() string
+	RollupConfig() *rcfg.Config
 
 	NodeHTTPModules() []string
 	IsRpcProxySupport() bool
@@ -105,11 +107,22 @@ type Backend interface {
 	IsSequencerWorking() bool
 	AddSequencerInfo(ctx context.Context, seq *types.SequencerInfo) error
 	ListSequencerInfo(ctx context.Context) *types.SequencerInfoList
+	SignSequencerInfo(ctx context.Context, url string) (*types.SequencerInfo, error)
+	StateRootMismatches(ctx context.Context, start uint64, limit int) ([]*types.StateRootMismatch, error)
+	EnqueueAlerts(ctx context.Context) ([]*types.EnqueueAlert, error)
+	FeeThresholds() (up *big.Float, down *big.Float)
 	// rollup bridge API
 	SetPreRespan(ctx context.Context, oldAddress common.Address, newAddress common.Address, number uint64) error
+	PreRespans(ctx context.Context) ([]*types.PreRespan, error)
+	CancelPreRespan(ctx context.Context, number uint64) error
 
 	// Metis-specific API
 	FinalizedBlockNumber() (uint64, error)
+	SequencerEpochAt(ctx context.Context, number uint64) (*types.SequencerEpoch, error)
+	SequencerEpochs(ctx context.Context, from uint64, count int) ([]*types.SequencerEpoch, error)
+	Equivocations(ctx context.Context, from uint64, count int) ([]*types.Equivocation, error)
+	SequencerStatus(ctx context.Context) (*types.SequencerStatus, error)
+	SubscribeSequencerChangeEvent(ch chan<- types.SequencerChange) event.Subscription
 
 	// OP compatible API
 	SyncStatus() (*types.SyncStatus, error)
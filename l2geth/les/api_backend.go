@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/light"
 	"github.com/ethereum-optimism/optimism/l2geth/log"
 	"github.com/ethereum-optimism/optimism/l2geth/params"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
 	"github.com/ethereum-optimism/optimism/l2geth/rpc"
 )
 
@@ -91,6 +92,10 @@ func (b *LesApiBackend) SequencerClientHttp() string {
 	return b.eth.config.Rollup.SequencerClientHttp
 }
 
+func (b *LesApiBackend) RollupConfig() *rcfg.Config {
+	return &b.eth.config.OVM
+}
+
 func (b *LesApiBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
 	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
 		return b.eth.blockchain.CurrentHeader(), nil
@@ -202,7 +207,7 @@ func (b *LesApiBackend) GetTd(hash common.Hash) *big.Int {
 
 func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg *vm.Config) (*vm.EVM, func() error, error) {
 	state.SetBalance(msg.From(), math.MaxBig256)
-	context := core.NewEVMContext(msg, header, b.eth.blockchain, nil)
+	context := core.NewEVMContext(msg, header, b.eth.blockchain, nil, b.eth.config.OVM.UsingOVM)
 	return vm.NewEVM(context, state, b.eth.chainConfig, vm.Config{}), state.Error, nil
 }
 
@@ -370,15 +375,60 @@ func (b *LesApiBackend) AddSequencerInfo(ctx context.Context, seq *types.Sequenc
 func (b *LesApiBackend) ListSequencerInfo(ctx context.Context) *types.SequencerInfoList {
 	return nil
 }
+func (b *LesApiBackend) SignSequencerInfo(ctx context.Context, url string) (*types.SequencerInfo, error) {
+	return nil, errors.New("not supported")
+}
+func (b *LesApiBackend) StateRootMismatches(ctx context.Context, start uint64, limit int) ([]*types.StateRootMismatch, error) {
+	return nil, nil
+}
+
+func (b *LesApiBackend) EnqueueAlerts(ctx context.Context) ([]*types.EnqueueAlert, error) {
+	return nil, nil
+}
+
+func (b *LesApiBackend) FeeThresholds() (*big.Float, *big.Float) {
+	return nil, nil
+}
 
 func (b *LesApiBackend) SetPreRespan(ctx context.Context, oldAddress common.Address, newAddress common.Address, number uint64) error {
 	return nil
 }
 
+func (b *LesApiBackend) PreRespans(ctx context.Context) ([]*types.PreRespan, error) {
+	return nil, nil
+}
+
+func (b *LesApiBackend) CancelPreRespan(ctx context.Context, number uint64) error {
+	return nil
+}
+
 func (b *LesApiBackend) FinalizedBlockNumber() (uint64, error) {
 	return b.CurrentBlock().NumberU64(), nil
 }
 
+func (b *LesApiBackend) SequencerEpochAt(ctx context.Context, number uint64) (*types.SequencerEpoch, error) {
+	return nil, nil
+}
+
+func (b *LesApiBackend) SequencerEpochs(ctx context.Context, from uint64, count int) ([]*types.SequencerEpoch, error) {
+	return nil, nil
+}
+
+func (b *LesApiBackend) Equivocations(ctx context.Context, from uint64, count int) ([]*types.Equivocation, error) {
+	return nil, nil
+}
+
+func (b *LesApiBackend) SequencerStatus(ctx context.Context) (*types.SequencerStatus, error) {
+	return nil, errors.New("not supported")
+}
+
+func (b *LesApiBackend) SubscribeSequencerChangeEvent(ch chan<- types.SequencerChange) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SyncStatus() (*types.SyncStatus, error) {
 	return nil, nil
 }
@@ -44,6 +44,11 @@ type odrDatabase struct {
 	backend OdrBackend
 }
 
+// UsingOVM returns false, the light client does not support the OVM.
+func (db *odrDatabase) UsingOVM() bool {
+	return false
+}
+
 func (db *odrDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
 	return &odrTrie{db: db, id: db.id}, nil
 }
@@ -148,6 +148,7 @@ type worker struct {
 	engine      consensus.Engine
 	eth         Backend
 	chain       *core.BlockChain
+	ovm         rcfg.Config // Rollup configuration of the node
 
 	// Feeds
 	pendingLogsFeed event.Feed
@@ -215,6 +216,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		eth:                eth,
 		mux:                mux,
 		chain:              eth.BlockChain(),
+		ovm:                eth.BlockChain().GetVMConfig().OVM,
 		isLocalBlock:       isLocalBlock,
 		localUncles:        make(map[common.Hash]*types.Block),
 		remoteUncles:       make(map[common.Hash]*types.Block),
@@ -399,7 +401,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		case <-timer.C:
 			resubmit := w.chainConfig.Clique == nil || w.chainConfig.Clique.Period > 0
 			nextBN := w.chain.CurrentBlock().NumberU64() + 1
-			if rcfg.DeSeqBlock > 0 && nextBN >= rcfg.DeSeqBlock {
+			if w.ovm.IsDeSeq(nextBN) {
 				resubmit = true
 			}
 			if !resubmit {
@@ -567,7 +569,7 @@ func (w *worker) mainLoop() {
 				continue
 			}
 			var err error
-			if rcfg.DeSeqBlock > 0 && w.chain.CurrentBlock().NumberU64()+1 >= rcfg.DeSeqBlock && (ev.Time > 0 || len(ev.Txs) > 1) {
+			if w.ovm.IsDeSeq(w.chain.CurrentBlock().NumberU64()+1) && (ev.Time > 0 || len(ev.Txs) > 1) {
 				log.Debug("Attempting to commit rollup transactions", "hash0", ev.Txs[0].Hash().Hex())
 				err = w.commitNewTxDeSeq(ev.Txs, ev.Time)
 			} else {
@@ -624,7 +626,7 @@ func (w *worker) mainLoop() {
 				// If clique is running in dev mode(period is 0), disable
 				// advance sealing here.
 				deSeqModel := false
-				if rcfg.DeSeqBlock > 0 && w.chain.CurrentBlock().NumberU64()+1 >= rcfg.DeSeqBlock {
+				if w.ovm.IsDeSeq(w.chain.CurrentBlock().NumberU64() + 1) {
 					deSeqModel = true
 				}
 				log.Debug("Special info in worker", "working else", true, "deSeqMode", deSeqModel, "cmp to DeSeqBlock", w.chain.CurrentBlock().NumberU64()+1)
@@ -895,7 +897,7 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	if w.current != nil && len(w.current.txs) > 0 {
 		// after DeSeqBlock, allow multiple tx in a pool, header number with new block
 		log.Debug("Special info in worker: commitTransaction", "cmp to DeSeqBlock", w.current.header.Number.Uint64())
-		if rcfg.UsingOVM && !(rcfg.DeSeqBlock > 0 && w.current.header.Number.Uint64() >= rcfg.DeSeqBlock) {
+		if w.ovm.UsingOVM && !w.ovm.IsDeSeq(w.current.header.Number.Uint64()) {
 			return nil, core.ErrGasLimitReached
 		}
 	}
@@ -935,7 +937,7 @@ func (w *worker) commitTransactionsWithError(txs *types.TransactionsByPriceAndNo
 	pn := parent.Number().Uint64()
 	deSeqModel := false
 	log.Debug("Special info in worker: commitTransactionsWithError", "cmp to DeSeqBlock", pn+1)
-	if rcfg.DeSeqBlock > 0 && pn+1 >= rcfg.DeSeqBlock {
+	if w.ovm.IsDeSeq(pn + 1) {
 		deSeqModel = true
 	}
 
@@ -1028,7 +1030,7 @@ func (w *worker) commitTransactionsWithError(txs *types.TransactionsByPriceAndNo
 			// Strange error, discard the transaction and get the next in line (note, the
 			// nonce-too-high clause will prevent us from executing in vain).
 			log.Debug("Transaction failed, account skipped", "hash", tx.Hash(), "err", err)
-			if rcfg.UsingOVM {
+			if w.ovm.UsingOVM {
 				txs.Pop()
 			} else {
 				txs.Shift()
@@ -1329,7 +1331,7 @@ func (w *worker) commit(uncles []*types.Header, interval func(), start time.Time
 	deSeqModel := false
 	blockTime := w.current.header.Time
 	log.Debug("Special info in worker: commit", "cmp to DeSeqBlock", pn+1)
-	if rcfg.DeSeqBlock > 0 && pn+1 >= rcfg.DeSeqBlock {
+	if w.ovm.IsDeSeq(pn + 1) {
 		deSeqModel = true
 	}
 	// Note, cannot modify tx L1Timestamp in the method, because TX apply to EVM before, block.time will be effected
@@ -1350,7 +1352,7 @@ func (w *worker) commit(uncles []*types.Header, interval func(), start time.Time
 	txs := block.Transactions()
 	// New block, DeSeqBlock compare not plus 1
 	log.Debug("Special info in worker: commit", "cmp to DeSeqBlock", w.current.header.Number.Uint64())
-	if rcfg.UsingOVM && !(rcfg.DeSeqBlock > 0 && w.current.header.Number.Uint64() >= rcfg.DeSeqBlock) {
+	if w.ovm.UsingOVM && !w.ovm.IsDeSeq(w.current.header.Number.Uint64()) {
 		if len(txs) != 1 {
 			return fmt.Errorf("Block created with %d transactions rather than 1 at %d", len(txs), block.NumberU64())
 		}
@@ -1415,7 +1417,7 @@ func updateTransactionStateMetrics(start time.Time, state *state.StateDB) {
 }
 
 func (w *worker) handleErrInTask(err error, headFlag bool) {
-	if rcfg.UsingOVM {
+	if w.ovm.UsingOVM {
 		w.eth.SyncService().PushTxApplyError(err)
 	}
 	if headFlag {
@@ -304,6 +304,9 @@ type MVMRollupConfig struct {
 	SeqSetHeight     *big.Int       `json:"seqset_height,omitempty"`
 	SeqSetPeerHeight *big.Int       `json:"seqset_peer_height,omitempty"`
 	TxPoolHeight     *big.Int       `json:"txpool_height,omitempty"`
+	// Height from which the sequencer signatures are bound to the chain id,
+	// the block number and the epoch
+	SeqSignHeight *big.Int `json:"seqsign_height,omitempty"`
 }
 
 // ChainConfig is the core config which determines the blockchain settings.
@@ -526,6 +529,12 @@ func (c *ChainConfig) IsSeqSetPeerEnabled(num *big.Int) bool {
 	return isForked(MetisFallbackRollupConfig.SeqSetPeerHeight, num)
 }
 
+// IsSeqSignEnabled returns whether the sequencer signatures of the block
+// number are domain separated
+func (c *ChainConfig) IsSeqSignEnabled(num *big.Int) bool {
+	return isForked(c.MetisRollupConfig().SeqSignHeight, num)
+}
+
 func (c *ChainConfig) IsMetisMainnet() bool {
 	return c.ChainID.Cmp(MetisMainnetChainID) == 0
 }
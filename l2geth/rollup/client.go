@@ -720,7 +720,7 @@ func (c *Client) SyncStatusV2() (*types.SyncStatus, error) {
 		SetPathParams(map[string]string{
 			"chainId": c.chainID,
 		}).
-		SetResult(&SyncStatus{}).
+		SetResult(&types.SyncStatus{}).
 		Get("/rollup/sync-status/{chainId}")
 
 	if err != nil {
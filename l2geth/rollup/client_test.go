@@ -8,6 +8,11 @@ import (
 	"testing"
 
 	"github.com/jarcoal/httpmock"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/dtltest"
 )
 
 const url = "http://localhost:9999"
@@ -73,3 +78,127 @@ func TestDecodedJSON(t *testing.T) {
 		t.Fatal("Cannot decode")
 	}
 }
+
+func TestClientWithDTL(t *testing.T) {
+	chainID := big.NewInt(420)
+	signer := types.NewEIP155Signer(chainID)
+	key, _ := crypto.GenerateKey()
+	fixture := dtltest.NewFixture(chainID)
+	server := dtltest.NewServer(fixture)
+	defer server.Close()
+	client := NewClient(server.URL, chainID)
+
+	// Nothing is found in an empty server
+	if _, err := client.GetLatestEnqueue(); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetLatestTransaction(BackendL2); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	origin, target := common.Address{1}, common.Address{2}
+	queueIndex := fixture.AddEnqueue(origin, target, 100000, []byte{0x01})
+	if _, err := fixture.IncludeEnqueue(queueIndex); err != nil {
+		t.Fatal(err)
+	}
+	tx, _ := types.SignTx(types.NewTransaction(0, target, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+	if _, err := fixture.AddSequencerTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	enqueue, err := client.GetEnqueue(queueIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := enqueue.GetMeta(); *meta.QueueIndex != 0 || *meta.Index != 0 || *meta.L1MessageSender != origin {
+		t.Fatal("enqueue not decoded")
+	}
+	latest, err := client.GetLatestTransaction(BackendL2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Hash() != tx.Hash() || *latest.GetMeta().Index != 1 {
+		t.Fatal("sequencer transaction not decoded")
+	}
+	if from, _ := types.Sender(signer, latest); from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("sequencer transaction signature not decoded")
+	}
+
+	// Transactions are only served by the L1 backend once batched
+	if _, err := client.GetLatestTransaction(BackendL1); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fixture.SubmitBatch()
+	batch, txs, err := client.GetTransactionBatch(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Index != 0 || len(txs) != 2 {
+		t.Fatal("transaction batch not served")
+	}
+	if index, err := client.GetLatestTransactionIndex(BackendL1); err != nil || *index != 1 {
+		t.Fatalf("unexpected latest index: %v", err)
+	}
+
+	root := common.Hash{3}
+	fixture.SetStateRoot(1, root)
+	if have, err := client.GetStateRoot(1); err != nil || have != root {
+		t.Fatalf("unexpected state root: %v", err)
+	}
+	if err := client.SetLastVerifier(1, root.Hex(), root.Hex(), true); err != nil {
+		t.Fatal(err)
+	}
+	if v := fixture.Verifications(); len(v) != 1 || !v[0].Success || v[0].Index != 1 {
+		t.Fatal("verification not recorded")
+	}
+
+	if ctx, err := client.GetLatestEthContext(); err != nil || ctx.BlockNumber != 1 {
+		t.Fatalf("unexpected eth context: %v", err)
+	}
+	if _, err := client.GetEthContext(1); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := client.SyncStatus(BackendL1); err != nil || status.Syncing || status.CurrentTransactionIndex != 1 {
+		t.Fatalf("unexpected sync status: %v", err)
+	}
+	fixture.SetL1Origin(2, &types.L1BlockRef{Number: 1})
+	if ref, err := client.GetL1Origin(2); err != nil || ref.Number != 1 {
+		t.Fatalf("unexpected l1 origin: %v", err)
+	}
+	fixture.SetSyncStatus(&types.SyncStatus{CurrentL1: types.L1BlockRef{Number: 1}})
+	if status, err := client.SyncStatusV2(); err != nil || status.CurrentL1.Number != 1 {
+		t.Fatalf("unexpected sync status: %v", err)
+	}
+
+	// Blocks follow the transactions, the batch index moves to the inbox
+	tx2, _ := types.SignTx(types.NewTransaction(1, target, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+	if _, err := fixture.AddBlock(tx2); err != nil {
+		t.Fatal(err)
+	}
+	if index, err := client.GetLatestBlockIndex(BackendL2); err != nil || *index != 2 {
+		t.Fatalf("unexpected latest block index: %v", err)
+	}
+	fixture.SubmitBlockBatch()
+	if index, err := client.GetLatestTransactionBatchIndex(); err != nil || *index != 1 {
+		t.Fatalf("unexpected latest batch index: %v", err)
+	}
+	_, blocks, err := client.GetBlockBatch(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].NumberU64() != 3 || blocks[0].Transactions()[0].Hash() != tx2.Hash() {
+		t.Fatal("block batch not served")
+	}
+
+	// Injected failures are returned as http errors
+	fixture.Fail(dtltest.EndpointEthContext, 1)
+	if _, err := client.GetLatestEthContext(); !errors.Is(err, errHTTPError) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetLatestEthContext(); err != nil {
+		t.Fatal(err)
+	}
+	if n := fixture.Requests(dtltest.EndpointEthContext); n != 4 {
+		t.Fatalf("unexpected number of requests: %d", n)
+	}
+}
@@ -16,8 +16,29 @@ type Config struct {
 	Eth1SyncServiceEnable bool
 	// Gas Limit
 	GasLimit uint64
-	// HTTP endpoint of the data transport layer
+	// HTTP endpoint of the data transport layer, a comma separated list of
+	// replicas is failed over in order
 	RollupClientHttp string
+	// Per request timeout, retries and the number of elements a replica may
+	// lag behind before it is skipped. The defaults are used when
+	// RollupClientRetries or RollupClientMaxLag is nil.
+	RollupClientTimeout time.Duration
+	RollupClientRetries *int
+	RollupClientMaxLag  *uint64
+	// Subscribe to the event stream of the data transport layer to sync new
+	// elements without waiting for the next poll. The data transport layer
+	// serves the stream at /stream/{chainId}, when no endpoint serves it the
+	// sync service only polls.
+	RollupClientStream bool
+	// JSON-RPC endpoint of L1, when set the rollup data is read from the L1
+	// contracts directly instead of from the data transport layer
+	RollupClientL1Http string
+	// Number of confirmations an L1 block needs before the rollup data in it
+	// is read, used with RollupClientL1Http
+	RollupClientL1Confirmations uint64
+	// Addresses of the L1 rollup contracts, used with RollupClientL1Http
+	CanonicalTransactionChainAddress common.Address
+	StateCommitmentChainAddress      common.Address
 	// Pos HTTP endpoint for pos layer
 	PosClientHttp string
 	// SeqsetContract
@@ -31,6 +52,20 @@ type Config struct {
 	EnableL2GasPolling bool
 	// Deployment Height of the canonical transaction chain
 	CanonicalTransactionChainDeployHeight *big.Int
+	// Compare the batches posted to L1 with the local chain in sequencer mode
+	// and rewind the chain on divergence. A max depth of zero is unlimited,
+	// in alarm mode divergences are only reported.
+	ReorgEnable    bool
+	ReorgMaxDepth  uint64
+	ReorgAlarmOnly bool
+	// What the verifier does on a state root mismatch, one of continue,
+	// halt or rewind
+	StateRootMismatchPolicy string
+	// Time an enqueue may wait after its L1 timestamp before the enqueue
+	// watchdog raises an alert, zero disables the watchdog
+	ForceInclusionWindow time.Duration
+	// Number of batches downloaded ahead of the one being applied
+	BatchPrefetchDepth int
 	// Polling interval for rollup client
 	PollInterval time.Duration
 	// Interval for updating the timestamp
@@ -50,4 +85,28 @@ type Config struct {
 	SeqAddress   string
 	SeqPriv      string
 	SeqBridgeUrl string
+	// Sequencer signing backends, at most one of them and SeqPriv may be set.
+	// An encrypted keystore file with the file holding its password, the
+	// endpoint of clef and the URL of a remote signer.
+	SeqKeystore     string
+	SeqPasswordFile string
+	SeqClef         string
+	SeqRemoteSigner string
+	// How long the sequencer info entries of other sequencers are kept after
+	// they were signed
+	SeqInfoTTL time.Duration
+	// Url of the local sequencer that is re-signed every half TTL and pushed
+	// to the known sequencers, empty to not announce it
+	SeqInfoUrl string
+	// How long the chain may go without a new block before the sequencer is
+	// considered stale
+	SeqStaleThreshold time.Duration
+	// Let a backup sequencer take over from an active sequencer that did not
+	// add a block for the threshold and fails its liveness probe. The respan
+	// is signed by the private key or the remote signer of the seqset mpc
+	// address.
+	SeqTakeover           bool
+	SeqTakeoverThreshold  time.Duration
+	SeqRespanPriv         string
+	SeqRespanRemoteSigner string
 }
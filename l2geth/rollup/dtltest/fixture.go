@@ -0,0 +1,419 @@
+package dtltest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+// Endpoint is a group of routes of the data transport layer. It is used to
+// inject failures and delays and to count requests.
+type Endpoint string
+
+// The endpoints served by the Server
+const (
+	EndpointEnqueue     Endpoint = "enqueue"
+	EndpointTransaction Endpoint = "transaction"
+	EndpointBlock       Endpoint = "block"
+	EndpointBatch       Endpoint = "batch"
+	EndpointEthContext  Endpoint = "eth/context"
+	EndpointSyncing     Endpoint = "eth/syncing"
+	EndpointL1Origin    Endpoint = "rollup/l1origin"
+	EndpointSyncStatus  Endpoint = "rollup/sync-status"
+	EndpointStateRoot   Endpoint = "stateroot"
+	EndpointVerifier    Endpoint = "verifier/set"
+)
+
+// Backends as passed in the `backend` query parameter
+const (
+	backendL1 = "l1"
+	backendL2 = "l2"
+)
+
+var (
+	// errUnknownEnqueue is returned when including an enqueue that does not
+	// exist
+	errUnknownEnqueue = errors.New("unknown enqueue")
+	// errBlocksStarted is returned when adding a transaction to the chain
+	// after the first block
+	errBlocksStarted = errors.New("chain continues with blocks")
+)
+
+// rawTransaction is the RLP layout of a transaction as it is submitted to
+// L1, without the L2 metadata of types.Transaction
+type rawTransaction struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	GasLimit uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// batch is a posted batch together with its elements, a batch holds either
+// transactions or blocks
+type batch struct {
+	*Batch
+	transactions []*Transaction
+	blocks       []*Block
+}
+
+// Fixture is the scriptable state of the fake data transport layer. The
+// canonical transaction chain is built by including enqueues and adding
+// sequencer transactions, the elements are visible to the L1 backend once
+// they are submitted in a batch. All methods are safe for concurrent use
+// while the server is running.
+type Fixture struct {
+	chainID *big.Int
+
+	mu            sync.Mutex
+	ethContext    EthContext
+	ethContexts   map[uint64]EthContext
+	syncing       bool
+	enqueues      []*Enqueue
+	transactions  []*Transaction
+	blocks        []*Block
+	batches       []*batch
+	stateRoots    map[uint64]common.Hash
+	l1Origins     map[uint64]*types.L1BlockRef
+	syncStatus    *types.SyncStatus
+	verifications []Verification
+	failures      map[Endpoint]int
+	delays        map[Endpoint]time.Duration
+	requests      map[Endpoint]int
+}
+
+// NewFixture creates an empty Fixture for a chain id. The L1 starts at block
+// 1 with the current time.
+func NewFixture(chainID *big.Int) *Fixture {
+	f := &Fixture{
+		chainID:     chainID,
+		ethContexts: make(map[uint64]EthContext),
+		stateRoots:  make(map[uint64]common.Hash),
+		l1Origins:   make(map[uint64]*types.L1BlockRef),
+		failures:    make(map[Endpoint]int),
+		delays:      make(map[Endpoint]time.Duration),
+		requests:    make(map[Endpoint]int),
+	}
+	f.AdvanceL1(1, uint64(time.Now().Unix()))
+	return f
+}
+
+// ChainID returns the chain id served by the fixture
+func (f *Fixture) ChainID() *big.Int {
+	return new(big.Int).Set(f.chainID)
+}
+
+// AdvanceL1 sets the latest L1 block. Enqueues and sequencer transactions
+// added afterwards are stamped with it.
+func (f *Fixture) AdvanceL1(number, timestamp uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ethContext = EthContext{
+		BlockNumber: number,
+		BlockHash:   crypto.Keccak256Hash(new(big.Int).SetUint64(number).Bytes()),
+		Timestamp:   timestamp,
+	}
+	f.ethContexts[number] = f.ethContext
+}
+
+// SetSyncing sets whether the server reports that it is still syncing
+func (f *Fixture) SetSyncing(syncing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.syncing = syncing
+}
+
+// AddEnqueue adds an L1 to L2 transaction to the queue and returns its queue
+// index
+func (f *Fixture) AddEnqueue(origin, target common.Address, gasLimit uint64, data []byte) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	queueIndex := uint64(len(f.enqueues))
+	blockNumber, timestamp := f.ethContext.BlockNumber, f.ethContext.Timestamp
+	input := hexutil.Bytes(common.CopyBytes(data))
+	f.enqueues = append(f.enqueues, &Enqueue{
+		Target:      &target,
+		Data:        &input,
+		GasLimit:    &gasLimit,
+		Origin:      &origin,
+		BlockNumber: &blockNumber,
+		Timestamp:   &timestamp,
+		QueueIndex:  &queueIndex,
+	})
+	return queueIndex
+}
+
+// IncludeEnqueue appends an enqueue to the canonical transaction chain and
+// returns its index
+func (f *Fixture) IncludeEnqueue(queueIndex uint64) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if queueIndex >= uint64(len(f.enqueues)) {
+		return 0, fmt.Errorf("%w: %d", errUnknownEnqueue, queueIndex)
+	}
+	if len(f.blocks) > 0 {
+		return 0, errBlocksStarted
+	}
+	enqueue := f.enqueues[queueIndex]
+	index := f.nextIndex()
+	enqueue.Index = &index
+	qi := queueIndex
+	f.transactions = append(f.transactions, &Transaction{
+		Index:       index,
+		BlockNumber: *enqueue.BlockNumber,
+		Timestamp:   *enqueue.Timestamp,
+		Value:       (*hexutil.Big)(new(big.Int)),
+		GasLimit:    *enqueue.GasLimit,
+		Target:      *enqueue.Target,
+		Origin:      enqueue.Origin,
+		Data:        *enqueue.Data,
+		QueueOrigin: QueueOriginL1,
+		QueueIndex:  &qi,
+	})
+	return index, nil
+}
+
+// AddSequencerTransaction appends a signed transaction to the canonical
+// transaction chain and returns its index
+func (f *Fixture) AddSequencerTransaction(tx *types.Transaction) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.blocks) > 0 {
+		return 0, errBlocksStarted
+	}
+	element, err := f.sequencerTransaction(tx, f.nextIndex())
+	if err != nil {
+		return 0, err
+	}
+	f.transactions = append(f.transactions, element)
+	return element.Index, nil
+}
+
+// AddBlock appends a block of signed transactions to the chain and returns
+// its index. Blocks are only served after the transactions, they are used
+// once the decentralized sequencers produce blocks.
+func (f *Fixture) AddBlock(txs ...*types.Transaction) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	index := f.nextIndex()
+	block := &Block{
+		Index:     index,
+		Timestamp: f.ethContext.Timestamp,
+	}
+	for _, tx := range txs {
+		element, err := f.sequencerTransaction(tx, index)
+		if err != nil {
+			return 0, err
+		}
+		block.Transactions = append(block.Transactions, element)
+	}
+	f.blocks = append(f.blocks, block)
+	return index, nil
+}
+
+// SubmitBatch posts the transactions that are not batched yet in a new
+// batch and returns its index. It returns false when there is nothing to
+// submit.
+func (f *Fixture) SubmitBatch() (uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var pending []*Transaction
+	for _, tx := range f.transactions {
+		if !tx.Confirmed {
+			pending = append(pending, tx)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, false
+	}
+	b := f.newBatch(pending[0].Index, len(pending))
+	for _, tx := range pending {
+		tx.BatchIndex = b.Index
+		tx.Confirmed = true
+	}
+	b.transactions = pending
+	return b.Index, true
+}
+
+// SubmitBlockBatch posts the blocks that are not batched yet in a new batch
+// and returns its index. It returns false when there is nothing to submit.
+func (f *Fixture) SubmitBlockBatch() (uint64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var pending []*Block
+	for _, block := range f.blocks {
+		if !block.Confirmed {
+			pending = append(pending, block)
+		}
+	}
+	if len(pending) == 0 {
+		return 0, false
+	}
+	b := f.newBatch(pending[0].Index, len(pending))
+	for _, block := range pending {
+		block.BatchIndex = b.Index
+		block.Confirmed = true
+		for _, tx := range block.Transactions {
+			tx.BatchIndex = b.Index
+			tx.Confirmed = true
+		}
+	}
+	b.blocks = pending
+	return b.Index, true
+}
+
+// SetStateRoot posts the state root after the element at index
+func (f *Fixture) SetStateRoot(index uint64, root common.Hash) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.stateRoots[index] = root
+}
+
+// SetL1Origin sets the L1 origin of an L2 block
+func (f *Fixture) SetL1Origin(l2block uint64, ref *types.L1BlockRef) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.l1Origins[l2block] = ref
+}
+
+// SetSyncStatus sets the rollup sync status
+func (f *Fixture) SetSyncStatus(status *types.SyncStatus) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.syncStatus = status
+}
+
+// Fail makes the next n requests to an endpoint fail with an internal
+// server error
+func (f *Fixture) Fail(endpoint Endpoint, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures[endpoint] = n
+}
+
+// Delay delays every response of an endpoint, zero removes the delay
+func (f *Fixture) Delay(endpoint Endpoint, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if d == 0 {
+		delete(f.delays, endpoint)
+		return
+	}
+	f.delays[endpoint] = d
+}
+
+// Requests returns the number of requests that were made to an endpoint
+func (f *Fixture) Requests(endpoint Endpoint) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.requests[endpoint]
+}
+
+// Verifications returns the reports made by verifiers
+func (f *Fixture) Verifications() []Verification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Verification(nil), f.verifications...)
+}
+
+// nextIndex returns the index of the next element of the chain
+func (f *Fixture) nextIndex() uint64 {
+	return uint64(len(f.transactions) + len(f.blocks))
+}
+
+// newBatch appends a batch of size elements starting at index
+func (f *Fixture) newBatch(index uint64, size int) *batch {
+	b := &batch{
+		Batch: &Batch{
+			Index:             uint64(len(f.batches)),
+			Size:              uint32(size),
+			PrevTotalElements: uint32(index),
+			BlockNumber:       f.ethContext.BlockNumber,
+			Timestamp:         f.ethContext.Timestamp,
+		},
+	}
+	f.batches = append(f.batches, b)
+	return b
+}
+
+// sequencerTransaction converts a signed transaction into its decoded form
+// in a batch
+func (f *Fixture) sequencerTransaction(tx *types.Transaction, index uint64) (*Transaction, error) {
+	v, r, s := tx.RawSignatureValues()
+	if v == nil || v.Sign() == 0 {
+		return nil, errors.New("transaction is not signed")
+	}
+	raw, err := rlp.EncodeToBytes(&rawTransaction{
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasPrice(),
+		GasLimit: tx.Gas(),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+		V:        v,
+		R:        r,
+		S:        s,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Legacy signatures are passed as is, EIP155 ones as the recovery id
+	recovery := new(big.Int).Set(v)
+	if v.Uint64() != 27 && v.Uint64() != 28 {
+		recovery.Sub(recovery, new(big.Int).Add(new(big.Int).Mul(f.chainID, big.NewInt(2)), big.NewInt(35)))
+	}
+	element := &Transaction{
+		Index:       index,
+		BlockNumber: f.ethContext.BlockNumber,
+		Timestamp:   f.ethContext.Timestamp,
+		Value:       (*hexutil.Big)(tx.Value()),
+		GasLimit:    tx.Gas(),
+		Data:        raw,
+		QueueOrigin: QueueOriginSequencer,
+		Decoded: &Decoded{
+			Signature: Signature{
+				R: r.Bytes(),
+				S: s.Bytes(),
+				V: uint(recovery.Uint64()),
+			},
+			Value:    (*hexutil.Big)(tx.Value()),
+			GasLimit: tx.Gas(),
+			GasPrice: tx.GasPrice().Uint64(),
+			Nonce:    tx.Nonce(),
+			Target:   tx.To(),
+			Data:     tx.Data(),
+		},
+	}
+	if to := tx.To(); to != nil {
+		element.Target = *to
+	}
+	if sign := tx.GetSeqSign(); sign != nil {
+		element.SeqSign = fmt.Sprintf("%s,%s,%s", hexutil.EncodeBig(sign.R), hexutil.EncodeBig(sign.S), hexutil.EncodeBig(sign.V))
+	}
+	return element, nil
+}
@@ -0,0 +1,294 @@
+// Package dtltest provides an in-process fake of the data transport layer
+// for testing the rollup client and the sync service.
+package dtltest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNotFound is returned for unknown routes
+var errNotFound = errors.New("not found")
+
+// Server is an httptest server that serves a Fixture with the routes of the
+// data transport layer
+type Server struct {
+	*httptest.Server
+	*Fixture
+}
+
+// NewServer starts a Server for a Fixture. The caller must close it.
+func NewServer(fixture *Fixture) *Server {
+	s := &Server{Fixture: fixture}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// serve dispatches a request after applying the injected delays and failures
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	endpoint := endpointOf(parts)
+
+	s.mu.Lock()
+	s.requests[endpoint]++
+	delay := s.delays[endpoint]
+	fail := s.failures[endpoint] > 0
+	if fail {
+		s.failures[endpoint]--
+	}
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fail {
+		http.Error(w, "injected failure", http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.Lock()
+	res, status, err := s.route(parts, r.URL.Query().Get("backend"))
+	var body []byte
+	if err == nil {
+		body, err = json.Marshal(res)
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		if status == 0 {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// endpointOf returns the endpoint of the path parts of a request
+func endpointOf(parts []string) Endpoint {
+	if len(parts) >= 2 {
+		switch endpoint := Endpoint(parts[0] + "/" + parts[1]); endpoint {
+		case EndpointEthContext, EndpointSyncing, EndpointL1Origin, EndpointSyncStatus, EndpointVerifier:
+			return endpoint
+		}
+	}
+	return Endpoint(parts[0])
+}
+
+// route returns the response to a request, it must be called with the lock
+// held
+func (s *Server) route(parts []string, backend string) (interface{}, int, error) {
+	if backend == "" {
+		backend = backendL1
+	}
+	if backend != backendL1 && backend != backendL2 {
+		return nil, http.StatusBadRequest, errors.New("unknown backend: " + backend)
+	}
+	path := strings.Join(parts, "/")
+	switch {
+	case match(parts, "enqueue", "index", "*", "chain"):
+		return s.enqueue(parseIndex(parts[2])), 0, s.checkChain(parts[3])
+	case match(parts, "enqueue", "latest", "chain"):
+		return s.enqueue(uint64(len(s.enqueues)) - 1), 0, s.checkChain(parts[2])
+	case match(parts, "transaction", "index", "*", "chain"):
+		return s.transaction(parseIndex(parts[2]), backend), 0, s.checkChain(parts[3])
+	case match(parts, "transaction", "latest", "chain"):
+		return s.transaction(s.latestTransaction(backend), backend), 0, s.checkChain(parts[2])
+	case match(parts, "block", "index", "*", "chain"):
+		return s.block(parseIndex(parts[2]), backend), 0, s.checkChain(parts[3])
+	case match(parts, "block", "latest", "chain"):
+		return s.block(s.latestBlock(backend), backend), 0, s.checkChain(parts[2])
+	case match(parts, "batch", "transaction", "index", "*", "chain"):
+		return s.transactionBatch(parseIndex(parts[3])), 0, s.checkChain(parts[4])
+	case match(parts, "batch", "transaction", "latest", "chain"):
+		// The latest batch is an inbox batch once blocks are submitted
+		if len(s.batches) > 0 && s.batches[len(s.batches)-1].blocks != nil {
+			return nil, http.StatusBadRequest, errors.New("USE_INBOX_BATCH_INDEX")
+		}
+		return s.transactionBatch(uint64(len(s.batches)) - 1), 0, s.checkChain(parts[3])
+	case match(parts, "batch", "block", "index", "*", "chain"):
+		return s.blockBatch(parseIndex(parts[3])), 0, s.checkChain(parts[4])
+	case match(parts, "batch", "block", "latest", "chain"):
+		return s.blockBatch(uint64(len(s.batches)) - 1), 0, s.checkChain(parts[3])
+	case match(parts, "eth", "context", "latest"):
+		return s.ethContext, 0, nil
+	case match(parts, "eth", "context", "blocknumber", "*"):
+		if ctx, ok := s.ethContexts[parseIndex(parts[3])]; ok {
+			return ctx, 0, nil
+		}
+		return nil, http.StatusNotFound, errNotFound
+	case match(parts, "eth", "syncing", "chain"):
+		return s.syncStatusOf(backend), 0, s.checkChain(parts[2])
+	case match(parts, "rollup", "l1origin", "chain", "*"):
+		if ref, ok := s.l1Origins[parseIndex(parts[3])]; ok {
+			return ref, 0, s.checkChain(parts[2])
+		}
+		return nil, http.StatusNotFound, errNotFound
+	case match(parts, "rollup", "sync-status", "chain"):
+		if s.syncStatus == nil {
+			return nil, http.StatusNotFound, errNotFound
+		}
+		return s.syncStatus, 0, s.checkChain(parts[2])
+	case match(parts, "stateroot", "index", "*", "chain"):
+		return s.stateRoot(parseIndex(parts[2])), 0, s.checkChain(parts[3])
+	case match(parts, "verifier", "set", "*", "chain", "*", "*", "*"):
+		s.verifications = append(s.verifications, Verification{
+			Index:        parseIndex(parts[4]),
+			Success:      parts[2] == "true",
+			StateRoot:    parts[5],
+			VerifierRoot: parts[6],
+		})
+		return s.stateRoot(parseIndex(parts[4])), 0, s.checkChain(parts[3])
+	}
+	return nil, http.StatusNotFound, errors.New("unknown route: " + path)
+}
+
+// match returns true when the path parts match a pattern, `*` and `chain`
+// match any part
+func match(parts []string, pattern ...string) bool {
+	if len(parts) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != "chain" && p != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkChain returns an error when the chain id is not the one served
+func (s *Server) checkChain(chainID string) error {
+	if chainID != s.chainID.String() {
+		return errors.New("unknown chain id: " + chainID)
+	}
+	return nil
+}
+
+// parseIndex parses an index, invalid indices are out of range
+func parseIndex(str string) uint64 {
+	n, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return ^uint64(0)
+	}
+	return n
+}
+
+func (s *Server) enqueue(index uint64) *Enqueue {
+	if index >= uint64(len(s.enqueues)) {
+		return &Enqueue{}
+	}
+	return s.enqueues[index]
+}
+
+// visible returns true when an element is served by a backend
+func visible(confirmed bool, backend string) bool {
+	return confirmed || backend == backendL2
+}
+
+func (s *Server) transaction(index uint64, backend string) *transactionResponse {
+	if index >= uint64(len(s.transactions)) || !visible(s.transactions[index].Confirmed, backend) {
+		return &transactionResponse{}
+	}
+	tx := s.transactions[index]
+	res := &transactionResponse{Transaction: tx}
+	if tx.Confirmed {
+		res.Batch = s.batches[tx.BatchIndex].Batch
+	}
+	return res
+}
+
+func (s *Server) latestTransaction(backend string) uint64 {
+	for i := len(s.transactions) - 1; i >= 0; i-- {
+		if visible(s.transactions[i].Confirmed, backend) {
+			return uint64(i)
+		}
+	}
+	return ^uint64(0)
+}
+
+// blockAt returns the block of an index, blocks follow the transactions
+func (s *Server) blockAt(index uint64) *Block {
+	first := uint64(len(s.transactions))
+	if index < first || index-first >= uint64(len(s.blocks)) {
+		return nil
+	}
+	return s.blocks[index-first]
+}
+
+func (s *Server) block(index uint64, backend string) *blockResponse {
+	block := s.blockAt(index)
+	if block == nil || !visible(block.Confirmed, backend) {
+		return &blockResponse{}
+	}
+	res := &blockResponse{Block: block}
+	if block.Confirmed {
+		res.Batch = s.batches[block.BatchIndex].Batch
+	}
+	return res
+}
+
+func (s *Server) latestBlock(backend string) uint64 {
+	for i := len(s.blocks) - 1; i >= 0; i-- {
+		if visible(s.blocks[i].Confirmed, backend) {
+			return s.blocks[i].Index
+		}
+	}
+	return ^uint64(0)
+}
+
+func (s *Server) transactionBatch(index uint64) *transactionBatchResponse {
+	if index >= uint64(len(s.batches)) || s.batches[index].blocks != nil {
+		return &transactionBatchResponse{Transactions: []*Transaction{}}
+	}
+	b := s.batches[index]
+	return &transactionBatchResponse{Batch: b.Batch, Transactions: b.transactions}
+}
+
+func (s *Server) blockBatch(index uint64) *blockBatchResponse {
+	if index >= uint64(len(s.batches)) || s.batches[index].blocks == nil {
+		return &blockBatchResponse{Blocks: []*Block{}}
+	}
+	b := s.batches[index]
+	return &blockBatchResponse{Batch: b.Batch, Blocks: b.blocks}
+}
+
+func (s *Server) syncStatusOf(backend string) *SyncStatus {
+	status := &SyncStatus{Syncing: s.syncing}
+	if latest := s.latestTransaction(backend); latest != ^uint64(0) {
+		status.CurrentTransactionIndex = latest
+		status.HighestKnownTransactionIndex = latest
+	}
+	return status
+}
+
+func (s *Server) stateRoot(index uint64) *stateRootResponse {
+	root, ok := s.stateRoots[index]
+	if !ok {
+		return &stateRootResponse{}
+	}
+	res := &stateRootResponse{
+		StateRoot: &StateRoot{Index: index, Value: root},
+	}
+	if index < uint64(len(s.transactions)) && s.transactions[index].Confirmed {
+		tx := s.transactions[index]
+		res.Batch = s.batches[tx.BatchIndex].Batch
+		res.StateRoot.BatchIndex = tx.BatchIndex
+		res.StateRoot.Confirmed = true
+	} else if block := s.blockAt(index); block != nil && block.Confirmed {
+		res.Batch = s.batches[block.BatchIndex].Batch
+		res.StateRoot.BatchIndex = block.BatchIndex
+		res.StateRoot.Confirmed = true
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+package dtltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamPath is the path of the server sent event stream that the sync
+// service subscribes to with --rollup.clientstream, followed by the chain id
+const StreamPath = "/stream/"
+
+// StreamEvent announces that the data transport layer has indexed a new
+// element of the given type
+type StreamEvent struct {
+	Type  string `json:"type"`
+	Index uint64 `json:"index"`
+}
+
+// StreamServer is a stand-in for the event stream of the data transport
+// layer. It serves the stream at `/stream/{chainId}` and broadcasts the
+// published events to every subscriber.
+type StreamServer struct {
+	chainID   string
+	keepAlive time.Duration
+
+	mu      sync.Mutex
+	clients map[chan StreamEvent]struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+// NewStreamServer creates a StreamServer for a chain id
+func NewStreamServer(chainID uint64) *StreamServer {
+	return &StreamServer{
+		chainID:   fmt.Sprintf("%d", chainID),
+		keepAlive: 15 * time.Second,
+		clients:   make(map[chan StreamEvent]struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Publish sends an event to all subscribers. Subscribers that cannot keep up
+// miss the event, they catch up by polling.
+func (s *StreamServer) Publish(ev StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.clients {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// SetKeepAlive sets the interval of the keep alive comments, it must be
+// called before the first subscriber connects
+func (s *StreamServer) SetKeepAlive(interval time.Duration) {
+	s.keepAlive = interval
+}
+
+// Subscribers returns the number of connected subscribers
+func (s *StreamServer) Subscribers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.clients)
+}
+
+// Close disconnects all subscribers
+func (s *StreamServer) Close() {
+	s.once.Do(func() { close(s.closed) })
+}
+
+// ServeHTTP implements http.Handler
+func (s *StreamServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != StreamPath+s.chainID {
+		http.NotFound(w, r)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan StreamEvent, 16)
+	s.mu.Lock()
+	s.clients[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(s.keepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case ev := <-ch:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", strings.ToLower(ev.Type), data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
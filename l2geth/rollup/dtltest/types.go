@@ -0,0 +1,141 @@
+package dtltest
+
+import (
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+)
+
+// The types in this file mirror the JSON served by the data transport layer.
+// They are kept separate from the ones in the rollup package so that the
+// fake server exercises the same decoding as a real deployment.
+
+// Queue origins of a Transaction
+const (
+	QueueOriginSequencer = "sequencer"
+	QueueOriginL1        = "l1"
+)
+
+// Batch is a transaction or block batch posted to L1
+type Batch struct {
+	Index             uint64         `json:"index"`
+	Root              common.Hash    `json:"root,omitempty"`
+	Size              uint32         `json:"size,omitempty"`
+	PrevTotalElements uint32         `json:"prevTotalElements,omitempty"`
+	ExtraData         hexutil.Bytes  `json:"extraData,omitempty"`
+	BlockNumber       uint64         `json:"blockNumber"`
+	Timestamp         uint64         `json:"timestamp"`
+	Submitter         common.Address `json:"submitter"`
+}
+
+// EthContext is the L1 block the server has synced
+type EthContext struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+	Timestamp   uint64      `json:"timestamp"`
+}
+
+// SyncStatus is the sync status of the server
+type SyncStatus struct {
+	Syncing                      bool   `json:"syncing"`
+	HighestKnownTransactionIndex uint64 `json:"highestKnownTransactionIndex"`
+	CurrentTransactionIndex      uint64 `json:"currentTransactionIndex"`
+}
+
+// Enqueue is an L1 to L2 transaction, Index is set once it is included in
+// the canonical transaction chain
+type Enqueue struct {
+	Index       *uint64         `json:"ctcIndex"`
+	Target      *common.Address `json:"target"`
+	Data        *hexutil.Bytes  `json:"data"`
+	GasLimit    *uint64         `json:"gasLimit,string"`
+	Origin      *common.Address `json:"origin"`
+	BlockNumber *uint64         `json:"blockNumber"`
+	Timestamp   *uint64         `json:"timestamp"`
+	QueueIndex  *uint64         `json:"index"`
+}
+
+// Signature is the signature of a decoded sequencer transaction
+type Signature struct {
+	R hexutil.Bytes `json:"r"`
+	S hexutil.Bytes `json:"s"`
+	V uint          `json:"v"`
+}
+
+// Decoded is a sequencer transaction decoded from its batch
+type Decoded struct {
+	Signature Signature       `json:"sig"`
+	Value     *hexutil.Big    `json:"value"`
+	GasLimit  uint64          `json:"gasLimit,string"`
+	GasPrice  uint64          `json:"gasPrice,string"`
+	Nonce     uint64          `json:"nonce,string"`
+	Target    *common.Address `json:"target"`
+	Data      hexutil.Bytes   `json:"data"`
+}
+
+// Transaction is an element of the canonical transaction chain
+type Transaction struct {
+	Index       uint64          `json:"index"`
+	BatchIndex  uint64          `json:"batchIndex"`
+	BlockNumber uint64          `json:"blockNumber"`
+	Timestamp   uint64          `json:"timestamp"`
+	Value       *hexutil.Big    `json:"value"`
+	GasLimit    uint64          `json:"gasLimit,string"`
+	Target      common.Address  `json:"target"`
+	Origin      *common.Address `json:"origin"`
+	Data        hexutil.Bytes   `json:"data"`
+	QueueOrigin string          `json:"queueOrigin"`
+	QueueIndex  *uint64         `json:"queueIndex"`
+	Decoded     *Decoded        `json:"decoded"`
+	SeqSign     string          `json:"seqSign"`
+	Confirmed   bool            `json:"confirmed"`
+}
+
+// Block is a block produced by the decentralized sequencers
+type Block struct {
+	Index        uint64         `json:"index"`
+	BatchIndex   uint64         `json:"batchIndex"`
+	Timestamp    uint64         `json:"timestamp"`
+	Transactions []*Transaction `json:"transactions"`
+	Confirmed    bool           `json:"confirmed"`
+}
+
+// StateRoot is a state root posted to L1
+type StateRoot struct {
+	Index      uint64      `json:"index"`
+	BatchIndex uint64      `json:"batchIndex"`
+	Value      common.Hash `json:"value"`
+	Confirmed  bool        `json:"confirmed"`
+}
+
+// Verification is a report of the verifier received by the server
+type Verification struct {
+	Index        uint64
+	Success      bool
+	StateRoot    string
+	VerifierRoot string
+}
+
+type transactionResponse struct {
+	Transaction *Transaction `json:"transaction"`
+	Batch       *Batch       `json:"batch"`
+}
+
+type transactionBatchResponse struct {
+	Batch        *Batch         `json:"batch"`
+	Transactions []*Transaction `json:"transactions"`
+}
+
+type blockResponse struct {
+	Block *Block `json:"block"`
+	Batch *Batch `json:"batch"`
+}
+
+type blockBatchResponse struct {
+	Batch  *Batch   `json:"batch"`
+	Blocks []*Block `json:"blocks"`
+}
+
+type stateRootResponse struct {
+	Batch     *Batch     `json:"batch"`
+	StateRoot *StateRoot `json:"stateRoot"`
+}
@@ -0,0 +1,244 @@
+package rollup
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/accounts/abi"
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/metrics"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+const (
+	// equivocationWindow is the number of signed transactions kept to compare
+	// the transactions that are not in the chain
+	equivocationWindow = 1024
+)
+
+// Sources of the sequencer signed txs that the node sees
+const (
+	SeqTxSourceLocal = "local"
+	SeqTxSourceP2P   = "p2p"
+	SeqTxSourceDTL   = "dtl"
+	SeqTxSourceChain = "chain"
+)
+
+var (
+	equivocationMeter = metrics.NewRegisteredMeter("rollup/seq/equivocations", nil)
+	seqTxObserveMeter = metrics.NewRegisteredMeter("rollup/seq/observed", nil)
+
+	equivocationArguments = newEquivocationArguments()
+)
+
+// newEquivocationArguments returns the ABI arguments of the evidence of an
+// equivocation
+func newEquivocationArguments() abi.Arguments {
+	var arguments abi.Arguments
+	for _, t := range []string{"address", "uint256", "uint256", "bytes32", "bytes", "uint256", "bytes32", "bytes"} {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		arguments = append(arguments, abi.Argument{Type: typ})
+	}
+	return arguments
+}
+
+type seqTxKey struct {
+	number uint64
+	signer common.Address
+}
+
+// equivocationDetector compares the sequencer signed transactions that the
+// node sees by block number and signer. A signer that signed two different
+// transactions for the same block equivocated, the pair of signatures is
+// stored as evidence.
+//
+// Only the blocks from the SeqSign fork on are compared, before it the
+// signature does not bind the transaction to a block. The transactions of
+// blocks that the local chain rewound are not evidence either, their blocks
+// are produced again.
+type equivocationDetector struct {
+	db        ethdb.Database
+	config    *params.ChainConfig
+	signEpoch func(tx *types.Transaction, number uint64) (*big.Int, error)
+	canonical func(number uint64) *types.Transaction
+	now       func() time.Time
+
+	mu           sync.Mutex
+	seen         map[seqTxKey]*types.SequencerSignedTx
+	order        []seqTxKey
+	rewound      map[common.Hash]struct{}
+	rewoundOrder []common.Hash
+}
+
+func newEquivocationDetector(db ethdb.Database, config *params.ChainConfig, signEpoch func(*types.Transaction, uint64) (*big.Int, error), canonical func(uint64) *types.Transaction) *equivocationDetector {
+	return &equivocationDetector{
+		db:        db,
+		config:    config,
+		signEpoch: signEpoch,
+		canonical: canonical,
+		now:       time.Now,
+		seen:      make(map[seqTxKey]*types.SequencerSignedTx),
+		rewound:   make(map[common.Hash]struct{}),
+	}
+}
+
+// signedTx returns the signer and the signature of the sequencer of a
+// transaction in the block number
+func (d *equivocationDetector) signedTx(tx *types.Transaction, number uint64, source string) (common.Address, *types.SequencerSignedTx, error) {
+	var epoch *big.Int
+	digest := tx.Hash()
+	if d.config.IsSeqSignEnabled(new(big.Int).SetUint64(number)) {
+		var err error
+		if epoch, err = d.signEpoch(tx, number); err != nil {
+			return common.Address{}, nil, err
+		}
+		digest = core.SeqSignHash(d.config.ChainID, number, epoch, tx.Hash())
+	}
+	signer, err := core.RecoverSeqAddressAt(d.config, tx, number, epoch)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	seqSign := tx.GetSeqSign()
+	signature := append(common.LeftPadBytes(seqSign.R.Bytes(), 32), common.LeftPadBytes(seqSign.S.Bytes(), 32)...)
+	signature = append(signature, byte(seqSign.V.Uint64()+27))
+	signed := &types.SequencerSignedTx{
+		TxHash:    tx.Hash(),
+		Digest:    digest,
+		Signature: signature,
+		Source:    source,
+	}
+	if epoch != nil {
+		signed.EpochID = epoch.Uint64()
+	}
+	return signer, signed, nil
+}
+
+// Observe compares a sequencer signed transaction in the block number with
+// the transactions of its signer that were seen before for the block
+func (d *equivocationDetector) Observe(tx *types.Transaction, number uint64, source string) {
+	if tx == nil || tx.QueueOrigin() == types.QueueOriginL1ToL2 || tx.GetSeqSign() == nil {
+		return
+	}
+	if !d.config.IsSeqSignEnabled(new(big.Int).SetUint64(number)) {
+		return
+	}
+	d.mu.Lock()
+	_, rewound := d.rewound[tx.Hash()]
+	d.mu.Unlock()
+	if rewound {
+		return
+	}
+	signer, signed, err := d.signedTx(tx, number, source)
+	if err != nil {
+		log.Debug("Cannot recover sequencer of observed tx", "tx", tx.Hash().Hex(), "number", number, "err", err)
+		return
+	}
+	seqTxObserveMeter.Mark(1)
+	key := seqTxKey{number: number, signer: signer}
+
+	d.mu.Lock()
+	first := d.seen[key]
+	if first == nil {
+		d.seen[key] = signed
+		d.order = append(d.order, key)
+		if len(d.order) > equivocationWindow {
+			delete(d.seen, d.order[0])
+			d.order = d.order[1:]
+		}
+	}
+	d.mu.Unlock()
+
+	// The transactions of the chain are compared even when they left the
+	// window
+	if first == nil && d.canonical != nil {
+		if chainTx := d.canonical(number); chainTx != nil && chainTx.Hash() != tx.Hash() && chainTx.GetSeqSign() != nil {
+			if chainSigner, chainSigned, err := d.signedTx(chainTx, number, SeqTxSourceChain); err == nil && chainSigner == signer {
+				first = chainSigned
+			}
+		}
+	}
+	if first != nil && first.Digest != signed.Digest {
+		d.record(signer, number, first, signed)
+	}
+}
+
+// Rewind forgets the transactions seen for the blocks after number before the
+// local chain is rewound to it. The transactions of the rewound blocks are
+// ignored when they are seen again.
+func (d *equivocationDetector) Rewind(number uint64) {
+	var txs []common.Hash
+	if d.canonical != nil {
+		for n := number + 1; n <= number+equivocationWindow; n++ {
+			tx := d.canonical(n)
+			if tx == nil {
+				break
+			}
+			txs = append(txs, tx.Hash())
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	order := d.order[:0]
+	for _, key := range d.order {
+		if key.number > number {
+			txs = append(txs, d.seen[key].TxHash)
+			delete(d.seen, key)
+			continue
+		}
+		order = append(order, key)
+	}
+	d.order = order
+	for _, hash := range txs {
+		if _, ok := d.rewound[hash]; ok {
+			continue
+		}
+		d.rewound[hash] = struct{}{}
+		d.rewoundOrder = append(d.rewoundOrder, hash)
+		if len(d.rewoundOrder) > equivocationWindow {
+			delete(d.rewound, d.rewoundOrder[0])
+			d.rewoundOrder = d.rewoundOrder[1:]
+		}
+	}
+	if len(txs) > 0 {
+		log.Info("Forgot sequencer txs of rewound blocks", "number", number, "txs", len(txs))
+	}
+}
+
+// record stores the evidence of an equivocation, once for each signer and
+// block
+func (d *equivocationDetector) record(signer common.Address, number uint64, first, second *types.SequencerSignedTx) {
+	if rawdb.HasEquivocation(d.db, number, signer) {
+		return
+	}
+	evidence, err := equivocationArguments.Pack(
+		signer, new(big.Int).SetUint64(number),
+		new(big.Int).SetUint64(first.EpochID), first.TxHash, []byte(first.Signature),
+		new(big.Int).SetUint64(second.EpochID), second.TxHash, []byte(second.Signature),
+	)
+	if err != nil {
+		log.Error("Cannot encode equivocation evidence", "signer", signer.Hex(), "number", number, "err", err)
+		return
+	}
+	rawdb.WriteEquivocation(d.db, &types.Equivocation{
+		Signer:      signer,
+		BlockNumber: number,
+		First:       *first,
+		Second:      *second,
+		Evidence:    evidence,
+		Detected:    uint64(d.now().Unix()),
+	})
+	equivocationMeter.Mark(1)
+	log.Error("Sequencer equivocation detected", "signer", signer.Hex(), "number", number,
+		"first", first.TxHash.Hex(), "first-source", first.Source, "second", second.TxHash.Hex(), "second-source", second.Source)
+}
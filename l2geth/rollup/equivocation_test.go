@@ -0,0 +1,130 @@
+package rollup
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+// seqSignedTx returns a tx that the key signed as sequencer for a block
+func seqSignedTx(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, number uint64, epoch *big.Int) *types.Transaction {
+	tx := types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1), 21000, common.Big1, nil)
+	hash := core.SeqSignHash(params.TestChainConfig.ChainID, number, epoch, tx.Hash())
+	signature, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetSeqSign(&types.SeqSign{
+		R: new(big.Int).SetBytes(signature[0:32]),
+		S: new(big.Int).SetBytes(signature[32:64]),
+		V: new(big.Int).SetBytes(signature[64:65]),
+	})
+	return tx
+}
+
+func TestEquivocationDetector(t *testing.T) {
+	defer func(height *big.Int) { params.MetisFallbackRollupConfig.SeqSignHeight = height }(params.MetisFallbackRollupConfig.SeqSignHeight)
+	params.MetisFallbackRollupConfig.SeqSignHeight = big.NewInt(1)
+
+	db := rawdb.NewMemoryDatabase()
+	epoch := big.NewInt(3)
+	canonical := make(map[uint64]*types.Transaction)
+	d := newEquivocationDetector(db, params.TestChainConfig,
+		func(*types.Transaction, uint64) (*big.Int, error) { return epoch, nil },
+		func(number uint64) *types.Transaction { return canonical[number] })
+
+	seqKey, _ := crypto.GenerateKey()
+	seq := crypto.PubkeyToAddress(seqKey.PublicKey)
+	otherKey, _ := crypto.GenerateKey()
+
+	// The same tx seen twice and another signer at the same height are fine
+	first := seqSignedTx(t, seqKey, 0, 10, epoch)
+	d.Observe(first, 10, SeqTxSourceLocal)
+	d.Observe(first, 10, SeqTxSourceP2P)
+	d.Observe(seqSignedTx(t, otherKey, 1, 10, epoch), 10, SeqTxSourceP2P)
+	if equivocations := rawdb.ReadEquivocations(db, 0, 10); len(equivocations) != 0 {
+		t.Fatalf("unexpected equivocations: %v", equivocations)
+	}
+
+	second := seqSignedTx(t, seqKey, 1, 10, epoch)
+	d.Observe(second, 10, SeqTxSourceDTL)
+	equivocations := rawdb.ReadEquivocations(db, 0, 10)
+	if len(equivocations) != 1 {
+		t.Fatalf("unexpected number of equivocations: %d", len(equivocations))
+	}
+	e := equivocations[0]
+	if e.Signer != seq || e.BlockNumber != 10 || e.First.TxHash != first.Hash() || e.Second.TxHash != second.Hash() || e.Second.Source != SeqTxSourceDTL {
+		t.Fatalf("unexpected equivocation: %+v", e)
+	}
+
+	// Both signatures of the evidence recover to the signer from the block
+	// number, epoch and tx hash
+	values, err := equivocationArguments.UnpackValues(e.Evidence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(common.Address) != seq || values[1].(*big.Int).Uint64() != 10 {
+		t.Fatalf("unexpected evidence header: %v", values[:2])
+	}
+	for _, i := range []int{2, 5} {
+		txHash := common.Hash(values[i+1].([32]byte))
+		signature := common.CopyBytes(values[i+2].([]byte))
+		signature[64] -= 27
+		pub, err := crypto.SigToPub(core.SeqSignHash(params.TestChainConfig.ChainID, 10, values[i].(*big.Int), txHash).Bytes(), signature)
+		if err != nil || crypto.PubkeyToAddress(*pub) != seq {
+			t.Fatalf("evidence signature %d does not recover to the signer: %v", i, err)
+		}
+	}
+
+	// A tx of the chain is compared after it left the window
+	canonical[20] = seqSignedTx(t, seqKey, 2, 20, epoch)
+	d.Observe(seqSignedTx(t, seqKey, 3, 20, epoch), 20, SeqTxSourceP2P)
+	if equivocations := rawdb.ReadEquivocations(db, 11, 10); len(equivocations) != 1 || equivocations[0].First.Source != SeqTxSourceChain {
+		t.Fatalf("equivocation against the chain not detected: %v", equivocations)
+	}
+}
+
+func TestEquivocationDetectorSkips(t *testing.T) {
+	defer func(height *big.Int) { params.MetisFallbackRollupConfig.SeqSignHeight = height }(params.MetisFallbackRollupConfig.SeqSignHeight)
+	params.MetisFallbackRollupConfig.SeqSignHeight = big.NewInt(10)
+
+	db := rawdb.NewMemoryDatabase()
+	epoch := big.NewInt(3)
+	canonical := make(map[uint64]*types.Transaction)
+	d := newEquivocationDetector(db, params.TestChainConfig,
+		func(*types.Transaction, uint64) (*big.Int, error) { return epoch, nil },
+		func(number uint64) *types.Transaction { return canonical[number] })
+	seqKey, _ := crypto.GenerateKey()
+
+	// Before the SeqSign fork the signature does not bind the block
+	d.Observe(seqSignedTx(t, seqKey, 0, 5, epoch), 5, SeqTxSourceLocal)
+	d.Observe(seqSignedTx(t, seqKey, 1, 5, epoch), 5, SeqTxSourceP2P)
+
+	// The blocks after 20 are rewound and produced again with other txs, the
+	// rewound txs are ignored when they are seen again
+	canonical[21] = seqSignedTx(t, seqKey, 2, 21, epoch)
+	rewound := seqSignedTx(t, seqKey, 3, 22, epoch)
+	d.Observe(rewound, 22, SeqTxSourceLocal)
+	d.Rewind(20)
+	delete(canonical, 21)
+	d.Observe(seqSignedTx(t, seqKey, 4, 21, epoch), 21, SeqTxSourceLocal)
+	d.Observe(seqSignedTx(t, seqKey, 5, 22, epoch), 22, SeqTxSourceLocal)
+	d.Observe(seqSignedTx(t, seqKey, 2, 21, epoch), 21, SeqTxSourceP2P)
+	d.Observe(rewound, 22, SeqTxSourceP2P)
+	if equivocations := rawdb.ReadEquivocations(db, 0, 10); len(equivocations) != 0 {
+		t.Fatalf("unexpected equivocations: %v", equivocations)
+	}
+
+	// The blocks produced again after the rewind are compared
+	d.Observe(seqSignedTx(t, seqKey, 6, 22, epoch), 22, SeqTxSourceP2P)
+	if equivocations := rawdb.ReadEquivocations(db, 0, 10); len(equivocations) != 1 {
+		t.Fatalf("equivocation after the rewind not detected: %v", equivocations)
+	}
+}
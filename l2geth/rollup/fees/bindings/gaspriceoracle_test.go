@@ -108,7 +108,7 @@ func TestCalculateFee(t *testing.T) {
 			}
 
 			// Ignore the error here because the tx isn't signed
-			msg, _ := tx.AsMessage(signer)
+			msg, _ := tx.AsMessage(signer, false)
 
 			l1MsgFee, err := fees.CalculateL1MsgFee(msg, state, &addr)
 			if err != nil {
@@ -312,29 +312,36 @@ func PaysEnough(opts *PaysEnoughOpts) error {
 		return fmt.Errorf("%w: no expected fee", errMissingInput)
 	}
 
-	fee := new(big.Int).Set(opts.ExpectedGasPrice)
-	// Allow for a downward buffer to protect against L1 gas price volatility
-	if opts.ThresholdDown != nil {
-		fee = mulByFloat(fee, opts.ThresholdDown)
-	}
+	min, max := PaysEnoughBounds(opts.ExpectedGasPrice, opts.ThresholdUp, opts.ThresholdDown)
 	// Protect the sequencer from being underpaid
 	// if user fee < expected fee, return error
-	if opts.UserGasPrice.Cmp(fee) == -1 {
+	if opts.UserGasPrice.Cmp(min) == -1 {
 		return ErrGasPriceTooLow
 	}
 	// Protect users from overpaying by too much
-	if opts.ThresholdUp != nil {
-		// overpaying = user fee - expected fee
-		overpaying := new(big.Int).Sub(opts.UserGasPrice, opts.ExpectedGasPrice)
-		threshold := mulByFloat(opts.ExpectedGasPrice, opts.ThresholdUp)
-		// if overpaying > threshold, return error
-		if overpaying.Cmp(threshold) == 1 {
-			return ErrGasPriceTooHigh
-		}
+	if max != nil && opts.UserGasPrice.Cmp(max) == 1 {
+		return ErrGasPriceTooHigh
 	}
 	return nil
 }
 
+// PaysEnoughBounds returns the lowest and the highest gas price that
+// PaysEnough accepts for the expected gas price. The highest gas price is
+// nil when the upward threshold is not set.
+func PaysEnoughBounds(expected *big.Int, thresholdUp, thresholdDown *big.Float) (*big.Int, *big.Int) {
+	min := new(big.Int).Set(expected)
+	// Allow for a downward buffer to protect against L1 gas price volatility
+	if thresholdDown != nil {
+		min = mulByFloat(min, thresholdDown)
+	}
+	if thresholdUp == nil {
+		return min, nil
+	}
+	// The user may overpay the expected gas price by the threshold
+	max := new(big.Int).Add(expected, mulByFloat(expected, thresholdUp))
+	return min, max
+}
+
 // zeroesAndOnes counts the number of 0 bytes and non 0 bytes in a byte slice
 func zeroesAndOnes(data []byte) (uint64, uint64) {
 	var zeroes uint64
@@ -105,3 +105,47 @@ func TestPaysEnough(t *testing.T) {
 		})
 	}
 }
+
+func TestPaysEnoughBounds(t *testing.T) {
+	expected := new(big.Int).SetUint64(10_000)
+	tests := map[string]struct {
+		up, down *big.Float
+		min, max *big.Int
+	}{
+		"no-thresholds": {nil, nil, big.NewInt(10_000), nil},
+		"down":          {nil, big.NewFloat(0.8), big.NewInt(8_000), nil},
+		"up-and-down":   {big.NewFloat(3), big.NewFloat(0.8), big.NewInt(8_000), big.NewInt(40_000)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			min, max := PaysEnoughBounds(expected, tt.up, tt.down)
+			if min.Cmp(tt.min) != 0 {
+				t.Fatalf("min: got %s, expected %s", min, tt.min)
+			}
+			if (max == nil) != (tt.max == nil) || (max != nil && max.Cmp(tt.max) != 0) {
+				t.Fatalf("max: got %v, expected %v", max, tt.max)
+			}
+			// The bounds are the edges of what PaysEnough accepts
+			opts := &PaysEnoughOpts{ExpectedGasPrice: expected, ThresholdUp: tt.up, ThresholdDown: tt.down}
+			opts.UserGasPrice = min
+			if err := PaysEnough(opts); err != nil {
+				t.Fatalf("min rejected: %s", err)
+			}
+			opts.UserGasPrice = new(big.Int).Sub(min, common.Big1)
+			if err := PaysEnough(opts); !errors.Is(err, ErrGasPriceTooLow) {
+				t.Fatalf("below min: got %v", err)
+			}
+			if max != nil {
+				opts.UserGasPrice = max
+				if err := PaysEnough(opts); err != nil {
+					t.Fatalf("max rejected: %s", err)
+				}
+				opts.UserGasPrice = new(big.Int).Add(max, common.Big1)
+				if err := PaysEnough(opts); !errors.Is(err, ErrGasPriceTooHigh) {
+					t.Fatalf("above max: got %v", err)
+				}
+			}
+		})
+	}
+}
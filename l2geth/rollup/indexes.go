@@ -0,0 +1,141 @@
+package rollup
+
+import (
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+)
+
+// Names of the rollup indexes that are derived from the chain
+const (
+	IndexHead     = "index"
+	IndexVerified = "verified-index"
+	IndexQueue    = "queue-index"
+)
+
+// indexChain is the part of the blockchain the rollup indexes are derived
+// from
+type indexChain interface {
+	CurrentBlock() *types.Block
+	GetBlockByNumber(number uint64) *types.Block
+}
+
+// IndexRepair is a rollup index that disagrees with the chain, a nil index
+// is not stored
+type IndexRepair struct {
+	Name     string
+	Stored   *uint64
+	Repaired *uint64
+}
+
+// startupIndexDepth is the number of blocks the sync service looks back from
+// the head for the latest enqueue when it checks the indexes at start up
+const startupIndexDepth = 1024
+
+// CheckIndexes compares the stored rollup indexes with the ones described by
+// the TransactionMeta of the head blocks and returns the ones that disagree.
+// The batch index and the index time are not described by the chain and are
+// not checked. The queue index is only checked when an enqueue is found
+// within depth blocks of the head, 0 looks back to genesis.
+func CheckIndexes(db ethdb.KeyValueReader, chain indexChain, depth uint64) []IndexRepair {
+	head := chain.CurrentBlock()
+	index := headIndexOf(head)
+	expected := []IndexRepair{
+		{Name: IndexHead, Stored: rawdb.ReadHeadIndex(db), Repaired: index},
+		{Name: IndexVerified, Stored: rawdb.ReadHeadVerifiedIndex(db), Repaired: index},
+	}
+	if queueIndex, ok := latestQueueIndexWithin(chain, head, depth); ok {
+		expected = append(expected, IndexRepair{Name: IndexQueue, Stored: rawdb.ReadHeadQueueIndex(db), Repaired: queueIndex})
+	}
+	var repairs []IndexRepair
+	for _, r := range expected {
+		if !equalIndex(r.Stored, r.Repaired) {
+			repairs = append(repairs, r)
+		}
+	}
+	return repairs
+}
+
+// RepairIndexes rewrites the rollup indexes that disagree with the chain in
+// a single batch. This can happen when the node stopped between writing the
+// indexes and inserting the block they describe. See CheckIndexes for depth.
+func RepairIndexes(db ethdb.KeyValueStore, chain indexChain, depth uint64) ([]IndexRepair, error) {
+	repairs := CheckIndexes(db, chain, depth)
+	if len(repairs) == 0 {
+		return nil, nil
+	}
+	batch := db.NewBatch()
+	for _, r := range repairs {
+		log.Warn("Repairing rollup index", "name", r.Name, "stored", stringify(r.Stored), "repaired", stringify(r.Repaired))
+		switch r.Name {
+		case IndexHead:
+			if r.Repaired == nil {
+				rawdb.DeleteHeadIndex(batch)
+			} else {
+				rawdb.WriteHeadIndex(batch, *r.Repaired)
+			}
+		case IndexVerified:
+			if r.Repaired == nil {
+				rawdb.DeleteHeadVerifiedIndex(batch)
+			} else {
+				rawdb.WriteHeadVerifiedIndex(batch, *r.Repaired)
+			}
+		case IndexQueue:
+			if r.Repaired == nil {
+				rawdb.DeleteHeadQueueIndex(batch)
+			} else {
+				rawdb.WriteHeadQueueIndex(batch, *r.Repaired)
+			}
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	return repairs, nil
+}
+
+// headIndexOf returns the CTC index of a head block, nil for the genesis
+// block
+func headIndexOf(head *types.Block) *uint64 {
+	if head == nil || head.NumberU64() == 0 {
+		return nil
+	}
+	// The CTC index is the block number minus one
+	index := head.NumberU64() - 1
+	return &index
+}
+
+// latestQueueIndexAt walks back from a block to find the last queue index
+// that was included in the chain
+func latestQueueIndexAt(chain indexChain, block *types.Block) *uint64 {
+	index, _ := latestQueueIndexWithin(chain, block, 0)
+	return index
+}
+
+// latestQueueIndexWithin walks back at most depth blocks from a block to
+// find the last queue index that was included in the chain, 0 walks back to
+// genesis. It returns false when the walk stopped before it found an enqueue
+// or reached genesis.
+func latestQueueIndexWithin(chain indexChain, block *types.Block, depth uint64) (*uint64, bool) {
+	for walked := uint64(0); block != nil && block.NumberU64() > 0; walked++ {
+		if depth > 0 && walked == depth {
+			return nil, false
+		}
+		txs := block.Transactions()
+		for i := len(txs) - 1; i >= 0; i-- {
+			if txs[i].QueueOrigin() == types.QueueOriginL1ToL2 && txs[i].GetMeta().QueueIndex != nil {
+				return txs[i].GetMeta().QueueIndex, true
+			}
+		}
+		block = chain.GetBlockByNumber(block.NumberU64() - 1)
+	}
+	return nil, true
+}
+
+func equalIndex(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,136 @@
+package rollup
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+)
+
+// newTestEnqueue creates an L1 to L2 transaction with a queue index
+func newTestEnqueue(index, queueIndex uint64) *types.Transaction {
+	origin := common.Address{0x01}
+	tx := types.NewTransaction(0, common.Address{0x02}, common.Big0, 100000, common.Big0, nil)
+	tx.SetTransactionMeta(types.NewTransactionMeta(common.Big1, 1, &origin, types.QueueOriginL1ToL2, &index, &queueIndex, nil))
+	return tx
+}
+
+func TestIndexesWrittenWithBlock(t *testing.T) {
+	bc, db := newTestChain(t)
+
+	if err := mineTransaction(bc, newTestEnqueue(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	tx := signTestTransaction(t, testKey, 0)
+	tx.SetIndex(1)
+	tx.SetL1Timestamp(1)
+	if err := mineTransaction(bc, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The indexes are committed with the head block without the sync service
+	if index := rawdb.ReadHeadIndex(db); index == nil || *index != 1 {
+		t.Fatalf("unexpected index: %s", stringify(index))
+	}
+	if index := rawdb.ReadHeadVerifiedIndex(db); index == nil || *index != 1 {
+		t.Fatalf("unexpected verified index: %s", stringify(index))
+	}
+	// The queue index is kept when the head block has no enqueue
+	if index := rawdb.ReadHeadQueueIndex(db); index == nil || *index != 0 {
+		t.Fatalf("unexpected queue index: %s", stringify(index))
+	}
+	if repairs := CheckIndexes(db, bc, 0); len(repairs) != 0 {
+		t.Fatalf("unexpected repairs: %v", repairs)
+	}
+}
+
+func TestRepairIndexes(t *testing.T) {
+	bc, db := newTestChain(t)
+
+	// Indexes stored at genesis are removed
+	rawdb.WriteHeadIndex(db, 3)
+	rawdb.WriteHeadQueueIndex(db, 0)
+	repairs, err := RepairIndexes(db, bc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repairs) != 2 {
+		t.Fatalf("unexpected number of repairs: %d", len(repairs))
+	}
+	if rawdb.ReadHeadIndex(db) != nil || rawdb.ReadHeadQueueIndex(db) != nil {
+		t.Fatal("indexes not removed at genesis")
+	}
+
+	for i := uint64(0); i < 2; i++ {
+		if err := mineTransaction(bc, newTestEnqueue(i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Simulate a crash after the indexes of the next enqueue were written
+	// but before its block was inserted
+	rawdb.WriteHeadIndex(db, 2)
+	rawdb.WriteHeadVerifiedIndex(db, 2)
+	rawdb.WriteHeadQueueIndex(db, 2)
+	rawdb.WriteHeadBatchIndex(db, 7)
+
+	repairs, err = RepairIndexes(db, bc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]uint64{IndexHead: 1, IndexVerified: 1, IndexQueue: 1}
+	if len(repairs) != len(want) {
+		t.Fatalf("unexpected number of repairs: %d", len(repairs))
+	}
+	for _, r := range repairs {
+		if r.Stored == nil || *r.Stored != 2 || r.Repaired == nil || *r.Repaired != want[r.Name] {
+			t.Fatalf("unexpected repair of %s: %s -> %s", r.Name, stringify(r.Stored), stringify(r.Repaired))
+		}
+	}
+	if index := rawdb.ReadHeadIndex(db); *index != 1 {
+		t.Fatalf("index not repaired: %d", *index)
+	}
+	if index := rawdb.ReadHeadQueueIndex(db); *index != 1 {
+		t.Fatalf("queue index not repaired: %d", *index)
+	}
+	// The batch index is not described by the chain
+	if index := rawdb.ReadHeadBatchIndex(db); *index != 7 {
+		t.Fatalf("batch index changed: %d", *index)
+	}
+	if repairs, _ := RepairIndexes(db, bc, 0); len(repairs) != 0 {
+		t.Fatalf("indexes repaired twice: %v", repairs)
+	}
+}
+
+func TestRepairIndexesDepth(t *testing.T) {
+	bc, db := newTestChain(t)
+
+	if err := mineTransaction(bc, newTestEnqueue(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(1); i < 3; i++ {
+		tx := signTestTransaction(t, testKey, i-1)
+		tx.SetIndex(i)
+		tx.SetL1Timestamp(1)
+		if err := mineTransaction(bc, tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rawdb.WriteHeadQueueIndex(db, 5)
+
+	// The enqueue is further back than the depth, the queue index is not
+	// checked
+	if repairs := CheckIndexes(db, bc, 2); len(repairs) != 0 {
+		t.Fatalf("unexpected repairs: %v", repairs)
+	}
+	repairs, err := RepairIndexes(db, bc, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repairs) != 1 || repairs[0].Name != IndexQueue {
+		t.Fatalf("unexpected repairs: %v", repairs)
+	}
+	if index := rawdb.ReadHeadQueueIndex(db); *index != 0 {
+		t.Fatalf("queue index not repaired: %d", *index)
+	}
+}
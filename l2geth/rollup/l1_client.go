@@ -0,0 +1,1139 @@
+package rollup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum-optimism/optimism/l2geth"
+	"github.com/ethereum-optimism/optimism/l2geth/accounts/abi"
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethclient"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+	"github.com/ethereum-optimism/optimism/l2geth/rpc"
+)
+
+// l1ContractsABI holds the subset of the CanonicalTransactionChain and
+// StateCommitmentChain ABIs that the L1Client needs to decode
+const l1ContractsABI = `[
+	{"type":"event","name":"TransactionEnqueued","anonymous":false,"inputs":[
+		{"name":"_chainId","type":"uint256","indexed":false},
+		{"name":"_l1TxOrigin","type":"address","indexed":true},
+		{"name":"_target","type":"address","indexed":true},
+		{"name":"_gasLimit","type":"uint256","indexed":false},
+		{"name":"_data","type":"bytes","indexed":false},
+		{"name":"_queueIndex","type":"uint256","indexed":true},
+		{"name":"_timestamp","type":"uint256","indexed":false}]},
+	{"type":"event","name":"SequencerBatchAppended","anonymous":false,"inputs":[
+		{"name":"_chainId","type":"uint256","indexed":false},
+		{"name":"_startingQueueIndex","type":"uint256","indexed":false},
+		{"name":"_numQueueElements","type":"uint256","indexed":false},
+		{"name":"_totalElements","type":"uint256","indexed":false}]},
+	{"type":"event","name":"TransactionBatchAppended","anonymous":false,"inputs":[
+		{"name":"_chainId","type":"uint256","indexed":false},
+		{"name":"_batchIndex","type":"uint256","indexed":true},
+		{"name":"_batchRoot","type":"bytes32","indexed":false},
+		{"name":"_batchSize","type":"uint256","indexed":false},
+		{"name":"_prevTotalElements","type":"uint256","indexed":false},
+		{"name":"_extraData","type":"bytes","indexed":false}]},
+	{"type":"event","name":"StateBatchAppended","anonymous":false,"inputs":[
+		{"name":"_chainId","type":"uint256","indexed":false},
+		{"name":"_batchIndex","type":"uint256","indexed":true},
+		{"name":"_batchRoot","type":"bytes32","indexed":false},
+		{"name":"_batchSize","type":"uint256","indexed":false},
+		{"name":"_prevTotalElements","type":"uint256","indexed":false},
+		{"name":"_extraData","type":"bytes","indexed":false}]},
+	{"type":"function","name":"appendStateBatchByChainId","stateMutability":"nonpayable","outputs":[],"inputs":[
+		{"name":"_chainId","type":"uint256"},
+		{"name":"_batch","type":"bytes32[]"},
+		{"name":"_shouldStartAtElement","type":"uint256"},
+		{"name":"proposer","type":"string"}]}
+]`
+
+// Layout of the custom encoded calldata of
+// `CanonicalTransactionChain.appendSequencerBatchByChainId`
+const (
+	batchContextStart = 47
+	batchContextSize  = 16
+	batchTxLengthSize = 3
+)
+
+// l1ContextTimestampIndex mirrors the data transport layer: queue transactions
+// at or below this index take the timestamp of their enqueue instead of the
+// timestamp of the batch context they were appended in.
+const l1ContextTimestampIndex = 2287472
+
+// l1LogRange is the maximum number of L1 blocks queried in a single
+// eth_getLogs call. Most providers reject larger ranges.
+const l1LogRange = 5000
+
+// l1BatchCacheSize is the number of decoded transaction batches kept. A sync
+// pass reads the elements of a batch one after the other, so only the last
+// few batches are needed.
+const l1BatchCacheSize = 16
+
+var (
+	l1ABI abi.ABI
+
+	transactionEnqueuedTopic      common.Hash
+	sequencerBatchAppendedTopic   common.Hash
+	transactionBatchAppendedTopic common.Hash
+	stateBatchAppendedTopic       common.Hash
+)
+
+// errL1ClientUnsupported is returned for RollupClient methods that can only be
+// served by the data transport layer
+var errL1ClientUnsupported = errors.New("not supported by the L1 rollup client")
+
+func init() {
+	var err error
+	l1ABI, err = abi.JSON(strings.NewReader(l1ContractsABI))
+	if err != nil {
+		panic(err)
+	}
+	transactionEnqueuedTopic = l1ABI.Events["TransactionEnqueued"].ID()
+	sequencerBatchAppendedTopic = l1ABI.Events["SequencerBatchAppended"].ID()
+	transactionBatchAppendedTopic = l1ABI.Events["TransactionBatchAppended"].ID()
+	stateBatchAppendedTopic = l1ABI.Events["StateBatchAppended"].ID()
+}
+
+// L1Backend is the subset of the L1 JSON-RPC API that the L1Client depends on.
+// It is satisfied by the simulated backend and, through DialL1Client, by an
+// ethclient connected to an L1 node.
+type L1Backend interface {
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+}
+
+// l1BatchRef locates a batch that was appended to the CanonicalTransactionChain
+// or the StateCommitmentChain on L1
+type l1BatchRef struct {
+	index             uint64
+	root              common.Hash
+	size              uint64
+	prevTotalElements uint64
+	extraData         []byte
+	blockNumber       uint64
+	txHash            common.Hash
+	logIndex          uint
+	// Only set for transaction batches, from the SequencerBatchAppended event
+	startingQueueIndex *uint64
+}
+
+// l1BatchKey identifies a decoded transaction batch. The L1 transaction hash
+// tells a batch apart from the one that replaced it after an L1 reorg.
+type l1BatchKey struct {
+	index  uint64
+	txHash common.Hash
+}
+
+// contains returns true if the batch contains the element at index
+func (b *l1BatchRef) contains(index uint64) bool {
+	return b.prevTotalElements <= index && index < b.prevTotalElements+b.size
+}
+
+// L1Client is a RollupClient that derives enqueues, transaction batches and
+// state roots directly from the logs and calldata of the L1 rollup contracts.
+// It allows a verifier to sync without a data transport layer. Only the
+// BackendL1 view of the chain can be served and batches that were posted to
+// the inbox or to external storage are not supported.
+type L1Client struct {
+	backend L1Backend
+	ctc     common.Address
+	scc     common.Address
+	signer  *types.EIP155Signer
+	chainID *big.Int
+	timeout time.Duration
+
+	// Number of blocks the scan stays behind the L1 tip
+	confirmations uint64
+	// First L1 block to scan
+	start uint64
+
+	mu          sync.Mutex
+	scanned     uint64
+	scannedHash common.Hash
+	// Hashes of the L1 blocks that contain an indexed event, used to find
+	// the common ancestor after a reorg
+	blockHashes  map[uint64]common.Hash
+	enqueues     []*Enqueue
+	txBatches    []*l1BatchRef
+	stateBatches []*l1BatchRef
+	// Decoded transaction batches, so that the elements of a batch do not
+	// each fetch and decode the whole batch
+	batchCache map[l1BatchKey]*TransactionBatchResponse
+}
+
+// l1RPCBackend reads calldata over raw JSON-RPC so that typed L1 transactions,
+// which the l2geth transaction type cannot decode, can still be consumed
+type l1RPCBackend struct {
+	*ethclient.Client
+	rpc *rpc.Client
+}
+
+// TransactionByHash returns a transaction that only carries the recipient and
+// calldata of the L1 transaction, which is all that the L1Client consumes
+func (b *l1RPCBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	var res *struct {
+		To          *common.Address `json:"to"`
+		Input       hexutil.Bytes   `json:"input"`
+		BlockNumber *string         `json:"blockNumber"`
+	}
+	if err := b.rpc.CallContext(ctx, &res, "eth_getTransactionByHash", hash); err != nil {
+		return nil, false, err
+	}
+	if res == nil {
+		return nil, false, ethereum.NotFound
+	}
+	var tx *types.Transaction
+	if res.To == nil {
+		tx = types.NewContractCreation(0, common.Big0, 0, common.Big0, res.Input)
+	} else {
+		tx = types.NewTransaction(0, *res.To, common.Big0, 0, common.Big0, res.Input)
+	}
+	return tx, res.BlockNumber == nil, nil
+}
+
+// DialL1Client connects to an L1 JSON-RPC endpoint and creates an L1Client
+// that reads the given CanonicalTransactionChain and StateCommitmentChain
+func DialL1Client(url string, ctc, scc common.Address, deployHeight *big.Int, chainID *big.Int, confirmations uint64) (*L1Client, error) {
+	c, err := rpc.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial L1 endpoint: %w", err)
+	}
+	backend := &l1RPCBackend{Client: ethclient.NewClient(c), rpc: c}
+	return NewL1Client(backend, ctc, scc, deployHeight, chainID, confirmations), nil
+}
+
+// NewL1Client creates a new L1Client given an L1 backend, the addresses of the
+// rollup contracts, the L1 height to start scanning from, the L2 chain id and
+// the number of confirmations an L1 block needs before it is scanned
+func NewL1Client(backend L1Backend, ctc, scc common.Address, deployHeight *big.Int, chainID *big.Int, confirmations uint64) *L1Client {
+	signer := types.NewEIP155Signer(chainID)
+	// Start scanning at the deploy height
+	start := uint64(0)
+	if deployHeight != nil && deployHeight.Uint64() > 0 {
+		start = deployHeight.Uint64() - 1
+	}
+	return &L1Client{
+		backend:       backend,
+		ctc:           ctc,
+		scc:           scc,
+		signer:        &signer,
+		chainID:       chainID,
+		timeout:       30 * time.Second,
+		confirmations: confirmations,
+		start:         start,
+		scanned:       start,
+		blockHashes:   make(map[uint64]common.Hash),
+		batchCache:    make(map[l1BatchKey]*TransactionBatchResponse),
+	}
+}
+
+// refresh indexes the rollup contract events between the last scanned L1
+// block and the L1 tip minus the confirmations. The events of blocks that
+// were reorged out are dropped first. It must be called with the lock held.
+func (c *L1Client) refresh(ctx context.Context) error {
+	head, err := c.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot fetch L1 head: %w", err)
+	}
+	if head.Number.Uint64() <= c.confirmations {
+		return nil
+	}
+	tip := head.Number.Uint64() - c.confirmations
+	if err := c.handleReorg(ctx); err != nil {
+		return err
+	}
+	for c.scanned < tip {
+		from := c.scanned + 1
+		to := from + l1LogRange - 1
+		if to > tip {
+			to = tip
+		}
+		logs, err := c.backend.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{c.ctc, c.scc},
+			Topics: [][]common.Hash{{
+				transactionEnqueuedTopic,
+				sequencerBatchAppendedTopic,
+				transactionBatchAppendedTopic,
+				stateBatchAppendedTopic,
+			}},
+		})
+		if err != nil {
+			return fmt.Errorf("cannot filter L1 logs from %d to %d: %w", from, to, err)
+		}
+		reorged := false
+		for _, l := range logs {
+			// A removed log belongs to a block that was reorged out, scan
+			// again from its parent
+			if l.Removed {
+				c.rewind(l.BlockNumber-1, common.Hash{})
+				reorged = true
+				break
+			}
+			if err := c.indexLog(l); err != nil {
+				return err
+			}
+			c.blockHashes[l.BlockNumber] = l.BlockHash
+		}
+		if reorged {
+			continue
+		}
+		hash, err := c.canonicalHash(ctx, to)
+		if err != nil {
+			return err
+		}
+		c.scanned = to
+		c.scannedHash = hash
+	}
+	return nil
+}
+
+// handleReorg checks that the last scanned L1 block is still canonical. If
+// it is not, the events of the blocks after the latest canonical block that
+// contains an event are dropped, so that they are scanned again.
+func (c *L1Client) handleReorg(ctx context.Context) error {
+	if c.scannedHash == (common.Hash{}) {
+		return nil
+	}
+	hash, err := c.canonicalHash(ctx, c.scanned)
+	if err != nil {
+		return err
+	}
+	if hash == c.scannedHash {
+		return nil
+	}
+	numbers := make([]uint64, 0, len(c.blockHashes))
+	for number := range c.blockHashes {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+	for _, number := range numbers {
+		hash, err := c.canonicalHash(ctx, number)
+		if err != nil {
+			return err
+		}
+		if hash == c.blockHashes[number] {
+			log.Warn("L1 reorg detected", "scanned", c.scanned, "ancestor", number)
+			c.rewind(number, hash)
+			return nil
+		}
+	}
+	log.Warn("L1 reorg detected, no indexed block is canonical", "scanned", c.scanned)
+	c.rewind(c.start, common.Hash{})
+	return nil
+}
+
+// canonicalHash returns the hash of the canonical L1 block with the given
+// number, or the zero hash if the L1 chain is shorter after a reorg
+func (c *L1Client) canonicalHash(ctx context.Context, number uint64) (common.Hash, error) {
+	header, err := c.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if errors.Is(err, ethereum.NotFound) || (err == nil && header == nil) {
+		return common.Hash{}, nil
+	}
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("cannot fetch L1 block %d: %w", number, err)
+	}
+	return header.Hash(), nil
+}
+
+// rewind drops the events of the L1 blocks after number and continues the
+// scan from there. The hash of the block is zero when it is unknown.
+func (c *L1Client) rewind(number uint64, hash common.Hash) {
+	if number < c.start {
+		number = c.start
+	}
+	enqueues := c.enqueues[:0]
+	for _, enqueue := range c.enqueues {
+		if *enqueue.BlockNumber <= number {
+			enqueues = append(enqueues, enqueue)
+		}
+	}
+	c.enqueues = enqueues
+	c.txBatches = truncateBatchRefs(c.txBatches, number)
+	c.stateBatches = truncateBatchRefs(c.stateBatches, number)
+	for n := range c.blockHashes {
+		if n > number {
+			delete(c.blockHashes, n)
+		}
+	}
+	for key, res := range c.batchCache {
+		if res.Batch.BlockNumber > number {
+			delete(c.batchCache, key)
+		}
+	}
+	c.scanned = number
+	c.scannedHash = hash
+}
+
+// indexLog adds a single rollup contract event to the in memory index. Events
+// for other L2 chains are skipped.
+func (c *L1Client) indexLog(l types.Log) error {
+	if l.Removed || len(l.Topics) == 0 {
+		return nil
+	}
+	switch {
+	case l.Topics[0] == transactionEnqueuedTopic && l.Address == c.ctc:
+		var ev struct {
+			ChainId   *big.Int
+			GasLimit  *big.Int
+			Data      []byte
+			Timestamp *big.Int
+		}
+		if err := l1ABI.Unpack(&ev, "TransactionEnqueued", l.Data); err != nil {
+			return fmt.Errorf("cannot decode TransactionEnqueued: %w", err)
+		}
+		if ev.ChainId.Cmp(c.chainID) != 0 || len(l.Topics) != 4 {
+			return nil
+		}
+		origin := common.BytesToAddress(l.Topics[1].Bytes())
+		target := common.BytesToAddress(l.Topics[2].Bytes())
+		queueIndex := l.Topics[3].Big().Uint64()
+		gasLimit := ev.GasLimit.Uint64()
+		data := hexutil.Bytes(ev.Data)
+		blockNumber := l.BlockNumber
+		timestamp := ev.Timestamp.Uint64()
+		enqueue := &Enqueue{
+			Target:      &target,
+			Data:        &data,
+			GasLimit:    &gasLimit,
+			Origin:      &origin,
+			BlockNumber: &blockNumber,
+			Timestamp:   &timestamp,
+			QueueIndex:  &queueIndex,
+		}
+		pos := sort.Search(len(c.enqueues), func(i int) bool {
+			return *c.enqueues[i].QueueIndex >= queueIndex
+		})
+		c.enqueues = append(c.enqueues[:pos], enqueue)
+
+	case l.Topics[0] == transactionBatchAppendedTopic && l.Address == c.ctc:
+		ref, err := c.decodeBatchAppended("TransactionBatchAppended", l)
+		if err != nil || ref == nil {
+			return err
+		}
+		c.txBatches = insertBatchRef(c.txBatches, ref)
+
+	case l.Topics[0] == sequencerBatchAppendedTopic && l.Address == c.ctc:
+		var ev struct {
+			ChainId            *big.Int
+			StartingQueueIndex *big.Int
+			NumQueueElements   *big.Int
+			TotalElements      *big.Int
+		}
+		if err := l1ABI.Unpack(&ev, "SequencerBatchAppended", l.Data); err != nil {
+			return fmt.Errorf("cannot decode SequencerBatchAppended: %w", err)
+		}
+		if ev.ChainId.Cmp(c.chainID) != 0 || len(c.txBatches) == 0 {
+			return nil
+		}
+		// The SequencerBatchAppended event is emitted directly after the
+		// TransactionBatchAppended event of the same batch
+		last := c.txBatches[len(c.txBatches)-1]
+		if last.txHash != l.TxHash || last.logIndex+1 != l.Index {
+			return fmt.Errorf("SequencerBatchAppended without TransactionBatchAppended in tx %s", l.TxHash.Hex())
+		}
+		start := ev.StartingQueueIndex.Uint64()
+		last.startingQueueIndex = &start
+
+	case l.Topics[0] == stateBatchAppendedTopic && l.Address == c.scc:
+		ref, err := c.decodeBatchAppended("StateBatchAppended", l)
+		if err != nil || ref == nil {
+			return err
+		}
+		c.stateBatches = insertBatchRef(c.stateBatches, ref)
+	}
+	return nil
+}
+
+// decodeBatchAppended decodes the TransactionBatchAppended and the
+// StateBatchAppended events, which share the same layout. It returns nil if
+// the batch belongs to another L2 chain.
+func (c *L1Client) decodeBatchAppended(name string, l types.Log) (*l1BatchRef, error) {
+	var ev struct {
+		ChainId           *big.Int
+		BatchRoot         [32]byte
+		BatchSize         *big.Int
+		PrevTotalElements *big.Int
+		ExtraData         []byte
+	}
+	if err := l1ABI.Unpack(&ev, name, l.Data); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", name, err)
+	}
+	if ev.ChainId.Cmp(c.chainID) != 0 || len(l.Topics) != 2 {
+		return nil, nil
+	}
+	return &l1BatchRef{
+		index:             l.Topics[1].Big().Uint64(),
+		root:              ev.BatchRoot,
+		size:              ev.BatchSize.Uint64(),
+		prevTotalElements: ev.PrevTotalElements.Uint64(),
+		extraData:         ev.ExtraData,
+		blockNumber:       l.BlockNumber,
+		txHash:            l.TxHash,
+		logIndex:          l.Index,
+	}, nil
+}
+
+// insertBatchRef appends a batch to a list that is sorted by batch index. A
+// batch that is appended again after its index was deleted on L1 replaces the
+// old batch and every batch after it.
+func insertBatchRef(refs []*l1BatchRef, ref *l1BatchRef) []*l1BatchRef {
+	pos := sort.Search(len(refs), func(i int) bool {
+		return refs[i].index >= ref.index
+	})
+	return append(refs[:pos], ref)
+}
+
+// truncateBatchRefs drops the batches that were appended after an L1 block
+func truncateBatchRefs(refs []*l1BatchRef, number uint64) []*l1BatchRef {
+	for i, ref := range refs {
+		if ref.blockNumber > number {
+			return refs[:i]
+		}
+	}
+	return refs
+}
+
+// findBatchRef returns the batch with the given batch index
+func findBatchRef(refs []*l1BatchRef, index uint64) *l1BatchRef {
+	pos := sort.Search(len(refs), func(i int) bool {
+		return refs[i].index >= index
+	})
+	if pos == len(refs) || refs[pos].index != index {
+		return nil
+	}
+	return refs[pos]
+}
+
+// findBatchRefByElement returns the batch that contains the element with the
+// given index
+func findBatchRefByElement(refs []*l1BatchRef, index uint64) *l1BatchRef {
+	pos := sort.Search(len(refs), func(i int) bool {
+		return refs[i].prevTotalElements+refs[i].size > index
+	})
+	if pos == len(refs) || !refs[pos].contains(index) {
+		return nil
+	}
+	return refs[pos]
+}
+
+// findEnqueue returns the indexed enqueue with the given queue index
+func (c *L1Client) findEnqueue(index uint64) *Enqueue {
+	pos := sort.Search(len(c.enqueues), func(i int) bool {
+		return *c.enqueues[i].QueueIndex >= index
+	})
+	if pos == len(c.enqueues) || *c.enqueues[pos].QueueIndex != index {
+		return nil
+	}
+	return c.enqueues[pos]
+}
+
+// batch builds the Batch that the data transport layer would serve for a
+// batch reference
+func (c *L1Client) batch(ctx context.Context, ref *l1BatchRef) (*Batch, error) {
+	header, err := c.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(ref.blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch L1 block %d: %w", ref.blockNumber, err)
+	}
+	return &Batch{
+		Index:             ref.index,
+		Root:              ref.root,
+		Size:              uint32(ref.size),
+		PrevTotalElements: uint32(ref.prevTotalElements),
+		ExtraData:         ref.extraData,
+		BlockNumber:       ref.blockNumber,
+		Timestamp:         header.Time,
+	}, nil
+}
+
+// calldata fetches the calldata of the L1 transaction that appended a batch
+func (c *L1Client) calldata(ctx context.Context, ref *l1BatchRef) ([]byte, error) {
+	tx, _, err := c.backend.TransactionByHash(ctx, ref.txHash)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch L1 transaction %s: %w", ref.txHash.Hex(), err)
+	}
+	return tx.Data(), nil
+}
+
+// transactionBatch decodes the calldata of a transaction batch into the same
+// response that the data transport layer serves. The latest decoded batches
+// are cached.
+func (c *L1Client) transactionBatch(ctx context.Context, ref *l1BatchRef) (*TransactionBatchResponse, error) {
+	if ref.startingQueueIndex == nil {
+		return nil, fmt.Errorf("no SequencerBatchAppended event for batch %d", ref.index)
+	}
+	key := l1BatchKey{index: ref.index, txHash: ref.txHash}
+	if res, ok := c.batchCache[key]; ok {
+		return res, nil
+	}
+	batch, err := c.batch(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := c.calldata(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := c.decodeSequencerBatch(calldata, ref)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode batch %d: %w", ref.index, err)
+	}
+	res := &TransactionBatchResponse{
+		Batch:        batch,
+		Transactions: txs,
+	}
+	c.cacheBatch(key, res)
+	return res, nil
+}
+
+// cacheBatch adds a decoded batch to the cache, evicting the batch with the
+// lowest index when the cache is full
+func (c *L1Client) cacheBatch(key l1BatchKey, res *TransactionBatchResponse) {
+	if len(c.batchCache) >= l1BatchCacheSize {
+		var oldest *l1BatchKey
+		for k := range c.batchCache {
+			if oldest == nil || k.index < oldest.index {
+				k := k
+				oldest = &k
+			}
+		}
+		delete(c.batchCache, *oldest)
+	}
+	c.batchCache[key] = res
+}
+
+// decodeSequencerBatch decodes the calldata of
+// `appendSequencerBatchByChainId`. The calldata is laid out as follows:
+// [4: selector] [32: chain id] [5: start element] [3: total elements]
+// [3: num contexts] [16 * num contexts: contexts] [3: tx length] [tx] ...
+// followed by an optional list of length prefixed sequencer signatures.
+func (c *L1Client) decodeSequencerBatch(calldata []byte, ref *l1BatchRef) ([]*transaction, error) {
+	if len(calldata) < batchContextStart {
+		return nil, fmt.Errorf("calldata too short: %d", len(calldata))
+	}
+	numContexts := readUint(calldata[44:batchContextStart])
+	pointer := batchContextStart + batchContextSize*int(numContexts)
+	if len(calldata) < pointer {
+		return nil, errors.New("not enough batch contexts")
+	}
+
+	var (
+		txs          []*transaction
+		sequencerTxs []int
+		queueIndex   = *ref.startingQueueIndex
+		index        = ref.prevTotalElements
+	)
+	for i := 0; i < int(numContexts); i++ {
+		batchContext := calldata[batchContextStart+batchContextSize*i : batchContextStart+batchContextSize*(i+1)]
+		numSequenced := readUint(batchContext[0:3])
+		numQueued := readUint(batchContext[3:6])
+		timestamp := readUint(batchContext[6:11])
+		blockNumber := readUint(batchContext[11:16])
+		if i == 0 && numSequenced == 0 && numQueued == 0 && timestamp == 0 && blockNumber == 0 {
+			return nil, fmt.Errorf("batch data in external storage: %w", errL1ClientUnsupported)
+		}
+
+		for j := uint64(0); j < numSequenced; j++ {
+			raw, next, err := readLengthPrefixed(calldata, pointer)
+			if err != nil {
+				return nil, err
+			}
+			pointer = next
+			decoded, err := c.decodeSequencerTransaction(raw)
+			if err != nil {
+				return nil, fmt.Errorf("cannot decode transaction %d: %w", index, err)
+			}
+			sequencerTxs = append(sequencerTxs, len(txs))
+			txs = append(txs, &transaction{
+				Index:       index,
+				BatchIndex:  ref.index,
+				BlockNumber: blockNumber,
+				Timestamp:   timestamp,
+				Value:       decoded.Value,
+				Origin:      &common.Address{},
+				Data:        raw,
+				QueueOrigin: sequencer,
+				Decoded:     decoded,
+			})
+			index++
+		}
+
+		for j := uint64(0); j < numQueued; j++ {
+			qi := queueIndex
+			// Andromeda failed queue 20397, the queue indexes of the
+			// transactions after it are shifted by one
+			if c.chainID.Uint64() == 1088 && qi >= 20397 {
+				qi++
+			}
+			enqueue := c.findEnqueue(qi)
+			if enqueue == nil {
+				return nil, fmt.Errorf("enqueue %d not found", qi)
+			}
+			ts := timestamp
+			if index <= l1ContextTimestampIndex {
+				ts = *enqueue.Timestamp
+			}
+			txs = append(txs, &transaction{
+				Index:       index,
+				BatchIndex:  ref.index,
+				BlockNumber: *enqueue.BlockNumber,
+				Timestamp:   ts,
+				Value:       (*hexutil.Big)(new(big.Int)),
+				GasLimit:    *enqueue.GasLimit,
+				Target:      *enqueue.Target,
+				Origin:      enqueue.Origin,
+				Data:        *enqueue.Data,
+				QueueOrigin: l1,
+				QueueIndex:  &qi,
+			})
+			queueIndex++
+			index++
+		}
+	}
+
+	// Restore the sequencer signatures that trail the transactions. Batches
+	// posted while signatures were rolled out may only sign the last txs.
+	var signs []string
+	for pointer < len(calldata) && len(signs) < len(sequencerTxs) {
+		raw, next, err := readLengthPrefixed(calldata, pointer)
+		if err != nil {
+			return nil, err
+		}
+		pointer = next
+		signs = append(signs, decodeSeqSign(raw))
+	}
+	offset := len(sequencerTxs) - len(signs)
+	for i, sign := range signs {
+		txs[sequencerTxs[offset+i]].SeqSign = sign
+	}
+	return txs, nil
+}
+
+// rawSequencerTransaction is the RLP layout of a transaction as it is
+// submitted to L1. Unlike `types.Transaction` it carries no L2 metadata.
+type rawSequencerTransaction struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	GasLimit uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// decodeSequencerTransaction decodes a raw RLP encoded L2 transaction
+func (c *L1Client) decodeSequencerTransaction(raw []byte) (*decoded, error) {
+	tx := new(rawSequencerTransaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, err
+	}
+	// Normalize the EIP155 v value the same way as the data transport layer
+	sigV := tx.V.Uint64()
+	if sigV != 27 && sigV != 28 {
+		sigV = sigV - 2*c.chainID.Uint64() - 35
+	}
+	return &decoded{
+		Signature: signature{
+			R: tx.R.Bytes(),
+			S: tx.S.Bytes(),
+			V: uint(sigV),
+		},
+		Value:    (*hexutil.Big)(tx.Value),
+		GasLimit: tx.GasLimit,
+		GasPrice: tx.GasPrice.Uint64(),
+		Nonce:    tx.Nonce,
+		Target:   tx.To,
+		Data:     tx.Data,
+	}, nil
+}
+
+// decodeSeqSign turns a raw sequencer signature into the `r,s,v` string format
+// used by the data transport layer
+func decodeSeqSign(raw []byte) string {
+	if len(raw) == 3 && readUint(raw) == 0 {
+		return "0x0,0x0,0x0"
+	}
+	if len(raw) < 65 {
+		return ""
+	}
+	r := new(big.Int).SetBytes(raw[0:32])
+	s := new(big.Int).SetBytes(raw[32:64])
+	v := new(big.Int).SetBytes(raw[64:])
+	return hexutil.EncodeBig(r) + "," + hexutil.EncodeBig(s) + "," + hexutil.EncodeBig(v)
+}
+
+// readLengthPrefixed reads a 3 byte length prefixed element from the calldata
+// and returns it along with the offset of the next element
+func readLengthPrefixed(calldata []byte, offset int) ([]byte, int, error) {
+	if offset+batchTxLengthSize > len(calldata) {
+		return nil, 0, fmt.Errorf("unexpected end of calldata at %d", offset)
+	}
+	size := int(readUint(calldata[offset : offset+batchTxLengthSize]))
+	start := offset + batchTxLengthSize
+	if start+size > len(calldata) {
+		return nil, 0, fmt.Errorf("element at %d exceeds calldata", offset)
+	}
+	return calldata[start : start+size], start + size, nil
+}
+
+// readUint reads a big endian encoded unsigned integer
+func readUint(b []byte) uint64 {
+	return new(big.Int).SetBytes(b).Uint64()
+}
+
+// timeoutCtx returns a context bounded by the client timeout
+func (c *L1Client) timeoutCtx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), c.timeout)
+}
+
+// GetEnqueue fetches an `enqueue` transaction by queue index
+func (c *L1Client) GetEnqueue(index uint64) (*types.Transaction, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	enqueue := c.findEnqueue(index)
+	if enqueue == nil {
+		return nil, errElementNotFound
+	}
+	return enqueueToTransaction(enqueue)
+}
+
+// GetLatestEnqueue fetches the `enqueue` transaction with the greatest queue
+// index
+func (c *L1Client) GetLatestEnqueue() (*types.Transaction, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if len(c.enqueues) == 0 {
+		return nil, errElementNotFound
+	}
+	return enqueueToTransaction(c.enqueues[len(c.enqueues)-1])
+}
+
+// GetLatestEnqueueIndex returns the latest `enqueue()` index
+func (c *L1Client) GetLatestEnqueueIndex() (*uint64, error) {
+	tx, err := c.GetLatestEnqueue()
+	if err != nil {
+		return nil, err
+	}
+	return tx.GetMeta().QueueIndex, nil
+}
+
+// GetLastConfirmedEnqueue returns the last `enqueue` transaction that has been
+// appended to the canonical transaction chain
+func (c *L1Client) GetLastConfirmedEnqueue() (*types.Transaction, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	for i := len(c.txBatches) - 1; i >= 0; i-- {
+		ref := c.txBatches[i]
+		res, err := c.transactionBatch(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		for j := len(res.Transactions) - 1; j >= 0; j-- {
+			tx := res.Transactions[j]
+			if tx.QueueOrigin != l1 {
+				continue
+			}
+			found := c.findEnqueue(*tx.QueueIndex)
+			if found == nil {
+				return nil, fmt.Errorf("enqueue %d not found", *tx.QueueIndex)
+			}
+			enqueue := *found
+			ctcIndex := tx.Index
+			enqueue.Index = &ctcIndex
+			return enqueueToTransaction(&enqueue)
+		}
+	}
+	return nil, errElementNotFound
+}
+
+// GetRawTransaction returns the batched transaction with the given canonical
+// transaction chain index
+func (c *L1Client) GetRawTransaction(index uint64, backend Backend) (*TransactionResponse, error) {
+	if backend != BackendL1 {
+		return nil, fmt.Errorf("backend %s: %w", backend, errL1ClientUnsupported)
+	}
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A sync pass refreshes the index when it fetches the latest index,
+	// only an element that is not indexed yet needs another refresh
+	ref := findBatchRefByElement(c.txBatches, index)
+	if ref == nil {
+		if err := c.refresh(ctx); err != nil {
+			return nil, err
+		}
+		ref = findBatchRefByElement(c.txBatches, index)
+	}
+	if ref == nil {
+		return nil, errElementNotFound
+	}
+	res, err := c.transactionBatch(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if index-ref.prevTotalElements >= uint64(len(res.Transactions)) {
+		return nil, fmt.Errorf("batch %d has %d transactions, expected %d", ref.index, len(res.Transactions), ref.size)
+	}
+	return &TransactionResponse{
+		Transaction: res.Transactions[index-ref.prevTotalElements],
+		Batch:       res.Batch,
+	}, nil
+}
+
+// GetTransaction returns the batched transaction with the given canonical
+// transaction chain index
+func (c *L1Client) GetTransaction(index uint64, backend Backend) (*types.Transaction, error) {
+	res, err := c.GetRawTransaction(index, backend)
+	if err != nil {
+		return nil, err
+	}
+	return batchedTransactionToTransaction(res.Transaction, c.signer)
+}
+
+// GetLatestTransaction returns the last transaction of the latest transaction
+// batch
+func (c *L1Client) GetLatestTransaction(backend Backend) (*types.Transaction, error) {
+	index, err := c.GetLatestTransactionIndex(backend)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetTransaction(*index, backend)
+}
+
+// GetLatestTransactionIndex returns the latest canonical transaction chain
+// index that has been batch submitted
+func (c *L1Client) GetLatestTransactionIndex(backend Backend) (*uint64, error) {
+	if backend != BackendL1 {
+		return nil, fmt.Errorf("backend %s: %w", backend, errL1ClientUnsupported)
+	}
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if len(c.txBatches) == 0 {
+		return nil, errElementNotFound
+	}
+	last := c.txBatches[len(c.txBatches)-1]
+	if last.prevTotalElements+last.size == 0 {
+		return nil, errElementNotFound
+	}
+	index := last.prevTotalElements + last.size - 1
+	return &index, nil
+}
+
+// GetEthContext returns the EthContext of an L1 block
+func (c *L1Client) GetEthContext(blockNumber uint64) (*EthContext, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+
+	header, err := c.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch L1 block %d: %w", blockNumber, err)
+	}
+	return &EthContext{
+		BlockNumber: header.Number.Uint64(),
+		BlockHash:   header.Hash(),
+		Timestamp:   header.Time,
+	}, nil
+}
+
+// GetLatestEthContext returns the EthContext of the L1 tip
+func (c *L1Client) GetLatestEthContext() (*EthContext, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+
+	header, err := c.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot fetch eth context: %w", err)
+	}
+	return &EthContext{
+		BlockNumber: header.Number.Uint64(),
+		BlockHash:   header.Hash(),
+		Timestamp:   header.Time,
+	}, nil
+}
+
+// GetLatestTransactionBatch returns the latest transaction batch
+func (c *L1Client) GetLatestTransactionBatch() (*Batch, []*types.Transaction, error) {
+	index, err := c.GetLatestTransactionBatchIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.GetTransactionBatch(*index)
+}
+
+// GetLatestTransactionBatchIndex returns the latest transaction batch index
+func (c *L1Client) GetLatestTransactionBatchIndex() (*uint64, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if len(c.txBatches) == 0 {
+		return nil, errElementNotFound
+	}
+	index := c.txBatches[len(c.txBatches)-1].index
+	return &index, nil
+}
+
+// GetTransactionBatch returns the transaction batch by batch index
+func (c *L1Client) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, nil, err
+	}
+	ref := findBatchRef(c.txBatches, index)
+	if ref == nil {
+		return nil, nil, errElementNotFound
+	}
+	res, err := c.transactionBatch(ctx, ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseTransactionBatchResponse(res, c.signer)
+}
+
+// SyncStatus reports the L1 client as synced, as it reads the L1 tip directly
+func (c *L1Client) SyncStatus(backend Backend) (*SyncStatus, error) {
+	index, err := c.GetLatestTransactionIndex(backend)
+	if err != nil && !errors.Is(err, errElementNotFound) {
+		return nil, fmt.Errorf("Cannot fetch sync status: %w", err)
+	}
+	status := &SyncStatus{}
+	if index != nil {
+		status.HighestKnownTransactionIndex = *index
+		status.CurrentTransactionIndex = *index
+	}
+	return status, nil
+}
+
+// SyncStatusV2 is only served by the data transport layer
+func (c *L1Client) SyncStatusV2() (*types.SyncStatus, error) {
+	return nil, errL1ClientUnsupported
+}
+
+// GetL1Origin is only served by the data transport layer
+func (c *L1Client) GetL1Origin(l2block uint64) (*types.L1BlockRef, error) {
+	return nil, errL1ClientUnsupported
+}
+
+// GetStateRoot returns the state root that was committed to the state
+// commitment chain for an L2 index. The zero hash is returned when the
+// state root has not been committed yet.
+func (c *L1Client) GetStateRoot(index uint64) (common.Hash, error) {
+	ctx, cancel := c.timeoutCtx()
+	defer cancel()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.refresh(ctx); err != nil {
+		return common.Hash{}, err
+	}
+	ref := findBatchRefByElement(c.stateBatches, index)
+	if ref == nil {
+		return common.Hash{}, nil
+	}
+	calldata, err := c.calldata(ctx, ref)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(calldata) < 4 {
+		return common.Hash{}, fmt.Errorf("Cannot decode state batch %d", ref.index)
+	}
+	method := l1ABI.Methods["appendStateBatchByChainId"]
+	args, err := method.Inputs.UnpackValues(calldata[4:])
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("Cannot decode state batch %d: %w", ref.index, err)
+	}
+	roots, ok := args[1].([][32]byte)
+	if !ok || uint64(len(roots)) != ref.size || index-ref.prevTotalElements >= uint64(len(roots)) {
+		return common.Hash{}, fmt.Errorf("Unexpected state roots in batch %d", ref.index)
+	}
+	return roots[index-ref.prevTotalElements], nil
+}
+
+// SetLastVerifier is a no-op as there is no data transport layer to report to
+func (c *L1Client) SetLastVerifier(index uint64, stateRoot string, verifierRoot string, success bool) error {
+	log.Debug("Skipping verifier report to L1", "index", index, "success", success)
+	return nil
+}
+
+// GetRawBlock is not supported as inbox batches are not contract events
+func (c *L1Client) GetRawBlock(index uint64, backend Backend) (*BlockResponse, error) {
+	return nil, errL1ClientUnsupported
+}
+
+// GetBlock is not supported as inbox batches are not contract events
+func (c *L1Client) GetBlock(index uint64, backend Backend) (*types.Block, error) {
+	return nil, errL1ClientUnsupported
+}
+
+// GetLatestBlock is not supported as inbox batches are not contract events
+func (c *L1Client) GetLatestBlock(backend Backend) (*types.Block, error) {
+	return nil, errL1ClientUnsupported
+}
+
+// GetLatestBlockIndex is not supported as inbox batches are not contract events
+func (c *L1Client) GetLatestBlockIndex(backend Backend) (*uint64, error) {
+	return nil, errL1ClientUnsupported
+}
+
+// GetLatestBlockBatch is not supported as inbox batches are not contract events
+func (c *L1Client) GetLatestBlockBatch() (*Batch, []*types.Block, error) {
+	return nil, nil, errL1ClientUnsupported
+}
+
+// GetLatestBlockBatchIndex is not supported as inbox batches are not contract
+// events
+func (c *L1Client) GetLatestBlockBatchIndex() (*uint64, error) {
+	return nil, errL1ClientUnsupported
+}
+
+// GetBlockBatch is not supported as inbox batches are not contract events
+func (c *L1Client) GetBlockBatch(index uint64) (*Batch, []*types.Block, error) {
+	return nil, nil, errL1ClientUnsupported
+}
@@ -0,0 +1,485 @@
+package rollup
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+
+	ethereum "github.com/ethereum-optimism/optimism/l2geth"
+	"github.com/ethereum-optimism/optimism/l2geth/accounts/abi/bind/backends"
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+// l1ProxyCode is the runtime code of a contract that stores an implementation
+// address when called with the 0xdeadbeef selector and delegatecalls it
+// otherwise. Logs emitted by the implementation are attributed to the proxy,
+// which lets a test emit arbitrary rollup events from a fixed address while
+// the calldata of the transaction stays under the control of the test.
+var l1ProxyCode = common.FromHex("0x600035" + "60e01c" + "63deadbeef14" + "602257" +
+	"3660006000" + "37" + "60006000366000600054" + "5af400" + "5b600435600055" + "00")
+
+// l1Event is a log that is emitted by the test implementation contract
+type l1Event struct {
+	topics []common.Hash
+	data   []byte
+}
+
+// emitterCode returns the init code of a contract that emits the given
+// events whenever it is called
+func emitterCode(events []l1Event) []byte {
+	var runtime []byte
+	for _, ev := range events {
+		for offset := 0; offset < len(ev.data); offset += 32 {
+			word := make([]byte, 32)
+			copy(word, ev.data[offset:])
+			runtime = append(runtime, 0x7f)
+			runtime = append(runtime, word...)
+			runtime = append(runtime, 0x61, byte(offset>>8), byte(offset), 0x52)
+		}
+		for i := len(ev.topics) - 1; i >= 0; i-- {
+			runtime = append(runtime, 0x7f)
+			runtime = append(runtime, ev.topics[i].Bytes()...)
+		}
+		runtime = append(runtime, 0x61, byte(len(ev.data)>>8), byte(len(ev.data)), 0x60, 0x00, byte(0xa0+len(ev.topics)))
+	}
+	runtime = append(runtime, 0x00)
+	init := []byte{0x61, byte(len(runtime) >> 8), byte(len(runtime)), 0x80, 0x61, 0x00, 0x0d, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3}
+	return append(init, runtime...)
+}
+
+type l1Harness struct {
+	t       *testing.T
+	backend *backends.SimulatedBackend
+	key     *ecdsa.PrivateKey
+	nonce   uint64
+	ctc     common.Address
+	scc     common.Address
+}
+
+func newL1Harness(t *testing.T) *l1Harness {
+	key, _ := crypto.GenerateKey()
+	ctc := common.HexToAddress("0x00000000000000000000000000000000000c7c00")
+	scc := common.HexToAddress("0x00000000000000000000000000000000000bcc00")
+	alloc := core.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1e18)},
+		ctc:                                   {Code: l1ProxyCode, Balance: common.Big0},
+		scc:                                   {Code: l1ProxyCode, Balance: common.Big0},
+	}
+	return &l1Harness{
+		t:       t,
+		backend: backends.NewSimulatedBackend(alloc, 10000000),
+		key:     key,
+		ctc:     ctc,
+		scc:     scc,
+	}
+}
+
+func (h *l1Harness) send(to *common.Address, data []byte) {
+	var tx *types.Transaction
+	if to == nil {
+		tx = types.NewContractCreation(h.nonce, common.Big0, 5000000, common.Big0, data)
+	} else {
+		tx = types.NewTransaction(h.nonce, *to, common.Big0, 5000000, common.Big0, data)
+	}
+	tx, err := types.SignTx(tx, types.HomesteadSigner{}, h.key)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	if err := h.backend.SendTransaction(context.Background(), tx); err != nil {
+		h.t.Fatal(err)
+	}
+	h.nonce++
+	h.backend.Commit()
+}
+
+// emit sends calldata to a rollup contract and makes it emit the events
+func (h *l1Harness) emit(contract common.Address, calldata []byte, events ...l1Event) {
+	impl := crypto.CreateAddress(crypto.PubkeyToAddress(h.key.PublicKey), h.nonce)
+	h.send(nil, emitterCode(events))
+	h.send(&contract, append(common.FromHex("0xdeadbeef"), common.LeftPadBytes(impl.Bytes(), 32)...))
+	h.send(&contract, calldata)
+}
+
+func (h *l1Harness) event(name string, topics []common.Hash, args ...interface{}) l1Event {
+	ev := l1ABI.Events[name]
+	data, err := ev.Inputs.NonIndexed().Pack(args...)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return l1Event{topics: append([]common.Hash{ev.ID()}, topics...), data: data}
+}
+
+func (h *l1Harness) enqueue(chainID *big.Int, queueIndex uint64, origin, target common.Address, data []byte) {
+	h.emit(h.ctc, []byte{0x01}, h.event(
+		"TransactionEnqueued",
+		[]common.Hash{common.BytesToHash(origin.Bytes()), common.BytesToHash(target.Bytes()), common.BigToHash(new(big.Int).SetUint64(queueIndex))},
+		chainID, big.NewInt(1000000), data, big.NewInt(1600000000),
+	))
+}
+
+func uintBytes(v uint64, size int) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b[8-size:]
+}
+
+func TestL1ClientDerivesRollupData(t *testing.T) {
+	h := newL1Harness(t)
+	defer h.backend.Close()
+
+	chainID := big.NewInt(420)
+	origin := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	target := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	// Enqueues for this and for another L2 chain
+	h.enqueue(chainID, 0, origin, target, []byte{0xaa})
+	h.enqueue(big.NewInt(421), 0, origin, target, []byte{0xbb})
+	h.enqueue(chainID, 1, origin, target, []byte{0xcc})
+
+	// A signed sequencer transaction followed by the first enqueue
+	userKey, _ := crypto.GenerateKey()
+	l2Signer := types.NewEIP155Signer(chainID)
+	l2Tx, _ := types.SignTx(types.NewTransaction(3, target, big.NewInt(7), 21000, big.NewInt(1), nil), l2Signer, userKey)
+	v, r, s := l2Tx.RawSignatureValues()
+	raw, _ := rlp.EncodeToBytes(&rawSequencerTransaction{
+		Nonce:    l2Tx.Nonce(),
+		GasPrice: l2Tx.GasPrice(),
+		GasLimit: l2Tx.Gas(),
+		To:       l2Tx.To(),
+		Value:    l2Tx.Value(),
+		Data:     l2Tx.Data(),
+		V:        v,
+		R:        r,
+		S:        s,
+	})
+	seqKey, _ := crypto.GenerateKey()
+	seqSig, _ := crypto.Sign(l2Tx.Hash().Bytes(), seqKey)
+
+	calldata := crypto.Keccak256([]byte("appendSequencerBatchByChainId()"))[:4]
+	calldata = append(calldata, common.BigToHash(chainID).Bytes()...)
+	calldata = append(calldata, uintBytes(0, 5)...)
+	calldata = append(calldata, uintBytes(2, 3)...)
+	calldata = append(calldata, uintBytes(1, 3)...)
+	calldata = append(calldata, uintBytes(1, 3)...)
+	calldata = append(calldata, uintBytes(1, 3)...)
+	calldata = append(calldata, uintBytes(1700000000, 5)...)
+	calldata = append(calldata, uintBytes(5, 5)...)
+	calldata = append(calldata, uintBytes(uint64(len(raw)), 3)...)
+	calldata = append(calldata, raw...)
+	calldata = append(calldata, uintBytes(uint64(len(seqSig)), 3)...)
+	calldata = append(calldata, seqSig...)
+
+	batchRoot := common.HexToHash("0x1234")
+	h.emit(h.ctc, calldata,
+		h.event("TransactionBatchAppended", []common.Hash{common.BigToHash(common.Big0)},
+			chainID, batchRoot, big.NewInt(2), big.NewInt(0), []byte{}),
+		h.event("SequencerBatchAppended", nil,
+			chainID, big.NewInt(0), big.NewInt(1), big.NewInt(2)),
+	)
+
+	roots := [][32]byte{common.HexToHash("0x01"), common.HexToHash("0x02")}
+	method := l1ABI.Methods["appendStateBatchByChainId"]
+	args, err := method.Inputs.Pack(chainID, roots, big.NewInt(0), "proposer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.emit(h.scc, append(method.ID(), args...),
+		h.event("StateBatchAppended", []common.Hash{common.BigToHash(common.Big0)},
+			chainID, common.HexToHash("0x5678"), big.NewInt(2), big.NewInt(0), []byte{}),
+	)
+
+	client := NewL1Client(h.backend, h.ctc, h.scc, big.NewInt(1), chainID, 0)
+
+	queueIndex, err := client.GetLatestEnqueueIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *queueIndex != 1 {
+		t.Fatalf("unexpected latest queue index: %d", *queueIndex)
+	}
+	enqueue, err := client.GetEnqueue(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *enqueue.To() != target || enqueue.Data()[0] != 0xcc || *enqueue.L1MessageSender() != origin {
+		t.Fatal("unexpected enqueue")
+	}
+
+	batchIndex, err := client.GetLatestTransactionBatchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *batchIndex != 0 {
+		t.Fatalf("unexpected latest batch index: %d", *batchIndex)
+	}
+	batch, txs, err := client.GetTransactionBatch(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Root != batchRoot || batch.Size != 2 {
+		t.Fatal("unexpected batch")
+	}
+	if len(txs) != 2 {
+		t.Fatalf("unexpected number of transactions: %d", len(txs))
+	}
+	if txs[0].Hash() != l2Tx.Hash() {
+		t.Fatal("sequencer transaction not restored")
+	}
+	if txs[0].L1Timestamp() != 1700000000 || txs[0].L1BlockNumber().Uint64() != 5 {
+		t.Fatal("unexpected batch context")
+	}
+	seqAddr, err := core.RecoverSeqAddress(txs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seqAddr != crypto.PubkeyToAddress(seqKey.PublicKey) {
+		t.Fatal("sequencer signature not restored")
+	}
+	if txs[1].QueueOrigin() != types.QueueOriginL1ToL2 || *txs[1].GetMeta().QueueIndex != 0 || txs[1].Data()[0] != 0xaa {
+		t.Fatal("unexpected queue transaction")
+	}
+
+	index, err := client.GetLatestTransactionIndex(BackendL1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *index != 1 {
+		t.Fatalf("unexpected latest index: %d", *index)
+	}
+	if _, err := client.GetLatestTransactionIndex(BackendL2); !errors.Is(err, errL1ClientUnsupported) {
+		t.Fatalf("unexpected error for BackendL2: %v", err)
+	}
+
+	confirmed, err := client.GetLastConfirmedEnqueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *confirmed.GetMeta().QueueIndex != 0 || *confirmed.GetMeta().Index != 1 {
+		t.Fatal("unexpected last confirmed enqueue")
+	}
+
+	root, err := client.GetStateRoot(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != common.Hash(roots[1]) {
+		t.Fatalf("unexpected state root: %s", root.Hex())
+	}
+	root, err = client.GetStateRoot(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != (common.Hash{}) {
+		t.Fatal("expected empty state root")
+	}
+
+	if _, _, err := client.GetBlockBatch(0); !errors.Is(err, errL1ClientUnsupported) {
+		t.Fatalf("unexpected error for block batches: %v", err)
+	}
+}
+
+func TestL1ClientConfirmations(t *testing.T) {
+	h := newL1Harness(t)
+	defer h.backend.Close()
+
+	chainID := big.NewInt(420)
+	origin := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	target := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	h.enqueue(chainID, 0, origin, target, []byte{0xaa})
+
+	// The enqueue is in the tip, it is only read once it is confirmed
+	client := NewL1Client(h.backend, h.ctc, h.scc, big.NewInt(1), chainID, 2)
+	if _, err := client.GetEnqueue(0); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("unconfirmed enqueue read: %v", err)
+	}
+	h.backend.Commit()
+	h.backend.Commit()
+	if _, err := client.GetEnqueue(0); err != nil {
+		t.Fatalf("confirmed enqueue not read: %v", err)
+	}
+}
+
+func TestL1ClientReorg(t *testing.T) {
+	chainID := big.NewInt(420)
+	origin := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	target := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	h := newL1Harness(t)
+	defer h.backend.Close()
+	h.enqueue(chainID, 0, origin, target, []byte{0xaa})
+	h.enqueue(chainID, 1, origin, target, []byte{0xbb})
+
+	client := NewL1Client(h.backend, h.ctc, h.scc, big.NewInt(1), chainID, 0)
+	queueIndex, err := client.GetLatestEnqueueIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *queueIndex != 1 {
+		t.Fatalf("unexpected latest queue index: %d", *queueIndex)
+	}
+
+	// L1 reorgs to a longer chain that only has the first enqueue
+	reorged := newL1Harness(t)
+	defer reorged.backend.Close()
+	reorged.enqueue(chainID, 0, origin, target, []byte{0xcc})
+	for i := 0; i < 5; i++ {
+		reorged.backend.Commit()
+	}
+	client.backend = reorged.backend
+
+	queueIndex, err = client.GetLatestEnqueueIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *queueIndex != 0 {
+		t.Fatalf("reorged enqueue not dropped, latest queue index: %d", *queueIndex)
+	}
+	enqueue, err := client.GetEnqueue(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enqueue.Data()[0] != 0xcc {
+		t.Fatal("enqueue of the reorged chain not read")
+	}
+	if _, err := client.GetEnqueue(1); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("unexpected error for the reorged enqueue: %v", err)
+	}
+}
+
+func TestL1ClientShortBatch(t *testing.T) {
+	h := newL1Harness(t)
+	defer h.backend.Close()
+
+	// The batch claims 2 elements, the calldata only has 1
+	chainID := big.NewInt(420)
+	calldata := crypto.Keccak256([]byte("appendSequencerBatchByChainId()"))[:4]
+	calldata = append(calldata, common.BigToHash(chainID).Bytes()...)
+	calldata = append(calldata, uintBytes(0, 5)...)
+	calldata = append(calldata, uintBytes(2, 3)...)
+	calldata = append(calldata, uintBytes(1, 3)...)
+	calldata = append(calldata, uintBytes(1, 3)...)
+	calldata = append(calldata, uintBytes(0, 3)...)
+	calldata = append(calldata, uintBytes(1700000000, 5)...)
+	calldata = append(calldata, uintBytes(5, 5)...)
+	raw, _ := rlp.EncodeToBytes(&rawSequencerTransaction{
+		GasPrice: common.Big0,
+		Value:    common.Big0,
+		V:        big.NewInt(27),
+		R:        common.Big1,
+		S:        common.Big1,
+	})
+	calldata = append(calldata, uintBytes(uint64(len(raw)), 3)...)
+	calldata = append(calldata, raw...)
+	h.emit(h.ctc, calldata,
+		h.event("TransactionBatchAppended", []common.Hash{common.BigToHash(common.Big0)},
+			chainID, common.HexToHash("0x1234"), big.NewInt(2), big.NewInt(0), []byte{}),
+		h.event("SequencerBatchAppended", nil,
+			chainID, big.NewInt(0), big.NewInt(0), big.NewInt(2)),
+	)
+
+	client := NewL1Client(h.backend, h.ctc, h.scc, big.NewInt(1), chainID, 0)
+	if _, err := client.GetRawTransaction(0, BackendL1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetRawTransaction(1, BackendL1); err == nil {
+		t.Fatal("expected an error for the missing transaction")
+	}
+}
+
+// countingL1Backend counts the calls made to an L1 backend
+type countingL1Backend struct {
+	L1Backend
+	headers, logs, txs int
+}
+
+func (b *countingL1Backend) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	b.logs++
+	return b.L1Backend.FilterLogs(ctx, q)
+}
+
+func (b *countingL1Backend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.headers++
+	return b.L1Backend.HeaderByNumber(ctx, number)
+}
+
+func (b *countingL1Backend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	b.txs++
+	return b.L1Backend.TransactionByHash(ctx, hash)
+}
+
+func TestL1ClientBatchCache(t *testing.T) {
+	h := newL1Harness(t)
+	defer h.backend.Close()
+
+	chainID := big.NewInt(420)
+	origin := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	target := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	for i := uint64(0); i < 3; i++ {
+		h.enqueue(chainID, i, origin, target, []byte{byte(i)})
+	}
+	// A batch of the three enqueues
+	calldata := crypto.Keccak256([]byte("appendSequencerBatchByChainId()"))[:4]
+	calldata = append(calldata, common.BigToHash(chainID).Bytes()...)
+	calldata = append(calldata, uintBytes(0, 5)...)
+	calldata = append(calldata, uintBytes(3, 3)...)
+	calldata = append(calldata, uintBytes(1, 3)...)
+	calldata = append(calldata, uintBytes(0, 3)...)
+	calldata = append(calldata, uintBytes(3, 3)...)
+	calldata = append(calldata, uintBytes(1700000000, 5)...)
+	calldata = append(calldata, uintBytes(5, 5)...)
+	h.emit(h.ctc, calldata,
+		h.event("TransactionBatchAppended", []common.Hash{common.BigToHash(common.Big0)},
+			chainID, common.HexToHash("0x1234"), big.NewInt(3), big.NewInt(0), []byte{}),
+		h.event("SequencerBatchAppended", nil,
+			chainID, big.NewInt(0), big.NewInt(3), big.NewInt(3)),
+	)
+
+	backend := &countingL1Backend{L1Backend: h.backend}
+	client := NewL1Client(backend, h.ctc, h.scc, big.NewInt(1), chainID, 0)
+	index, err := client.GetLatestTransactionIndex(BackendL1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *index != 2 {
+		t.Fatalf("unexpected latest index: %d", *index)
+	}
+
+	// The pass reads the elements of the batch without refreshing the
+	// index, the batch is fetched and decoded once
+	headers, logs := backend.headers, backend.logs
+	for i := uint64(0); i <= *index; i++ {
+		tx, err := client.GetTransaction(i, BackendL1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *tx.GetMeta().QueueIndex != i || tx.Data()[0] != byte(i) {
+			t.Fatalf("unexpected transaction %d", i)
+		}
+	}
+	if backend.logs != logs || backend.headers != headers+1 || backend.txs != 1 {
+		t.Fatalf("unexpected L1 calls: %d headers, %d log filters, %d transactions",
+			backend.headers-headers, backend.logs-logs, backend.txs)
+	}
+
+	// A rewind drops the decoded batch
+	client.mu.Lock()
+	client.rewind(client.start, common.Hash{})
+	cached := len(client.batchCache)
+	client.mu.Unlock()
+	if cached != 0 {
+		t.Fatalf("decoded batch kept after a rewind: %d", cached)
+	}
+	if _, err := client.GetTransaction(1, BackendL1); err != nil {
+		t.Fatal(err)
+	}
+	if backend.txs != 2 {
+		t.Fatalf("batch not fetched again after a rewind: %d", backend.txs)
+	}
+}
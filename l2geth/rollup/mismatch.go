@@ -0,0 +1,150 @@
+package rollup
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+)
+
+// errStateRootMismatch is returned when the state root computed locally does
+// not match the state root posted to L1
+var errStateRootMismatch = errors.New("state root mismatch")
+
+// maxMismatchRewinds is the number of times the verifier rewinds and retries
+// the same index before it halts
+const maxMismatchRewinds = 3
+
+// MismatchPolicy decides what the verifier does when a state root does not
+// match the one posted to L1
+type MismatchPolicy uint
+
+const (
+	// MismatchContinue records the mismatch and keeps syncing
+	MismatchContinue MismatchPolicy = iota
+	// MismatchHalt records the mismatch and stops syncing batches
+	MismatchHalt
+	// MismatchRewind records the mismatch, rewinds the chain to before the
+	// transaction and executes it again
+	MismatchRewind
+)
+
+func (p MismatchPolicy) String() string {
+	switch p {
+	case MismatchContinue:
+		return "continue"
+	case MismatchHalt:
+		return "halt"
+	case MismatchRewind:
+		return "rewind"
+	default:
+		return ""
+	}
+}
+
+// NewMismatchPolicy parses a MismatchPolicy, the empty string is
+// MismatchContinue
+func NewMismatchPolicy(policy string) (MismatchPolicy, error) {
+	switch policy {
+	case "", "continue":
+		return MismatchContinue, nil
+	case "halt":
+		return MismatchHalt, nil
+	case "rewind":
+		return MismatchRewind, nil
+	default:
+		return 0, fmt.Errorf("unknown state root mismatch policy: %s", policy)
+	}
+}
+
+// checkStateRoot verifies the state root after a batched transaction was
+// applied and reports the result to the data transport layer. Mismatches are
+// recorded in the database and handled according to the mismatch policy.
+func (s *SyncService) checkStateRoot(tx *types.Transaction, batch *Batch) error {
+	txIndex, stateRoot, verifierRoot, err := s.verifyStateRoot(tx, batch.Root)
+	if err == nil {
+		// report to dtl success=true
+		s.client.SetLastVerifier(txIndex, stateRoot, verifierRoot, true)
+		// The indexes up to this one are settled, forget their rewinds
+		for index := range s.mismatchRewinds {
+			if index <= txIndex {
+				delete(s.mismatchRewinds, index)
+			}
+		}
+		return nil
+	}
+	// report to dtl success=false
+	s.client.SetLastVerifier(txIndex, stateRoot, verifierRoot, false)
+	if !errors.Is(err, errStateRootMismatch) {
+		return err
+	}
+
+	mismatch := &types.StateRootMismatch{
+		Index:      txIndex,
+		LocalRoot:  common.HexToHash(verifierRoot),
+		RemoteRoot: common.HexToHash(stateRoot),
+		BatchIndex: batch.Index,
+		BatchRoot:  batch.Root,
+		Timestamp:  uint64(time.Now().Unix()),
+	}
+	rawdb.WriteStateRootMismatch(s.db, mismatch)
+	log.Error("State root mismatch", "index", txIndex, "local", verifierRoot, "remote", stateRoot, "batch-index", batch.Index, "batch-root", batch.Root.Hex(), "policy", s.mismatchPolicy)
+
+	switch s.mismatchPolicy {
+	case MismatchHalt:
+		s.halted.Store(mismatch)
+		return err
+	case MismatchRewind:
+		s.mismatchRewinds[txIndex]++
+		if s.mismatchRewinds[txIndex] > maxMismatchRewinds {
+			log.Error("State root still mismatches after rewinding, halting", "index", txIndex, "rewinds", maxMismatchRewinds)
+			s.halted.Store(mismatch)
+			return err
+		}
+		// The block of the transaction is index + 1, rewind to its parent
+		// and fetch the batch again
+		if txIndex == 0 {
+			return fmt.Errorf("%w: %s", errReorgGenesis, err)
+		}
+		if rerr := s.rewind(txIndex, batch.Index); rerr != nil {
+			return fmt.Errorf("Cannot rewind after state root mismatch: %w", rerr)
+		}
+		return err
+	default:
+		return nil
+	}
+}
+
+// restoreHalt halts the verifier again after a restart when it had halted on
+// a mismatch. A halted verifier keeps the transaction that mismatched at the
+// head, so a mismatch recorded at the index of the head is the one it halted
+// on. The halt is lifted by switching to the continue policy.
+func (s *SyncService) restoreHalt() {
+	if s.mismatchPolicy == MismatchContinue {
+		return
+	}
+	index := s.GetLatestIndex()
+	if index == nil {
+		return
+	}
+	if mismatch := rawdb.ReadStateRootMismatch(s.db, *index); mismatch != nil {
+		log.Error("Verifier halted on state root mismatch before the restart", "index", mismatch.Index, "local", mismatch.LocalRoot.Hex(), "remote", mismatch.RemoteRoot.Hex(), "batch-index", mismatch.BatchIndex)
+		s.halted.Store(mismatch)
+	}
+}
+
+// haltedAt returns the mismatch the verifier halted on, if any
+func (s *SyncService) haltedAt() *types.StateRootMismatch {
+	mismatch, _ := s.halted.Load().(*types.StateRootMismatch)
+	return mismatch
+}
+
+// StateRootMismatches returns up to limit recorded state root mismatches
+// starting at the given index
+func (s *SyncService) StateRootMismatches(start uint64, limit int) []*types.StateRootMismatch {
+	return rawdb.ReadStateRootMismatches(s.db, start, limit)
+}
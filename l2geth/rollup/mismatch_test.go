@@ -0,0 +1,131 @@
+package rollup
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+)
+
+// stateRootClient is a RollupClient that serves a fixed state root
+type stateRootClient struct {
+	RollupClient
+	root     common.Hash
+	verified []bool
+}
+
+func (c *stateRootClient) GetStateRoot(index uint64) (common.Hash, error) {
+	return c.root, nil
+}
+
+func (c *stateRootClient) SetLastVerifier(index uint64, stateRoot string, verifierRoot string, success bool) error {
+	c.verified = append(c.verified, success)
+	return nil
+}
+
+func TestMismatchPolicy(t *testing.T) {
+	for _, policy := range []string{"", "continue", "halt", "rewind"} {
+		if _, err := NewMismatchPolicy(policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := NewMismatchPolicy("stop"); err == nil {
+		t.Fatal("unknown policy accepted")
+	}
+}
+
+func TestCheckStateRootMismatch(t *testing.T) {
+	service, posted := newReorgTestService(t, 4)
+	client := &stateRootClient{root: common.Hash{1}}
+	service.client = client
+	service.mismatchRewinds = make(map[uint64]int)
+	batch := &Batch{Index: 0, Root: common.Hash{2}}
+	tx := posted[3]
+
+	// Continue records the mismatch and goes on
+	if err := service.checkStateRoot(tx, batch); err != nil {
+		t.Fatal(err)
+	}
+	mismatches := service.StateRootMismatches(0, 10)
+	if len(mismatches) != 1 {
+		t.Fatalf("unexpected mismatches: %d", len(mismatches))
+	}
+	want := types.StateRootMismatch{
+		Index:      3,
+		LocalRoot:  service.bc.CurrentBlock().Root(),
+		RemoteRoot: client.root,
+		BatchIndex: 0,
+		BatchRoot:  batch.Root,
+		Timestamp:  mismatches[0].Timestamp,
+	}
+	if *mismatches[0] != want {
+		t.Fatalf("unexpected mismatch: have %+v, want %+v", mismatches[0], want)
+	}
+
+	// Rewind resets the chain to before the transaction
+	service.mismatchPolicy = MismatchRewind
+	if err := service.checkStateRoot(tx, batch); !errors.Is(err, errStateRootMismatch) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if service.bc.CurrentBlock().NumberU64() != 3 {
+		t.Fatal("chain not rewound")
+	}
+	if service.haltedAt() != nil {
+		t.Fatal("halted after rewinding")
+	}
+
+	// Halt stops the verifier
+	service.mismatchPolicy = MismatchHalt
+	if err := service.checkStateRoot(tx, batch); !errors.Is(err, errStateRootMismatch) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mismatch := service.haltedAt(); mismatch == nil || mismatch.Index != 3 {
+		t.Fatal("verifier not halted")
+	}
+	for _, ok := range client.verified {
+		if ok {
+			t.Fatal("mismatch reported as verified")
+		}
+	}
+}
+
+func TestMismatchRewindsPruned(t *testing.T) {
+	service, posted := newReorgTestService(t, 4)
+	service.client = &stateRootClient{root: service.bc.CurrentBlock().Root()}
+	service.mismatchRewinds = map[uint64]int{1: 1, 3: 2, 5: 1}
+
+	// Verifying an index forgets the rewinds up to it
+	if err := service.checkStateRoot(posted[3], &Batch{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(service.mismatchRewinds) != 1 || service.mismatchRewinds[5] != 1 {
+		t.Fatalf("unexpected rewinds: %v", service.mismatchRewinds)
+	}
+}
+
+func TestRestoreHalt(t *testing.T) {
+	service, _ := newReorgTestService(t, 4)
+	service.mismatchPolicy = MismatchHalt
+
+	// A mismatch before the head was passed
+	rawdb.WriteStateRootMismatch(service.db, &types.StateRootMismatch{Index: 2})
+	service.restoreHalt()
+	if service.haltedAt() != nil {
+		t.Fatal("halted on a mismatch before the head")
+	}
+
+	// The verifier halted with the mismatching transaction at the head
+	rawdb.WriteStateRootMismatch(service.db, &types.StateRootMismatch{Index: 3})
+	service.mismatchPolicy = MismatchContinue
+	service.restoreHalt()
+	if service.haltedAt() != nil {
+		t.Fatal("halted with the continue policy")
+	}
+	service.mismatchPolicy = MismatchHalt
+	service.restoreHalt()
+	if mismatch := service.haltedAt(); mismatch == nil || mismatch.Index != 3 {
+		t.Fatal("halt not restored")
+	}
+}
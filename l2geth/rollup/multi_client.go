@@ -0,0 +1,656 @@
+package rollup
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+)
+
+// errNoEndpoints is returned when a MultiClient is created without endpoints
+var errNoEndpoints = errors.New("no rollup client endpoints")
+
+const (
+	// Names of the cross checks, each endpoint tracks its lag per check
+	checkBatchIndex   = "batch-index"
+	checkEnqueueIndex = "enqueue-index"
+)
+
+// MultiClientConfig configures the retries and failover of a MultiClient
+type MultiClientConfig struct {
+	// Timeout of a single request against an endpoint
+	Timeout time.Duration
+	// Number of retries of a request before giving up
+	MaxRetries int
+	// Backoff after the first failure, doubled with every consecutive
+	// failure up to BackoffMax. A random jitter of up to the backoff itself
+	// is added.
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// Number of elements an endpoint may be behind the index that most
+	// endpoints reach before it is skipped
+	MaxLag uint64
+}
+
+// DefaultMultiClientConfig is used for the data transport layer endpoints
+// when nothing else is configured
+var DefaultMultiClientConfig = MultiClientConfig{
+	Timeout:     10 * time.Second,
+	MaxRetries:  3,
+	BackoffBase: 250 * time.Millisecond,
+	BackoffMax:  30 * time.Second,
+	MaxLag:      2,
+}
+
+// endpoint is a single replica of the data transport layer along with its
+// health
+type endpoint struct {
+	name   string
+	client RollupClient
+
+	failures uint
+	retryAt  time.Time
+	// Latest indices reported by the endpoint and whether the endpoint is
+	// lagging behind the others, per cross check
+	latest  map[string]uint64
+	lagging map[string]bool
+}
+
+// usable returns true when the endpoint is neither backing off nor lagging
+func (e *endpoint) usable(now time.Time) bool {
+	if now.Before(e.retryAt) {
+		return false
+	}
+	for _, lagging := range e.lagging {
+		if lagging {
+			return false
+		}
+	}
+	return true
+}
+
+// MultiClient is a RollupClient that spreads requests over several replicas
+// of the data transport layer. Requests are sent to the first usable
+// endpoint and fail over to the next one on error. Endpoints that fail are
+// backed off exponentially and endpoints that lag behind or report
+// inconsistent data are skipped.
+type MultiClient struct {
+	cfg       MultiClientConfig
+	endpoints []*endpoint
+	mu        sync.Mutex
+	// Index of the endpoint that served the last successful request, it is
+	// preferred for the next request
+	active int
+}
+
+// NewMultiClient creates a MultiClient from a comma separated list of
+// remote HTTP urls and a chain id
+func NewMultiClient(urls string, chainID *big.Int, cfg MultiClientConfig) (*MultiClient, error) {
+	var names []string
+	var clients []RollupClient
+	for _, url := range strings.Split(urls, ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		client := NewClient(url, chainID)
+		if cfg.Timeout != 0 {
+			client.client.SetTimeout(cfg.Timeout)
+		}
+		names = append(names, url)
+		clients = append(clients, client)
+	}
+	return newMultiClient(names, clients, cfg)
+}
+
+func newMultiClient(names []string, clients []RollupClient, cfg MultiClientConfig) (*MultiClient, error) {
+	if len(clients) == 0 {
+		return nil, errNoEndpoints
+	}
+	endpoints := make([]*endpoint, len(clients))
+	for i, client := range clients {
+		endpoints[i] = &endpoint{
+			name:    names[i],
+			client:  client,
+			latest:  make(map[string]uint64),
+			lagging: make(map[string]bool),
+		}
+	}
+	return &MultiClient{
+		cfg:       cfg,
+		endpoints: endpoints,
+	}, nil
+}
+
+// backoff returns the exponential backoff with jitter for the given number
+// of consecutive failures
+func (c *MultiClient) backoff(failures uint) time.Duration {
+	if c.cfg.BackoffBase <= 0 || failures == 0 {
+		return 0
+	}
+	delay := c.cfg.BackoffBase
+	for i := uint(1); i < failures && delay < c.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	if c.cfg.BackoffMax > 0 && delay > c.cfg.BackoffMax {
+		delay = c.cfg.BackoffMax
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)+1))
+}
+
+// pick returns the endpoint to send the next request to. The active
+// endpoint is preferred, then the first usable endpoint in configured order.
+// When no endpoint is usable, the one that is closest to the end of its
+// backoff is used so that requests are never refused outright.
+func (c *MultiClient) pick(exclude map[int]bool) (int, *endpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if !exclude[c.active] && c.endpoints[c.active].usable(now) {
+		return c.active, c.endpoints[c.active]
+	}
+	for i, e := range c.endpoints {
+		if !exclude[i] && e.usable(now) {
+			return i, e
+		}
+	}
+	best := -1
+	for i, e := range c.endpoints {
+		if exclude[i] && len(exclude) < len(c.endpoints) {
+			continue
+		}
+		if best == -1 || e.retryAt.Before(c.endpoints[best].retryAt) {
+			best = i
+		}
+	}
+	return best, c.endpoints[best]
+}
+
+// markSuccess resets the failure count of an endpoint and makes it active
+func (c *MultiClient) markSuccess(i int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.endpoints[i]
+	if e.failures != 0 {
+		log.Info("Rollup client endpoint recovered", "endpoint", e.name, "failures", e.failures)
+	}
+	e.failures = 0
+	e.retryAt = time.Time{}
+	c.active = i
+}
+
+// markFailure backs off an endpoint after a failed request
+func (c *MultiClient) markFailure(i int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := c.endpoints[i]
+	e.failures++
+	backoff := c.backoff(e.failures)
+	e.retryAt = time.Now().Add(backoff)
+	log.Warn("Rollup client endpoint failed", "endpoint", e.name, "failures", e.failures, "backoff", backoff, "msg", err)
+}
+
+// behind returns true when an endpoint that was not tried yet reported a
+// higher index than the given endpoint in any cross check
+func (c *MultiClient) behind(i int, tried map[int]bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for check, index := range c.endpoints[i].latest {
+		for j, e := range c.endpoints {
+			if latest, ok := e.latest[check]; ok && !tried[j] && latest > index {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// call runs a request against the endpoints until it succeeds or the
+// retries are exhausted. A missing element is a valid answer and is not
+// retried, unless another endpoint is further ahead and may have it.
+func (c *MultiClient) call(name string, fn func(RollupClient) error) error {
+	var err, notFound error
+	tried := make(map[int]bool)
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if len(tried) == len(c.endpoints) {
+			if notFound != nil {
+				return notFound
+			}
+			// Every endpoint has failed in this round, wait before starting
+			// over
+			time.Sleep(c.backoff(uint(attempt)))
+			tried = make(map[int]bool)
+		}
+		i, e := c.pick(tried)
+		tried[i] = true
+
+		err = fn(e.client)
+		if errors.Is(err, errElementNotFound) && c.behind(i, tried) {
+			log.Debug("Element not found on lagging rollup client endpoint", "endpoint", e.name, "request", name)
+			notFound = err
+			continue
+		}
+		if err == nil || errors.Is(err, errElementNotFound) {
+			c.markSuccess(i)
+			return err
+		}
+		c.markFailure(i, err)
+	}
+	if notFound != nil {
+		return notFound
+	}
+	return fmt.Errorf("%s failed after %d attempts: %w", name, c.cfg.MaxRetries+1, err)
+}
+
+// crossCheck fetches an index from every endpoint that is not backing off
+// and returns the highest index that a majority of the responding endpoints
+// reach, so that a single endpoint reporting an inflated or foreign index
+// cannot take over. Endpoints that are more than MaxLag behind that index
+// are skipped until they catch up and endpoints whose index moves backwards
+// are backed off. The element at the highest index that all endpoints that
+// keep up have is compared with hashFn, endpoints that disagree with the
+// majority are backed off.
+func (c *MultiClient) crossCheck(check string, fn func(RollupClient) (*uint64, error), hashFn func(RollupClient, uint64) (common.Hash, error)) (*uint64, error) {
+	type result struct {
+		index *uint64
+		err   error
+	}
+	c.mu.Lock()
+	now := time.Now()
+	var candidates []int
+	for i, e := range c.endpoints {
+		if !now.Before(e.retryAt) {
+			candidates = append(candidates, i)
+		}
+	}
+	c.mu.Unlock()
+	if len(candidates) == 0 {
+		// Everything is backing off, fall back to the regular retries
+		var index *uint64
+		err := c.call(check, func(client RollupClient) error {
+			var err error
+			index, err = fn(client)
+			return err
+		})
+		return index, err
+	}
+
+	results := make([]result, len(candidates))
+	var wg sync.WaitGroup
+	for j, i := range candidates {
+		wg.Add(1)
+		go func(j int, client RollupClient) {
+			defer wg.Done()
+			index, err := fn(client)
+			results[j] = result{index, err}
+		}(j, c.endpoints[i].client)
+	}
+	wg.Wait()
+
+	var indices []uint64
+	var notFound, lastErr error
+	for j, i := range candidates {
+		res := results[j]
+		switch {
+		case errors.Is(res.err, errElementNotFound):
+			notFound = res.err
+		case res.err != nil:
+			lastErr = res.err
+			c.markFailure(i, res.err)
+		case res.index == nil:
+			lastErr = fmt.Errorf("endpoint %s returned no index", c.endpoints[i].name)
+			c.markFailure(i, lastErr)
+		default:
+			indices = append(indices, *res.index)
+		}
+	}
+	var target *uint64
+	if len(indices) > 0 {
+		sort.Slice(indices, func(i, j int) bool { return indices[i] > indices[j] })
+		index := indices[len(indices)/2]
+		target = &index
+	}
+
+	c.mu.Lock()
+	var consistent []int
+	for j, i := range candidates {
+		e, res := c.endpoints[i], results[j]
+		if res.err != nil || res.index == nil {
+			// Without an index the endpoint cannot serve any element
+			e.lagging[check] = target != nil && errors.Is(res.err, errElementNotFound)
+			continue
+		}
+		index := *res.index
+		if prev, ok := e.latest[check]; ok && index < prev {
+			e.failures++
+			backoff := c.backoff(e.failures)
+			e.retryAt = time.Now().Add(backoff)
+			log.Warn("Rollup client endpoint went backwards", "endpoint", e.name, "check", check, "previous", prev, "current", index, "backoff", backoff)
+		}
+		e.latest[check] = index
+		lagging := index+c.cfg.MaxLag < *target
+		if lagging && !e.lagging[check] {
+			log.Warn("Rollup client endpoint is lagging", "endpoint", e.name, "check", check, "index", index, "target", *target)
+		} else if !lagging && e.lagging[check] {
+			log.Info("Rollup client endpoint caught up", "endpoint", e.name, "check", check, "index", index)
+		}
+		e.lagging[check] = lagging
+		if !lagging && !now.Before(e.retryAt) {
+			consistent = append(consistent, i)
+		}
+	}
+	c.mu.Unlock()
+
+	if target != nil {
+		c.compareContent(check, consistent, hashFn)
+		return target, nil
+	}
+	if notFound != nil {
+		return nil, notFound
+	}
+	return nil, fmt.Errorf("%s failed on all endpoints: %w", check, lastErr)
+}
+
+// compareContent fetches the element at the lowest of the latest indices
+// of the given endpoints from each of them and backs off the endpoints whose
+// element differs from the one that most endpoints return. A tie is decided
+// in favour of the active endpoint.
+func (c *MultiClient) compareContent(check string, indices []int, hashFn func(RollupClient, uint64) (common.Hash, error)) {
+	if len(indices) < 2 {
+		return
+	}
+	c.mu.Lock()
+	index := c.endpoints[indices[0]].latest[check]
+	for _, i := range indices[1:] {
+		if latest := c.endpoints[i].latest[check]; latest < index {
+			index = latest
+		}
+	}
+	active := c.active
+	c.mu.Unlock()
+
+	hashes := make([]common.Hash, len(indices))
+	errs := make([]error, len(indices))
+	var wg sync.WaitGroup
+	for j, i := range indices {
+		wg.Add(1)
+		go func(j int, client RollupClient) {
+			defer wg.Done()
+			hashes[j], errs[j] = hashFn(client, index)
+		}(j, c.endpoints[i].client)
+	}
+	wg.Wait()
+
+	votes := make(map[common.Hash]int)
+	var agreed common.Hash
+	for j, i := range indices {
+		if errs[j] != nil {
+			continue
+		}
+		votes[hashes[j]]++
+		if votes[hashes[j]] > votes[agreed] || (votes[hashes[j]] == votes[agreed] && i == active) {
+			agreed = hashes[j]
+		}
+	}
+	for j, i := range indices {
+		if errs[j] != nil || hashes[j] == agreed {
+			continue
+		}
+		c.mu.Lock()
+		e := c.endpoints[i]
+		e.failures++
+		backoff := c.backoff(e.failures)
+		e.retryAt = time.Now().Add(backoff)
+		c.mu.Unlock()
+		log.Warn("Rollup client endpoint returned inconsistent data", "endpoint", e.name, "check", check, "index", index, "hash", hashes[j].Hex(), "expected", agreed.Hex(), "backoff", backoff)
+	}
+}
+
+// enqueueHash returns the hash of an enqueue, to compare it across endpoints
+func enqueueHash(client RollupClient, index uint64) (common.Hash, error) {
+	tx, err := client.GetEnqueue(index)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// batchRoot returns the root of a transaction batch, to compare it across
+// endpoints
+func batchRoot(client RollupClient, index uint64) (common.Hash, error) {
+	batch, _, err := client.GetTransactionBatch(index)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return batch.Root, nil
+}
+
+// GetEnqueue fetches an `enqueue` transaction by queue index
+func (c *MultiClient) GetEnqueue(index uint64) (tx *types.Transaction, err error) {
+	err = c.call("GetEnqueue", func(client RollupClient) error {
+		tx, err = client.GetEnqueue(index)
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestEnqueue fetches the latest `enqueue` transaction
+func (c *MultiClient) GetLatestEnqueue() (tx *types.Transaction, err error) {
+	err = c.call("GetLatestEnqueue", func(client RollupClient) error {
+		tx, err = client.GetLatestEnqueue()
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestEnqueueIndex returns the latest `enqueue()` index known to the
+// most advanced endpoint
+func (c *MultiClient) GetLatestEnqueueIndex() (*uint64, error) {
+	return c.crossCheck(checkEnqueueIndex, RollupClient.GetLatestEnqueueIndex, enqueueHash)
+}
+
+// GetRawTransaction will get a transaction by Canonical Transaction Chain index
+func (c *MultiClient) GetRawTransaction(index uint64, backend Backend) (res *TransactionResponse, err error) {
+	err = c.call("GetRawTransaction", func(client RollupClient) error {
+		res, err = client.GetRawTransaction(index, backend)
+		return err
+	})
+	return res, err
+}
+
+// GetTransaction will get a transaction by Canonical Transaction Chain index
+func (c *MultiClient) GetTransaction(index uint64, backend Backend) (tx *types.Transaction, err error) {
+	err = c.call("GetTransaction", func(client RollupClient) error {
+		tx, err = client.GetTransaction(index, backend)
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestTransaction will get the latest transaction
+func (c *MultiClient) GetLatestTransaction(backend Backend) (tx *types.Transaction, err error) {
+	err = c.call("GetLatestTransaction", func(client RollupClient) error {
+		tx, err = client.GetLatestTransaction(backend)
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestTransactionIndex returns the latest CTC index
+func (c *MultiClient) GetLatestTransactionIndex(backend Backend) (index *uint64, err error) {
+	err = c.call("GetLatestTransactionIndex", func(client RollupClient) error {
+		index, err = client.GetLatestTransactionIndex(backend)
+		return err
+	})
+	return index, err
+}
+
+// GetEthContext will return the EthContext by block number
+func (c *MultiClient) GetEthContext(blockNumber uint64) (ctx *EthContext, err error) {
+	err = c.call("GetEthContext", func(client RollupClient) error {
+		ctx, err = client.GetEthContext(blockNumber)
+		return err
+	})
+	return ctx, err
+}
+
+// GetLatestEthContext will return the latest EthContext
+func (c *MultiClient) GetLatestEthContext() (ctx *EthContext, err error) {
+	err = c.call("GetLatestEthContext", func(client RollupClient) error {
+		ctx, err = client.GetLatestEthContext()
+		return err
+	})
+	return ctx, err
+}
+
+// GetLastConfirmedEnqueue will get the last `enqueue` transaction that has
+// been batched up
+func (c *MultiClient) GetLastConfirmedEnqueue() (tx *types.Transaction, err error) {
+	err = c.call("GetLastConfirmedEnqueue", func(client RollupClient) error {
+		tx, err = client.GetLastConfirmedEnqueue()
+		return err
+	})
+	return tx, err
+}
+
+// GetLatestTransactionBatch will return the latest transaction batch
+func (c *MultiClient) GetLatestTransactionBatch() (batch *Batch, txs []*types.Transaction, err error) {
+	err = c.call("GetLatestTransactionBatch", func(client RollupClient) error {
+		batch, txs, err = client.GetLatestTransactionBatch()
+		return err
+	})
+	return batch, txs, err
+}
+
+// GetLatestTransactionBatchIndex returns the latest transaction batch index
+// known to the most advanced endpoint
+func (c *MultiClient) GetLatestTransactionBatchIndex() (*uint64, error) {
+	return c.crossCheck(checkBatchIndex, RollupClient.GetLatestTransactionBatchIndex, batchRoot)
+}
+
+// GetTransactionBatch will return the transaction batch by batch index
+func (c *MultiClient) GetTransactionBatch(index uint64) (batch *Batch, txs []*types.Transaction, err error) {
+	err = c.call("GetTransactionBatch", func(client RollupClient) error {
+		batch, txs, err = client.GetTransactionBatch(index)
+		return err
+	})
+	return batch, txs, err
+}
+
+// SyncStatus will query the remote server to determine if it is still syncing
+func (c *MultiClient) SyncStatus(backend Backend) (status *SyncStatus, err error) {
+	err = c.call("SyncStatus", func(client RollupClient) error {
+		status, err = client.SyncStatus(backend)
+		return err
+	})
+	return status, err
+}
+
+// SyncStatusV2 will query the sync status of the remote server
+func (c *MultiClient) SyncStatusV2() (status *types.SyncStatus, err error) {
+	err = c.call("SyncStatusV2", func(client RollupClient) error {
+		status, err = client.SyncStatusV2()
+		return err
+	})
+	return status, err
+}
+
+// GetL1Origin will return the L1 origin of a L2 block
+func (c *MultiClient) GetL1Origin(l2block uint64) (ref *types.L1BlockRef, err error) {
+	err = c.call("GetL1Origin", func(client RollupClient) error {
+		ref, err = client.GetL1Origin(l2block)
+		return err
+	})
+	return ref, err
+}
+
+// GetStateRoot will return the stateroot by batch index
+func (c *MultiClient) GetStateRoot(index uint64) (root common.Hash, err error) {
+	err = c.call("GetStateRoot", func(client RollupClient) error {
+		root, err = client.GetStateRoot(index)
+		return err
+	})
+	return root, err
+}
+
+// SetLastVerifier reports the verification result to the data transport layer
+func (c *MultiClient) SetLastVerifier(index uint64, stateRoot string, verifierRoot string, success bool) error {
+	return c.call("SetLastVerifier", func(client RollupClient) error {
+		return client.SetLastVerifier(index, stateRoot, verifierRoot, success)
+	})
+}
+
+// GetRawBlock will get a block with transactions by inbox batch index
+func (c *MultiClient) GetRawBlock(index uint64, backend Backend) (res *BlockResponse, err error) {
+	err = c.call("GetRawBlock", func(client RollupClient) error {
+		res, err = client.GetRawBlock(index, backend)
+		return err
+	})
+	return res, err
+}
+
+// GetBlock will get a block with transactions by inbox batch index
+func (c *MultiClient) GetBlock(index uint64, backend Backend) (block *types.Block, err error) {
+	err = c.call("GetBlock", func(client RollupClient) error {
+		block, err = client.GetBlock(index, backend)
+		return err
+	})
+	return block, err
+}
+
+// GetLatestBlock will get the latest block
+func (c *MultiClient) GetLatestBlock(backend Backend) (block *types.Block, err error) {
+	err = c.call("GetLatestBlock", func(client RollupClient) error {
+		block, err = client.GetLatestBlock(backend)
+		return err
+	})
+	return block, err
+}
+
+// GetLatestBlockIndex returns the latest inbox batch index
+func (c *MultiClient) GetLatestBlockIndex(backend Backend) (index *uint64, err error) {
+	err = c.call("GetLatestBlockIndex", func(client RollupClient) error {
+		index, err = client.GetLatestBlockIndex(backend)
+		return err
+	})
+	return index, err
+}
+
+// GetLatestBlockBatch will return the latest block batch
+func (c *MultiClient) GetLatestBlockBatch() (batch *Batch, blocks []*types.Block, err error) {
+	err = c.call("GetLatestBlockBatch", func(client RollupClient) error {
+		batch, blocks, err = client.GetLatestBlockBatch()
+		return err
+	})
+	return batch, blocks, err
+}
+
+// GetLatestBlockBatchIndex returns the latest block batch index
+func (c *MultiClient) GetLatestBlockBatchIndex() (index *uint64, err error) {
+	err = c.call("GetLatestBlockBatchIndex", func(client RollupClient) error {
+		index, err = client.GetLatestBlockBatchIndex()
+		return err
+	})
+	return index, err
+}
+
+// GetBlockBatch will return the block batch by batch index
+func (c *MultiClient) GetBlockBatch(index uint64) (batch *Batch, blocks []*types.Block, err error) {
+	err = c.call("GetBlockBatch", func(client RollupClient) error {
+		batch, blocks, err = client.GetBlockBatch(index)
+		return err
+	})
+	return batch, blocks, err
+}
@@ -0,0 +1,218 @@
+package rollup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+)
+
+// fakeEndpoint is a RollupClient that only serves batch indices, batches
+// and state roots
+type fakeEndpoint struct {
+	RollupClient
+	batchIndex uint64
+	batchRoot  common.Hash
+	root       common.Hash
+	err        error
+	calls      int
+}
+
+func (f *fakeEndpoint) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	if index > f.batchIndex {
+		return nil, nil, errElementNotFound
+	}
+	return &Batch{Index: index, Root: f.batchRoot}, nil, nil
+}
+
+func (f *fakeEndpoint) GetLatestTransactionBatchIndex() (*uint64, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	index := f.batchIndex
+	return &index, nil
+}
+
+func (f *fakeEndpoint) GetStateRoot(index uint64) (common.Hash, error) {
+	f.calls++
+	if f.err != nil {
+		return common.Hash{}, f.err
+	}
+	return f.root, nil
+}
+
+func newTestMultiClient(t *testing.T, maxLag uint64, endpoints ...*fakeEndpoint) *MultiClient {
+	names := make([]string, len(endpoints))
+	clients := make([]RollupClient, len(endpoints))
+	for i, e := range endpoints {
+		names[i] = string(rune('a' + i))
+		clients[i] = e
+	}
+	client, err := newMultiClient(names, clients, MultiClientConfig{
+		MaxRetries:  2,
+		BackoffBase: time.Minute,
+		BackoffMax:  time.Minute,
+		MaxLag:      maxLag,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	a := &fakeEndpoint{err: errors.New("down"), root: common.Hash{1}}
+	b := &fakeEndpoint{root: common.Hash{2}}
+	client := newTestMultiClient(t, 0, a, b)
+
+	root, err := client.GetStateRoot(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != b.root {
+		t.Fatal("request did not fail over")
+	}
+	// The failed endpoint is backing off and not asked again
+	a.err = nil
+	if _, err := client.GetStateRoot(0); err != nil {
+		t.Fatal(err)
+	}
+	if a.calls != 1 {
+		t.Fatalf("endpoint in backoff was called %d times", a.calls)
+	}
+
+	// A missing element is an answer and does not fail over
+	b.err = errElementNotFound
+	if _, err := client.GetStateRoot(0); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.calls != 1 {
+		t.Fatal("missing element triggered a failover")
+	}
+}
+
+func TestMultiClientCrossCheck(t *testing.T) {
+	a := &fakeEndpoint{batchIndex: 5, root: common.Hash{1}}
+	b := &fakeEndpoint{batchIndex: 9, root: common.Hash{2}}
+	c := &fakeEndpoint{batchIndex: 8, root: common.Hash{3}}
+	client := newTestMultiClient(t, 1, a, b, c)
+
+	// The index that most endpoints reach is the target
+	index, err := client.GetLatestTransactionBatchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *index != 8 {
+		t.Fatalf("unexpected index: %d", *index)
+	}
+	// The first endpoint lags more than allowed and is skipped
+	root, err := client.GetStateRoot(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != b.root {
+		t.Fatal("lagging endpoint was used")
+	}
+
+	// Once it has caught up it is preferred again
+	a.batchIndex = 8
+	if _, err := client.GetLatestTransactionBatchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	client.active = 0
+	if root, _ := client.GetStateRoot(0); root != a.root {
+		t.Fatal("endpoint that caught up was not used")
+	}
+
+	// An endpoint going backwards is backed off
+	b.batchIndex = 3
+	index, err = client.GetLatestTransactionBatchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *index != 8 {
+		t.Fatalf("unexpected index: %d", *index)
+	}
+	if client.endpoints[1].usable(time.Now()) {
+		t.Fatal("inconsistent endpoint is still used")
+	}
+}
+
+func TestMultiClientNotFoundOnLaggingEndpoint(t *testing.T) {
+	a := &fakeEndpoint{batchIndex: 5}
+	b := &fakeEndpoint{batchIndex: 6}
+	client := newTestMultiClient(t, 1, a, b)
+	if _, err := client.GetLatestTransactionBatchIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first endpoint is within the allowed lag but does not have the
+	// latest batch, the endpoint that is ahead is asked
+	batch, _, err := client.GetTransactionBatch(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.Index != 6 {
+		t.Fatalf("unexpected batch %d", batch.Index)
+	}
+	// Once no endpoint is ahead, a missing element is returned as is
+	if _, _, err := client.GetTransactionBatch(7); !errors.Is(err, errElementNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMultiClientInconsistentContent(t *testing.T) {
+	a := &fakeEndpoint{batchIndex: 5, batchRoot: common.Hash{1}}
+	b := &fakeEndpoint{batchIndex: 5, batchRoot: common.Hash{2}}
+	c := &fakeEndpoint{batchIndex: 6, batchRoot: common.Hash{1}}
+	client := newTestMultiClient(t, 1, a, b, c)
+
+	index, err := client.GetLatestTransactionBatchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *index != 5 {
+		t.Fatalf("unexpected index: %d", *index)
+	}
+	// The endpoint that disagrees with the others about batch 5 is backed off
+	if !client.endpoints[0].usable(time.Now()) || !client.endpoints[2].usable(time.Now()) {
+		t.Fatal("consistent endpoint is not used")
+	}
+	if client.endpoints[1].usable(time.Now()) {
+		t.Fatal("inconsistent endpoint is still used")
+	}
+}
+
+func TestMultiClientRogueEndpoint(t *testing.T) {
+	a := &fakeEndpoint{batchIndex: 5, batchRoot: common.Hash{1}, root: common.Hash{1}}
+	b := &fakeEndpoint{batchIndex: 6, batchRoot: common.Hash{1}, root: common.Hash{1}}
+	c := &fakeEndpoint{batchIndex: 1000, batchRoot: common.Hash{2}, root: common.Hash{2}}
+	client := newTestMultiClient(t, DefaultMultiClientConfig.MaxLag, c, a, b)
+
+	// The endpoint that reports an index far ahead of the others neither
+	// sets the sync target nor marks the others as lagging, the target is
+	// the index that two of the three endpoints reach
+	index, err := client.GetLatestTransactionBatchIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *index != 6 {
+		t.Fatalf("unexpected index: %d", *index)
+	}
+	if !client.endpoints[1].usable(time.Now()) || !client.endpoints[2].usable(time.Now()) {
+		t.Fatal("honest endpoint is not used")
+	}
+	// Its content is compared with the others and it is backed off
+	if client.endpoints[0].usable(time.Now()) {
+		t.Fatal("rogue endpoint is still used")
+	}
+	if root, err := client.GetStateRoot(0); err != nil || root != a.root {
+		t.Fatalf("request served by the rogue endpoint: %v", err)
+	}
+}
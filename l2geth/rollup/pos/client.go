@@ -0,0 +1,206 @@
+// Package pos implements a client of the Metis PoS layer, which elects the
+// sequencers and proposes the respans of the sequencer set.
+package pos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/metrics"
+)
+
+// Paths of the PoS layer API
+const (
+	PathSynced         = "/checkPosIsSynced"
+	PathLatestEpoch    = "/metis/latest-span"
+	PathPendingRespans = "/metis/pending-respans"
+	PathValidator      = "/staking/signer/"
+)
+
+var (
+	// ErrNotFound is returned when the PoS layer does not know the object
+	ErrNotFound = errors.New("not found in the PoS layer")
+
+	errHTTPError = errors.New("PoS layer http error")
+
+	requestTimer = metrics.NewRegisteredTimer("rollup/pos/requests", nil)
+	retryMeter   = metrics.NewRegisteredMeter("rollup/pos/retries", nil)
+	failureMeter = metrics.NewRegisteredMeter("rollup/pos/failures", nil)
+)
+
+// Client is the API of the PoS layer that the sequencer uses
+type Client interface {
+	// IsSynced returns whether the PoS layer follows the head of its chain
+	IsSynced(ctx context.Context) (bool, error)
+	// CurrentEpoch returns the latest epoch of the sequencer set
+	CurrentEpoch(ctx context.Context) (*Epoch, error)
+	// PendingRespans returns the proposed respans that are not committed yet
+	PendingRespans(ctx context.Context) ([]*Respan, error)
+	// ValidatorStatus returns the staking status of a sequencer
+	ValidatorStatus(ctx context.Context, signer common.Address) (*ValidatorStatus, error)
+}
+
+// Config configures the timeouts and retries of an HTTPClient
+type Config struct {
+	// Timeout of a single request
+	Timeout time.Duration
+	// Number of retries of a request before giving up
+	MaxRetries int
+	// Backoff after the first failure, doubled with every retry up to
+	// BackoffMax
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+}
+
+// DefaultConfig is used when nothing else is configured
+var DefaultConfig = Config{
+	Timeout:     5 * time.Second,
+	MaxRetries:  2,
+	BackoffBase: 200 * time.Millisecond,
+	BackoffMax:  2 * time.Second,
+}
+
+// HTTPClient is a Client over the REST API of the PoS layer
+type HTTPClient struct {
+	url    string
+	cfg    Config
+	client *http.Client
+}
+
+// NewClient returns a client of the PoS layer at a url
+func NewClient(url string, cfg Config) *HTTPClient {
+	return &HTTPClient{
+		url:    strings.TrimRight(url, "/"),
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// get fetches a path and returns its body, retrying failed requests. A
+// missing object is not retried.
+func (c *HTTPClient) get(ctx context.Context, path string) ([]byte, error) {
+	var err error
+	backoff := c.cfg.BackoffBase
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			retryMeter.Mark(1)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if backoff *= 2; backoff > c.cfg.BackoffMax {
+				backoff = c.cfg.BackoffMax
+			}
+		}
+		var body []byte
+		start := time.Now()
+		body, err = c.do(ctx, path)
+		requestTimer.UpdateSince(start)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			return body, err
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		log.Debug("PoS layer request failed", "path", path, "attempt", attempt, "err", err)
+	}
+	failureMeter.Mark(1)
+	log.Warn("PoS layer request failed", "path", path, "err", err)
+	return nil, fmt.Errorf("cannot get %s from the PoS layer: %w", path, err)
+}
+
+// do runs a single request
+func (c *HTTPClient) do(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "sequencer")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode >= 400:
+		return nil, fmt.Errorf("%d %s: %w", resp.StatusCode, strings.TrimSpace(string(body)), errHTTPError)
+	}
+	return body, nil
+}
+
+// getResult fetches a path and decodes the result of the response into v
+func (c *HTTPClient) getResult(ctx context.Context, path string, v interface{}) error {
+	body, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	var res response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return fmt.Errorf("cannot decode %s: %w", path, err)
+	}
+	if len(res.Result) == 0 || string(res.Result) == "null" {
+		return ErrNotFound
+	}
+	if err := json.Unmarshal(res.Result, v); err != nil {
+		return fmt.Errorf("cannot decode the result of %s: %w", path, err)
+	}
+	return nil
+}
+
+// IsSynced returns whether the PoS layer follows the head of its chain
+func (c *HTTPClient) IsSynced(ctx context.Context) (bool, error) {
+	body, err := c.get(ctx, PathSynced)
+	if err != nil {
+		return false, err
+	}
+	synced, err := strconv.ParseBool(strings.TrimSpace(string(body)))
+	if err != nil {
+		return false, fmt.Errorf("cannot decode %s: %w", PathSynced, err)
+	}
+	return synced, nil
+}
+
+// CurrentEpoch returns the latest epoch of the sequencer set
+func (c *HTTPClient) CurrentEpoch(ctx context.Context) (*Epoch, error) {
+	epoch := new(Epoch)
+	if err := c.getResult(ctx, PathLatestEpoch, epoch); err != nil {
+		return nil, err
+	}
+	return epoch, nil
+}
+
+// PendingRespans returns the proposed respans that are not committed yet
+func (c *HTTPClient) PendingRespans(ctx context.Context) ([]*Respan, error) {
+	var respans []*Respan
+	err := c.getResult(ctx, PathPendingRespans, &respans)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return respans, err
+}
+
+// ValidatorStatus returns the staking status of a sequencer
+func (c *HTTPClient) ValidatorStatus(ctx context.Context, signer common.Address) (*ValidatorStatus, error) {
+	status := new(ValidatorStatus)
+	if err := c.getResult(ctx, PathValidator+signer.Hex(), status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
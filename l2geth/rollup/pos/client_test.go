@@ -0,0 +1,67 @@
+package pos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/pos"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/pos/postest"
+)
+
+func TestClient(t *testing.T) {
+	server := postest.NewServer()
+	defer server.Close()
+	client := pos.NewClient(server.URL, pos.Config{Timeout: time.Second, MaxRetries: 2, BackoffBase: time.Millisecond, BackoffMax: time.Millisecond})
+	ctx := context.Background()
+
+	if synced, err := client.IsSynced(ctx); err != nil || !synced {
+		t.Fatalf("unexpected synced status: %v, %v", synced, err)
+	}
+	server.SetSynced(false)
+	if synced, err := client.IsSynced(ctx); err != nil || synced {
+		t.Fatalf("unexpected synced status: %v, %v", synced, err)
+	}
+
+	if _, err := client.CurrentEpoch(ctx); !errors.Is(err, pos.ErrNotFound) {
+		t.Fatalf("unexpected error without epochs: %v", err)
+	}
+	signer := common.Address{0x0a}
+	server.SetEpoch(&pos.Epoch{ID: 3, StartBlock: 100, EndBlock: 199, SelectedProducers: []pos.Producer{{ID: 1, Signer: signer}}})
+	epoch, err := client.CurrentEpoch(ctx)
+	if err != nil || epoch.ID != 3 || epoch.StartBlock != 100 || epoch.Signer() != signer {
+		t.Fatalf("unexpected epoch: %+v, %v", epoch, err)
+	}
+
+	if respans, err := client.PendingRespans(ctx); err != nil || len(respans) != 0 {
+		t.Fatalf("unexpected respans: %v, %v", respans, err)
+	}
+	server.SetRespans([]*pos.Respan{{EpochID: 4, StartBlock: 150, EndBlock: 249, OldSigner: signer, NewSigner: common.Address{0x0b}}})
+	if respans, err := client.PendingRespans(ctx); err != nil || len(respans) != 1 || respans[0].NewSigner != (common.Address{0x0b}) {
+		t.Fatalf("unexpected respans: %v, %v", respans, err)
+	}
+
+	if _, err := client.ValidatorStatus(ctx, signer); !errors.Is(err, pos.ErrNotFound) {
+		t.Fatalf("unexpected error for an unknown validator: %v", err)
+	}
+	server.SetValidator(&pos.ValidatorStatus{ID: 1, Signer: signer, VotingPower: 10, Jailed: true})
+	if status, err := client.ValidatorStatus(ctx, signer); err != nil || !status.Jailed || status.VotingPower != 10 {
+		t.Fatalf("unexpected validator status: %+v, %v", status, err)
+	}
+
+	// Failed requests are retried up to the limit
+	server.FailNext(2)
+	if _, err := client.CurrentEpoch(ctx); err != nil {
+		t.Fatalf("request not retried: %v", err)
+	}
+	server.FailNext(3)
+	before := server.Requests(pos.PathLatestEpoch)
+	if _, err := client.CurrentEpoch(ctx); err == nil {
+		t.Fatal("request succeeded after the retries")
+	}
+	if n := server.Requests(pos.PathLatestEpoch) - before; n != 3 {
+		t.Fatalf("unexpected number of attempts: %d", n)
+	}
+}
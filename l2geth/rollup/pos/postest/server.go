@@ -0,0 +1,134 @@
+// Package postest provides an in-process fake of the Metis PoS layer for
+// testing the PoS client and the sequencer adapter.
+package postest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/pos"
+)
+
+// Server is an httptest server with the routes of the PoS layer that the
+// sequencer uses. Its state is set by the test.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	height     uint64
+	synced     bool
+	epoch      *pos.Epoch
+	respans    []*pos.Respan
+	validators map[common.Address]*pos.ValidatorStatus
+	failures   int
+	requests   map[string]int
+}
+
+// NewServer starts a synced Server without epochs. The caller must close it.
+func NewServer() *Server {
+	s := &Server{
+		synced:     true,
+		validators: make(map[common.Address]*pos.ValidatorStatus),
+		requests:   make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// SetSynced sets whether the PoS layer is synced
+func (s *Server) SetSynced(synced bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.synced = synced
+}
+
+// SetEpoch sets the latest epoch and advances the PoS height
+func (s *Server) SetEpoch(epoch *pos.Epoch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.epoch = epoch
+	s.height++
+}
+
+// SetRespans sets the pending respans
+func (s *Server) SetRespans(respans []*pos.Respan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.respans = respans
+}
+
+// SetValidator sets the staking status of a sequencer
+func (s *Server) SetValidator(status *pos.ValidatorStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.validators[status.Signer] = status
+}
+
+// FailNext makes the next n requests fail with an internal server error
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+// Requests returns the number of requests of a path, including the failed
+// ones
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := r.URL.Path
+	if strings.HasPrefix(path, pos.PathValidator) {
+		s.requests[pos.PathValidator]++
+	} else {
+		s.requests[path]++
+	}
+	if s.failures > 0 {
+		s.failures--
+		http.Error(w, "injected failure", http.StatusInternalServerError)
+		return
+	}
+
+	var result interface{}
+	switch {
+	case path == pos.PathSynced:
+		w.Write([]byte(strconv.FormatBool(s.synced)))
+		return
+	case path == pos.PathLatestEpoch && s.epoch != nil:
+		result = s.epoch
+	case path == pos.PathPendingRespans:
+		result = s.respans
+	case strings.HasPrefix(path, pos.PathValidator):
+		status, ok := s.validators[common.HexToAddress(strings.TrimPrefix(path, pos.PathValidator))]
+		if !ok {
+			http.Error(w, "validator not found", http.StatusNotFound)
+			return
+		}
+		result = status
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"height": strconv.FormatUint(s.height, 10),
+		"result": json.RawMessage(data),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,56 @@
+package pos
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+)
+
+// response is the envelope of the responses of the PoS layer, the result is
+// read at the height of the PoS chain
+type response struct {
+	Height string          `json:"height"`
+	Result json.RawMessage `json:"result"`
+}
+
+// Producer is a validator that is selected to produce the blocks of an epoch
+type Producer struct {
+	ID     uint64         `json:"ID"`
+	Signer common.Address `json:"signer"`
+}
+
+// Epoch is an epoch of the sequencer set as the PoS layer knows it
+type Epoch struct {
+	ID                uint64     `json:"span_id"`
+	StartBlock        uint64     `json:"start_block"`
+	EndBlock          uint64     `json:"end_block"`
+	SelectedProducers []Producer `json:"selected_producers"`
+}
+
+// Signer returns the sequencer of the epoch, zero if no producer is selected
+func (e *Epoch) Signer() common.Address {
+	if len(e.SelectedProducers) == 0 {
+		return common.Address{}
+	}
+	return e.SelectedProducers[0].Signer
+}
+
+// Respan is a respan that the PoS layer proposed and that is not yet
+// committed to the sequencer set
+type Respan struct {
+	EpochID    uint64         `json:"span_id"`
+	StartBlock uint64         `json:"start_block"`
+	EndBlock   uint64         `json:"end_block"`
+	OldSigner  common.Address `json:"current_producer"`
+	NewSigner  common.Address `json:"next_producer"`
+}
+
+// ValidatorStatus is the staking status of a sequencer in the PoS layer
+type ValidatorStatus struct {
+	ID          uint64         `json:"ID"`
+	Signer      common.Address `json:"signer"`
+	StartEpoch  uint64         `json:"startEpoch"`
+	EndEpoch    uint64         `json:"endEpoch"`
+	VotingPower int64          `json:"power"`
+	Jailed      bool           `json:"jailed"`
+}
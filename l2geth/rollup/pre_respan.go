@@ -0,0 +1,219 @@
+package rollup
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/event"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+)
+
+const (
+	// PreRespanCreated is sent when a pre-respan is queued
+	PreRespanCreated = "created"
+	// PreRespanFired is sent when the chain reaches the start block of a
+	// pre-respan and it is removed from the queue
+	PreRespanFired = "fired"
+	// PreRespanCleared is sent when a pre-respan is removed before the chain
+	// reaches its start block, because it was cancelled or replaced
+	PreRespanCleared = "cleared"
+)
+
+var errPreRespanNotFound = errors.New("pre-respan not found")
+
+// PreRespanEvent is sent on every change of the pre-respan queue
+type PreRespanEvent struct {
+	Action string
+	Respan *types.PreRespan
+}
+
+// preRespanQueue keeps the pending pre-respans ordered by start block and
+// persists them in the node database, so a restart does not let blocks past a
+// respan through
+type preRespanQueue struct {
+	db      ethdb.Database
+	mu      sync.RWMutex
+	pending []*types.PreRespan
+	feed    event.Feed
+	scope   event.SubscriptionScope
+}
+
+// newPreRespanQueue loads the pending pre-respans of the database
+func newPreRespanQueue(db ethdb.Database) *preRespanQueue {
+	q := &preRespanQueue{db: db}
+	if db != nil {
+		q.pending = rawdb.ReadPreRespans(db)
+	}
+	if len(q.pending) > 0 {
+		log.Info("Loaded pre-respans", "count", len(q.pending), "first", q.pending[0].StartBlock)
+	}
+	return q
+}
+
+// Add queues a pre-respan, a pending pre-respan with the same start block is
+// replaced
+func (q *preRespanQueue) Add(respan *types.PreRespan) {
+	if respan.Created == 0 {
+		respan.Created = uint64(time.Now().Unix())
+	}
+	q.mu.Lock()
+	var replaced *types.PreRespan
+	i := sort.Search(len(q.pending), func(i int) bool { return q.pending[i].StartBlock >= respan.StartBlock })
+	if i < len(q.pending) && q.pending[i].StartBlock == respan.StartBlock {
+		replaced = q.pending[i]
+		q.pending[i] = respan
+	} else {
+		q.pending = append(q.pending, nil)
+		copy(q.pending[i+1:], q.pending[i:])
+		q.pending[i] = respan
+	}
+	if q.db != nil {
+		rawdb.WritePreRespan(q.db, respan)
+	}
+	q.mu.Unlock()
+
+	log.Info("Set pre-respan", "preSigner", respan.PreSigner.Hex(), "newSigner", respan.NewSigner.Hex(), "startBlock", respan.StartBlock, "source", respan.Source)
+	if replaced != nil {
+		q.feed.Send(PreRespanEvent{Action: PreRespanCleared, Respan: replaced})
+	}
+	q.feed.Send(PreRespanEvent{Action: PreRespanCreated, Respan: respan})
+}
+
+// Cancel removes the pending pre-respan with a start block
+func (q *preRespanQueue) Cancel(start uint64) error {
+	q.mu.Lock()
+	i := sort.Search(len(q.pending), func(i int) bool { return q.pending[i].StartBlock >= start })
+	if i == len(q.pending) || q.pending[i].StartBlock != start {
+		q.mu.Unlock()
+		return errPreRespanNotFound
+	}
+	respan := q.pending[i]
+	q.pending = append(q.pending[:i], q.pending[i+1:]...)
+	if q.db != nil {
+		rawdb.DeletePreRespan(q.db, start)
+	}
+	q.mu.Unlock()
+
+	log.Info("Cancelled pre-respan", "startBlock", start, "newSigner", respan.NewSigner.Hex())
+	q.feed.Send(PreRespanEvent{Action: PreRespanCleared, Respan: respan})
+	return nil
+}
+
+// Fire removes the pre-respans whose start block the chain head reached
+func (q *preRespanQueue) Fire(head uint64) {
+	q.mu.RLock()
+	due := len(q.pending) > 0 && q.pending[0].StartBlock <= head
+	q.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	q.mu.Lock()
+	var fired []*types.PreRespan
+	for len(q.pending) > 0 && q.pending[0].StartBlock <= head {
+		fired = append(fired, q.pending[0])
+		if q.db != nil {
+			rawdb.DeletePreRespan(q.db, q.pending[0].StartBlock)
+		}
+		q.pending = q.pending[1:]
+	}
+	q.mu.Unlock()
+
+	for _, respan := range fired {
+		log.Info("Fired pre-respan", "startBlock", respan.StartBlock, "head", head, "newSigner", respan.NewSigner.Hex())
+		q.feed.Send(PreRespanEvent{Action: PreRespanFired, Respan: respan})
+	}
+}
+
+// At returns the pending pre-respan that a block number falls under, the one
+// with the highest start block not above it
+func (q *preRespanQueue) At(number uint64) *types.PreRespan {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	i := sort.Search(len(q.pending), func(i int) bool { return q.pending[i].StartBlock > number })
+	if i == 0 {
+		return nil
+	}
+	return q.pending[i-1]
+}
+
+// List returns the pending pre-respans ordered by start block
+func (q *preRespanQueue) List() []*types.PreRespan {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	list := make([]*types.PreRespan, len(q.pending))
+	copy(list, q.pending)
+	return list
+}
+
+// Subscribe registers a subscription of PreRespanEvent
+func (q *preRespanQueue) Subscribe(ch chan<- PreRespanEvent) event.Subscription {
+	return q.scope.Track(q.feed.Subscribe(ch))
+}
+
+// respanTxLoop queues the pre-respans of the recommit txs as the chain
+// applies them, until the service is stopped
+func (s *SyncService) respanTxLoop() {
+	headCh := make(chan core.ChainHeadEvent, 16)
+	headSub := s.bc.SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	last := s.bc.CurrentBlock().NumberU64()
+	for {
+		select {
+		case ev := <-headCh:
+			last = s.queueRespanTxs(last, ev.Block.NumberU64())
+		case <-headSub.Err():
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// queueRespanTxs queues the pre-respans of the recommit txs in the blocks
+// after last up to the head and returns the last block checked. Only the
+// recommits with a successful receipt are queued, a reverted one does not
+// respan.
+func (s *SyncService) queueRespanTxs(last, head uint64) uint64 {
+	if head <= last {
+		// The chain was rewound, the blocks after the head are checked again
+		return head
+	}
+	validHeight := s.seqAdapter.GetSeqValidHeight()
+	for number := last + 1; number <= head; number++ {
+		if number < validHeight {
+			continue
+		}
+		block := s.bc.GetBlockByNumber(number)
+		if block == nil {
+			return number - 1
+		}
+		var receipts types.Receipts
+		for i, tx := range block.Transactions() {
+			if tx.QueueOrigin() == types.QueueOriginL1ToL2 || tx.To() == nil || *tx.To() != s.seqsetContract {
+				continue
+			}
+			if !s.seqAdapter.IsRespanCall(tx) {
+				continue
+			}
+			if receipts == nil {
+				receipts = rawdb.ReadRawReceipts(s.db, block.Hash(), number)
+			}
+			if i >= len(receipts) || receipts[i].Status != types.ReceiptStatusSuccessful {
+				log.Warn("Respan tx failed, not queued", "number", number, "hash", tx.Hash().Hex())
+				continue
+			}
+			s.seqAdapter.SetPreRespanTx(tx, number)
+		}
+	}
+	return head
+}
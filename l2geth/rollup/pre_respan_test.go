@@ -0,0 +1,122 @@
+package rollup
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+)
+
+func TestPreRespanQueue(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	q := newPreRespanQueue(db)
+	events := make(chan PreRespanEvent, 16)
+	sub := q.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	expect := func(action string, start uint64) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Action != action || ev.Respan.StartBlock != start {
+				t.Fatalf("unexpected event: %s %d, want %s %d", ev.Action, ev.Respan.StartBlock, action, start)
+			}
+		default:
+			t.Fatalf("missing event: %s %d", action, start)
+		}
+	}
+
+	signerA, signerB, signerC := common.Address{0x0a}, common.Address{0x0b}, common.Address{0x0c}
+	q.Add(&types.PreRespan{PreSigner: signerB, NewSigner: signerC, StartBlock: 200, Source: types.PreRespanSourceTx, TxHash: common.Hash{0x01}})
+	expect(PreRespanCreated, 200)
+	q.Add(&types.PreRespan{PreSigner: signerA, NewSigner: signerB, StartBlock: 100, Source: types.PreRespanSourceBridge})
+	expect(PreRespanCreated, 100)
+
+	for number, want := range map[uint64]common.Address{99: {}, 100: signerB, 199: signerB, 200: signerC, 300: signerC} {
+		var got common.Address
+		if respan := q.At(number); respan != nil {
+			got = respan.NewSigner
+		}
+		if got != want {
+			t.Fatalf("unexpected new signer at %d: %s", number, got.Hex())
+		}
+	}
+
+	// A bridge call with the same start block replaces the pending one
+	q.Add(&types.PreRespan{PreSigner: signerA, NewSigner: signerC, StartBlock: 100, Source: types.PreRespanSourceBridge})
+	expect(PreRespanCleared, 100)
+	expect(PreRespanCreated, 100)
+
+	// The queue survives a restart
+	q = newPreRespanQueue(db)
+	q.Subscribe(events)
+	if list := q.List(); len(list) != 2 || list[0].StartBlock != 100 || list[0].NewSigner != signerC || list[1].Source != types.PreRespanSourceTx {
+		t.Fatalf("unexpected pre-respans after restart: %v", list)
+	}
+
+	q.Fire(99)
+	if len(q.List()) != 2 {
+		t.Fatal("pre-respan fired before its start block")
+	}
+	q.Fire(150)
+	expect(PreRespanFired, 100)
+	if err := q.Cancel(100); err != errPreRespanNotFound {
+		t.Fatalf("fired pre-respan cancelled: %v", err)
+	}
+	if err := q.Cancel(200); err != nil {
+		t.Fatal(err)
+	}
+	expect(PreRespanCleared, 200)
+	if respans := rawdb.ReadPreRespans(db); len(respans) != 0 {
+		t.Fatalf("pre-respans left in the database: %v", respans)
+	}
+}
+
+// respanTxAdapter treats every call of the seqset contract as a respan and
+// records the pre-respans queued from txs
+type respanTxAdapter struct {
+	RollupAdapter
+	queued []uint64
+}
+
+func (a *respanTxAdapter) GetSeqValidHeight() uint64               { return 0 }
+func (a *respanTxAdapter) IsRespanCall(tx *types.Transaction) bool { return true }
+func (a *respanTxAdapter) SetPreRespanTx(tx *types.Transaction, number uint64) {
+	a.queued = append(a.queued, number)
+}
+
+func TestQueueRespanTxs(t *testing.T) {
+	s, _ := newReorgTestService(t, 4)
+	adapter := new(respanTxAdapter)
+	s.seqAdapter = adapter
+	s.seqsetContract = common.Address{1}
+
+	// The recommit of block 3 reverted
+	block := s.bc.GetBlockByNumber(3)
+	receipts := rawdb.ReadRawReceipts(s.db, block.Hash(), 3)
+	receipts[0].Status = types.ReceiptStatusFailed
+	rawdb.WriteReceipts(s.db, block.Hash(), 3, receipts)
+
+	if last := s.queueRespanTxs(1, 4); last != 4 {
+		t.Fatalf("unexpected last block: %d", last)
+	}
+	if want := []uint64{2, 4}; !reflect.DeepEqual(adapter.queued, want) {
+		t.Fatalf("unexpected queued respans: %v, want %v", adapter.queued, want)
+	}
+	// Blocks past the head are not checked yet, a rewind checks them again
+	if last := s.queueRespanTxs(4, 6); last != 4 {
+		t.Fatalf("unexpected last block past the head: %d", last)
+	}
+	if last := s.queueRespanTxs(4, 2); last != 2 {
+		t.Fatalf("unexpected last block after a rewind: %d", last)
+	}
+	// Calls of other contracts are ignored
+	s.seqsetContract = common.Address{2}
+	adapter.queued = nil
+	s.queueRespanTxs(0, 4)
+	if len(adapter.queued) != 0 {
+		t.Fatalf("unexpected queued respans: %v", adapter.queued)
+	}
+}
@@ -0,0 +1,138 @@
+package rollup
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/metrics"
+)
+
+var (
+	prefetchDepthGauge   = metrics.NewRegisteredGauge("rollup/prefetch/depth", nil)
+	prefetchBatchMeter   = metrics.NewRegisteredMeter("rollup/prefetch/batches", nil)
+	prefetchElementMeter = metrics.NewRegisteredMeter("rollup/prefetch/elements", nil)
+	prefetchFetchTimer   = metrics.NewRegisteredTimer("rollup/prefetch/fetch", nil)
+	prefetchWaitTimer    = metrics.NewRegisteredTimer("rollup/prefetch/wait", nil)
+)
+
+// defaultPrefetchDepth is the number of batches that are downloaded ahead of
+// the batch that is being applied when nothing else is configured
+const defaultPrefetchDepth = 4
+
+// fetchedBatch is a downloaded and decoded batch. Transaction batches set
+// txs, block batches set blocks.
+type fetchedBatch struct {
+	index      uint64
+	blockBatch bool
+	batch      *Batch
+	txs        []*types.Transaction
+	blocks     []*types.Block
+	err        error
+}
+
+// batchPrefetcher downloads a range of batches concurrently while handing
+// them out strictly in order. At most depth batches are held in memory or
+// in flight at any time.
+type batchPrefetcher struct {
+	results chan chan *fetchedBatch
+	slots   chan struct{}
+	quit    chan struct{}
+}
+
+// newBatchPrefetcher starts downloading the batches from start to end
+// (inclusive) with the given fetch function
+func newBatchPrefetcher(fetch func(uint64) *fetchedBatch, start, end uint64, depth int) *batchPrefetcher {
+	if depth <= 0 {
+		depth = 1
+	}
+	p := &batchPrefetcher{
+		results: make(chan chan *fetchedBatch, depth),
+		slots:   make(chan struct{}, depth),
+		quit:    make(chan struct{}),
+	}
+	go p.loop(fetch, start, end)
+	return p
+}
+
+func (p *batchPrefetcher) loop(fetch func(uint64) *fetchedBatch, start, end uint64) {
+	defer close(p.results)
+	for i := start; i <= end; i++ {
+		select {
+		case p.slots <- struct{}{}:
+		case <-p.quit:
+			return
+		}
+		prefetchDepthGauge.Inc(1)
+		result := make(chan *fetchedBatch, 1)
+		go func(index uint64) {
+			start := time.Now()
+			res := fetch(index)
+			prefetchFetchTimer.UpdateSince(start)
+			if res.err == nil {
+				prefetchBatchMeter.Mark(1)
+				prefetchElementMeter.Mark(int64(len(res.txs) + len(res.blocks)))
+			}
+			result <- res
+		}(i)
+		select {
+		case p.results <- result:
+		case <-p.quit:
+			return
+		}
+		// Guard against overflowing the index
+		if i == end {
+			return
+		}
+	}
+}
+
+// next returns the next batch in order, it returns nil when the range is
+// exhausted
+func (p *batchPrefetcher) next() *fetchedBatch {
+	start := time.Now()
+	result, ok := <-p.results
+	if !ok {
+		return nil
+	}
+	res := <-result
+	prefetchWaitTimer.UpdateSince(start)
+	<-p.slots
+	prefetchDepthGauge.Dec(1)
+	return res
+}
+
+// close stops the prefetcher without waiting for the batches that are in
+// flight. Their downloads finish in the background and the results are
+// dropped, the result channels are buffered so nothing blocks on them.
+func (p *batchPrefetcher) close() {
+	close(p.quit)
+	// Release the slots of the batches that were never handed out
+	for range p.results {
+		<-p.slots
+		prefetchDepthGauge.Dec(1)
+	}
+}
+
+// useBlockBatches returns true when the batches at the current height are
+// block batches
+func (s *SyncService) useBlockBatches() bool {
+	return s.ovm.IsDeSeq(s.bc.CurrentBlock().NumberU64() + 1)
+}
+
+// fetchBatch downloads and decodes a transaction or block batch. The kind of
+// batch is guessed from the current height and corrected when the remote
+// server asks for a block batch.
+func (s *SyncService) fetchBatch(index uint64, blockBatch bool) *fetchedBatch {
+	res := &fetchedBatch{index: index}
+	if !blockBatch {
+		res.batch, res.txs, res.err = s.client.GetTransactionBatch(index)
+		if res.err == nil || !strings.Contains(res.err.Error(), "USE_INBOX_BATCH_INDEX") {
+			return res
+		}
+		res.txs = nil
+	}
+	res.blockBatch = true
+	res.batch, res.blocks, res.err = s.client.GetBlockBatch(index)
+	return res
+}
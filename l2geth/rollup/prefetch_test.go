@@ -0,0 +1,82 @@
+package rollup
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchPrefetcherOrder(t *testing.T) {
+	var inflight, peak int32
+	fetch := func(index uint64) *fetchedBatch {
+		n := atomic.AddInt32(&inflight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		// Later batches finish first
+		time.Sleep(time.Duration(20-index) * time.Millisecond)
+		atomic.AddInt32(&inflight, -1)
+		return &fetchedBatch{index: index}
+	}
+	prefetcher := newBatchPrefetcher(fetch, 5, 14, 3)
+	defer prefetcher.close()
+
+	expect := uint64(5)
+	for res := prefetcher.next(); res != nil; res = prefetcher.next() {
+		if res.index != expect {
+			t.Fatalf("unexpected batch: have %d, want %d", res.index, expect)
+		}
+		expect++
+	}
+	if expect != 15 {
+		t.Fatalf("missing batches, stopped at %d", expect)
+	}
+	if peak > 3 {
+		t.Fatalf("prefetched %d batches with depth 3", peak)
+	}
+}
+
+func TestBatchPrefetcherClose(t *testing.T) {
+	var fetched int32
+	fetch := func(index uint64) *fetchedBatch {
+		atomic.AddInt32(&fetched, 1)
+		return &fetchedBatch{index: index}
+	}
+	prefetcher := newBatchPrefetcher(fetch, 0, 1000, 2)
+	if res := prefetcher.next(); res == nil || res.index != 0 {
+		t.Fatal("unexpected first batch")
+	}
+	prefetcher.close()
+	if n := atomic.LoadInt32(&fetched); n > 4 {
+		t.Fatalf("fetched %d batches after close", n)
+	}
+}
+
+func TestBatchPrefetcherCloseInFlight(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	fetch := func(index uint64) *fetchedBatch {
+		if index > 0 {
+			<-release
+		}
+		return &fetchedBatch{index: index}
+	}
+	prefetcher := newBatchPrefetcher(fetch, 0, 10, 3)
+	if res := prefetcher.next(); res == nil || res.index != 0 {
+		t.Fatal("unexpected first batch")
+	}
+	// The batches that are still downloading do not hold up close
+	done := make(chan struct{})
+	go func() {
+		prefetcher.close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("close waited for the batches in flight")
+	}
+}
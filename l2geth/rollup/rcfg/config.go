@@ -3,20 +3,59 @@ package rcfg
 import (
 	"fmt"
 	"math/big"
-	"os"
-	"strconv"
 
 	"github.com/ethereum-optimism/optimism/l2geth/common"
 )
 
-// UsingOVM is used to enable or disable functionality necessary for the OVM.
-var (
-	UsingOVM               bool
+// Config is the rollup configuration of a node. The zero value is a node
+// that does not use the OVM.
+type Config struct {
+	// UsingOVM enables the functionality necessary for the OVM
+	UsingOVM bool
+	// DeSeqBlock is the first block produced by the decentralized
+	// sequencers, zero disables decentralized sequencing
+	DeSeqBlock uint64
+	// PeerHealthCheckSeconds is the time after which a peer that has not
+	// synced is dropped, zero disables the check
 	PeerHealthCheckSeconds int64
-	ChainID                uint64
-	DeSeqBlock             uint64
-	SeqValidHeight         uint64
-)
+	// ChainID is the chain id of the rollup, zero is unset. It must match
+	// the chain id of the genesis.
+	ChainID uint64
+	// SeqValidHeight is the height from which the transaction meta holds
+	// the signature of the sequencer, zero disables the signature
+	SeqValidHeight uint64
+	// FirstSequencer is the sequencer before the sequencer set is enabled,
+	// the zero address is DefaultSeqAdderss
+	FirstSequencer common.Address
+}
+
+// Validate checks the config against the chain id of the genesis
+func (c *Config) Validate(chainID *big.Int) error {
+	if c.PeerHealthCheckSeconds < 0 {
+		return fmt.Errorf("invalid peer health check: %d", c.PeerHealthCheckSeconds)
+	}
+	if !c.UsingOVM && c.DeSeqBlock != 0 {
+		return fmt.Errorf("decentralized sequencer block %d set without the OVM", c.DeSeqBlock)
+	}
+	if c.ChainID != 0 && chainID != nil && c.ChainID != chainID.Uint64() {
+		return fmt.Errorf("chain id %d does not match the genesis chain id %d", c.ChainID, chainID)
+	}
+	return nil
+}
+
+// IsDeSeq returns true when the block is produced by the decentralized
+// sequencers
+func (c *Config) IsDeSeq(number uint64) bool {
+	return c.DeSeqBlock > 0 && number >= c.DeSeqBlock
+}
+
+// Sequencer returns the sequencer before the sequencer set is enabled
+func (c *Config) Sequencer() common.Address {
+	if c.FirstSequencer == (common.Address{}) {
+		return DefaultSeqAdderss
+	}
+	return c.FirstSequencer
+}
 
 var (
 	// l2GasPriceSlot refers to the storage slot that the L2 gas price is stored
@@ -45,60 +84,3 @@ var (
 	// DefaultSeqAdderss refers to the sequencer address before MPC enabled
 	DefaultSeqAdderss = common.HexToAddress("0x3525fdb496c612e4cDe817A2567081470b7a2Ecb")
 )
-
-func init() {
-	UsingOVM = os.Getenv("USING_OVM") == "true"
-
-	deseqHeight := os.Getenv("DESEQBLOCK")
-	if deseqHeight == "" {
-		DeSeqBlock = ^uint64(0)
-	} else {
-		parsed, err := strconv.ParseUint(deseqHeight, 0, 64)
-		if err != nil {
-			panic(err)
-		}
-		DeSeqBlock = parsed
-	}
-
-	peerHealthCheck := os.Getenv("PEER_HEALTH_CHECK")
-	if peerHealthCheck == "" {
-		PeerHealthCheckSeconds = ^int64(0)
-	} else {
-		parsed, err := strconv.ParseInt(peerHealthCheck, 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		PeerHealthCheckSeconds = parsed
-	}
-
-	envChainID := os.Getenv("CHAIN_ID")
-	if envChainID == "" {
-		ChainID = ^uint64(0)
-	} else {
-		parsed, err := strconv.ParseUint(envChainID, 0, 64)
-		if err != nil {
-			panic(err)
-		}
-		ChainID = parsed
-	}
-
-	envSvh := os.Getenv("SEQSET_VALID_HEIGHT")
-	if envSvh == "" {
-		SeqValidHeight = ^uint64(0)
-	} else {
-		parsed, err := strconv.ParseUint(envSvh, 0, 64)
-		if err != nil {
-			panic(err)
-		}
-		SeqValidHeight = parsed
-	}
-
-	// for testing
-	if defSeqAddr := os.Getenv("SEQSET_FIRST_SEQUENCER"); defSeqAddr != "" {
-		if addr := common.HexToAddress(defSeqAddr); !addr.IsZero() {
-			DefaultSeqAdderss = addr
-		}
-	}
-
-	fmt.Println("rcfg UsingOVM ", UsingOVM, " envChainID ", envChainID, "envSeqValidHeight", envSvh, "defaultSeqAdderss", DefaultSeqAdderss.Hex())
-}
@@ -0,0 +1,57 @@
+package rcfg
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		chainID *big.Int
+		ok      bool
+	}{
+		{"zero", Config{}, big.NewInt(1088), true},
+		{"ovm", Config{UsingOVM: true, DeSeqBlock: 100, ChainID: 1088}, big.NewInt(1088), true},
+		{"negative health check", Config{PeerHealthCheckSeconds: -1}, big.NewInt(1088), false},
+		{"deseq without ovm", Config{DeSeqBlock: 100}, big.NewInt(1088), false},
+		{"chain id mismatch", Config{UsingOVM: true, ChainID: 1088}, big.NewInt(588), false},
+	}
+	for _, test := range tests {
+		err := test.cfg.Validate(test.chainID)
+		if test.ok && err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.ok && err == nil {
+			t.Fatalf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestConfigIsDeSeq(t *testing.T) {
+	cfg := Config{UsingOVM: true}
+	if cfg.IsDeSeq(0) || cfg.IsDeSeq(1000) {
+		t.Fatal("decentralized sequencing enabled without a block")
+	}
+	cfg.DeSeqBlock = 100
+	if cfg.IsDeSeq(99) {
+		t.Fatal("decentralized sequencing enabled before its block")
+	}
+	if !cfg.IsDeSeq(100) || !cfg.IsDeSeq(101) {
+		t.Fatal("decentralized sequencing disabled after its block")
+	}
+}
+
+func TestConfigSequencer(t *testing.T) {
+	cfg := Config{}
+	if cfg.Sequencer() != DefaultSeqAdderss {
+		t.Fatalf("expected default sequencer, got %s", cfg.Sequencer().Hex())
+	}
+	cfg.FirstSequencer = common.HexToAddress("0x1234")
+	if cfg.Sequencer() != cfg.FirstSequencer {
+		t.Fatalf("expected first sequencer, got %s", cfg.Sequencer().Hex())
+	}
+}
@@ -0,0 +1,234 @@
+package rollup
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+)
+
+var (
+	// errReorgTooDeep is returned when the batches posted to L1 diverge from
+	// the local chain further back than the configured maximum reorg depth
+	errReorgTooDeep = errors.New("reorg too deep")
+	// errReorgGenesis is returned when the very first transaction does not
+	// match, the chain cannot be rewound past genesis
+	errReorgGenesis = errors.New("cannot reorg the first transaction")
+)
+
+// postedBlock is a L2 block as it was posted to L1. Transaction batches have
+// one transaction per block, block batches carry the full block.
+type postedBlock struct {
+	number uint64
+	txs    []*types.Transaction
+	block  *types.Block
+}
+
+// matches returns true when the local block holds the posted transactions
+func (p *postedBlock) matches(local *types.Block) bool {
+	txs := local.Transactions()
+	if len(txs) != len(p.txs) {
+		return false
+	}
+	for i, tx := range p.txs {
+		if !isCtcTxEqual(tx, txs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchPostedBlocks fetches a batch from L1 and splits it into blocks
+func (s *SyncService) fetchPostedBlocks(index uint64) ([]*postedBlock, error) {
+	_, txs, err := s.client.GetTransactionBatch(index)
+	if err == nil {
+		posted := make([]*postedBlock, len(txs))
+		for i, tx := range txs {
+			posted[i] = &postedBlock{
+				number: *tx.GetMeta().Index + 1,
+				txs:    []*types.Transaction{tx},
+			}
+		}
+		return posted, nil
+	}
+	if !strings.Contains(err.Error(), "USE_INBOX_BATCH_INDEX") {
+		return nil, err
+	}
+	_, blocks, err := s.client.GetBlockBatch(index)
+	if err != nil {
+		return nil, err
+	}
+	posted := make([]*postedBlock, len(blocks))
+	for i, block := range blocks {
+		posted[i] = &postedBlock{
+			number: block.NumberU64(),
+			txs:    block.Transactions(),
+			block:  block,
+		}
+	}
+	return posted, nil
+}
+
+// reconcileBatchesToTip compares the batches posted to L1 with the local
+// chain. The sequencer accepts transactions before they are posted, L1 is
+// the source of truth so the local chain is rewound to the first divergence
+// and the posted transactions are executed in its place.
+func (s *SyncService) reconcileBatchesToTip() error {
+	latest, err := s.client.GetLatestTransactionBatchIndex()
+	if errors.Is(err, errElementNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Cannot get latest batch index: %w", err)
+	}
+	start, err := s.reconcileStart(*latest)
+	if err != nil {
+		return err
+	}
+	for i := start; i <= *latest; i++ {
+		done, err := s.reconcileBatch(i)
+		if err != nil {
+			return fmt.Errorf("Cannot reconcile batch %d: %w", i, err)
+		}
+		if !done {
+			return nil
+		}
+		s.SetLatestBatchIndex(&i)
+	}
+	return nil
+}
+
+// reconcileStart returns the first batch to reconcile. Without a reconciled
+// batch the pass starts at the batch that contains the local head instead of
+// the first batch, the blocks before it were accepted when the batches were
+// already posted. When the head has not been posted yet only the latest
+// batch is reconciled and an empty chain is not reconciled at all.
+func (s *SyncService) reconcileStart(latest uint64) (uint64, error) {
+	if index := s.GetLatestBatchIndex(); index != nil {
+		return *index + 1, nil
+	}
+	head := s.GetLatestIndex()
+	if head == nil {
+		return latest + 1, nil
+	}
+	res, err := s.client.GetRawTransaction(*head, BackendL1)
+	if errors.Is(err, errElementNotFound) || (err != nil && strings.Contains(err.Error(), "USE_INBOX_BATCH_INDEX")) {
+		return latest, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("Cannot get batch of index %d: %w", *head, err)
+	}
+	if res == nil || res.Batch == nil || res.Batch.Index > latest {
+		return latest, nil
+	}
+	log.Info("Reconciling batches from the batch of the local head", "index", *head, "batch-index", res.Batch.Index)
+	return res.Batch.Index, nil
+}
+
+// reconcileBatch compares a single batch with the local chain. It returns
+// false when the batch still diverges after it was processed, which only
+// happens in alarm mode.
+func (s *SyncService) reconcileBatch(index uint64) (bool, error) {
+	posted, err := s.fetchPostedBlocks(index)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range posted {
+		if p.number == 0 {
+			return false, errReorgGenesis
+		}
+		local := s.bc.GetBlockByNumber(p.number)
+		if local != nil {
+			if p.matches(local) {
+				continue
+			}
+			rewound, err := s.handleDivergence(p, local, index)
+			if err != nil || !rewound {
+				return false, err
+			}
+		}
+		// The block is missing locally, either because the chain was just
+		// rewound or because the node is behind L1
+		if err := s.reexecute(p); err != nil {
+			return false, fmt.Errorf("Cannot re-execute block %d: %w", p.number, err)
+		}
+	}
+	return true, nil
+}
+
+// handleDivergence reports a mismatch between L1 and the local chain and
+// rewinds the chain unless running in alarm mode or the reorg is deeper than
+// allowed. It returns true when the chain was rewound.
+func (s *SyncService) handleDivergence(p *postedBlock, local *types.Block, batchIndex uint64) (bool, error) {
+	current := s.bc.CurrentBlock().NumberU64()
+	depth := current - p.number + 1
+	var remote string
+	if len(p.txs) > 0 {
+		remote = p.txs[0].Hash().Hex()
+	}
+	log.Error("Local chain diverges from L1", "block", p.number, "batch-index", batchIndex, "local", local.Hash().Hex(), "remote-tx", remote, "depth", depth)
+
+	if s.reorgAlarmOnly {
+		return false, nil
+	}
+	if p.number == 1 {
+		return false, errReorgGenesis
+	}
+	if s.reorgMaxDepth != 0 && depth > s.reorgMaxDepth {
+		return false, fmt.Errorf("%w: depth %d, max %d", errReorgTooDeep, depth, s.reorgMaxDepth)
+	}
+	if err := s.rewind(p.number-1, batchIndex); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// rewind sets the head of the chain to the given block and resets the
+// rollup indices to match it
+func (s *SyncService) rewind(number uint64, batchIndex uint64) error {
+	log.Warn("Rewinding chain to match L1", "number", number, "batch-index", batchIndex)
+	if s.seqAdapter != nil {
+		s.seqAdapter.RewindSequencerTxs(number)
+	}
+	if err := s.bc.SetHead(number); err != nil {
+		return fmt.Errorf("Cannot rewind chain to %d: %w", number, err)
+	}
+	head := s.bc.CurrentBlock()
+	if head.NumberU64() != number {
+		return fmt.Errorf("Chain rewound to %d instead of %d", head.NumberU64(), number)
+	}
+
+	// The CTC index is the block number minus one
+	index := number - 1
+	s.SetLatestIndex(&index)
+	s.SetLatestVerifiedIndex(&index)
+	s.SetLatestEnqueueIndex(latestQueueIndexAt(s.bc, head))
+	if batchIndex > 0 {
+		prev := batchIndex - 1
+		s.SetLatestBatchIndex(&prev)
+	}
+	if txs := head.Transactions(); len(txs) > 0 {
+		tx := txs[len(txs)-1]
+		s.SetLatestL1Timestamp(tx.L1Timestamp())
+		if bn := tx.L1BlockNumber(); bn != nil {
+			s.SetLatestL1BlockNumber(bn.Uint64())
+		}
+	}
+	log.Info("Rewound chain to match L1", "number", number, "index", index, "queue-index", stringify(s.GetLatestEnqueueIndex()))
+	return nil
+}
+
+// reexecute applies a posted block on top of the local chain
+func (s *SyncService) reexecute(p *postedBlock) error {
+	if p.block != nil {
+		return s.applyBlock(p.block)
+	}
+	for _, tx := range p.txs {
+		if err := s.applyBatchedTransaction(tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
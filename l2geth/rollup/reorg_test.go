@@ -0,0 +1,175 @@
+package rollup
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/consensus/ethash"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/core/vm"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+// batchClient is a RollupClient that serves transaction batches of
+// batchSize transactions, a single batch by default
+type batchClient struct {
+	RollupClient
+	txs       []*types.Transaction
+	batchSize int
+	requested []uint64
+}
+
+func (c *batchClient) size() int {
+	if c.batchSize == 0 {
+		return len(c.txs)
+	}
+	return c.batchSize
+}
+
+func (c *batchClient) GetLatestTransactionBatchIndex() (*uint64, error) {
+	index := uint64((len(c.txs) - 1) / c.size())
+	return &index, nil
+}
+
+func (c *batchClient) GetTransactionBatch(index uint64) (*Batch, []*types.Transaction, error) {
+	start := int(index) * c.size()
+	if start >= len(c.txs) {
+		return nil, nil, errElementNotFound
+	}
+	end := start + c.size()
+	if end > len(c.txs) {
+		end = len(c.txs)
+	}
+	c.requested = append(c.requested, index)
+	return &Batch{Index: index}, c.txs[start:end], nil
+}
+
+func (c *batchClient) GetRawTransaction(index uint64, backend Backend) (*TransactionResponse, error) {
+	if index >= uint64(len(c.txs)) {
+		return nil, errElementNotFound
+	}
+	return &TransactionResponse{Batch: &Batch{Index: index / uint64(c.size())}}, nil
+}
+
+// newReorgTestService creates a SyncService on top of a chain of blocks
+// with one transaction each. The returned transactions are the posted
+// versions of the local ones.
+func newReorgTestService(t *testing.T, blocks int) (*SyncService, []*types.Transaction) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(1e18)}},
+	}
+	genesis := gspec.MustCommit(db)
+	signer := types.NewEIP155Signer(params.TestChainConfig.ChainID)
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, blocks, func(i int, gen *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addr), common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+		gen.AddTx(tx)
+	})
+	bc, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bc.InsertChain(chain); err != nil {
+		t.Fatal(err)
+	}
+
+	posted := make([]*types.Transaction, blocks)
+	for i, block := range chain {
+		tx := block.Transactions()[0]
+		posted[i] = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), tx.GasPrice(), tx.Data())
+		posted[i].SetIndex(uint64(i))
+	}
+	index := uint64(blocks - 1)
+	rawdb.WriteHeadIndex(db, index)
+
+	return &SyncService{bc: bc, db: db}, posted
+}
+
+func TestReconcileMatchingBatch(t *testing.T) {
+	service, posted := newReorgTestService(t, 4)
+	service.client = &batchClient{txs: posted}
+
+	if err := service.reconcileBatchesToTip(); err != nil {
+		t.Fatal(err)
+	}
+	if index := service.GetLatestBatchIndex(); index == nil || *index != 0 {
+		t.Fatal("matching batch was not marked as reconciled")
+	}
+}
+
+func TestReconcileDivergence(t *testing.T) {
+	service, posted := newReorgTestService(t, 4)
+	posted[2] = types.NewTransaction(0, common.Address{2}, common.Big0, 21000, common.Big0, nil)
+	posted[2].SetIndex(2)
+	service.client = &batchClient{txs: posted}
+
+	// In alarm mode the divergence is only reported
+	service.reorgAlarmOnly = true
+	if err := service.reconcileBatchesToTip(); err != nil {
+		t.Fatal(err)
+	}
+	if service.bc.CurrentBlock().NumberU64() != 4 {
+		t.Fatal("chain was rewound in alarm mode")
+	}
+	if service.GetLatestBatchIndex() != nil {
+		t.Fatal("diverging batch was marked as reconciled")
+	}
+
+	// Rewinding block 3 and 4 exceeds the max depth
+	service.reorgAlarmOnly = false
+	service.reorgMaxDepth = 1
+	if err := service.reconcileBatchesToTip(); !errors.Is(err, errReorgTooDeep) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if service.bc.CurrentBlock().NumberU64() != 4 {
+		t.Fatal("chain was rewound beyond the max depth")
+	}
+
+	// Rewind to the last matching block
+	if err := service.rewind(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if service.bc.CurrentBlock().NumberU64() != 2 {
+		t.Fatal("chain not rewound")
+	}
+	if index := service.GetLatestIndex(); index == nil || *index != 1 {
+		t.Fatal("latest index not reset")
+	}
+}
+
+func TestReconcileStartsAtHeadBatch(t *testing.T) {
+	service, posted := newReorgTestService(t, 6)
+	client := &batchClient{txs: posted, batchSize: 2}
+	service.client = client
+
+	// Without a reconciled batch the pass starts at the batch of the head
+	if err := service.reconcileBatchesToTip(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.requested) != 1 || client.requested[0] != 2 {
+		t.Fatalf("unexpected batches reconciled: %v", client.requested)
+	}
+	if index := service.GetLatestBatchIndex(); index == nil || *index != 2 {
+		t.Fatal("head batch was not marked as reconciled")
+	}
+
+	// The head is not posted yet, only the latest batch is reconciled
+	service, posted = newReorgTestService(t, 6)
+	client = &batchClient{txs: posted[:4], batchSize: 2}
+	service.client = client
+	if err := service.reconcileBatchesToTip(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.requested) != 1 || client.requested[0] != 1 {
+		t.Fatalf("unexpected batches reconciled: %v", client.requested)
+	}
+}
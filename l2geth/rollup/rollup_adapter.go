@@ -4,28 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"math/big"
-	"net/http"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum-optimism/optimism/l2geth/contracts/checkpointoracle/contract/seqset"
 	"github.com/ethereum-optimism/optimism/l2geth/core"
 
 	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
 	"github.com/ethereum-optimism/optimism/l2geth/core/types"
 	"github.com/ethereum-optimism/optimism/l2geth/ethclient"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/event"
 	"github.com/ethereum-optimism/optimism/l2geth/log"
-	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
+	"github.com/ethereum-optimism/optimism/l2geth/metrics"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/pos"
 )
 
 // RollupAdapter is the adapter for decentralized sequencers
 // that is required by the SyncService
 type RollupAdapter interface {
-	RecoverSeqAddress(tx *types.Transaction) (string, error)
+	// recover the sequencer of a tx in the block number
+	RecoverSeqAddress(tx *types.Transaction, number uint64) (string, error)
+	// get the epoch that the sequencer signature of a tx is bound to
+	SeqSignEpoch(tx *types.Transaction, number uint64) (*big.Int, error)
 	// get tx sequencer for checking tx is valid
 	GetTxSequencer(tx *types.Transaction, expectIndex uint64) (common.Address, error)
 	GetEpochByBlockNumber(expectIndex uint64) (struct {
@@ -40,15 +46,51 @@ type RollupAdapter interface {
 	GetSeqValidHeight() uint64
 	GetFinalizedBlock() (uint64, error)
 	CheckPosLayerSynced() (bool, error)
+	// cross-check the current epoch of the PoS layer with the seqset contract
+	CheckPosEpoch() error
+	PosClient() pos.Client
 	ParseUpdateSeqData(data []byte) (bool, common.Address, *big.Int, *big.Int)
 	IsSeqSetContractCall(tx *types.Transaction) (bool, []byte)
 	IsRespanCall(tx *types.Transaction) bool
 	SetPreRespan(oldAddress common.Address, newAddress common.Address, number uint64) error
+	// queue the pre-respan of a respan tx that was applied successfully in
+	// the block number
+	SetPreRespanTx(tx *types.Transaction, number uint64)
+	CancelPreRespan(number uint64) error
+	PreRespans() []*types.PreRespan
+	SubscribePreRespanEvent(ch chan<- PreRespanEvent) event.Subscription
 	IsPreRespanSequencer(seqAddress string, number uint64) bool
 	IsNotNextRespanSequencer(seqAddress string, number uint64) bool
 	RemoveCachedSeqEpoch()
+	// check an address is a signer of the sequencer set
+	IsSeqSetSigner(addr common.Address) (bool, error)
+	// compare a sequencer signed tx in the block number with the ones seen before
+	ObserveSequencerTx(tx *types.Transaction, number uint64, source string)
+	// forget the sequencer signed txs of the blocks after number before the
+	// chain is rewound to it
+	RewindSequencerTxs(number uint64)
+	// recover and cache the senders and sequencers of blocks or batched txs
+	// concurrently ahead of their insertion
+	CacheSeqSenders(blocks []*types.Block)
+	CacheBatchSeqSenders(txs []*types.Transaction)
+	// get the mpc address that signs the respans of the seqset contract
+	SeqSetMpcAddress() (common.Address, error)
 }
 
+// posRequestTimeout bounds a call of the PoS layer including its retries
+const posRequestTimeout = 15 * time.Second
+
+var (
+	errNoPosClient      = errors.New("pos client not configured")
+	errPosEpochMismatch = errors.New("pos epoch does not match the seqset contract")
+
+	posEpochMismatchMeter = metrics.NewRegisteredMeter("rollup/pos/epochmismatch", nil)
+)
+
+// seqSetSignerEpochs is the number of recent epochs whose signers make up the
+// signer set of the sequencer set
+const seqSetSignerEpochs = 10
+
 // Cached seq epoch, if recommit or block number < start | > end, clear cache with status false
 type CachedSeqEpoch struct {
 	Signer     common.Address
@@ -58,13 +100,6 @@ type CachedSeqEpoch struct {
 	Status     bool
 }
 
-// RreRespan is called by bridge, to notify sequencer to prevent save p2p blocks >= RespanStartBlock
-type PreRespan struct {
-	PreSigner        common.Address
-	NewSigner        common.Address
-	RespanStartBlock uint64
-}
-
 // SeqAdapter is an adpater used by sequencer based RollupClient
 type SeqAdapter struct {
 	// posClient  // connnect to pos layer
@@ -73,24 +108,26 @@ type SeqAdapter struct {
 	seqContractValidHeight uint64         // l2 seq contract valid height
 	localL2Url             string
 	localL2Conn            *ethclient.Client
-	metisPosURL            string
-	metisPosClient         *http.Client
+	posClient              pos.Client // nil when no PoS layer is configured
 	bc                     *core.BlockChain
+	db                     ethdb.Database // holds the sequencer epoch index
 	seqContract            *seqset.Seqset
 	cachedSeqEpoch         *CachedSeqEpoch
 	cachedSeqMux           sync.Mutex
-	preRespan              *PreRespan
+	posEpochChecking       int32 // set while a cross-check of the PoS epoch runs
+	preRespans             *preRespanQueue
+	equivocations          *equivocationDetector
 }
 
-func NewSeqAdapter(l2SeqContract common.Address, seqContractValidHeight uint64, posClientUrl, localL2Url string, bc *core.BlockChain) *SeqAdapter {
-	return &SeqAdapter{
+func NewSeqAdapter(l2SeqContract common.Address, seqContractValidHeight uint64, posClientUrl, localL2Url string, bc *core.BlockChain, db ethdb.Database) *SeqAdapter {
+	s := &SeqAdapter{
 		l2SeqContract:          l2SeqContract,
 		seqContractValidHeight: seqContractValidHeight,
-		metisPosURL:            posClientUrl,
 		localL2Url:             localL2Url,
 
-		metisPosClient: &http.Client{},
-		bc:             bc,
+		bc:         bc,
+		db:         db,
+		preRespans: newPreRespanQueue(db),
 		cachedSeqEpoch: &CachedSeqEpoch{
 			Signer:     common.HexToAddress("0x0"),
 			StartBlock: new(big.Int).SetUint64(0),
@@ -98,6 +135,17 @@ func NewSeqAdapter(l2SeqContract common.Address, seqContractValidHeight uint64,
 			Status:     false,
 		},
 	}
+	if posClientUrl != "" {
+		s.posClient = pos.NewClient(posClientUrl, pos.DefaultConfig)
+	}
+	s.equivocations = newEquivocationDetector(db, bc.Config(), s.SeqSignEpoch, func(number uint64) *types.Transaction {
+		block := bc.GetBlockByNumber(number)
+		if block == nil || block.Transactions().Len() == 0 {
+			return nil
+		}
+		return block.Transactions()[0]
+	})
+	return s
 }
 
 func (s *SeqAdapter) ParseUpdateSeqData(data []byte) (bool, common.Address, *big.Int, *big.Int) {
@@ -157,6 +205,16 @@ func (s *SeqAdapter) GetEpochByBlockNumber(expectIndex uint64) (struct {
 		StartBlock *big.Int
 		EndBlock   *big.Int
 	})
+	// read the epoch index of the chain first
+	if s.db != nil {
+		if epoch := rawdb.ReadSequencerEpochAt(s.db, expectIndex); epoch != nil {
+			ret.Number = new(big.Int).SetUint64(epoch.ID)
+			ret.Signer = epoch.Signer
+			ret.StartBlock = new(big.Int).SetUint64(epoch.StartBlock)
+			ret.EndBlock = new(big.Int).SetUint64(epoch.EndBlock)
+			return *ret, nil
+		}
+	}
 	err := s.ensureSeqContract()
 	if err != nil {
 		return *ret, err
@@ -197,11 +255,8 @@ func (s *SeqAdapter) getSequencer(expectIndex uint64) (common.Address, error) {
 	if block != nil {
 		blockNumber = block.Number().Uint64()
 	}
-	// clear preRespan when block >= respanStart
-	if s.preRespan != nil && s.preRespan.RespanStartBlock <= blockNumber {
-		log.Info("clear pre respan")
-		s.preRespan = nil
-	}
+	// fire the pre-respans when block >= respanStart
+	s.preRespans.Fire(blockNumber)
 	if len(s.cachedSeqEpoch.RespanArr) > 0 {
 		// at this time, blockChain has not reach the respan height, pause sequencer with an error
 		respanStart := s.cachedSeqEpoch.RespanArr[0].Uint64()
@@ -228,6 +283,8 @@ func (s *SeqAdapter) getSequencer(expectIndex uint64) (common.Address, error) {
 		s.cachedSeqEpoch.StartBlock = epoch.StartBlock
 		s.cachedSeqEpoch.EndBlock = epoch.EndBlock
 		s.cachedSeqEpoch.Status = true
+		// cross-check the reloaded epoch with the PoS layer
+		s.crossCheckPosEpoch()
 		// loaded epoch cache
 		log.Info("get tx seqeuencer loaded epoch cache", "status", s.cachedSeqEpoch.Status, "start", s.cachedSeqEpoch.StartBlock.Uint64(), "end", s.cachedSeqEpoch.EndBlock.Uint64(), "signer", s.cachedSeqEpoch.Signer.String())
 	}
@@ -259,14 +316,44 @@ func (s *SeqAdapter) GetFinalizedBlock() (uint64, error) {
 	return finalizedBlock.Uint64(), nil
 }
 
-func (s *SeqAdapter) RecoverSeqAddress(tx *types.Transaction) (string, error) {
-	addr, err := core.RecoverSeqAddress(tx)
+func (s *SeqAdapter) RecoverSeqAddress(tx *types.Transaction, number uint64) (string, error) {
+	var epoch *big.Int
+	if s.bc.Config().IsSeqSignEnabled(new(big.Int).SetUint64(number)) {
+		var err error
+		if epoch, err = s.SeqSignEpoch(tx, number); err != nil {
+			return "", err
+		}
+	}
+	addr, err := core.RecoverSeqAddressAt(s.bc.Config(), tx, number, epoch)
 	if err != nil {
 		return "", err
 	}
 	return addr.String(), nil
 }
 
+// SeqSignEpoch returns the epoch that the sequencer signature of a tx in the
+// block number is bound to. It is zero before the sequencer set is valid, the
+// new epoch for recommits and otherwise the epoch of the block. Past the end
+// of the last committed epoch its sequencer keeps signing in that epoch.
+func (s *SeqAdapter) SeqSignEpoch(tx *types.Transaction, number uint64) (*big.Int, error) {
+	if number <= s.seqContractValidHeight {
+		return new(big.Int), nil
+	}
+	if tx != nil && tx.To() != nil && *tx.To() == s.l2SeqContract {
+		if isRecommit, epoch := core.DecodeReCommitEpoch(tx.Data()); isRecommit {
+			return epoch, nil
+		}
+	}
+	epoch, err := s.GetEpochByBlockNumber(number)
+	if err == nil {
+		return epoch.Number, nil
+	}
+	if err.Error() != "get sequencer incorrect epoch number" {
+		return nil, err
+	}
+	return s.seqContract.CurrentEpochNumber(nil)
+}
+
 func (s *SeqAdapter) IsSeqSetContractCall(tx *types.Transaction) (bool, []byte) {
 	if (tx.To() == nil || s.l2SeqContract == common.Address{}) {
 		return false, nil
@@ -303,41 +390,91 @@ func (s *SeqAdapter) IsRespanCall(tx *types.Transaction) bool {
 	return isRespan
 }
 
+// SetPreRespan queues a respan that the bridge announced, to prevent saving
+// p2p blocks >= number from the old signer
 func (s *SeqAdapter) SetPreRespan(oldAddress common.Address, newAddress common.Address, number uint64) error {
-	// s.preRespan = &PreRespan{
-	// 	PreSigner:        oldAddress,
-	// 	NewSigner:        newAddress,
-	// 	RespanStartBlock: number,
-	// }
-	log.Info("set pre respan, log", "preSigner", oldAddress.Hex(), "newSigner", newAddress.Hex(), "startBlock", number)
+	if number == 0 || (newAddress == common.Address{}) {
+		return fmt.Errorf("invalid pre respan, newSigner %s, startBlock %d", newAddress.Hex(), number)
+	}
+	s.preRespans.Add(&types.PreRespan{
+		PreSigner:  oldAddress,
+		NewSigner:  newAddress,
+		StartBlock: number,
+		Source:     types.PreRespanSourceBridge,
+	})
 	return nil
 }
 
+// SetPreRespanTx queues the respan of a recommit tx that was applied
+// successfully in the block number, if the respan starts after it
+func (s *SeqAdapter) SetPreRespanTx(tx *types.Transaction, number uint64) {
+	seqOper, data := s.IsSeqSetContractCall(tx)
+	if !seqOper {
+		return
+	}
+	isRespan, newSigner, startBlock, _ := s.ParseUpdateSeqData(data)
+	if !isRespan || startBlock.Uint64() <= number {
+		return
+	}
+	var preSigner common.Address
+	if epoch, err := s.GetEpochByBlockNumber(number); err == nil {
+		preSigner = epoch.Signer
+	}
+	s.preRespans.Add(&types.PreRespan{
+		PreSigner:  preSigner,
+		NewSigner:  newSigner,
+		StartBlock: startBlock.Uint64(),
+		Source:     types.PreRespanSourceTx,
+		TxHash:     tx.Hash(),
+	})
+}
+
+// CancelPreRespan removes the pending pre-respan that starts at the number
+func (s *SeqAdapter) CancelPreRespan(number uint64) error {
+	return s.preRespans.Cancel(number)
+}
+
+// PreRespans returns the pending pre-respans ordered by start block
+func (s *SeqAdapter) PreRespans() []*types.PreRespan {
+	return s.preRespans.List()
+}
+
+// SubscribePreRespanEvent registers a subscription of PreRespanEvent, sent
+// when a pre-respan is created, fired or cleared
+func (s *SeqAdapter) SubscribePreRespanEvent(ch chan<- PreRespanEvent) event.Subscription {
+	return s.preRespans.Subscribe(ch)
+}
+
+// pendingPreRespan returns the pre-respan that the block number falls under
+// after firing the ones the chain reached
+func (s *SeqAdapter) pendingPreRespan(number uint64) *types.PreRespan {
+	if block := s.bc.CurrentBlock(); block != nil {
+		s.preRespans.Fire(block.NumberU64())
+	}
+	return s.preRespans.At(number)
+}
+
 func (s *SeqAdapter) IsPreRespanSequencer(seqAddress string, number uint64) bool {
-	if s.preRespan == nil || s.preRespan.RespanStartBlock == 0 || (s.preRespan.PreSigner == common.Address{}) {
+	preRespan := s.pendingPreRespan(number)
+	if preRespan == nil || (preRespan.PreSigner == common.Address{}) {
 		return false
 	}
-	if number >= s.preRespan.RespanStartBlock && strings.EqualFold(s.preRespan.PreSigner.Hex(), seqAddress) {
-		return true
-	}
-	return false
+	return strings.EqualFold(preRespan.PreSigner.Hex(), seqAddress)
 }
 
 func (s *SeqAdapter) IsNotNextRespanSequencer(seqAddress string, number uint64) bool {
-	if s.preRespan == nil || s.preRespan.RespanStartBlock == 0 || (s.preRespan.NewSigner == common.Address{}) {
+	preRespan := s.pendingPreRespan(number)
+	if preRespan == nil || (preRespan.NewSigner == common.Address{}) {
 		return false
 	}
-	if number >= s.preRespan.RespanStartBlock && !strings.EqualFold(s.preRespan.NewSigner.Hex(), seqAddress) {
-		return true
-	}
-	return false
+	return !strings.EqualFold(preRespan.NewSigner.Hex(), seqAddress)
 }
 
 func (s *SeqAdapter) GetTxSequencer(tx *types.Transaction, expectIndex uint64) (common.Address, error) {
 	// check is update sequencer operate
 	if expectIndex <= s.seqContractValidHeight {
 		// return default address
-		return rcfg.DefaultSeqAdderss, nil
+		return s.bc.GetVMConfig().OVM.Sequencer(), nil
 	}
 
 	s.cachedSeqMux.Lock()
@@ -417,6 +554,87 @@ func (s *SeqAdapter) GetTxSequencer(tx *types.Transaction, expectIndex uint64) (
 	return address, err
 }
 
+// IsSeqSetSigner returns whether an address signs one of the recent epochs of
+// the sequencer set. Before the sequencer set is valid only the default
+// sequencer is a signer.
+func (s *SeqAdapter) IsSeqSetSigner(addr common.Address) (bool, error) {
+	if addr == (common.Address{}) {
+		return false, nil
+	}
+	if addr == s.bc.GetVMConfig().OVM.Sequencer() {
+		return true, nil
+	}
+	block := s.bc.CurrentBlock()
+	if block == nil || block.NumberU64() <= s.seqContractValidHeight {
+		return false, nil
+	}
+	err := s.ensureSeqContract()
+	if err != nil {
+		return false, err
+	}
+	currentEpochNumber, err := s.seqContract.CurrentEpochNumber(nil)
+	if err != nil {
+		log.Error("Get sequencer error when CurrentEpochNumber", "err", err)
+		return false, err
+	}
+	current := currentEpochNumber.Uint64()
+	for i := uint64(0); i < seqSetSignerEpochs && i <= current; i++ {
+		id := current - i
+		if s.db != nil {
+			if epoch := rawdb.ReadSequencerEpoch(s.db, id); epoch != nil {
+				if epoch.Signer == addr {
+					return true, nil
+				}
+				continue
+			}
+		}
+		epoch, err := s.seqContract.Epochs(nil, new(big.Int).SetUint64(id))
+		if err != nil {
+			log.Error("Get sequencer error when Epochs", "err", err)
+			return false, err
+		}
+		if epoch.Signer == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ObserveSequencerTx checks a sequencer signed tx that the node saw for the
+// block number against the txs of its signer seen before for the block, and
+// stores the evidence of an equivocation
+func (s *SeqAdapter) ObserveSequencerTx(tx *types.Transaction, number uint64, source string) {
+	s.equivocations.Observe(tx, number, source)
+}
+
+// RewindSequencerTxs forgets the sequencer signed txs of the blocks after
+// number, so they are not taken as equivocations when the blocks are produced
+// again. It is called before the chain is rewound to number.
+func (s *SeqAdapter) RewindSequencerTxs(number uint64) {
+	s.equivocations.Rewind(number)
+}
+
+// CacheSeqSenders recovers the senders and the sequencers of the txs of
+// blocks on the worker pool and returns when the sequencers are cached
+func (s *SeqAdapter) CacheSeqSenders(blocks []*types.Block) {
+	core.CacheSeqSenders(s.bc.Config(), blocks, s.SeqSignEpoch)
+}
+
+// CacheBatchSeqSenders recovers the senders and the sequencers of batched
+// txs on the worker pool and returns when the sequencers are cached
+func (s *SeqAdapter) CacheBatchSeqSenders(txs []*types.Transaction) {
+	core.CacheBatchSeqSenders(s.bc.Config(), txs, s.SeqSignEpoch)
+}
+
+// SeqSetMpcAddress returns the mpc address of the seqset contract, the only
+// sender of respans
+func (s *SeqAdapter) SeqSetMpcAddress() (common.Address, error) {
+	if err := s.ensureSeqContract(); err != nil {
+		return common.Address{}, err
+	}
+	return s.seqContract.MpcAddress(nil)
+}
+
 func (s *SeqAdapter) RemoveCachedSeqEpoch() {
 	s.cachedSeqMux.Lock()
 	defer s.cachedSeqMux.Unlock()
@@ -429,20 +647,69 @@ func (s *SeqAdapter) CheckPosLayerSynced() (bool, error) {
 	if s == nil {
 		return false, errors.New("client is null")
 	}
-	path := fmt.Sprintf("%v/checkPosIsSynced", s.metisPosURL)
-	resp, err := s.metisPosClient.Get(path)
+	if s.posClient == nil {
+		return false, errNoPosClient
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), posRequestTimeout)
+	defer cancel()
+	return s.posClient.IsSynced(ctx)
+}
 
+// CheckPosEpoch compares the current epoch of the PoS layer with the same
+// epoch of the seqset contract. An epoch that the contract does not have yet
+// is not a mismatch.
+func (s *SeqAdapter) CheckPosEpoch() error {
+	if s.posClient == nil {
+		return errNoPosClient
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), posRequestTimeout)
+	defer cancel()
+	posEpoch, err := s.posClient.CurrentEpoch(ctx)
 	if err != nil {
-		fmt.Println("error:", err)
-		return false, err
+		return err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	if err := s.ensureSeqContract(); err != nil {
+		return err
+	}
+	currentEpochNumber, err := s.seqContract.CurrentEpochNumber(nil)
 	if err != nil {
-		return false, err
+		return err
+	}
+	if posEpoch.ID > currentEpochNumber.Uint64() {
+		log.Debug("PoS epoch not committed to the seqset contract yet", "pos", posEpoch.ID, "contract", currentEpochNumber.Uint64())
+		return nil
+	}
+	epoch, err := s.seqContract.Epochs(nil, new(big.Int).SetUint64(posEpoch.ID))
+	if err != nil {
+		return err
 	}
-	//fmt.Println(string(body))
+	if epoch.Signer != posEpoch.Signer() || epoch.StartBlock.Uint64() != posEpoch.StartBlock || epoch.EndBlock.Uint64() != posEpoch.EndBlock {
+		posEpochMismatchMeter.Mark(1)
+		log.Warn("PoS epoch does not match the seqset contract", "epoch", posEpoch.ID,
+			"pos-signer", posEpoch.Signer().Hex(), "pos-start", posEpoch.StartBlock, "pos-end", posEpoch.EndBlock,
+			"seqset-signer", epoch.Signer.Hex(), "seqset-start", epoch.StartBlock, "seqset-end", epoch.EndBlock)
+		return fmt.Errorf("%w: epoch %d", errPosEpochMismatch, posEpoch.ID)
+	}
+	return nil
+}
 
-	return strconv.ParseBool(string(body))
+// crossCheckPosEpoch runs CheckPosEpoch in the background, the PoS layer is
+// not called with the epoch cache locked. A check that runs already is not
+// started again.
+func (s *SeqAdapter) crossCheckPosEpoch() {
+	if s.posClient == nil || !atomic.CompareAndSwapInt32(&s.posEpochChecking, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&s.posEpochChecking, 0)
+		if err := s.CheckPosEpoch(); err != nil && !errors.Is(err, errPosEpochMismatch) {
+			log.Debug("Cannot cross-check the PoS epoch", "err", err)
+		}
+	}()
+}
 
+// PosClient returns the client of the PoS layer, nil when it is not
+// configured
+func (s *SeqAdapter) PosClient() pos.Client {
+	return s.posClient
 }
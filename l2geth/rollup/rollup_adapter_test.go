@@ -0,0 +1,57 @@
+package rollup
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/pos"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/pos/postest"
+)
+
+func TestCheckPosLayerSynced(t *testing.T) {
+	if _, err := (&SeqAdapter{}).CheckPosLayerSynced(); !errors.Is(err, errNoPosClient) {
+		t.Fatalf("unexpected error without a PoS layer: %v", err)
+	}
+
+	server := postest.NewServer()
+	defer server.Close()
+	adapter := &SeqAdapter{posClient: pos.NewClient(server.URL, pos.Config{Timeout: time.Second, MaxRetries: 1, BackoffBase: time.Millisecond})}
+	if synced, err := adapter.CheckPosLayerSynced(); err != nil || !synced {
+		t.Fatalf("unexpected synced status: %v, %v", synced, err)
+	}
+	server.SetSynced(false)
+	server.FailNext(1)
+	if synced, err := adapter.CheckPosLayerSynced(); err != nil || synced {
+		t.Fatalf("unexpected synced status: %v, %v", synced, err)
+	}
+}
+
+func TestCrossCheckPosEpochAsync(t *testing.T) {
+	// The PoS layer only answers when the test releases it
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	adapter := &SeqAdapter{posClient: pos.NewClient(server.URL, pos.Config{Timeout: time.Minute, BackoffBase: time.Millisecond})}
+	start := time.Now()
+	adapter.crossCheckPosEpoch()
+	adapter.crossCheckPosEpoch()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("cross-check blocked the caller for %v", elapsed)
+	}
+	waitFor(t, "pos request", func() bool { return atomic.LoadInt32(&requests) == 1 })
+	close(release)
+	waitFor(t, "cross-check", func() bool { return atomic.LoadInt32(&adapter.posEpochChecking) == 0 })
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("a running cross-check was started again: %d requests", n)
+	}
+}
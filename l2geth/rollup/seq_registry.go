@@ -0,0 +1,331 @@
+package rollup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/ethclient"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/rpc"
+)
+
+const (
+	// defaultSeqInfoTTL is how long a sequencer info entry is kept after it
+	// was signed when no TTL is configured
+	defaultSeqInfoTTL = 10 * time.Minute
+	// seqInfoMaxSkew is how far in the future the timestamp of an entry may be
+	seqInfoMaxSkew = 30 * time.Second
+	// seqInfoProbeTimeout bounds the liveness probe of a single sequencer
+	seqInfoProbeTimeout = 3 * time.Second
+	// seqInfoPushTimeout bounds the push of the local entry to a sequencer
+	seqInfoPushTimeout = 5 * time.Second
+)
+
+var (
+	errSeqInfoSelf      = errors.New("sequencer info of the local sequencer")
+	errSeqInfoExpired   = errors.New("sequencer info expired")
+	errSeqInfoFuture    = errors.New("sequencer info timestamp in the future")
+	errSeqInfoSignature = errors.New("invalid sequencer info signature")
+	errSeqInfoSigner    = errors.New("sequencer not in the seqset signer set")
+	errSeqInfoStale     = errors.New("sequencer info older than the known one")
+)
+
+// seqRegistry keeps the sequencer info entries that other sequencers signed
+// with their sequencer keys. Only signers of the seqset contract are accepted,
+// the entries are persisted in the node database and dropped a TTL after
+// they were signed.
+//
+// A sequencer that announces its url re-signs its own entry every half TTL
+// and pushes it to the sequencers it knows, so its entry does not expire at
+// them while it runs. Adding the entry of one sequencer to another is enough
+// for both to keep each other's entry fresh.
+type seqRegistry struct {
+	db       ethdb.Database
+	chainID  *big.Int
+	self     common.Address
+	ttl      time.Duration
+	isSigner func(common.Address) (bool, error)
+	probe    func(ctx context.Context, url string) (uint64, error)
+	push     func(ctx context.Context, url string, info *types.SequencerInfo) error
+	now      func() time.Time
+
+	mu    sync.RWMutex
+	infos map[common.Address]*types.SequencerInfo
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newSeqRegistry loads the unexpired sequencer info entries of the database
+func newSeqRegistry(db ethdb.Database, chainID *big.Int, self common.Address, ttl time.Duration, isSigner func(common.Address) (bool, error)) *seqRegistry {
+	if ttl == 0 {
+		ttl = defaultSeqInfoTTL
+	}
+	r := &seqRegistry{
+		db:       db,
+		chainID:  chainID,
+		self:     self,
+		ttl:      ttl,
+		isSigner: isSigner,
+		probe:    probeSequencerHeight,
+		push:     pushSequencerInfo,
+		now:      time.Now,
+		infos:    make(map[common.Address]*types.SequencerInfo),
+		quit:     make(chan struct{}),
+	}
+	for _, info := range rawdb.ReadSequencerInfos(db) {
+		r.infos[info.SequencerAddress] = info
+	}
+	r.expire()
+	log.Info("Loaded sequencer infos", "count", len(r.infos), "ttl", ttl)
+	return r
+}
+
+// expired returns whether an entry is older than the TTL
+func (r *seqRegistry) expired(info *types.SequencerInfo) bool {
+	return r.now().Sub(time.Unix(int64(info.Timestamp), 0)) > r.ttl
+}
+
+// expire drops the expired entries from memory and the database
+func (r *seqRegistry) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for addr, info := range r.infos {
+		if r.expired(info) {
+			log.Debug("Sequencer info expired", "address", addr.Hex(), "url", info.SequencerUrl)
+			delete(r.infos, addr)
+			rawdb.DeleteSequencerInfo(r.db, addr)
+		}
+	}
+}
+
+// Add verifies a sequencer info entry and stores it if it is newer than the
+// known entry of the sequencer
+func (r *seqRegistry) Add(info *types.SequencerInfo) error {
+	if info.SequencerAddress == r.self {
+		return errSeqInfoSelf
+	}
+	signed := time.Unix(int64(info.Timestamp), 0)
+	if signed.Sub(r.now()) > seqInfoMaxSkew {
+		return fmt.Errorf("%w: %d", errSeqInfoFuture, info.Timestamp)
+	}
+	if r.expired(info) {
+		return fmt.Errorf("%w: %d", errSeqInfoExpired, info.Timestamp)
+	}
+	if err := r.verify(info); err != nil {
+		return err
+	}
+	isSigner, err := r.isSigner(info.SequencerAddress)
+	if err != nil {
+		return fmt.Errorf("Cannot check the seqset signer set: %w", err)
+	}
+	if !isSigner {
+		return fmt.Errorf("%w: %s", errSeqInfoSigner, info.SequencerAddress.Hex())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if known := r.infos[info.SequencerAddress]; known != nil && known.Timestamp >= info.Timestamp && !r.expired(known) {
+		return fmt.Errorf("%w: %d, known %d", errSeqInfoStale, info.Timestamp, known.Timestamp)
+	}
+	entry := &types.SequencerInfo{
+		SequencerAddress: info.SequencerAddress,
+		SequencerUrl:     info.SequencerUrl,
+		Timestamp:        info.Timestamp,
+		Signature:        common.CopyBytes(info.Signature),
+	}
+	r.infos[entry.SequencerAddress] = entry
+	rawdb.WriteSequencerInfo(r.db, entry)
+	log.Info("Added sequencer info", "address", entry.SequencerAddress.Hex(), "url", entry.SequencerUrl)
+	return nil
+}
+
+// verify checks that an entry is signed by the sequencer it claims
+func (r *seqRegistry) verify(info *types.SequencerInfo) error {
+	if len(info.Signature) != crypto.SignatureLength {
+		return fmt.Errorf("%w: length %d", errSeqInfoSignature, len(info.Signature))
+	}
+	signature := common.CopyBytes(info.Signature)
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+	pub, err := crypto.SigToPub(info.SigHash(r.chainID).Bytes(), signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errSeqInfoSignature, err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pub); recovered != info.SequencerAddress {
+		return fmt.Errorf("%w: signed by %s", errSeqInfoSignature, recovered.Hex())
+	}
+	return nil
+}
+
+// Url returns the url of a sequencer, empty if no unexpired entry is known
+func (r *seqRegistry) Url(addr common.Address) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info := r.infos[addr]
+	if info == nil || r.expired(info) {
+		return ""
+	}
+	return info.SequencerUrl
+}
+
+// List returns the unexpired entries ordered by address. The sequencers are
+// probed concurrently for their head height, so a dead sequencer delays the
+// list by at most the probe timeout.
+func (r *seqRegistry) List(ctx context.Context) []types.SequencerInfo {
+	r.expire()
+
+	r.mu.RLock()
+	list := make([]types.SequencerInfo, 0, len(r.infos))
+	for _, info := range r.infos {
+		list = append(list, *info)
+	}
+	r.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].SequencerAddress.Bytes(), list[j].SequencerAddress.Bytes()) < 0
+	})
+
+	var wg sync.WaitGroup
+	for i := range list {
+		wg.Add(1)
+		go func(info *types.SequencerInfo) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, seqInfoProbeTimeout)
+			defer cancel()
+			height, err := r.probe(ctx, info.SequencerUrl)
+			if err != nil {
+				log.Warn("Cannot reach sequencer", "address", info.SequencerAddress.Hex(), "url", info.SequencerUrl, "err", err)
+				return
+			}
+			info.SequencerHeight = height
+			info.Alive = true
+		}(&list[i])
+	}
+	wg.Wait()
+	return list
+}
+
+// probeSequencerHeight returns the head height of the sequencer at a url
+func probeSequencerHeight(ctx context.Context, url string) (uint64, error) {
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return header.Number.Uint64(), nil
+}
+
+// announce starts re-signing the entry of the local sequencer at a url and
+// pushing it to the known sequencers
+func (r *seqRegistry) announce(signer SeqSigner, url string) {
+	log.Info("Announcing sequencer info", "address", signer.Address().Hex(), "url", url, "interval", r.ttl/2)
+	r.wg.Add(1)
+	go r.announceLoop(signer, url)
+}
+
+// stop stops announcing the entry of the local sequencer
+func (r *seqRegistry) stop() {
+	close(r.quit)
+	r.wg.Wait()
+}
+
+func (r *seqRegistry) announceLoop(signer SeqSigner, url string) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.ttl / 2)
+	defer ticker.Stop()
+	for {
+		if err := r.pushSelf(signer, url); err != nil {
+			log.Warn("Cannot announce sequencer info", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// pushSelf signs the entry of the local sequencer at a url and pushes it to
+// the unexpired sequencers concurrently. The sequencers that cannot be
+// reached are logged, they get the entry of the next round.
+func (r *seqRegistry) pushSelf(signer SeqSigner, url string) error {
+	info, err := signSequencerInfo(signer, r.chainID, url, r.now())
+	if err != nil {
+		return err
+	}
+	r.expire()
+
+	r.mu.RLock()
+	urls := make([]string, 0, len(r.infos))
+	for _, known := range r.infos {
+		urls = append(urls, known.SequencerUrl)
+	}
+	r.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, peer := range urls {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), seqInfoPushTimeout)
+			defer cancel()
+			if err := r.push(ctx, peer, info); err != nil {
+				log.Warn("Cannot push sequencer info", "url", peer, "err", err)
+			}
+		}(peer)
+	}
+	wg.Wait()
+	log.Debug("Announced sequencer info", "url", url, "sequencers", len(urls))
+	return nil
+}
+
+// pushSequencerInfo adds an entry to the sequencer at a url
+func pushSequencerInfo(ctx context.Context, url string, info *types.SequencerInfo) error {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.CallContext(ctx, nil, "rollup_addSequencerInfo", info)
+}
+
+// signSequencerInfo returns the info entry of the local sequencer at a url,
+// signed with its sequencer key
+func signSequencerInfo(signer SeqSigner, chainID *big.Int, url string, now time.Time) (*types.SequencerInfo, error) {
+	info := &types.SequencerInfo{
+		SequencerAddress: signer.Address(),
+		SequencerUrl:     url,
+		Timestamp:        uint64(now.Unix()),
+	}
+	seqSign, err := signSequencerData(signer, info.SignData(chainID))
+	if err != nil {
+		return nil, err
+	}
+	signature := append(common.LeftPadBytes(seqSign.R.Bytes(), 32), common.LeftPadBytes(seqSign.S.Bytes(), 32)...)
+	info.Signature = append(signature, byte(seqSign.V.Uint64()))
+	return info, nil
+}
@@ -0,0 +1,171 @@
+package rollup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+)
+
+func TestSeqRegistry(t *testing.T) {
+	chainID := params.TestChainConfig.ChainID
+	db := rawdb.NewMemoryDatabase()
+	now := time.Unix(time.Now().Unix(), 0)
+
+	seqKey, _ := crypto.GenerateKey()
+	seq := crypto.PubkeyToAddress(seqKey.PublicKey)
+	otherKey, _ := crypto.GenerateKey()
+	other := crypto.PubkeyToAddress(otherKey.PublicKey)
+	isSigner := func(addr common.Address) (bool, error) { return addr == seq, nil }
+
+	newRegistry := func() *seqRegistry {
+		r := newSeqRegistry(db, chainID, testAddress, time.Minute, isSigner)
+		r.now = func() time.Time { return now }
+		return r
+	}
+	r := newRegistry()
+
+	signer, _ := NewKeySigner(common.Bytes2Hex(crypto.FromECDSA(seqKey)))
+	info, err := signSequencerInfo(signer, chainID, "http://seq:8545", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry signed by another key is rejected
+	forged := *info
+	forged.SequencerUrl = "http://attacker:8545"
+	if err := r.Add(&forged); !errors.Is(err, errSeqInfoSignature) {
+		t.Fatalf("forged entry accepted: %v", err)
+	}
+	// An entry of a sequencer outside the signer set is rejected
+	otherSigner, _ := NewKeySigner(common.Bytes2Hex(crypto.FromECDSA(otherKey)))
+	otherInfo, _ := signSequencerInfo(otherSigner, chainID, "http://other:8545", now)
+	if err := r.Add(otherInfo); !errors.Is(err, errSeqInfoSigner) {
+		t.Fatalf("entry of %s accepted: %v", other.Hex(), err)
+	}
+	if err := r.Add(info); err != nil {
+		t.Fatal(err)
+	}
+	if url := r.Url(seq); url != info.SequencerUrl {
+		t.Fatalf("unexpected url: %s", url)
+	}
+	// Replaying the entry does not override it
+	if err := r.Add(info); !errors.Is(err, errSeqInfoStale) {
+		t.Fatalf("replayed entry accepted: %v", err)
+	}
+
+	// The entry survives a restart and expires after the TTL
+	r = newRegistry()
+	if url := r.Url(seq); url != info.SequencerUrl {
+		t.Fatalf("entry not persisted: %s", url)
+	}
+	now = now.Add(2 * time.Minute)
+	if err := r.Add(info); !errors.Is(err, errSeqInfoExpired) {
+		t.Fatalf("expired entry accepted: %v", err)
+	}
+	if list := r.List(context.Background()); len(list) != 0 {
+		t.Fatalf("expired entry listed: %v", list)
+	}
+	if infos := rawdb.ReadSequencerInfos(db); len(infos) != 0 {
+		t.Fatalf("expired entry not deleted: %v", infos)
+	}
+}
+
+func TestSeqRegistryListProbesConcurrently(t *testing.T) {
+	chainID := params.TestChainConfig.ChainID
+	now := time.Now()
+	r := newSeqRegistry(rawdb.NewMemoryDatabase(), chainID, testAddress, time.Minute, func(common.Address) (bool, error) { return true, nil })
+
+	var live common.Address
+	for i, url := range []string{"http://dead-1", "http://dead-2", "http://live"} {
+		key, _ := crypto.GenerateKey()
+		signer, _ := NewKeySigner(common.Bytes2Hex(crypto.FromECDSA(key)))
+		info, _ := signSequencerInfo(signer, chainID, url, now)
+		if err := r.Add(info); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 {
+			live = signer.Address()
+		}
+	}
+	// Dead sequencers only answer when the probe times out
+	r.probe = func(ctx context.Context, url string) (uint64, error) {
+		if url == "http://live" {
+			return 42, nil
+		}
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	start := time.Now()
+	list := r.List(context.Background())
+	if elapsed := time.Since(start); elapsed > seqInfoProbeTimeout+time.Second {
+		t.Fatalf("dead sequencers probed one at a time: %v", elapsed)
+	}
+	if len(list) != 3 {
+		t.Fatalf("unexpected number of entries: %d", len(list))
+	}
+	for _, info := range list {
+		if alive := info.SequencerAddress == live; info.Alive != alive {
+			t.Fatalf("unexpected liveness of %s: %v", info.SequencerUrl, info.Alive)
+		}
+		if info.Alive && info.SequencerHeight != 42 {
+			t.Fatalf("unexpected height: %d", info.SequencerHeight)
+		}
+	}
+}
+
+func TestSeqRegistryAnnounce(t *testing.T) {
+	chainID := params.TestChainConfig.ChainID
+	now := time.Unix(time.Now().Unix(), 0)
+	isSigner := func(common.Address) (bool, error) { return true, nil }
+
+	// Two sequencers whose registries are reached by url
+	keyA, _ := crypto.GenerateKey()
+	keyB, _ := crypto.GenerateKey()
+	signerA, _ := NewKeySigner(common.Bytes2Hex(crypto.FromECDSA(keyA)))
+	signerB, _ := NewKeySigner(common.Bytes2Hex(crypto.FromECDSA(keyB)))
+	a := newSeqRegistry(rawdb.NewMemoryDatabase(), chainID, signerA.Address(), time.Minute, isSigner)
+	b := newSeqRegistry(rawdb.NewMemoryDatabase(), chainID, signerB.Address(), time.Minute, isSigner)
+	registries := map[string]*seqRegistry{"http://a": a, "http://b": b}
+	for _, r := range registries {
+		r.now = func() time.Time { return now }
+		r.push = func(ctx context.Context, url string, info *types.SequencerInfo) error {
+			return registries[url].Add(info)
+		}
+	}
+
+	// The entry of B is added to A once, from then on both keep each other's
+	// entry fresh
+	info, _ := signSequencerInfo(signerB, chainID, "http://b", now)
+	if err := a.Add(info); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := a.pushSelf(signerA, "http://a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.pushSelf(signerB, "http://b"); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(30 * time.Second)
+	}
+	if url := a.Url(signerB.Address()); url != "http://b" {
+		t.Fatalf("entry of b expired at a: %q", url)
+	}
+	if url := b.Url(signerA.Address()); url != "http://a" {
+		t.Fatalf("entry of a expired at b: %q", url)
+	}
+
+	// Without announcements the entries expire
+	now = now.Add(time.Minute)
+	if url := b.Url(signerA.Address()); url != "" {
+		t.Fatalf("entry of a not expired: %q", url)
+	}
+}
@@ -0,0 +1,331 @@
+package rollup
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/accounts"
+	"github.com/ethereum-optimism/optimism/l2geth/accounts/keystore"
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+	"github.com/ethereum-optimism/optimism/l2geth/rpc"
+)
+
+// remoteSignerTimeout bounds the requests to a remote signer
+const remoteSignerTimeout = 10 * time.Second
+
+var (
+	// errNoSeqSigner is returned when a sequencer signature is needed but no
+	// signer is configured
+	errNoSeqSigner = errors.New("seq signer not set")
+	// errInvalidSeqSignature is returned for signatures that are malformed
+	// or not made by the configured sequencer
+	errInvalidSeqSignature = errors.New("invalid seq signature")
+)
+
+// SeqSigner makes the sequencer signatures of transactions
+type SeqSigner interface {
+	// Address is the account of the sequencer
+	Address() common.Address
+	// SignData signs the keccak256 hash of data, the signature is in the
+	// [R || S || V] format with V 0 or 1
+	SignData(data []byte) ([]byte, error)
+}
+
+// NewSeqSigner creates the SeqSigner that is configured, at most one of them
+// may be. It returns nil when no signer is configured.
+func NewSeqSigner(cfg Config) (SeqSigner, error) {
+	var configured []string
+	for _, backend := range []struct {
+		name string
+		set  bool
+	}{
+		{"private key", cfg.SeqPriv != "" && cfg.SeqPriv != "0x"},
+		{"keystore", cfg.SeqKeystore != ""},
+		{"clef", cfg.SeqClef != ""},
+		{"remote", cfg.SeqRemoteSigner != ""},
+	} {
+		if backend.set {
+			configured = append(configured, backend.name)
+		}
+	}
+	if len(configured) > 1 {
+		return nil, fmt.Errorf("%w: more than one seq signer configured: %s", errBadConfig, strings.Join(configured, ", "))
+	}
+	switch {
+	case cfg.SeqKeystore != "":
+		return NewKeystoreSigner(cfg.SeqKeystore, cfg.SeqPasswordFile)
+	case cfg.SeqClef != "":
+		return NewClefSigner(cfg.SeqClef, common.HexToAddress(cfg.SeqAddress))
+	case cfg.SeqRemoteSigner != "":
+		return NewRemoteSigner(cfg.SeqRemoteSigner)
+	case len(configured) == 1:
+		return NewKeySigner(cfg.SeqPriv)
+	}
+	return nil, nil
+}
+
+// NewRespanSigner creates the signer of the respans of a takeover, it
+// returns nil when none is configured
+func NewRespanSigner(cfg Config) (SeqSigner, error) {
+	switch {
+	case cfg.SeqRespanPriv != "" && cfg.SeqRespanRemoteSigner != "":
+		return nil, fmt.Errorf("%w: more than one respan signer configured", errBadConfig)
+	case cfg.SeqRespanRemoteSigner != "":
+		return NewRemoteSigner(cfg.SeqRespanRemoteSigner)
+	case cfg.SeqRespanPriv != "":
+		return NewKeySigner(cfg.SeqRespanPriv)
+	}
+	return nil, nil
+}
+
+// keySigner signs with a private key held in memory
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates a SeqSigner from a hex encoded private key
+func NewKeySigner(priv string) (SeqSigner, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(priv, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode seq private key: %w", err)
+	}
+	key, err := crypto.ToECDSA(raw)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode seq private key: %w", err)
+	}
+	return &keySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+func (s *keySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *keySigner) SignData(data []byte) ([]byte, error) {
+	return crypto.Sign(crypto.Keccak256(data), s.key)
+}
+
+// NewKeystoreSigner creates a SeqSigner from an encrypted keystore file. The
+// password is read from the first line of the password file.
+func NewKeystoreSigner(path, passwordFile string) (SeqSigner, error) {
+	keyjson, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read seq keystore: %w", err)
+	}
+	var password string
+	if passwordFile != "" {
+		text, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read seq password file: %w", err)
+		}
+		password = strings.TrimRight(strings.Split(string(text), "\n")[0], "\r")
+	}
+	key, err := keystore.DecryptKey(keyjson, password)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decrypt seq keystore: %w", err)
+	}
+	return &keySigner{key: key.PrivateKey, address: key.Address}, nil
+}
+
+// clefSigner signs with an account of clef over its external API
+type clefSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// NewClefSigner creates a SeqSigner that signs with the account of clef at
+// the endpoint
+func NewClefSigner(endpoint string, address common.Address) (SeqSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot dial clef: %w", err)
+	}
+	var list []common.Address
+	if err := client.Call(&list, "account_list"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("Cannot list clef accounts: %w", err)
+	}
+	for _, account := range list {
+		if account == address {
+			return &clefSigner{client: client, address: address}, nil
+		}
+	}
+	client.Close()
+	return nil, fmt.Errorf("%w: clef does not hold the seq account %s", errBadConfig, address.Hex())
+}
+
+func (s *clefSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *clefSigner) SignData(data []byte) ([]byte, error) {
+	var res hexutil.Bytes
+	address := common.NewMixedcaseAddress(s.address)
+	if err := s.client.Call(&res, "account_signData", accounts.MimetypeSequencer, &address, hexutil.Encode(data)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// remoteSigner signs over the remote signer HTTP protocol:
+//
+//	GET  <url>/address  -> {"address": "0x..."}
+//	POST <url>/sign     {"address": "0x...", "data": "0x..."} -> {"signature": "0x..."}
+//
+// The remote signer signs the keccak256 hash of data.
+type remoteSigner struct {
+	url     string
+	client  *http.Client
+	address common.Address
+}
+
+type remoteAddressResponse struct {
+	Address common.Address `json:"address"`
+}
+
+type remoteSignRequest struct {
+	Address common.Address `json:"address"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+type remoteSignResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// NewRemoteSigner creates a SeqSigner for the remote signer at the url and
+// fetches its address
+func NewRemoteSigner(url string) (SeqSigner, error) {
+	s := &remoteSigner{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: remoteSignerTimeout},
+	}
+	var res remoteAddressResponse
+	if err := s.do(http.MethodGet, "/address", nil, &res); err != nil {
+		return nil, fmt.Errorf("Cannot get remote signer address: %w", err)
+	}
+	s.address = res.Address
+	return s, nil
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *remoteSigner) SignData(data []byte) ([]byte, error) {
+	var res remoteSignResponse
+	if err := s.do(http.MethodPost, "/sign", &remoteSignRequest{Address: s.address, Data: data}, &res); err != nil {
+		return nil, err
+	}
+	return res.Signature, nil
+}
+
+func (s *remoteSigner) do(method, path string, body, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, s.url+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer returned %s: %s", res.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, result)
+}
+
+// seqSignData returns the data whose hash the sequencer signs for a
+// transaction in the block number. From the SeqSign fork on it is the typed
+// payload bound to the chain, the block number and the epoch, before it the
+// RLP encoding of the transaction.
+func (s *SyncService) seqSignData(tx *types.Transaction, number uint64) ([]byte, error) {
+	config := s.bc.Config()
+	if !config.IsSeqSignEnabled(new(big.Int).SetUint64(number)) {
+		return legacySeqSignData(tx)
+	}
+	epoch, err := s.seqAdapter.SeqSignEpoch(tx, number)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot get seq sign epoch: %w", err)
+	}
+	return core.SeqSignData(config.ChainID, number, epoch, tx.Hash()), nil
+}
+
+// legacySeqSignData returns the RLP encoding of a transaction, its hash is
+// the transaction hash
+func legacySeqSignData(tx *types.Transaction) ([]byte, error) {
+	v, r, s := tx.RawSignatureValues()
+	data, err := rlp.EncodeToBytes(&rawSequencerTransaction{
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasPrice(),
+		GasLimit: tx.Gas(),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+		V:        v,
+		R:        r,
+		S:        s,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hash := crypto.Keccak256Hash(data); hash != tx.Hash() {
+		return nil, fmt.Errorf("seq sign data hashes to %s instead of %s", hash.Hex(), tx.Hash().Hex())
+	}
+	return data, nil
+}
+
+// signSequencerData signs data with a SeqSigner and checks that the signature
+// recovers to the signer
+func signSequencerData(signer SeqSigner, data []byte) (*types.SeqSign, error) {
+	signature, err := signer.SignData(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) != crypto.SignatureLength {
+		return nil, fmt.Errorf("%w: length %d", errInvalidSeqSignature, len(signature))
+	}
+	// Signers that follow the yellow paper return V 27 or 28
+	signature = common.CopyBytes(signature)
+	if signature[64] >= 27 {
+		signature[64] -= 27
+	}
+	pub, err := crypto.SigToPub(crypto.Keccak256(data), signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidSeqSignature, err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pub); recovered != signer.Address() {
+		return nil, fmt.Errorf("%w: signed by %s instead of %s", errInvalidSeqSignature, recovered.Hex(), signer.Address().Hex())
+	}
+	return &types.SeqSign{
+		R: new(big.Int).SetBytes(signature[0:32]),
+		S: new(big.Int).SetBytes(signature[32:64]),
+		V: new(big.Int).SetBytes(signature[64:65]),
+	}, nil
+}
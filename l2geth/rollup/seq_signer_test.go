@@ -0,0 +1,266 @@
+package rollup
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum-optimism/optimism/l2geth/accounts"
+	"github.com/ethereum-optimism/optimism/l2geth/accounts/keystore"
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/dtltest"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/signertest"
+	"github.com/ethereum-optimism/optimism/l2geth/rpc"
+	signercore "github.com/ethereum-optimism/optimism/l2geth/signer/core"
+)
+
+// fakeClef serves the account namespace of the external API of clef, it
+// accepts the sequencer data that clef accepts
+type fakeClef struct {
+	key *ecdsa.PrivateKey
+}
+
+func (c *fakeClef) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(c.key.PublicKey)}
+}
+
+func (c *fakeClef) SignData(ctx context.Context, contentType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if contentType != accounts.MimetypeSequencer {
+		return nil, errors.New("unexpected content type")
+	}
+	sighash, _, err := signercore.SequencerSignHash(params.TestChainConfig.ChainID, data)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := crypto.Sign(sighash, c.key)
+	if err != nil {
+		return nil, err
+	}
+	// clef returns V in the yellow paper format
+	signature[64] += 27
+	return signature, nil
+}
+
+func newTestClef(t *testing.T, key *ecdsa.PrivateKey) string {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &fakeClef{key: key}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+	return httpServer.URL
+}
+
+func TestSeqSigners(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	dir := t.TempDir()
+	ks := keystore.NewKeyStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(passwordFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := signertest.NewServer(key)
+	defer remote.Close()
+
+	configs := map[string]Config{
+		"key":      {SeqPriv: hexutil.Encode(crypto.FromECDSA(key))},
+		"keystore": {SeqKeystore: account.URL.Path, SeqPasswordFile: passwordFile},
+		"clef":     {SeqClef: newTestClef(t, key), SeqAddress: address.Hex()},
+		"remote":   {SeqRemoteSigner: remote.URL},
+	}
+	for name, cfg := range configs {
+		signer, err := NewSeqSigner(cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if signer.Address() != address {
+			t.Fatalf("%s: unexpected address: %s", name, signer.Address().Hex())
+		}
+		tx := signTestTransaction(t, testKey, 0)
+		data, err := legacySeqSignData(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqSign, err := signSequencerData(signer, data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		tx.SetSeqSign(seqSign)
+		if recovered, err := core.RecoverSeqAddress(tx); err != nil || recovered != address {
+			t.Fatalf("%s: unexpected seq address: %s, %v", name, recovered.Hex(), err)
+		}
+	}
+	if signed := remote.Signed(); len(signed) != 1 {
+		t.Fatalf("unexpected number of remote signatures: %d", len(signed))
+	}
+
+	// Remote failures are surfaced to the caller
+	signer, _ := NewSeqSigner(configs["remote"])
+	remote.FailNext(signertest.PathSign, 1)
+	if _, err := signSequencerData(signer, []byte{0x01}); err == nil {
+		t.Fatal("expected remote signer failure")
+	}
+
+	// A wrong keystore password is rejected
+	if err := ioutil.WriteFile(passwordFile, []byte("wrong"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSeqSigner(configs["keystore"]); err == nil {
+		t.Fatal("expected decryption failure")
+	}
+	// Clef must hold the sequencer account
+	if _, err := NewSeqSigner(Config{SeqClef: configs["clef"].SeqClef, SeqAddress: testAddress.Hex()}); !errors.Is(err, errBadConfig) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Only a single backend may be configured
+	if _, err := NewSeqSigner(Config{SeqPriv: configs["key"].SeqPriv, SeqRemoteSigner: remote.URL}); !errors.Is(err, errBadConfig) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signer, err := NewSeqSigner(Config{SeqPriv: "0x"}); signer != nil || err != nil {
+		t.Fatalf("unexpected signer: %v, %v", signer, err)
+	}
+}
+
+func TestClefSequencerSignHash(t *testing.T) {
+	chainID := params.TestChainConfig.ChainID
+	tx := signTestTransaction(t, testKey, 0)
+
+	// A sequenced transaction signs its hash
+	data, err := legacySeqSignData(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sighash, _, err := signercore.SequencerSignHash(chainID, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if common.BytesToHash(sighash) != tx.Hash() {
+		t.Fatalf("unexpected transaction sighash: %x, want %x", sighash, tx.Hash())
+	}
+	// A signature payload signs its EIP-712 hash
+	payload := core.SeqSignData(chainID, 5, big.NewInt(2), tx.Hash())
+	sighash, _, err = signercore.SequencerSignHash(chainID, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := core.SeqSignHash(chainID, 5, big.NewInt(2), tx.Hash()); common.BytesToHash(sighash) != want {
+		t.Fatalf("unexpected payload sighash: %x, want %x", sighash, want)
+	}
+
+	// Payloads of another chain, unsigned transactions and any other data
+	// are rejected
+	unsigned, err := rlp.EncodeToBytes(types.NewTransaction(0, testAddress, big.NewInt(1), 21000, big.NewInt(1), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rejected := map[string][]byte{
+		"other chain": core.SeqSignData(new(big.Int).Add(chainID, common.Big1), 5, big.NewInt(2), tx.Hash()),
+		"unsigned":    unsigned,
+		"hash":        tx.Hash().Bytes(),
+		"arbitrary":   []byte("sequenced transaction"),
+	}
+	for name, data := range rejected {
+		if _, _, err := signercore.SequencerSignHash(chainID, data); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSeqSignerAddressMismatch(t *testing.T) {
+	server := dtltest.NewServer(dtltest.NewFixture(params.TestChainConfig.ChainID))
+	defer server.Close()
+
+	key, _ := crypto.GenerateKey()
+	bc, db := newTestChain(t)
+	cfg := Config{
+		Eth1SyncServiceEnable:                 true,
+		RollupClientHttp:                      server.URL,
+		CanonicalTransactionChainDeployHeight: common.Big1,
+		SeqAddress:                            testAddress.Hex(),
+		SeqPriv:                               hexutil.Encode(crypto.FromECDSA(key)),
+	}
+	if _, err := NewSyncService(context.Background(), cfg, nil, bc, db, make(chan *types.Block)); !errors.Is(err, errBadConfig) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.SeqPriv = hexutil.Encode(crypto.FromECDSA(testKey))
+	service, err := NewSyncService(context.Background(), cfg, nil, bc, db, make(chan *types.Block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := signTestTransaction(t, testKey, 0)
+	if err := service.addSeqSignature(tx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if recovered, err := core.RecoverSeqAddress(tx); err != nil || recovered != testAddress {
+		t.Fatalf("unexpected seq address: %s, %v", recovered.Hex(), err)
+	}
+	if err := (&SyncService{}).addSeqSignature(signTestTransaction(t, testKey, 1), 1); err != errNoSeqSigner {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSeqSignatureDomain(t *testing.T) {
+	defer func(height *big.Int) { params.MetisFallbackRollupConfig.SeqSignHeight = height }(params.MetisFallbackRollupConfig.SeqSignHeight)
+	params.MetisFallbackRollupConfig.SeqSignHeight = big.NewInt(2)
+
+	server := dtltest.NewServer(dtltest.NewFixture(params.TestChainConfig.ChainID))
+	defer server.Close()
+
+	bc, db := newTestChain(t)
+	cfg := Config{
+		Eth1SyncServiceEnable:                 true,
+		RollupClientHttp:                      server.URL,
+		CanonicalTransactionChainDeployHeight: common.Big1,
+		SeqsetValidHeight:                     math.MaxUint64,
+		SeqAddress:                            testAddress.Hex(),
+		SeqPriv:                               hexutil.Encode(crypto.FromECDSA(testKey)),
+	}
+	service, err := NewSyncService(context.Background(), cfg, nil, bc, db, make(chan *types.Block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Before the fork the signature is over the transaction hash
+	legacy := signTestTransaction(t, testKey, 0)
+	if err := service.addSeqSignature(legacy, 1); err != nil {
+		t.Fatal(err)
+	}
+	if recovered, err := core.RecoverSeqAddress(legacy); err != nil || recovered != testAddress {
+		t.Fatalf("unexpected seq address: %s, %v", recovered.Hex(), err)
+	}
+	// From the fork on the signature is bound to the block number
+	tx := signTestTransaction(t, testKey, 1)
+	if err := service.addSeqSignature(tx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if recovered, err := service.recoverSeqAddress(tx, 2); err != nil || !strings.EqualFold(recovered, testAddress.Hex()) {
+		t.Fatalf("unexpected seq address: %s, %v", recovered, err)
+	}
+	if recovered, _ := service.recoverSeqAddress(tx, 3); strings.EqualFold(recovered, testAddress.Hex()) {
+		t.Fatal("signature replayed at another height")
+	}
+	if recovered, _ := core.RecoverSeqAddress(tx); recovered == testAddress {
+		t.Fatal("signature recovered over the transaction hash")
+	}
+}
@@ -0,0 +1,211 @@
+package rollup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/event"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+)
+
+// defaultSeqStaleThreshold is how long the chain may go without a new block
+// before the sequencer is considered stale
+const defaultSeqStaleThreshold = 60 * time.Second
+
+// seqChangeTracker follows the head of the chain and the pre-respan queue and
+// sends a SequencerChange on every epoch rollover and every change of a
+// respan
+type seqChangeTracker struct {
+	db ethdb.Database
+
+	headCh    chan core.ChainHeadEvent
+	headSub   event.Subscription
+	respanCh  chan PreRespanEvent
+	respanSub event.Subscription
+
+	// epoch is the epoch of the last head, only used by the loop
+	epoch *types.SequencerEpoch
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// newSeqChangeTracker creates a tracker that starts at the epoch of the block
+// head
+func newSeqChangeTracker(db ethdb.Database, head uint64) *seqChangeTracker {
+	t := &seqChangeTracker{
+		db:       db,
+		headCh:   make(chan core.ChainHeadEvent, 16),
+		respanCh: make(chan PreRespanEvent, 16),
+		quit:     make(chan struct{}),
+	}
+	if db != nil {
+		t.epoch = rawdb.ReadSequencerEpochAt(db, head)
+	}
+	return t
+}
+
+// start subscribes to the chain heads and the pre-respan events and tracks
+// them until stop is called
+func (t *seqChangeTracker) start(chain *core.BlockChain, adapter RollupAdapter) {
+	t.headSub = chain.SubscribeChainHeadEvent(t.headCh)
+	t.respanSub = adapter.SubscribePreRespanEvent(t.respanCh)
+	t.wg.Add(1)
+	go t.loop()
+}
+
+// stop ends the tracking and the subscriptions of the changes
+func (t *seqChangeTracker) stop() {
+	close(t.quit)
+	t.wg.Wait()
+	t.scope.Close()
+}
+
+// Subscribe registers a subscription of the sequencer changes
+func (t *seqChangeTracker) Subscribe(ch chan<- types.SequencerChange) event.Subscription {
+	return t.scope.Track(t.feed.Subscribe(ch))
+}
+
+func (t *seqChangeTracker) loop() {
+	defer t.wg.Done()
+	defer t.headSub.Unsubscribe()
+	defer t.respanSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-t.headCh:
+			t.handleHead(ev.Block.NumberU64())
+		case ev := <-t.respanCh:
+			t.handleRespan(ev)
+		case <-t.headSub.Err():
+			return
+		case <-t.respanSub.Err():
+			return
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// handleHead sends a rollover when the epoch of the head differs from the
+// epoch of the head before
+func (t *seqChangeTracker) handleHead(number uint64) {
+	if t.db == nil {
+		return
+	}
+	epoch := rawdb.ReadSequencerEpochAt(t.db, number)
+	if epoch == nil {
+		return
+	}
+	previous := t.epoch
+	t.epoch = epoch
+	if previous != nil && previous.ID == epoch.ID {
+		return
+	}
+	log.Info("Sequencer epoch rolled over", "number", number, "epoch", epoch.ID, "signer", epoch.Signer)
+	t.feed.Send(types.SequencerChange{
+		Type:        types.SequencerChangeEpoch,
+		BlockNumber: number,
+		Epoch:       epoch,
+		Previous:    previous,
+	})
+}
+
+// handleRespan forwards a change of the pre-respan queue
+func (t *seqChangeTracker) handleRespan(ev PreRespanEvent) {
+	t.feed.Send(types.SequencerChange{
+		Type:        types.SequencerChangeRespan,
+		BlockNumber: ev.Respan.StartBlock,
+		Action:      ev.Action,
+		Respan:      ev.Respan,
+	})
+}
+
+// SequencerStatus returns the sequencer schedule at the head of the chain and
+// the role of the node in it
+func (s *SyncService) SequencerStatus() (*types.SequencerStatus, error) {
+	head := s.bc.CurrentBlock()
+	number := head.NumberU64()
+	status := &types.SequencerStatus{
+		BlockNumber:    number,
+		PendingRespans: s.seqAdapter.PreRespans(),
+		LastBlockTime:  head.Time(),
+		StaleThreshold: uint64(s.seqStaleThreshold / time.Second),
+		Stale:          s.SeqStale(),
+	}
+	if indexTime := s.GetLatestIndexTime(); indexTime != nil {
+		status.LastIndexTime = *indexTime
+	}
+	epoch, err := s.sequencerEpochAt(number)
+	if err != nil {
+		return nil, err
+	}
+	if epoch != nil {
+		status.Epoch = epoch
+		if epoch.EndBlock > number {
+			status.BlocksLeft = epoch.EndBlock - number
+		}
+		status.NextEpoch = rawdb.ReadSequencerEpoch(s.db, epoch.ID+1)
+	}
+
+	seqModel, _ := s.GetSeqAndMpcStatus()
+	switch {
+	case !seqModel:
+		status.Role = types.SequencerRoleVerifier
+	case status.Epoch == nil || s.IsSelfSeqAddress(status.Epoch.Signer):
+		status.Role = types.SequencerRoleActive
+	default:
+		status.Role = types.SequencerRoleBackup
+	}
+	return status, nil
+}
+
+// sequencerEpochAt returns the sequencer epoch of a block number, nil before
+// the sequencer set is valid
+func (s *SyncService) sequencerEpochAt(number uint64) (*types.SequencerEpoch, error) {
+	if validHeight := s.seqAdapter.GetSeqValidHeight(); validHeight == 0 || number <= validHeight {
+		return nil, nil
+	}
+	if epoch := rawdb.ReadSequencerEpochAt(s.db, number); epoch != nil {
+		return epoch, nil
+	}
+	epoch, err := s.seqAdapter.GetEpochByBlockNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return &types.SequencerEpoch{
+		ID:         epoch.Number.Uint64(),
+		Signer:     epoch.Signer,
+		StartBlock: epoch.StartBlock.Uint64(),
+		EndBlock:   epoch.EndBlock.Uint64(),
+	}, nil
+}
+
+// SeqStale returns whether no index was added for the stale threshold. The
+// time the index was added locally is used, the timestamp of the head is the
+// L1 timestamp of its transaction.
+func (s *SyncService) SeqStale() bool {
+	indexTime := s.GetLatestIndexTime()
+	if indexTime == nil {
+		return true
+	}
+	return time.Since(time.Unix(int64(*indexTime), 0)) > s.seqStaleThreshold
+}
+
+// SeqStaleThreshold returns how long the chain may go without a new block
+// before the sequencer is considered stale
+func (s *SyncService) SeqStaleThreshold() time.Duration {
+	return s.seqStaleThreshold
+}
+
+// SubscribeSequencerChangeEvent registers a subscription of the epoch
+// rollovers and the respans
+func (s *SyncService) SubscribeSequencerChangeEvent(ch chan<- types.SequencerChange) event.Subscription {
+	return s.seqChanges.Subscribe(ch)
+}
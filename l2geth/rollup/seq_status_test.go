@@ -0,0 +1,63 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+)
+
+func TestSeqChangeTracker(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	first := &types.SequencerEpoch{ID: 1, Signer: common.Address{0x01}, StartBlock: 1, EndBlock: 10}
+	rawdb.WriteSequencerEpoch(db, first)
+
+	tracker := newSeqChangeTracker(db, 5)
+	changes := make(chan types.SequencerChange, 4)
+	sub := tracker.Subscribe(changes)
+	defer sub.Unsubscribe()
+
+	// No change within the epoch or without a known epoch
+	tracker.handleHead(6)
+	tracker.handleHead(11)
+	if len(changes) != 0 {
+		t.Fatalf("unexpected changes: %d", len(changes))
+	}
+
+	second := &types.SequencerEpoch{ID: 2, Signer: common.Address{0x02}, StartBlock: 11, EndBlock: 20}
+	rawdb.WriteSequencerEpoch(db, second)
+	tracker.handleHead(11)
+	tracker.handleHead(12)
+	if len(changes) != 1 {
+		t.Fatalf("unexpected number of changes: %d", len(changes))
+	}
+	change := <-changes
+	if change.Type != types.SequencerChangeEpoch || change.BlockNumber != 11 || change.Epoch.ID != 2 || change.Previous.ID != 1 {
+		t.Fatalf("unexpected rollover: %+v", change)
+	}
+
+	respan := &types.PreRespan{PreSigner: second.Signer, NewSigner: common.Address{0x03}, StartBlock: 15}
+	tracker.handleRespan(PreRespanEvent{Action: PreRespanCreated, Respan: respan})
+	change = <-changes
+	if change.Type != types.SequencerChangeRespan || change.Action != PreRespanCreated || change.BlockNumber != 15 || change.Respan != respan {
+		t.Fatalf("unexpected respan change: %+v", change)
+	}
+}
+
+func TestSeqStale(t *testing.T) {
+	s := &SyncService{db: rawdb.NewMemoryDatabase(), seqStaleThreshold: time.Minute}
+	if !s.SeqStale() {
+		t.Fatal("not stale before the first index")
+	}
+	// The time the index was added counts, not the timestamp of the head
+	s.SetLatestIndexTime(time.Now().Unix())
+	if s.SeqStale() {
+		t.Fatal("stale right after an index was added")
+	}
+	s.SetLatestIndexTime(time.Now().Add(-2 * time.Minute).Unix())
+	if !s.SeqStale() {
+		t.Fatal("not stale after the threshold")
+	}
+}
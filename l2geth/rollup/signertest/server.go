@@ -0,0 +1,141 @@
+// Package signertest provides an in-process stand-in for a remote signer that
+// speaks the remote signer protocol of the sequencer.
+package signertest
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+)
+
+// Routes of the remote signer protocol
+const (
+	PathAddress = "/address"
+	PathSign    = "/sign"
+)
+
+// AddressResponse is the response of the address route
+type AddressResponse struct {
+	Address common.Address `json:"address"`
+}
+
+// SignRequest is the request of the sign route. The signer signs the
+// keccak256 hash of the data.
+type SignRequest struct {
+	Address common.Address `json:"address"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// SignResponse is the response of the sign route, the signature is in the
+// [R || S || V] format with V 0 or 1
+type SignResponse struct {
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// Server is an httptest server that signs with a private key
+type Server struct {
+	*httptest.Server
+
+	key     *ecdsa.PrivateKey
+	address common.Address
+
+	mu       sync.Mutex
+	requests map[string]int
+	failures map[string]int
+	signed   [][]byte
+}
+
+// NewServer starts a Server that signs with the key. The caller must close
+// it.
+func NewServer(key *ecdsa.PrivateKey) *Server {
+	s := &Server{
+		key:      key,
+		address:  crypto.PubkeyToAddress(key.PublicKey),
+		requests: make(map[string]int),
+		failures: make(map[string]int),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Address returns the account of the signing key
+func (s *Server) Address() common.Address {
+	return s.address
+}
+
+// Requests returns the number of requests that were made to a route
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// FailNext makes the next n requests to a route fail
+func (s *Server) FailNext(path string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] += n
+}
+
+// Signed returns the data of the signing requests in order
+func (s *Server) Signed() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte(nil), s.signed...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	fail := s.failures[r.URL.Path] > 0
+	if fail {
+		s.failures[r.URL.Path]--
+	}
+	s.mu.Unlock()
+
+	if fail {
+		http.Error(w, "injected failure", http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case r.URL.Path == PathAddress && r.Method == http.MethodGet:
+		s.respond(w, &AddressResponse{Address: s.address})
+	case r.URL.Path == PathSign && r.Method == http.MethodPost:
+		var req SignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Address != s.address {
+			http.Error(w, "unknown account", http.StatusForbidden)
+			return
+		}
+		signature, err := crypto.Sign(crypto.Keccak256(req.Data), s.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.mu.Lock()
+		s.signed = append(s.signed, common.CopyBytes(req.Data))
+		s.mu.Unlock()
+		s.respond(w, &SignResponse{Signature: signature})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) respond(w http.ResponseWriter, res interface{}) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,230 @@
+package rollup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+)
+
+// Types of the events that are pushed by the data transport layer
+const (
+	StreamEventEnqueue     = "enqueue"
+	StreamEventTransaction = "transaction"
+	StreamEventBlock       = "block"
+	StreamEventBatch       = "batch"
+)
+
+const (
+	// streamPath is the path of the server sent event stream, relative to
+	// the data transport layer url. Data transport layers that predate the
+	// stream do not serve it, the subscriber then gives up.
+	streamPath = "/stream/"
+	// Backoff between reconnects of the stream
+	streamBackoffBase = time.Second
+	streamBackoffMax  = time.Minute
+	// streamIdleTimeout is how long the stream may stay silent before it is
+	// considered dead. The server is expected to send keep alive comments
+	// more often than this.
+	streamIdleTimeout = time.Minute
+)
+
+var (
+	// errStreamClosed is returned when the remote server ends the stream
+	errStreamClosed = errors.New("stream closed")
+	// errStreamIdle is returned when nothing was received from the stream
+	// within the idle timeout
+	errStreamIdle = errors.New("stream idle")
+	// errStreamUnsupported is returned when the remote server does not serve
+	// the stream
+	errStreamUnsupported = errors.New("stream not served")
+)
+
+// StreamEvent is pushed by the data transport layer when it has indexed a
+// new element. Only the type and index are sent, the element itself is
+// fetched with the regular RollupClient.
+type StreamEvent struct {
+	Type  string `json:"type"`
+	Index uint64 `json:"index"`
+}
+
+// streamSubscriber follows the server sent event stream of the data
+// transport layer and wakes up the sync loops when an event they are
+// interested in arrives. The sync loops keep polling, so when the stream
+// drops syncing continues at the poll interval until it reconnects. When
+// none of the urls serves the stream the subscriber gives up.
+type streamSubscriber struct {
+	urls    []string
+	chainID string
+	client  *http.Client
+	accept  func(StreamEvent) bool
+	wake    chan struct{}
+	idle    time.Duration
+
+	connected int32
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// newStreamSubscriber creates a subscriber for a comma separated list of
+// data transport layer urls. Events for which accept returns true are
+// coalesced into wake.
+func newStreamSubscriber(urls string, chainID *big.Int, accept func(StreamEvent) bool, wake chan struct{}) *streamSubscriber {
+	var list []string
+	for _, url := range strings.Split(urls, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			list = append(list, strings.TrimSuffix(url, "/"))
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &streamSubscriber{
+		urls:    list,
+		chainID: chainID.String(),
+		client:  &http.Client{},
+		accept:  accept,
+		wake:    wake,
+		idle:    streamIdleTimeout,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// start connects to the stream in the background
+func (s *streamSubscriber) start() {
+	if len(s.urls) == 0 {
+		return
+	}
+	go s.loop()
+}
+
+// stop disconnects from the stream
+func (s *streamSubscriber) stop() {
+	s.cancel()
+}
+
+// Connected returns true while the stream is connected
+func (s *streamSubscriber) Connected() bool {
+	return atomic.LoadInt32(&s.connected) == 1
+}
+
+func (s *streamSubscriber) loop() {
+	backoff := streamBackoffBase
+	unsupported := make(map[string]bool)
+	for i := 0; ; i++ {
+		url := s.urls[i%len(s.urls)] + streamPath + s.chainID
+		start := time.Now()
+		err := s.consume(url)
+		atomic.StoreInt32(&s.connected, 0)
+		if s.ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errStreamUnsupported) {
+			unsupported[url] = true
+			if len(unsupported) == len(s.urls) {
+				log.Error("No rollup client endpoint serves the rollup stream, syncing by polling only", "path", streamPath+s.chainID)
+				return
+			}
+		} else {
+			delete(unsupported, url)
+		}
+		// Reset the backoff when the stream was up for a while
+		if time.Since(start) > streamBackoffMax {
+			backoff = streamBackoffBase
+		}
+		log.Warn("Rollup stream disconnected, falling back to polling", "url", url, "retry", backoff, "msg", err)
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > streamBackoffMax {
+			backoff = streamBackoffMax
+		}
+	}
+}
+
+// consume reads events from the stream until it ends or stays idle for
+// longer than the idle timeout
+func (s *streamSubscriber) consume(url string) error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	var idle int32
+	timer := time.AfterFunc(s.idle, func() {
+		atomic.StoreInt32(&idle, 1)
+		cancel()
+	})
+	defer timer.Stop()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("User-Agent", "sequencer")
+	res, err := s.client.Do(req)
+	if err != nil {
+		if atomic.LoadInt32(&idle) == 1 {
+			return errStreamIdle
+		}
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: %w", url, errStreamUnsupported)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%d cannot subscribe to %s: %w", res.StatusCode, url, errHTTPError)
+	}
+	atomic.StoreInt32(&s.connected, 1)
+	log.Info("Rollup stream connected", "url", url)
+
+	var data []string
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		timer.Reset(s.idle)
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line terminates an event
+			if len(data) > 0 {
+				s.dispatch(strings.Join(data, "\n"))
+				data = data[:0]
+			}
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+		// Comments, used as keep alives, and other fields are ignored
+	}
+	if atomic.LoadInt32(&idle) == 1 {
+		return errStreamIdle
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errStreamClosed
+}
+
+// dispatch decodes an event and wakes up the sync loop when it is accepted
+func (s *streamSubscriber) dispatch(data string) {
+	var ev StreamEvent
+	if err := json.Unmarshal([]byte(data), &ev); err != nil {
+		log.Debug("Cannot decode rollup stream event", "data", data, "msg", err)
+		return
+	}
+	log.Trace("Rollup stream event", "type", ev.Type, "index", ev.Index)
+	if s.accept != nil && !s.accept(ev) {
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,121 @@
+package rollup
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/dtltest"
+)
+
+// waitFor polls a condition until it holds or the timeout expires
+func waitFor(t *testing.T, what string, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStreamSubscriber(t *testing.T) {
+	primary := dtltest.NewStreamServer(420)
+	primaryHTTP := httptest.NewServer(primary)
+	backup := dtltest.NewStreamServer(420)
+	backupHTTP := httptest.NewServer(backup)
+	defer backupHTTP.Close()
+
+	wake := make(chan struct{}, 1)
+	accept := func(ev StreamEvent) bool { return ev.Type == StreamEventEnqueue }
+	sub := newStreamSubscriber(primaryHTTP.URL+","+backupHTTP.URL, big.NewInt(420), accept, wake)
+	sub.start()
+	defer sub.stop()
+
+	waitFor(t, "subscription", func() bool { return sub.Connected() && primary.Subscribers() == 1 })
+
+	// Events that are not accepted do not wake up the loop
+	primary.Publish(dtltest.StreamEvent{Type: StreamEventBatch, Index: 1})
+	primary.Publish(dtltest.StreamEvent{Type: StreamEventEnqueue, Index: 7})
+	select {
+	case <-wake:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no wake up for enqueue")
+	}
+	select {
+	case <-wake:
+		t.Fatal("unexpected wake up")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// When the stream drops the subscriber moves on to the next endpoint
+	primary.Close()
+	primaryHTTP.Close()
+	waitFor(t, "disconnect", func() bool { return !sub.Connected() })
+	waitFor(t, "reconnect", func() bool { return sub.Connected() && backup.Subscribers() == 1 })
+
+	backup.Publish(dtltest.StreamEvent{Type: StreamEventEnqueue, Index: 8})
+	select {
+	case <-wake:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no wake up after reconnect")
+	}
+}
+
+func TestStreamSubscriberIdle(t *testing.T) {
+	// The server keeps the stream open but sends nothing
+	var connects int32
+	silent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&connects, 1)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer silent.Close()
+
+	sub := newStreamSubscriber(silent.URL, big.NewInt(420), nil, make(chan struct{}, 1))
+	sub.idle = 100 * time.Millisecond
+	sub.start()
+	defer sub.stop()
+
+	waitFor(t, "subscription", sub.Connected)
+	waitFor(t, "idle disconnect", func() bool { return !sub.Connected() })
+
+	// A server that sends keep alives stays connected
+	server := dtltest.NewStreamServer(420)
+	server.SetKeepAlive(20 * time.Millisecond)
+	serverHTTP := httptest.NewServer(server)
+	defer serverHTTP.Close()
+	alive := newStreamSubscriber(serverHTTP.URL, big.NewInt(420), nil, make(chan struct{}, 1))
+	alive.idle = 100 * time.Millisecond
+	alive.start()
+	defer alive.stop()
+	waitFor(t, "subscription", alive.Connected)
+	time.Sleep(300 * time.Millisecond)
+	if !alive.Connected() || server.Subscribers() != 1 {
+		t.Fatal("stream with keep alives was dropped")
+	}
+}
+
+func TestStreamSubscriberUnsupported(t *testing.T) {
+	var requests int32
+	dtl := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.NotFound(w, r)
+	}))
+	defer dtl.Close()
+
+	sub := newStreamSubscriber(dtl.URL, big.NewInt(420), nil, make(chan struct{}, 1))
+	sub.start()
+	defer sub.stop()
+
+	// The subscriber gives up without retrying
+	waitFor(t, "request", func() bool { return atomic.LoadInt32(&requests) == 1 })
+	time.Sleep(streamBackoffBase + 200*time.Millisecond)
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("stream requested %d times from a server that does not serve it", n)
+	}
+}
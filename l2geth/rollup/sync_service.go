@@ -2,7 +2,6 @@ package rollup
 
 import (
 	"context"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
@@ -15,7 +14,6 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/common"
 	"github.com/ethereum-optimism/optimism/l2geth/core"
 	"github.com/ethereum-optimism/optimism/l2geth/core/state"
-	"github.com/ethereum-optimism/optimism/l2geth/crypto"
 	"github.com/ethereum-optimism/optimism/l2geth/ethclient"
 	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
 	"github.com/ethereum-optimism/optimism/l2geth/event"
@@ -75,6 +73,7 @@ type SyncService struct {
 	RollupGpo                      *gasprice.RollupOracle
 	client                         RollupClient
 	seqAdapter                     RollupAdapter
+	seqsetContract                 common.Address
 	syncing                        atomic.Value
 	chainHeadSub                   event.Subscription
 	OVMContext                     OVMContext
@@ -95,10 +94,27 @@ type SyncService struct {
 	startSeqHeight    uint64
 	seqClientHttp     string
 	SeqAddress        string
-	seqPriv           string
+	seqSigner         SeqSigner
+	seqRegistry       *seqRegistry
+	seqInfoUrl        string
+	seqChanges        *seqChangeTracker
+	seqStaleThreshold time.Duration
+	takeover          *takeoverController
+	peerTd            func() (*big.Int, int)
+	ovm               rcfg.Config
+	prefetchDepth     int
+	stream            *streamSubscriber
+	streamWake        chan struct{}
+	mismatchPolicy    MismatchPolicy
+	mismatchRewinds   map[uint64]int
+	halted            atomic.Value
+	reorgEnable       bool
+	reorgMaxDepth     uint64
+	reorgAlarmOnly    bool
 	finalizedIndex    *uint64
 	finalizedSyncMs   int64
 	finalizedMu       sync.Mutex
+	watchdog          *enqueueWatchdog
 
 	syncQueueFromOthers chan *types.Block
 	enqueueIndexNil     bool
@@ -133,16 +149,51 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		timestampRefreshThreshold = time.Minute * 3
 	}
 
+	mismatchPolicy, err := NewMismatchPolicy(cfg.StateRootMismatchPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errBadConfig, err)
+	}
+
+	prefetchDepth := cfg.BatchPrefetchDepth
+	if prefetchDepth <= 0 {
+		log.Info("Sanitizing batch prefetch depth", "depth", defaultPrefetchDepth)
+		prefetchDepth = defaultPrefetchDepth
+	}
+
 	// Layer 2 chainid
 	chainID := bc.Config().ChainID
 	if chainID == nil {
 		return nil, errors.New("Must configure with chain id")
 	}
 	// Initialize the rollup client
-	client := NewClient(cfg.RollupClientHttp, chainID)
-	log.Info("Configured rollup client", "url", cfg.RollupClientHttp, "chain-id", chainID.Uint64(), "ctc-deploy-height", cfg.CanonicalTransactionChainDeployHeight)
+	var client RollupClient
+	if cfg.RollupClientL1Http != "" {
+		l1Client, err := DialL1Client(cfg.RollupClientL1Http, cfg.CanonicalTransactionChainAddress, cfg.StateCommitmentChainAddress, cfg.CanonicalTransactionChainDeployHeight, chainID, cfg.RollupClientL1Confirmations)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot initialize L1 rollup client: %w", err)
+		}
+		client = l1Client
+		log.Info("Configured L1 rollup client", "url", cfg.RollupClientL1Http, "ctc", cfg.CanonicalTransactionChainAddress.Hex(), "scc", cfg.StateCommitmentChainAddress.Hex(), "chain-id", chainID.Uint64(), "ctc-deploy-height", cfg.CanonicalTransactionChainDeployHeight, "confirmations", cfg.RollupClientL1Confirmations)
+	} else {
+		clientCfg := DefaultMultiClientConfig
+		if cfg.RollupClientTimeout != 0 {
+			clientCfg.Timeout = cfg.RollupClientTimeout
+		}
+		if cfg.RollupClientRetries != nil {
+			clientCfg.MaxRetries = *cfg.RollupClientRetries
+		}
+		if cfg.RollupClientMaxLag != nil {
+			clientCfg.MaxLag = *cfg.RollupClientMaxLag
+		}
+		multiClient, err := NewMultiClient(cfg.RollupClientHttp, chainID, clientCfg)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot initialize rollup client: %w", err)
+		}
+		client = multiClient
+		log.Info("Configured rollup client", "url", cfg.RollupClientHttp, "chain-id", chainID.Uint64(), "ctc-deploy-height", cfg.CanonicalTransactionChainDeployHeight, "timeout", clientCfg.Timeout, "retries", clientCfg.MaxRetries, "max-lag", clientCfg.MaxLag)
+	}
 
-	seqAdapter := NewSeqAdapter(cfg.SeqsetContract, cfg.SeqsetValidHeight, cfg.PosClientHttp, cfg.LocalL2ClientHttp, bc)
+	seqAdapter := NewSeqAdapter(cfg.SeqsetContract, cfg.SeqsetValidHeight, cfg.PosClientHttp, cfg.LocalL2ClientHttp, bc, db)
 	log.Info("Configured seqAdapter", "url", cfg.PosClientHttp, "SeqsetContract", cfg.SeqsetContract, "SeqsetValidHeight", cfg.SeqsetValidHeight, "SeqAddress", cfg.SeqAddress, "LocalL2ClientHttp", cfg.LocalL2ClientHttp)
 	// Ensure sane values for the fee thresholds
 	if cfg.FeeThresholdDown != nil {
@@ -160,6 +211,17 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		}
 	}
 
+	seqSigner, err := NewSeqSigner(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot initialize seq signer: %w", err)
+	}
+	if seqSigner != nil {
+		if !strings.EqualFold(seqSigner.Address().Hex(), cfg.SeqAddress) {
+			return nil, fmt.Errorf("%w: seq signer address %s does not match the seq address %s", errBadConfig, seqSigner.Address().Hex(), cfg.SeqAddress)
+		}
+		log.Info("Configured seq signer", "address", seqSigner.Address().Hex())
+	}
+
 	service := SyncService{
 		ctx:          ctx,
 		cancel:       cancel,
@@ -189,11 +251,50 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		startSeqHeight:      uint64(0),
 		seqClientHttp:       cfg.SequencerClientHttp,
 		SeqAddress:          cfg.SeqAddress,
-		seqPriv:             cfg.SeqPriv,
+		seqsetContract:      cfg.SeqsetContract,
+		seqSigner:           seqSigner,
+		ovm:                 bc.GetVMConfig().OVM,
+		prefetchDepth:       prefetchDepth,
+		mismatchPolicy:      mismatchPolicy,
+		mismatchRewinds:     make(map[uint64]int),
+		reorgEnable:         cfg.ReorgEnable,
+		reorgMaxDepth:       cfg.ReorgMaxDepth,
+		reorgAlarmOnly:      cfg.ReorgAlarmOnly,
 		syncQueueFromOthers: syncQueueFromOthers,
 		enqueueIndexNil:     false,
 	}
 
+	service.restoreHalt()
+
+	service.seqRegistry = newSeqRegistry(db, chainID, common.HexToAddress(cfg.SeqAddress), cfg.SeqInfoTTL, seqAdapter.IsSeqSetSigner)
+	if cfg.SeqInfoUrl != "" && seqSigner == nil {
+		return nil, fmt.Errorf("%w: sequencer info url without a sequencer signer", errBadConfig)
+	}
+	service.seqInfoUrl = cfg.SeqInfoUrl
+	service.seqChanges = newSeqChangeTracker(db, bc.CurrentBlock().NumberU64())
+	if cfg.SeqTakeover && !cfg.IsVerifier {
+		respanSigner, err := NewRespanSigner(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if respanSigner == nil {
+			return nil, fmt.Errorf("%w: sequencer takeover without a respan signer", errBadConfig)
+		}
+		service.takeover = newTakeoverController(service.takeoverBackend(), common.HexToAddress(cfg.SeqAddress), respanSigner, chainID, cfg.SeqsetContract, cfg.SeqTakeoverThreshold)
+	}
+	service.seqStaleThreshold = cfg.SeqStaleThreshold
+	if service.seqStaleThreshold <= 0 {
+		service.seqStaleThreshold = defaultSeqStaleThreshold
+	}
+
+	// Wake up the sync loops as soon as the data transport layer pushes an
+	// element they are interested in
+	if cfg.RollupClientStream && cfg.RollupClientL1Http == "" {
+		service.streamWake = make(chan struct{}, 1)
+		service.stream = newStreamSubscriber(cfg.RollupClientHttp, chainID, service.acceptStreamEvent, service.streamWake)
+		log.Info("Configured rollup stream", "url", cfg.RollupClientHttp)
+	}
+
 	// The chainHeadSub is used to synchronize the SyncService with the chain.
 	// As the SyncService processes transactions, it waits until the transaction
 	// is added to the chain. This synchronization is required for handling
@@ -275,6 +376,11 @@ func NewSyncService(ctx context.Context, cfg Config, txpool *core.TxPool, bc *co
 		if !service.verifier {
 			service.setSyncStatus(true)
 		}
+
+		// Track the enqueues from the queue index the chain has included
+		if cfg.ForceInclusionWindow > 0 {
+			service.watchdog = newEnqueueWatchdog(client, cfg.ForceInclusionWindow, pollInterval, service.GetNextEnqueueIndex(), service.GetLatestEnqueueIndex, service.skipEnqueue)
+		}
 	} else if cfg.SeqBridgeUrl != "" {
 		// peer only
 		log.Info("Start sync service only peer", "bridge", cfg.SeqBridgeUrl)
@@ -343,12 +449,24 @@ func (s *SyncService) Start() error {
 		return err
 	}
 
+	s.seqChanges.start(s.bc, s.seqAdapter)
+	go s.respanTxLoop()
+	if s.seqInfoUrl != "" {
+		s.seqRegistry.announce(s.seqSigner, s.seqInfoUrl)
+	}
+
 	if !s.enable {
 		log.Info("Running without syncing enabled")
 		return nil
 	}
 
 	log.Info("Initializing Sync Service")
+	if s.stream != nil {
+		s.stream.start()
+	}
+	if s.watchdog != nil {
+		s.watchdog.start()
+	}
 	if s.verifier {
 		go s.VerifierLoop()
 	} else {
@@ -360,6 +478,9 @@ func (s *SyncService) Start() error {
 				log.Crit("Sequencer cannot sync queue to tip", "err", err)
 			}
 			s.setSyncStatus(false)
+			if s.takeover != nil {
+				s.takeover.start()
+			}
 			go s.SequencerLoop()
 			go s.HandleSyncFromOther()
 		}()
@@ -373,6 +494,13 @@ func (s *SyncService) Start() error {
 // transaction processed. This must complete before transactions
 // are accepted via RPC when running as a sequencer.
 func (s *SyncService) initializeLatestL1(ctcDeployHeight *big.Int) error {
+	// The indexes are written ahead of the blocks they describe, make sure
+	// they match the chain before resuming from them. Only the recent blocks
+	// are checked for the queue index, `geth rollup repair-indexes` checks
+	// the whole chain.
+	if _, err := RepairIndexes(s.db, s.bc, startupIndexDepth); err != nil {
+		return fmt.Errorf("Cannot repair rollup indexes: %w", err)
+	}
 	index := s.GetLatestIndex()
 	log.Info("initializeLatestL1", "ctcDeployHeight", ctcDeployHeight.String())
 	if index == nil {
@@ -390,7 +518,7 @@ func (s *SyncService) initializeLatestL1(ctcDeployHeight *big.Int) error {
 		// Recover from accidentally skipped batches if necessary.
 		if s.verifier && s.backend == BackendL1 {
 			var newBatchIndex uint64
-			if rcfg.DeSeqBlock > 0 && *index+1 >= rcfg.DeSeqBlock {
+			if s.ovm.IsDeSeq(*index + 1) {
 				block, err := s.client.GetRawBlock(*index, s.backend)
 				if err != nil {
 					return fmt.Errorf("Cannot fetch block from dtl at index %d: %w", *index, err)
@@ -534,6 +662,17 @@ func (s *SyncService) IsSyncing() bool {
 func (s *SyncService) Stop() error {
 	s.scope.Close()
 	s.txOtherScope.Close()
+	if s.stream != nil {
+		s.stream.stop()
+	}
+	if s.watchdog != nil {
+		s.watchdog.stop()
+	}
+	s.seqChanges.stop()
+	s.seqRegistry.stop()
+	if s.takeover != nil {
+		s.takeover.stop()
+	}
 	s.chainHeadSub.Unsubscribe()
 	close(s.chainHeadCh)
 	close(s.syncQueueFromOthers)
@@ -554,6 +693,7 @@ func (s *SyncService) HandleSyncFromOther() {
 			blockNumber := block.NumberU64()
 			for index, tx := range block.Transactions() {
 				log.Debug("Handle SyncFromOther ", "tx", tx.Hash(), "index", index, "block", blockNumber)
+				s.seqAdapter.ObserveSequencerTx(tx, blockNumber, SeqTxSourceP2P)
 				err := s.applyTransaction(tx, false)
 				if err != nil {
 					log.Error("HandleSyncFromOther applyTransaction ", "tx", tx.Hash(), "err", err)
@@ -568,16 +708,22 @@ func (s *SyncService) VerifierLoop() {
 	log.Info("Starting Verifier Loop", "poll-interval", s.pollInterval, "timestamp-refresh-threshold", s.timestampRefreshThreshold)
 	t := time.NewTicker(s.pollInterval)
 	defer t.Stop()
-	for ; true; <-t.C {
+	for {
 		if err := s.verify(); err != nil {
 			log.Error("Could not verify", "error", err)
 		}
+		if !s.waitForNext(t) {
+			return
+		}
 	}
 }
 
 // verify is the main logic for the Verifier. The verifier logic is different
 // depending on the Backend
 func (s *SyncService) verify() error {
+	if mismatch := s.haltedAt(); mismatch != nil {
+		return fmt.Errorf("Verifier halted on state root mismatch at index %d", mismatch.Index)
+	}
 	switch s.backend {
 	case BackendL1:
 		if err := s.syncBatchesToTip(); err != nil {
@@ -597,7 +743,7 @@ func (s *SyncService) SequencerLoop() {
 	log.Info("Starting Sequencer Loop", "poll-interval", s.pollInterval, "timestamp-refresh-threshold", s.timestampRefreshThreshold)
 	t := time.NewTicker(s.pollInterval)
 	defer t.Stop()
-	for ; true; <-t.C {
+	for {
 		s.txLock.Lock()
 		if err := s.sequence(); err != nil {
 			log.Error("Could not sequence", "error", err)
@@ -607,7 +753,42 @@ func (s *SyncService) SequencerLoop() {
 		if err := s.updateL1BlockNumber(); err != nil {
 			log.Error("Could not update execution context", "error", err)
 		}
+		if !s.waitForNext(t) {
+			return
+		}
+	}
+}
+
+// waitForNext blocks until the next poll interval or until the rollup stream
+// announces a new element. It returns false when the service is stopped.
+func (s *SyncService) waitForNext(t *time.Ticker) bool {
+	select {
+	case <-t.C:
+	case <-s.streamWake:
+		// Skip the tick that would follow right after
+		select {
+		case <-t.C:
+		default:
+		}
+	case <-s.ctx.Done():
+		return false
+	}
+	return true
+}
+
+// acceptStreamEvent returns true for the rollup stream events that the sync
+// loop of the current mode consumes
+func (s *SyncService) acceptStreamEvent(ev StreamEvent) bool {
+	if !s.verifier {
+		return ev.Type == StreamEventEnqueue || (s.reorgEnable && ev.Type == StreamEventBatch)
+	}
+	switch s.backend {
+	case BackendL1:
+		return ev.Type == StreamEventBatch
+	case BackendL2:
+		return ev.Type == StreamEventTransaction || ev.Type == StreamEventBlock
 	}
+	return false
 }
 
 // sequence is the main logic for the Sequencer. It will sync any `enqueue`
@@ -615,12 +796,17 @@ func (s *SyncService) SequencerLoop() {
 // compare against the transactions it has in its local state. The sequencer
 // should reorg based on the transaction batches that are posted because
 // L1 is the source of truth. The sequencer concurrently accepts user
-// transactions via the RPC. When reorg logic is enabled, this should
-// also call `syncBatchesToTip`
+// transactions via the RPC. When reorg logic is enabled, the posted batches
+// are reconciled with the local chain.
 func (s *SyncService) sequence() error {
 	if err := s.syncQueueToTip(); err != nil {
 		return fmt.Errorf("Sequencer cannot sequence queue: %w", err)
 	}
+	if s.reorgEnable {
+		if err := s.reconcileBatchesToTip(); err != nil {
+			return fmt.Errorf("Sequencer cannot reconcile batches: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -670,7 +856,7 @@ func (s *SyncService) syncTransactionsToTip() error {
 		return s.syncTransactions(s.backend)
 	}
 	check := func() (*uint64, error) {
-		if rcfg.DeSeqBlock > 0 && s.bc.CurrentBlock().NumberU64()+1 >= rcfg.DeSeqBlock {
+		if s.ovm.IsDeSeq(s.bc.CurrentBlock().NumberU64() + 1) {
 			return s.client.GetLatestBlockIndex(s.backend)
 		}
 		return s.client.GetLatestTransactionIndex(s.backend)
@@ -685,7 +871,7 @@ func (s *SyncService) syncTransactionsToTip() error {
 // until sync the same height from main sequencer node p2p, syncQueueToTip loop works
 func (s *SyncService) waitingSequencerTip() (bool, error) {
 	seqModel, mpcEnabled := s.GetSeqAndMpcStatus()
-	if !seqModel || !mpcEnabled || s.startSeqHeight == 0 {
+	if !seqModel || !mpcEnabled || atomic.LoadUint64(&s.startSeqHeight) == 0 {
 		return false, nil
 	}
 	// check is current address is sequencer
@@ -826,6 +1012,13 @@ func (s *SyncService) GasPriceOracleOwnerAddress() *common.Address {
 	return &s.gasPriceOracleOwnerAddress
 }
 
+// FeeThresholds returns the bounds around the L2 gas price that the
+// sequencer accepts the gas price of a transaction within, nil bounds are
+// not enforced
+func (s *SyncService) FeeThresholds() (*big.Float, *big.Float) {
+	return s.feeThresholdUp, s.feeThresholdDown
+}
+
 // / Update the execution context's timestamp and blocknumber
 // / over time. This is only necessary for the sequencer.
 func (s *SyncService) updateL1BlockNumber() error {
@@ -1091,7 +1284,7 @@ func (s *SyncService) applyIndexedTransaction(tx *types.Transaction, fromLocal b
 		return s.applyTransactionToTip(tx, fromLocal)
 	}
 	// from p2p tx, when after DeSeqBlock, one block contains multiple transactions
-	if !fromLocal && *index+1 == next && rcfg.DeSeqBlock > 0 && *index+1 >= rcfg.DeSeqBlock {
+	if !fromLocal && *index+1 == next && s.ovm.IsDeSeq(*index+1) {
 		return s.applyTransactionToTip(tx, fromLocal)
 	}
 	if *index < next {
@@ -1123,9 +1316,9 @@ func (s *SyncService) applyHistoricalTransaction(tx *types.Transaction, fromLoca
 	// Handle the off by one
 	block := s.bc.GetBlockByNumber(*index + 1)
 	if block == nil {
-		return fmt.Errorf("Block %d is not found", *index+1, "fromLocal", fromLocal)
+		return fmt.Errorf("Block %d is not found, fromLocal %t", *index+1, fromLocal)
 	}
-	if rcfg.DeSeqBlock > 0 && *index+1 >= rcfg.DeSeqBlock {
+	if s.ovm.IsDeSeq(*index + 1) {
 		return nil
 	}
 	txs := block.Transactions()
@@ -1140,11 +1333,12 @@ func (s *SyncService) applyHistoricalTransaction(tx *types.Transaction, fromLoca
 	return nil
 }
 
-func (s *SyncService) recoverSeqAddress(tx *types.Transaction) (string, error) {
-	return s.seqAdapter.RecoverSeqAddress(tx)
+func (s *SyncService) recoverSeqAddress(tx *types.Transaction, number uint64) (string, error) {
+	return s.seqAdapter.RecoverSeqAddress(tx, number)
 }
 
-func (s *SyncService) addSeqSignature(tx *types.Transaction) error {
+// addSeqSignature signs a transaction that is sequenced in the block number
+func (s *SyncService) addSeqSignature(tx *types.Transaction, number uint64) error {
 	if tx.GetSeqSign() != nil {
 		return nil
 	}
@@ -1158,31 +1352,17 @@ func (s *SyncService) addSeqSignature(tx *types.Transaction) error {
 		tx.SetSeqSign(seqSign)
 		return nil
 	}
-	if s.seqPriv == "" || s.seqPriv == "0x" {
-		return errors.New("seq priv not set")
+	if s.seqSigner == nil {
+		return errNoSeqSigner
 	}
-	seqPriv := strings.Replace(s.seqPriv, "0x", "", 1)
-	hash := tx.Hash().Bytes()
-
-	privKey, err := hex.DecodeString(seqPriv)
+	data, err := s.seqSignData(tx, number)
 	if err != nil {
 		return err
 	}
-
-	ecdsaPri, err := crypto.ToECDSA(privKey)
+	seqSign, err := signSequencerData(s.seqSigner, data)
 	if err != nil {
 		return err
 	}
-
-	signature, err := crypto.Sign(hash, ecdsaPri)
-	if err != nil || len(signature) != 65 {
-		return errors.New("invalid signature")
-	}
-	seqSign := &types.SeqSign{
-		R: big.NewInt(0).SetBytes(signature[0:32]),
-		S: big.NewInt(0).SetBytes(signature[32:64]),
-		V: big.NewInt(0).SetBytes(signature[64:65]),
-	}
 	tx.SetSeqSign(seqSign)
 	if tx.GetSeqSign() == nil {
 		return errors.New("set signature failed")
@@ -1190,6 +1370,32 @@ func (s *SyncService) addSeqSignature(tx *types.Transaction) error {
 	return nil
 }
 
+// AddSequencerInfo stores the info entry of another sequencer after checking
+// that it is signed by a signer of the sequencer set
+func (s *SyncService) AddSequencerInfo(info *types.SequencerInfo) error {
+	return s.seqRegistry.Add(info)
+}
+
+// SequencerInfos returns the info entries of the other sequencers with their
+// liveness and head height
+func (s *SyncService) SequencerInfos(ctx context.Context) []types.SequencerInfo {
+	return s.seqRegistry.List(ctx)
+}
+
+// SequencerUrl returns the url of another sequencer, empty if it is unknown
+func (s *SyncService) SequencerUrl(addr common.Address) string {
+	return s.seqRegistry.Url(addr)
+}
+
+// SignSequencerInfo returns the info entry of the local sequencer at a url
+// for other sequencers to add
+func (s *SyncService) SignSequencerInfo(url string) (*types.SequencerInfo, error) {
+	if s.seqSigner == nil {
+		return nil, errNoSeqSigner
+	}
+	return signSequencerInfo(s.seqSigner, s.bc.Config().ChainID, url, time.Now())
+}
+
 func (s *SyncService) GetTxSequencer(tx *types.Transaction, expectIndex uint64) (common.Address, error) {
 	return s.seqAdapter.GetTxSequencer(tx, expectIndex)
 }
@@ -1205,7 +1411,7 @@ func (s *SyncService) IsSelfSeqAddress(expectSeq common.Address) bool {
 }
 
 func (s *SyncService) IsAboveStartHeight(num uint64) bool {
-	return num > s.startSeqHeight
+	return num > atomic.LoadUint64(&s.startSeqHeight)
 }
 
 // Only call when fromLocal tx, verifier or replica
@@ -1221,7 +1427,7 @@ func (s *SyncService) makeOrVerifySequencerSign(tx *types.Transaction, blockNumb
 	}
 	if seqModel && mpcEnabled && blockNumber >= s.seqAdapter.GetSeqValidHeight() && tx.QueueOrigin() == types.QueueOriginL1ToL2 {
 		// mpc status 2. add sequencer signature to tx in sequencer model, QueueOriginL1ToL2 always give 0 to sign
-		err = s.addSeqSignature(tx)
+		err = s.addSeqSignature(tx, blockNumber)
 		if err != nil {
 			log.Error("addSeqSignature err QueueOriginL1ToL2", "err", err)
 			return isRespan, err
@@ -1238,11 +1444,12 @@ func (s *SyncService) makeOrVerifySequencerSign(tx *types.Transaction, blockNumb
 				return isRespan, err
 			}
 			// mpc status 2. add sequencer signature to tx in sequencer model
-			err = s.addSeqSignature(tx)
+			err = s.addSeqSignature(tx, blockNumber)
 			if err != nil {
 				log.Error("addSeqSignature err QueueOriginSequencer", "err", err)
 				return isRespan, err
 			}
+			s.seqAdapter.ObserveSequencerTx(tx, blockNumber, SeqTxSourceLocal)
 		} else {
 			// mpc status 3. check sequencer signature in verifier model or BackendL2
 			signature := tx.GetSeqSign()
@@ -1251,11 +1458,12 @@ func (s *SyncService) makeOrVerifySequencerSign(tx *types.Transaction, blockNumb
 				log.Info(errInfo)
 				return isRespan, errors.New(errInfo)
 			}
-			recoverSeq, err := s.recoverSeqAddress(tx)
+			recoverSeq, err := s.recoverSeqAddress(tx, blockNumber)
 			if err != nil {
 				log.Error("recoverSeqAddress err ", err)
 				return isRespan, err
 			}
+			s.seqAdapter.ObserveSequencerTx(tx, blockNumber, SeqTxSourceDTL)
 			if !strings.EqualFold(expectSeq.String(), recoverSeq) {
 				errInfo := fmt.Sprintf("tx seq %v, is not expect seq %v", recoverSeq, expectSeq.String())
 				log.Error(errInfo)
@@ -1416,7 +1624,7 @@ func (s *SyncService) applyTransactionToTip(tx *types.Transaction, fromLocal boo
 	if fromLocal {
 		currentBN = currentBN + 1
 	}
-	if rcfg.DeSeqBlock > 0 && currentBN >= rcfg.DeSeqBlock {
+	if s.ovm.IsDeSeq(currentBN) {
 		return s.applyTransactionToPool(tx, fromLocal)
 	}
 	// If there is no L1 timestamp assigned to the transaction, then assign a
@@ -1878,11 +2086,9 @@ func (s *SyncService) syncBatches() (*uint64, error) {
 	return index, nil
 }
 
-func (s *SyncService) syncBlockBatch(index uint64) error {
-	batch, blocks, err := s.client.GetBlockBatch(index)
-	if err != nil {
-		return err
-	}
+// applyBlockBatch applies the blocks of a block batch that are not yet
+// indexed and verifies their state roots
+func (s *SyncService) applyBlockBatch(index uint64, batch *Batch, blocks []*types.Block) error {
 	next := s.GetNextIndex()
 	for _, block := range blocks {
 		index := block.NumberU64() - 1
@@ -1894,64 +2100,79 @@ func (s *SyncService) syncBlockBatch(index uint64) error {
 			return fmt.Errorf("cannot apply batched block: %w", err)
 		}
 		// verifier stateroot of txs[0]
-		txIndex, stateRoot, verifierRoot, err := s.verifyStateRoot(block.Transactions()[0], batch.Root)
-		if err != nil {
-			// report to dtl success=false
-			s.client.SetLastVerifier(txIndex, stateRoot, verifierRoot, false)
+		if err := s.checkStateRoot(block.Transactions()[0], batch); err != nil {
+			return err
+		}
+	}
+	s.SetLatestBatchIndex(&index)
+	return nil
+}
+
+// applyTransactionBatch applies the transactions of a transaction batch that
+// are not yet indexed and verifies their state roots
+func (s *SyncService) applyTransactionBatch(index uint64, batch *Batch, txs []*types.Transaction) error {
+	next := s.GetNextIndex()
+	for _, tx := range txs {
+		index := tx.GetMeta().Index
+		if *index < next {
+			log.Info("Tx indexed, continue", "index", *index)
+			continue
+		}
+		if err := s.applyBatchedTransaction(tx); err != nil {
+			return fmt.Errorf("cannot apply batched transaction: %w", err)
+		}
+		// verifier stateroot
+		if err := s.checkStateRoot(tx, batch); err != nil {
 			return err
 		}
-		// report to dtl success=true
-		s.client.SetLastVerifier(txIndex, stateRoot, verifierRoot, true)
 	}
 	s.SetLatestBatchIndex(&index)
 	return nil
 }
 
 // syncTransactionBatchRange will sync a range of batched transactions from
-// start to end (inclusive)
+// start to end (inclusive). The next batches are downloaded while the
+// current one is applied.
 func (s *SyncService) syncTransactionBatchRange(start, end uint64) error {
-	log.Info("Syncing transaction batch range", "start", start, "end", end)
-	for i := start; i <= end; i++ {
-		if rcfg.DeSeqBlock > 0 && s.bc.CurrentBlock().NumberU64()+1 >= rcfg.DeSeqBlock {
-			err := s.syncBlockBatch(i)
-			if err != nil {
-				return fmt.Errorf("Cannot get block batch: %w", err)
+	log.Info("Syncing transaction batch range", "start", start, "end", end, "prefetch", s.prefetchDepth)
+	fetch := func(index uint64) *fetchedBatch {
+		log.Debug("Fetching transaction batch", "index", index)
+		res := s.fetchBatch(index, s.useBlockBatches())
+		// Recover the signatures while the batches before are applied
+		if res.err == nil && s.seqAdapter != nil {
+			if res.blockBatch {
+				s.seqAdapter.CacheSeqSenders(res.blocks)
+			} else {
+				s.seqAdapter.CacheBatchSeqSenders(res.txs)
 			}
-			continue
 		}
-		log.Debug("Fetching transaction batch", "index", i)
-		batch, txs, err := s.client.GetTransactionBatch(i)
-		if err != nil {
-			if strings.Contains(err.Error(), "USE_INBOX_BATCH_INDEX") {
-				err := s.syncBlockBatch(i)
-				if err != nil {
-					return fmt.Errorf("Cannot get block batch: %w", err)
-				}
-				continue
-			}
-			return fmt.Errorf("Cannot get transaction batch: %w", err)
+		return res
+	}
+	prefetcher := newBatchPrefetcher(fetch, start, end, s.prefetchDepth)
+	defer prefetcher.close()
+
+	for res := prefetcher.next(); res != nil; res = prefetcher.next() {
+		// The kind of batch depends on the height it is applied at, a batch
+		// that was prefetched before the switch to block batches is fetched
+		// again
+		if res.err == nil && !res.blockBatch && s.useBlockBatches() {
+			res = s.fetchBatch(res.index, true)
 		}
-		next := s.GetNextIndex()
-		for _, tx := range txs {
-			index := tx.GetMeta().Index
-			if *index < next {
-				log.Info("Tx indexed, continue", "index", *index)
-				continue
+		if res.blockBatch {
+			if res.err != nil {
+				return fmt.Errorf("Cannot get block batch: %w", res.err)
 			}
-			if err := s.applyBatchedTransaction(tx); err != nil {
-				return fmt.Errorf("cannot apply batched transaction: %w", err)
-			}
-			// verifier stateroot
-			txIndex, stateRoot, verifierRoot, err := s.verifyStateRoot(tx, batch.Root)
-			if err != nil {
-				// report to dtl success=false
-				s.client.SetLastVerifier(txIndex, stateRoot, verifierRoot, false)
-				return err
+			if err := s.applyBlockBatch(res.index, res.batch, res.blocks); err != nil {
+				return fmt.Errorf("Cannot get block batch: %w", err)
 			}
-			// report to dtl success=true
-			s.client.SetLastVerifier(txIndex, stateRoot, verifierRoot, true)
+			continue
+		}
+		if res.err != nil {
+			return fmt.Errorf("Cannot get transaction batch: %w", res.err)
+		}
+		if err := s.applyTransactionBatch(res.index, res.batch, res.txs); err != nil {
+			return err
 		}
-		s.SetLatestBatchIndex(&i)
 	}
 	return nil
 }
@@ -1977,7 +2198,7 @@ func (s *SyncService) verifyStateRoot(tx *types.Transaction, batchRoot common.Ha
 			continue
 		}
 		if stateRootHash != localStateRoot {
-			return txIndex, stateRootHash.Hex(), localStateRoot.Hex(), fmt.Errorf("The remote stateroot is not equal to the local: tx index %d, remote %v, local %v, batch-root %v", txIndex, stateRootHash.Hex(), localStateRoot.Hex(), batchRoot.Hex())
+			return txIndex, stateRootHash.Hex(), localStateRoot.Hex(), fmt.Errorf("%w: tx index %d, remote %v, local %v, batch-root %v", errStateRootMismatch, txIndex, stateRootHash.Hex(), localStateRoot.Hex(), batchRoot.Hex())
 		}
 		log.Info("Verified tx with stateroot ok", "i", i, "index", txIndex, "batch-root", batchRoot.Hex())
 		return txIndex, stateRootHash.Hex(), localStateRoot.Hex(), nil
@@ -1995,13 +2216,18 @@ func (s *SyncService) syncQueue() (*uint64, error) {
 	return index, nil
 }
 
+// skipEnqueue returns true for the enqueues that are never included
+func (s *SyncService) skipEnqueue(index uint64) bool {
+	// NOTE, andromeda queue
+	return s.ovm.ChainID == 1088 && (index == 20397 || index == 37446)
+}
+
 // syncQueueTransactionRange will apply a range of queue transactions from
 // start to end (inclusive)
 func (s *SyncService) syncQueueTransactionRange(start, end uint64) error {
 	log.Info("Syncing enqueue transactions range", "start", start, "end", end)
 	for i := start; i <= end; i++ {
-		// NOTE, andromeda queue
-		if rcfg.ChainID == 1088 && (i == 20397 || i == 37446) {
+		if s.skipEnqueue(i) {
 			continue
 		}
 		tx, err := s.client.GetEnqueue(i)
@@ -2093,7 +2319,7 @@ func (s *SyncService) syncTransactionRange(start, end uint64, backend Backend) e
 	log.Info("Syncing transaction or block range", "start", start, "end", end, "backend", backend.String())
 	for i := start; i <= end; i++ {
 		// i + 1 equals the next block number
-		if rcfg.DeSeqBlock > 0 && i+1 >= rcfg.DeSeqBlock {
+		if s.ovm.IsDeSeq(i + 1) {
 			block, err := s.client.GetBlock(i, s.backend)
 			if err != nil {
 				return fmt.Errorf("cannot fetch block %d: %w", i, err)
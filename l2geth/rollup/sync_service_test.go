@@ -0,0 +1,299 @@
+package rollup
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/consensus/ethash"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/core/vm"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/eth/gasprice"
+	"github.com/ethereum-optimism/optimism/l2geth/ethdb"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/dtltest"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/dump"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/rcfg"
+)
+
+var (
+	testKey, _  = crypto.GenerateKey()
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+	testSigner  = types.NewEIP155Signer(params.TestChainConfig.ChainID)
+)
+
+// mineTransaction builds a block with a single transaction on top of the
+// current head and inserts it, the way the miner does for the sync service
+func mineTransaction(bc *core.BlockChain, tx *types.Transaction) error {
+	parent := bc.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Difficulty: common.Big1,
+		Time:       tx.L1Timestamp(),
+	}
+	statedb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return err
+	}
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	receipt, err := core.ApplyTransaction(bc.Config(), bc, &header.Coinbase, gp, statedb, header, tx, &header.GasUsed, *bc.GetVMConfig())
+	if err != nil {
+		return err
+	}
+	block, err := bc.Engine().FinalizeAndAssemble(bc, header, statedb, types.Transactions{tx}, nil, types.Receipts{receipt})
+	if err != nil {
+		return err
+	}
+	_, err = bc.InsertChain(types.Blocks{block})
+	return err
+}
+
+// startTestMiner mines the transactions emitted by the sync service until
+// the service is stopped
+func startTestMiner(s *SyncService) {
+	ch := make(chan core.NewTxsEvent, 16)
+	sub := s.SubscribeNewTxsEvent(ch)
+	go func() {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev := <-ch:
+				for _, tx := range ev.Txs {
+					if err := mineTransaction(s.bc, tx); err != nil {
+						s.PushTxApplyError(err)
+					}
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+}
+
+// newTestChain creates an OVM chain with a funded test account. The balances
+// are kept in OVM_ETH, it needs code so that it is not removed as an empty
+// account.
+func newTestChain(t *testing.T) (*core.BlockChain, ethdb.Database) {
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{
+		Config:   params.TestChainConfig,
+		GasLimit: 15000000,
+		Alloc: core.GenesisAlloc{
+			testAddress:        {Balance: big.NewInt(1e18)},
+			dump.OvmEthAddress: {Code: []byte{0x00}, Balance: common.Big0},
+		},
+		UsingOVM: true,
+	}
+	gspec.MustCommit(db)
+	vmConfig := vm.Config{OVM: rcfg.Config{UsingOVM: true}}
+	bc, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFullFaker(), vmConfig, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(bc.Stop)
+	return bc, db
+}
+
+// newTestSyncService starts a SyncService on a new chain that syncs from a
+// fake data transport layer
+func newTestSyncService(t *testing.T, server *dtltest.Server, cfg Config) *SyncService {
+	bc, db := newTestChain(t)
+	cfg.Eth1SyncServiceEnable = true
+	cfg.RollupClientHttp = server.URL
+	cfg.PollInterval = 10 * time.Millisecond
+	cfg.CanonicalTransactionChainDeployHeight = common.Big1
+	// The sequencer set is never enabled, the default sequencer is used
+	cfg.SeqsetValidHeight = math.MaxUint64
+	cfg.SeqAddress = rcfg.DefaultSeqAdderss.Hex()
+
+	service, err := NewSyncService(context.Background(), cfg, nil, bc, db, make(chan *types.Block))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.RollupGpo = gasprice.NewRollupOracle()
+	startTestMiner(service)
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { service.Stop() })
+	return service
+}
+
+// signTestTransaction signs a value transfer of the test account
+func signTestTransaction(t *testing.T, key *ecdsa.PrivateKey, nonce uint64) *types.Transaction {
+	tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{0xaa}, big.NewInt(1), 21000, common.Big1, nil), testSigner, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// waitForHeight waits until the chain of the service reaches a height
+func waitForHeight(t *testing.T, s *SyncService, height uint64) {
+	waitFor(t, "chain height", func() bool { return s.bc.CurrentBlock().NumberU64() >= height })
+	if number := s.bc.CurrentBlock().NumberU64(); number != height {
+		t.Fatalf("unexpected height: have %d, want %d", number, height)
+	}
+}
+
+func TestSyncServiceSequencer(t *testing.T) {
+	fixture := dtltest.NewFixture(params.TestChainConfig.ChainID)
+	server := dtltest.NewServer(fixture)
+	defer server.Close()
+
+	origin, target := common.Address{0x01}, common.Address{0x02}
+	fixture.AddEnqueue(origin, target, 100000, []byte{0x01})
+	fixture.AddEnqueue(origin, target, 100000, []byte{0x02})
+	fixture.Delay(dtltest.EndpointEnqueue, 5*time.Millisecond)
+
+	service := newTestSyncService(t, server, Config{})
+	// The sequencer syncs the queue at start up
+	waitForHeight(t, service, 2)
+
+	// New enqueues are picked up by the sequencer loop
+	fixture.AdvanceL1(2, uint64(time.Now().Unix()))
+	fixture.AddEnqueue(origin, target, 100000, []byte{0x03})
+	waitForHeight(t, service, 3)
+
+	for i := uint64(1); i <= 3; i++ {
+		tx := service.bc.GetBlockByNumber(i).Transactions()[0]
+		if tx.QueueOrigin() != types.QueueOriginL1ToL2 || *tx.L1MessageSender() != origin {
+			t.Fatalf("block %d does not hold an enqueue", i)
+		}
+		if *tx.GetMeta().QueueIndex != i-1 || *tx.GetMeta().Index != i-1 {
+			t.Fatalf("block %d has wrong indices", i)
+		}
+	}
+	if index := service.GetLatestEnqueueIndex(); index == nil || *index != 2 {
+		t.Fatal("latest enqueue index not updated")
+	}
+	waitFor(t, "sync status", func() bool { return !service.IsSyncing() })
+}
+
+func TestSyncServiceVerifierL2(t *testing.T) {
+	fixture := dtltest.NewFixture(params.TestChainConfig.ChainID)
+	server := dtltest.NewServer(fixture)
+	defer server.Close()
+
+	origin, target := common.Address{0x01}, common.Address{0x02}
+	queueIndex := fixture.AddEnqueue(origin, target, 100000, nil)
+	if _, err := fixture.IncludeEnqueue(queueIndex); err != nil {
+		t.Fatal(err)
+	}
+	txs := []*types.Transaction{signTestTransaction(t, testKey, 0), signTestTransaction(t, testKey, 1)}
+	for _, tx := range txs {
+		if _, err := fixture.AddSequencerTransaction(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The replica recovers from a failing data transport layer
+	fixture.Fail(dtltest.EndpointTransaction, 2)
+
+	service := newTestSyncService(t, server, Config{IsVerifier: true, Backend: BackendL2})
+	waitForHeight(t, service, 3)
+
+	// Unbatched transactions are synced as they are sequenced
+	tx := signTestTransaction(t, testKey, 2)
+	if _, err := fixture.AddSequencerTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	txs = append(txs, tx)
+	waitForHeight(t, service, 4)
+
+	for i, tx := range txs {
+		block := service.bc.GetBlockByNumber(uint64(i) + 2)
+		if block.Transactions()[0].Hash() != tx.Hash() {
+			t.Fatalf("block %d does not hold the sequenced transaction", block.NumberU64())
+		}
+	}
+	state, _ := service.bc.State()
+	if nonce := state.GetNonce(testAddress); nonce != 3 {
+		t.Fatalf("unexpected nonce: %d", nonce)
+	}
+	if len(fixture.Verifications()) != 0 {
+		t.Fatal("replica reported a verification")
+	}
+}
+
+func TestSyncServiceVerifierL1(t *testing.T) {
+	fixture := dtltest.NewFixture(params.TestChainConfig.ChainID)
+	server := dtltest.NewServer(fixture)
+	defer server.Close()
+
+	queueIndex := fixture.AddEnqueue(common.Address{0x01}, common.Address{0x02}, 100000, nil)
+	if _, err := fixture.IncludeEnqueue(queueIndex); err != nil {
+		t.Fatal(err)
+	}
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if _, err := fixture.AddSequencerTransaction(signTestTransaction(t, testKey, nonce)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Sync the posted transactions with a replica to learn the state roots
+	// the sequencer posts
+	replica := newTestSyncService(t, server, Config{IsVerifier: true, Backend: BackendL2})
+	waitForHeight(t, replica, 3)
+	for i := uint64(0); i < 3; i++ {
+		fixture.SetStateRoot(i, replica.bc.GetBlockByNumber(i+1).Root())
+	}
+	fixture.SubmitBatch()
+
+	verifier := newTestSyncService(t, server, Config{IsVerifier: true, Backend: BackendL1})
+	waitForHeight(t, verifier, 3)
+	waitFor(t, "batch", func() bool {
+		index := verifier.GetLatestBatchIndex()
+		return index != nil && *index == 0
+	})
+	if verifier.bc.CurrentBlock().Root() != replica.bc.CurrentBlock().Root() {
+		t.Fatal("verifier state diverged")
+	}
+	verifications := fixture.Verifications()
+	if len(verifications) != 3 {
+		t.Fatalf("unexpected number of verifications: %d", len(verifications))
+	}
+	for _, v := range verifications {
+		if !v.Success {
+			t.Fatalf("verification of index %d failed", v.Index)
+		}
+	}
+}
+
+func TestSyncServiceVerifierMismatch(t *testing.T) {
+	fixture := dtltest.NewFixture(params.TestChainConfig.ChainID)
+	server := dtltest.NewServer(fixture)
+	defer server.Close()
+
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		index, err := fixture.AddSequencerTransaction(signTestTransaction(t, testKey, nonce))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixture.SetStateRoot(index, common.Hash{0xff})
+	}
+	fixture.SubmitBatch()
+
+	verifier := newTestSyncService(t, server, Config{IsVerifier: true, Backend: BackendL1, StateRootMismatchPolicy: "halt"})
+	waitFor(t, "halt", func() bool { return verifier.haltedAt() != nil })
+	if index := verifier.haltedAt().Index; index != 0 {
+		t.Fatalf("halted at index %d", index)
+	}
+	if mismatches := verifier.StateRootMismatches(0, 10); len(mismatches) != 1 {
+		t.Fatalf("unexpected number of mismatches: %d", len(mismatches))
+	}
+	if v := fixture.Verifications(); len(v) != 1 || v[0].Success {
+		t.Fatal("mismatch not reported")
+	}
+	if verifier.bc.CurrentBlock().NumberU64() != 1 {
+		t.Fatal("verifier did not halt")
+	}
+}
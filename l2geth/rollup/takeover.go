@@ -0,0 +1,375 @@
+package rollup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/metrics"
+	"github.com/ethereum-optimism/optimism/l2geth/rlp"
+)
+
+const (
+	// defaultTakeoverThreshold is how long the active sequencer may not add
+	// a block before the first backup takes over
+	defaultTakeoverThreshold = 2 * time.Minute
+	// respanGasLimit is the gas limit of the recommit call of a takeover
+	respanGasLimit = 1000000
+)
+
+var (
+	errTakeoverIsolated = errors.New("no peers to confirm the chain height")
+	errTakeoverBehind   = errors.New("local chain behind its peers")
+	errTakeoverAlive    = errors.New("active sequencer is alive")
+	errTakeoverUnknown  = errors.New("active sequencer unknown, cannot confirm it is down")
+	errTakeoverRespan   = errors.New("respan already pending")
+	errTakeoverSigner   = errors.New("respan signer is not the seqset mpc address")
+
+	takeoverAttemptMeter = metrics.NewRegisteredMeter("rollup/takeover/attempts", nil)
+	takeoverAbortMeter   = metrics.NewRegisteredMeter("rollup/takeover/aborts", nil)
+	takeoverDoneMeter    = metrics.NewRegisteredMeter("rollup/takeover/done", nil)
+)
+
+// takeoverBackend is the view of the node that the takeover controller acts
+// on
+type takeoverBackend interface {
+	// CurrentHeader returns the head of the local chain
+	CurrentHeader() *types.Header
+	// PeerSynced returns whether the local chain is at the head of its peers
+	// and the number of peers
+	PeerSynced() (bool, int)
+	// EpochAt returns the sequencer epoch of a block number, nil before the
+	// sequencer set is valid
+	EpochAt(number uint64) (*types.SequencerEpoch, error)
+	// Sequencers returns the other known sequencers with their liveness and
+	// head height
+	Sequencers(ctx context.Context) []types.SequencerInfo
+	PendingRespans() []*types.PreRespan
+	MpcAddress() (common.Address, error)
+	Nonce(addr common.Address) uint64
+	GasPrice() (*big.Int, error)
+	// SubmitRespan sequences a signed recommit tx
+	SubmitRespan(tx *types.Transaction) error
+	// Activate starts sequencing from the block after number
+	Activate(number uint64)
+}
+
+// takeoverAttempt is a submitted respan that waits for its epoch
+type takeoverAttempt struct {
+	epoch     uint64
+	start     uint64
+	tx        common.Hash
+	submitted time.Time
+}
+
+// takeoverController lets a backup sequencer take over the blocks of an
+// active sequencer that stalled. It respans the rest of the epoch to itself
+// with a recommit call signed by the seqset mpc key and starts sequencing
+// once the chain holds the new epoch.
+//
+// The stall is measured from the local time at which the head was first seen,
+// the timestamp of a block is the L1 timestamp of its transaction. An idle
+// chain does not add blocks either, so the active sequencer must also fail
+// its liveness probe.
+//
+// The backups are ranked by address among the live ones at the local height
+// and wait one more threshold per rank, so the first respan makes the chain
+// move again before the next backup acts. A backup does not act while it has
+// no peers, is behind its peers, cannot confirm that the active sequencer is
+// down or a respan is pending.
+type takeoverController struct {
+	backend   takeoverBackend
+	self      common.Address
+	signer    SeqSigner
+	chainID   *big.Int
+	seqset    common.Address
+	threshold time.Duration
+	interval  time.Duration
+	now       func() time.Time
+
+	// pending and the last head with the time it was first seen are only
+	// accessed by the loop
+	pending  *takeoverAttempt
+	head     uint64
+	headSeen time.Time
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newTakeoverController(backend takeoverBackend, self common.Address, signer SeqSigner, chainID *big.Int, seqset common.Address, threshold time.Duration) *takeoverController {
+	if threshold <= 0 {
+		threshold = defaultTakeoverThreshold
+	}
+	return &takeoverController{
+		backend:   backend,
+		self:      self,
+		signer:    signer,
+		chainID:   chainID,
+		seqset:    seqset,
+		threshold: threshold,
+		interval:  threshold / 4,
+		now:       time.Now,
+		quit:      make(chan struct{}),
+	}
+}
+
+func (c *takeoverController) start() {
+	log.Info("Starting sequencer takeover controller", "self", c.self, "threshold", c.threshold, "respan-signer", c.signer.Address())
+	c.wg.Add(1)
+	go c.loop()
+}
+
+func (c *takeoverController) stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+func (c *takeoverController) loop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.step(context.Background()); err != nil {
+				takeoverAbortMeter.Mark(1)
+				log.Warn("Sequencer takeover aborted", "err", err)
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// step checks the active sequencer once and takes over or activates the
+// local sequencer when it is due
+func (c *takeoverController) step(ctx context.Context) error {
+	number := c.backend.CurrentHeader().Number.Uint64()
+	now := c.now()
+	if c.headSeen.IsZero() || number != c.head {
+		c.head, c.headSeen = number, now
+	}
+	if c.pending != nil {
+		return c.checkPending(number)
+	}
+	epoch, err := c.backend.EpochAt(number)
+	if err != nil || epoch == nil || epoch.Signer == c.self {
+		return err
+	}
+	stalled := now.Sub(c.headSeen)
+	if stalled < c.threshold {
+		return nil
+	}
+	sequencers := c.backend.Sequencers(ctx)
+	rank := c.rank(sequencers, epoch.Signer, number)
+	if stalled < c.threshold*time.Duration(rank+1) {
+		log.Debug("Waiting for a backup sequencer of a higher rank", "rank", rank, "stalled", stalled)
+		return nil
+	}
+
+	// Guards against a split brain
+	synced, peers := c.backend.PeerSynced()
+	if peers == 0 {
+		return errTakeoverIsolated
+	}
+	if !synced {
+		return errTakeoverBehind
+	}
+	active := -1
+	for i, info := range sequencers {
+		if info.SequencerAddress == epoch.Signer {
+			active = i
+		}
+	}
+	if active < 0 {
+		return fmt.Errorf("%w: %s", errTakeoverUnknown, epoch.Signer.Hex())
+	}
+	if info := sequencers[active]; info.Alive {
+		return fmt.Errorf("%w: height %d, local %d", errTakeoverAlive, info.SequencerHeight, number)
+	}
+	if respans := c.backend.PendingRespans(); len(respans) > 0 {
+		return fmt.Errorf("%w: start %d", errTakeoverRespan, respans[0].StartBlock)
+	}
+	mpc, err := c.backend.MpcAddress()
+	if err != nil {
+		return err
+	}
+	if mpc != c.signer.Address() {
+		return fmt.Errorf("%w: signer %s, mpc %s", errTakeoverSigner, c.signer.Address().Hex(), mpc.Hex())
+	}
+
+	// The recommit is applied in the next block, so the new epoch starts at
+	// the block after it and keeps the end of the stalled epoch
+	start := number + 2
+	end := epoch.EndBlock
+	if end < start {
+		end = start + epoch.EndBlock - epoch.StartBlock
+	}
+	tx, err := c.respanTx(epoch.ID, start, end)
+	if err != nil {
+		return err
+	}
+	takeoverAttemptMeter.Mark(1)
+	log.Warn("Taking over from a stalled sequencer", "signer", epoch.Signer, "epoch", epoch.ID, "stalled", stalled, "start", start, "end", end, "tx", tx.Hash())
+	if err := c.backend.SubmitRespan(tx); err != nil {
+		return fmt.Errorf("cannot submit respan: %w", err)
+	}
+	c.pending = &takeoverAttempt{epoch: epoch.ID, start: start, tx: tx.Hash(), submitted: c.now()}
+	return nil
+}
+
+// checkPending activates the local sequencer once the chain holds the epoch
+// of the submitted respan, and gives up on the respan when it is not applied
+// within a threshold
+func (c *takeoverController) checkPending(number uint64) error {
+	attempt := c.pending
+	if epoch, err := c.backend.EpochAt(attempt.start); err == nil && epoch != nil && epoch.ID > attempt.epoch {
+		c.pending = nil
+		if epoch.Signer != c.self {
+			log.Warn("Another sequencer took over", "signer", epoch.Signer, "epoch", epoch.ID)
+			return nil
+		}
+		takeoverDoneMeter.Mark(1)
+		log.Info("Took over the sequencer set", "epoch", epoch.ID, "start", epoch.StartBlock, "end", epoch.EndBlock)
+		c.backend.Activate(number)
+		return nil
+	}
+	if c.now().Sub(attempt.submitted) > c.threshold {
+		c.pending = nil
+		return fmt.Errorf("respan %s not applied in time", attempt.tx.Hex())
+	}
+	return nil
+}
+
+// rank returns the position of the local sequencer among the backups that
+// are alive at the local height, ordered by address
+func (c *takeoverController) rank(sequencers []types.SequencerInfo, active common.Address, number uint64) int {
+	rank := 0
+	for _, info := range sequencers {
+		if info.SequencerAddress == active || info.SequencerAddress == c.self || !info.Alive || info.SequencerHeight < number {
+			continue
+		}
+		if bytes.Compare(info.SequencerAddress.Bytes(), c.self.Bytes()) < 0 {
+			rank++
+		}
+	}
+	return rank
+}
+
+// respanTx returns the recommit call that hands the blocks from start to end
+// to the local sequencer, signed by the mpc key
+func (c *takeoverController) respanTx(epoch, start, end uint64) (*types.Transaction, error) {
+	gasPrice, err := c.backend.GasPrice()
+	if err != nil {
+		return nil, err
+	}
+	data := core.EncodeReCommitData(new(big.Int).SetUint64(epoch), new(big.Int).SetUint64(epoch+1), new(big.Int).SetUint64(start), new(big.Int).SetUint64(end), c.self)
+	tx := types.NewTransaction(c.backend.Nonce(c.signer.Address()), c.seqset, new(big.Int), respanGasLimit, gasPrice, data)
+
+	signer := types.NewEIP155Signer(c.chainID)
+	sigData, err := rlp.EncodeToBytes([]interface{}{
+		tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+		c.chainID, uint(0), uint(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := c.signer.SignData(sigData)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+// takeoverBackend returns the node view of the takeover controller
+func (s *SyncService) takeoverBackend() takeoverBackend {
+	return &syncTakeoverBackend{s}
+}
+
+type syncTakeoverBackend struct {
+	s *SyncService
+}
+
+func (b *syncTakeoverBackend) CurrentHeader() *types.Header {
+	return b.s.bc.CurrentHeader()
+}
+
+func (b *syncTakeoverBackend) PeerSynced() (bool, int) {
+	if b.s.peerTd == nil {
+		return false, 0
+	}
+	td, peers := b.s.peerTd()
+	if peers == 0 || td == nil {
+		return false, peers
+	}
+	head := b.s.bc.CurrentHeader()
+	local := b.s.bc.GetTd(head.Hash(), head.Number.Uint64())
+	return local != nil && local.Cmp(td) >= 0, peers
+}
+
+func (b *syncTakeoverBackend) EpochAt(number uint64) (*types.SequencerEpoch, error) {
+	return b.s.sequencerEpochAt(number)
+}
+
+func (b *syncTakeoverBackend) Sequencers(ctx context.Context) []types.SequencerInfo {
+	return b.s.SequencerInfos(ctx)
+}
+
+func (b *syncTakeoverBackend) PendingRespans() []*types.PreRespan {
+	return b.s.seqAdapter.PreRespans()
+}
+
+func (b *syncTakeoverBackend) MpcAddress() (common.Address, error) {
+	return b.s.seqAdapter.SeqSetMpcAddress()
+}
+
+func (b *syncTakeoverBackend) Nonce(addr common.Address) uint64 {
+	statedb, err := b.s.bc.State()
+	if err != nil {
+		return 0
+	}
+	return statedb.GetNonce(addr)
+}
+
+func (b *syncTakeoverBackend) GasPrice() (*big.Int, error) {
+	if b.s.RollupGpo == nil {
+		return new(big.Int), nil
+	}
+	return b.s.RollupGpo.SuggestL2GasPrice(context.Background())
+}
+
+func (b *syncTakeoverBackend) SubmitRespan(tx *types.Transaction) error {
+	return b.s.ValidateAndApplySequencerTransaction(tx)
+}
+
+func (b *syncTakeoverBackend) Activate(number uint64) {
+	b.s.activateSequencer(number)
+}
+
+// activateSequencer makes a backup sequencer sequence the blocks after the
+// number instead of waiting for the start height of the active sequencer
+func (s *SyncService) activateSequencer(number uint64) {
+	if atomic.LoadUint64(&s.startSeqHeight) > number {
+		atomic.StoreUint64(&s.startSeqHeight, number)
+	}
+	s.setSyncStatus(false)
+	log.Info("Activated the local sequencer", "number", number)
+}
+
+// SetPeerTd sets the function that returns the highest total difficulty of
+// the peers and the number of peers, the takeover controller compares it with
+// the local chain
+func (s *SyncService) SetPeerTd(peerTd func() (*big.Int, int)) {
+	s.peerTd = peerTd
+}
@@ -0,0 +1,507 @@
+package rollup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
+	"github.com/ethereum-optimism/optimism/l2geth/core"
+	"github.com/ethereum-optimism/optimism/l2geth/core/rawdb"
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/crypto"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+	"github.com/ethereum-optimism/optimism/l2geth/rpc"
+)
+
+// takeoverNet is the chain that the takeover nodes of a test share, a
+// submitted respan is applied in the next block right away
+type takeoverNet struct {
+	mu        sync.Mutex
+	chainID   *big.Int
+	seqset    common.Address
+	mpc       common.Address
+	head      *types.Header
+	epochs    []*types.SequencerEpoch
+	sequencer map[common.Address]types.SequencerInfo
+	respans   []*types.PreRespan
+	nonce     uint64
+	submitted []*types.Transaction
+}
+
+// takeoverNode is the view of one node of the takeover net
+type takeoverNode struct {
+	net       *takeoverNet
+	self      common.Address
+	peers     int
+	behind    bool
+	activated []uint64
+}
+
+func (n *takeoverNode) CurrentHeader() *types.Header {
+	n.net.mu.Lock()
+	defer n.net.mu.Unlock()
+	return types.CopyHeader(n.net.head)
+}
+
+func (n *takeoverNode) PeerSynced() (bool, int) { return !n.behind, n.peers }
+
+func (n *takeoverNode) EpochAt(number uint64) (*types.SequencerEpoch, error) {
+	n.net.mu.Lock()
+	defer n.net.mu.Unlock()
+	var found *types.SequencerEpoch
+	for _, epoch := range n.net.epochs {
+		if epoch.StartBlock <= number && number <= epoch.EndBlock && (found == nil || epoch.ID > found.ID) {
+			found = epoch
+		}
+	}
+	return found, nil
+}
+
+func (n *takeoverNode) Sequencers(ctx context.Context) []types.SequencerInfo {
+	n.net.mu.Lock()
+	defer n.net.mu.Unlock()
+	var list []types.SequencerInfo
+	for addr, info := range n.net.sequencer {
+		if addr != n.self {
+			list = append(list, info)
+		}
+	}
+	return list
+}
+
+func (n *takeoverNode) PendingRespans() []*types.PreRespan {
+	n.net.mu.Lock()
+	defer n.net.mu.Unlock()
+	return n.net.respans
+}
+
+func (n *takeoverNode) MpcAddress() (common.Address, error) { return n.net.mpc, nil }
+
+func (n *takeoverNode) Nonce(addr common.Address) uint64 {
+	n.net.mu.Lock()
+	defer n.net.mu.Unlock()
+	return n.net.nonce
+}
+
+func (n *takeoverNode) GasPrice() (*big.Int, error) { return common.Big1, nil }
+
+func (n *takeoverNode) SubmitRespan(tx *types.Transaction) error {
+	n.net.mu.Lock()
+	defer n.net.mu.Unlock()
+	sender, err := types.Sender(types.NewEIP155Signer(n.net.chainID), tx)
+	if err != nil || sender != n.net.mpc || tx.To() == nil || *tx.To() != n.net.seqset || tx.Nonce() != n.net.nonce {
+		return errors.New("invalid respan tx")
+	}
+	isRecommit, signer, start, end := core.DecodeReCommitData(tx.Data())
+	_, epoch := core.DecodeReCommitEpoch(tx.Data())
+	if !isRecommit {
+		return errors.New("not a recommit")
+	}
+	n.net.nonce++
+	n.net.submitted = append(n.net.submitted, tx)
+	n.net.epochs = append(n.net.epochs, &types.SequencerEpoch{ID: epoch.Uint64(), Signer: signer, StartBlock: start.Uint64(), EndBlock: end.Uint64(), RespanTx: tx.Hash()})
+	n.net.head = &types.Header{Number: new(big.Int).Add(n.net.head.Number, common.Big1)}
+	return nil
+}
+
+func (n *takeoverNode) Activate(number uint64) { n.activated = append(n.activated, number) }
+
+func TestTakeoverController(t *testing.T) {
+	mpcKey, _ := crypto.GenerateKey()
+	respanSigner, err := NewKeySigner(hexutil.Encode(crypto.FromECDSA(mpcKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		active = common.Address{0x01}
+		nodeA  = common.Address{0x0a}
+		nodeB  = common.Address{0x0b}
+		now    = time.Now()
+	)
+	net := &takeoverNet{
+		chainID: big.NewInt(1),
+		seqset:  common.Address{0xee},
+		mpc:     crypto.PubkeyToAddress(mpcKey.PublicKey),
+		head:    &types.Header{Number: big.NewInt(100)},
+		epochs:  []*types.SequencerEpoch{{ID: 3, Signer: active, StartBlock: 50, EndBlock: 149}},
+		sequencer: map[common.Address]types.SequencerInfo{
+			active: {SequencerAddress: active, Alive: false},
+			nodeA:  {SequencerAddress: nodeA, Alive: true, SequencerHeight: 100},
+			nodeB:  {SequencerAddress: nodeB, Alive: true, SequencerHeight: 100},
+		},
+	}
+	newNode := func(self common.Address) (*takeoverNode, *takeoverController) {
+		node := &takeoverNode{net: net, self: self, peers: 2}
+		c := newTakeoverController(node, self, respanSigner, net.chainID, net.seqset, time.Minute)
+		c.now = func() time.Time { return now }
+		return node, c
+	}
+	a, ctrlA := newNode(nodeA)
+	b, ctrlB := newNode(nodeB)
+
+	// The stall counts from when the nodes first saw the head
+	for _, c := range []*takeoverController{ctrlA, ctrlB} {
+		if err := c.step(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(90 * time.Second)
+
+	// The second backup waits another threshold, the first one takes over
+	if err := ctrlB.step(context.Background()); err != nil || len(net.submitted) != 0 {
+		t.Fatalf("backup of the second rank acted: %v, %d", err, len(net.submitted))
+	}
+	if err := ctrlA.step(context.Background()); err != nil || len(net.submitted) != 1 {
+		t.Fatalf("backup of the first rank did not take over: %v, %d", err, len(net.submitted))
+	}
+	if isRecommit, signer, start, end := core.DecodeReCommitData(net.submitted[0].Data()); !isRecommit || signer != nodeA || start.Uint64() != 102 || end.Uint64() != 149 {
+		t.Fatalf("unexpected respan: %v, %s, %v, %v", isRecommit, signer.Hex(), start, end)
+	}
+	// The recommit block is in the chain and hands the next blocks to the
+	// first backup, which starts to sequence after it
+	if err := ctrlA.step(context.Background()); err != nil || len(net.submitted) != 1 || len(a.activated) != 1 || a.activated[0] != 101 {
+		t.Fatalf("backup not activated: %v, %d, %v", err, len(net.submitted), a.activated)
+	}
+
+	// The first backup sequences the next block, so the second backup never
+	// acts
+	net.mu.Lock()
+	net.head = &types.Header{Number: big.NewInt(102)}
+	net.mu.Unlock()
+	now = now.Add(5 * time.Minute)
+	if err := ctrlB.step(context.Background()); err != nil || len(net.submitted) != 1 || len(b.activated) != 0 {
+		t.Fatalf("second backup acted: %v, %d, %v", err, len(net.submitted), b.activated)
+	}
+	if err := ctrlA.step(context.Background()); err != nil || len(net.submitted) != 1 {
+		t.Fatalf("active sequencer respanned itself: %v, %d", err, len(net.submitted))
+	}
+}
+
+func TestTakeoverControllerGuards(t *testing.T) {
+	mpcKey, _ := crypto.GenerateKey()
+	respanSigner, _ := NewKeySigner(hexutil.Encode(crypto.FromECDSA(mpcKey)))
+	var (
+		active = common.Address{0x01}
+		self   = common.Address{0x0a}
+		now    = time.Now()
+	)
+	newNet := func() *takeoverNet {
+		return &takeoverNet{
+			chainID:   big.NewInt(1),
+			seqset:    common.Address{0xee},
+			mpc:       crypto.PubkeyToAddress(mpcKey.PublicKey),
+			head:      &types.Header{Number: big.NewInt(100)},
+			epochs:    []*types.SequencerEpoch{{ID: 3, Signer: active, StartBlock: 50, EndBlock: 149}},
+			sequencer: map[common.Address]types.SequencerInfo{active: {SequencerAddress: active}},
+		}
+	}
+	// stepAfter steps a new controller when the head was seen the stall ago
+	stepAfter := func(node *takeoverNode, stall time.Duration) error {
+		c := newTakeoverController(node, self, respanSigner, node.net.chainID, node.net.seqset, time.Minute)
+		at := now.Add(-stall)
+		c.now = func() time.Time { return at }
+		if err := c.step(context.Background()); err != nil {
+			return err
+		}
+		at = now
+		return c.step(context.Background())
+	}
+	tests := []struct {
+		name  string
+		setup func(*takeoverNet, *takeoverNode)
+		err   error
+	}{
+		{"isolated", func(net *takeoverNet, n *takeoverNode) { n.peers = 0 }, errTakeoverIsolated},
+		{"behind", func(net *takeoverNet, n *takeoverNode) { n.behind = true }, errTakeoverBehind},
+		{"alive", func(net *takeoverNet, n *takeoverNode) {
+			net.sequencer[active] = types.SequencerInfo{SequencerAddress: active, Alive: true, SequencerHeight: 101}
+		}, errTakeoverAlive},
+		{"idle", func(net *takeoverNet, n *takeoverNode) {
+			net.sequencer[active] = types.SequencerInfo{SequencerAddress: active, Alive: true, SequencerHeight: 100}
+		}, errTakeoverAlive},
+		{"unknown", func(net *takeoverNet, n *takeoverNode) { delete(net.sequencer, active) }, errTakeoverUnknown},
+		{"respan", func(net *takeoverNet, n *takeoverNode) {
+			net.respans = []*types.PreRespan{{NewSigner: common.Address{0x0c}, StartBlock: 110}}
+		}, errTakeoverRespan},
+		{"signer", func(net *takeoverNet, n *takeoverNode) { net.mpc = common.Address{0x0d} }, errTakeoverSigner},
+	}
+	for _, tt := range tests {
+		net := newNet()
+		node := &takeoverNode{net: net, self: self, peers: 1}
+		tt.setup(net, node)
+		if err := stepAfter(node, 2*time.Minute); !errors.Is(err, tt.err) {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(net.submitted) != 0 {
+			t.Errorf("%s: respan submitted", tt.name)
+		}
+	}
+
+	// Not stalled yet
+	net := newNet()
+	if err := stepAfter(&takeoverNode{net: net, self: self, peers: 1}, 30*time.Second); err != nil || len(net.submitted) != 0 {
+		t.Fatalf("took over before the threshold: %v, %d", err, len(net.submitted))
+	}
+}
+
+// takeoverSeqset is the seqset contract that the in-process nodes of a test
+// read, a respan is applied when its tx is mined
+type takeoverSeqset struct {
+	mu      sync.Mutex
+	address common.Address
+	mpc     common.Address
+	epochs  []*types.SequencerEpoch
+}
+
+func (s *takeoverSeqset) epochAt(number uint64) *types.SequencerEpoch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found *types.SequencerEpoch
+	for _, epoch := range s.epochs {
+		if epoch.StartBlock <= number && number <= epoch.EndBlock && (found == nil || epoch.ID > found.ID) {
+			found = epoch
+		}
+	}
+	return found
+}
+
+// takeoverHeadAPI serves the head of a chain to the liveness probes
+type takeoverHeadAPI struct {
+	bc *core.BlockChain
+}
+
+func (api *takeoverHeadAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, full bool) (*types.Header, error) {
+	return api.bc.CurrentHeader(), nil
+}
+
+// serveHead starts an RPC endpoint that serves the head of a chain
+func serveHead(t *testing.T, bc *core.BlockChain) string {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &takeoverHeadAPI{bc: bc}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+	return httpServer.URL
+}
+
+// takeoverPeer is an in-process backup sequencer with its own chain and
+// sequencer registry. The other sequencers are probed over RPC and the
+// blocks it mines are relayed to its peers.
+type takeoverPeer struct {
+	t         *testing.T
+	signer    SeqSigner
+	bc        *core.BlockChain
+	registry  *seqRegistry
+	seqset    *takeoverSeqset
+	url       string
+	peers     []*takeoverPeer
+	activated []uint64
+}
+
+func newTakeoverPeer(t *testing.T, seqset *takeoverSeqset) *takeoverPeer {
+	key, _ := crypto.GenerateKey()
+	signer, _ := NewKeySigner(hexutil.Encode(crypto.FromECDSA(key)))
+	bc, _ := newTestChain(t)
+	p := &takeoverPeer{t: t, signer: signer, bc: bc, seqset: seqset}
+	p.registry = newSeqRegistry(rawdb.NewMemoryDatabase(), params.TestChainConfig.ChainID, signer.Address(), time.Hour, func(common.Address) (bool, error) { return true, nil })
+	p.url = serveHead(t, bc)
+	return p
+}
+
+// know adds the entry of a sequencer at a url to the registry
+func (p *takeoverPeer) know(signer SeqSigner, url string) {
+	info, err := signSequencerInfo(signer, params.TestChainConfig.ChainID, url, time.Now())
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	if err := p.registry.Add(info); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// mine adds a block with the tx to the chain and relays it to the peers
+func (p *takeoverPeer) mine(tx *types.Transaction) error {
+	if err := mineTransaction(p.bc, tx); err != nil {
+		return err
+	}
+	block := p.bc.CurrentBlock()
+	for _, peer := range p.peers {
+		if _, err := peer.bc.InsertChain(types.Blocks{block}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *takeoverPeer) CurrentHeader() *types.Header { return p.bc.CurrentHeader() }
+
+func (p *takeoverPeer) PeerSynced() (bool, int) {
+	number := p.bc.CurrentBlock().NumberU64()
+	for _, peer := range p.peers {
+		if peer.bc.CurrentBlock().NumberU64() > number {
+			return false, len(p.peers)
+		}
+	}
+	return true, len(p.peers)
+}
+
+func (p *takeoverPeer) EpochAt(number uint64) (*types.SequencerEpoch, error) {
+	return p.seqset.epochAt(number), nil
+}
+
+func (p *takeoverPeer) Sequencers(ctx context.Context) []types.SequencerInfo {
+	return p.registry.List(ctx)
+}
+
+func (p *takeoverPeer) PendingRespans() []*types.PreRespan { return nil }
+
+func (p *takeoverPeer) MpcAddress() (common.Address, error) { return p.seqset.mpc, nil }
+
+func (p *takeoverPeer) Nonce(addr common.Address) uint64 {
+	statedb, err := p.bc.State()
+	if err != nil {
+		return 0
+	}
+	return statedb.GetNonce(addr)
+}
+
+func (p *takeoverPeer) GasPrice() (*big.Int, error) { return common.Big1, nil }
+
+func (p *takeoverPeer) SubmitRespan(tx *types.Transaction) error {
+	if err := p.mine(tx); err != nil {
+		return err
+	}
+	isRecommit, signer, start, end := core.DecodeReCommitData(tx.Data())
+	_, epoch := core.DecodeReCommitEpoch(tx.Data())
+	if !isRecommit {
+		return errors.New("not a recommit")
+	}
+	p.seqset.mu.Lock()
+	defer p.seqset.mu.Unlock()
+	p.seqset.epochs = append(p.seqset.epochs, &types.SequencerEpoch{ID: epoch.Uint64(), Signer: signer, StartBlock: start.Uint64(), EndBlock: end.Uint64(), RespanTx: tx.Hash()})
+	return nil
+}
+
+func (p *takeoverPeer) Activate(number uint64) { p.activated = append(p.activated, number) }
+
+// newTakeoverPeers returns two backups of an active sequencer at a url that
+// know each other and the active sequencer, ordered by their rank
+func newTakeoverPeers(t *testing.T, seqset *takeoverSeqset, active SeqSigner, activeUrl string) (*takeoverPeer, *takeoverPeer) {
+	first, second := newTakeoverPeer(t, seqset), newTakeoverPeer(t, seqset)
+	if bytes.Compare(first.signer.Address().Bytes(), second.signer.Address().Bytes()) > 0 {
+		first, second = second, first
+	}
+	first.peers, second.peers = []*takeoverPeer{second}, []*takeoverPeer{first}
+	for _, p := range []*takeoverPeer{first, second} {
+		p.know(active, activeUrl)
+		p.know(p.peers[0].signer, p.peers[0].url)
+	}
+	// The blocks of the active sequencer, the first one funds the mpc key
+	fund, err := types.SignTx(types.NewTransaction(0, seqset.mpc, big.NewInt(1e17), 21000, common.Big1, nil), testSigner, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tx := range []*types.Transaction{fund, signTestTransaction(t, testKey, 1), signTestTransaction(t, testKey, 2)} {
+		if err := first.mine(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return first, second
+}
+
+func TestTakeoverTwoNodes(t *testing.T) {
+	mpcKey, _ := crypto.GenerateKey()
+	respanSigner, _ := NewKeySigner(hexutil.Encode(crypto.FromECDSA(mpcKey)))
+	activeKey, _ := crypto.GenerateKey()
+	active, _ := NewKeySigner(hexutil.Encode(crypto.FromECDSA(activeKey)))
+
+	now := time.Now()
+	newController := func(p *takeoverPeer) *takeoverController {
+		c := newTakeoverController(p, p.signer.Address(), respanSigner, params.TestChainConfig.ChainID, p.seqset.address, time.Minute)
+		c.now = func() time.Time { return now }
+		return c
+	}
+	newSeqset := func() *takeoverSeqset {
+		return &takeoverSeqset{
+			address: common.Address{0xee},
+			mpc:     respanSigner.Address(),
+			epochs:  []*types.SequencerEpoch{{ID: 3, Signer: active.Address(), StartBlock: 1, EndBlock: 100}},
+		}
+	}
+
+	// The active sequencer is alive but the chain is idle, nobody takes over
+	seqset := newSeqset()
+	idle := serveHead(t, newTestChainWithHead(t, 3))
+	first, second := newTakeoverPeers(t, seqset, active, idle)
+	ctrlFirst, ctrlSecond := newController(first), newController(second)
+	for _, c := range []*takeoverController{ctrlFirst, ctrlSecond} {
+		if err := c.step(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(5 * time.Minute)
+	for _, c := range []*takeoverController{ctrlFirst, ctrlSecond} {
+		if err := c.step(context.Background()); !errors.Is(err, errTakeoverAlive) {
+			t.Fatalf("unexpected error with an idle sequencer: %v", err)
+		}
+	}
+	if len(seqset.epochs) != 1 {
+		t.Fatalf("took over from an idle sequencer: %d epochs", len(seqset.epochs))
+	}
+
+	// The active sequencer is down, the first backup takes over
+	seqset = newSeqset()
+	down := httptest.NewServer(nil)
+	down.Close()
+	first, second = newTakeoverPeers(t, seqset, active, down.URL)
+	ctrlFirst, ctrlSecond = newController(first), newController(second)
+	for _, c := range []*takeoverController{ctrlFirst, ctrlSecond} {
+		if err := c.step(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(90 * time.Second)
+	if err := ctrlSecond.step(context.Background()); err != nil || len(seqset.epochs) != 1 {
+		t.Fatalf("backup of the second rank acted: %v, %d", err, len(seqset.epochs))
+	}
+	if err := ctrlFirst.step(context.Background()); err != nil || len(seqset.epochs) != 2 {
+		t.Fatalf("backup of the first rank did not take over: %v, %d", err, len(seqset.epochs))
+	}
+	if first.bc.CurrentHeader().Hash() != second.bc.CurrentHeader().Hash() || first.bc.CurrentBlock().NumberU64() != 4 {
+		t.Fatalf("recommit block not relayed: %d, %d", first.bc.CurrentBlock().NumberU64(), second.bc.CurrentBlock().NumberU64())
+	}
+	// The second backup sees the recommit block as progress and waits again
+	now = now.Add(90 * time.Second)
+	if err := ctrlSecond.step(context.Background()); err != nil || len(seqset.epochs) != 2 {
+		t.Fatalf("second backup acted after the recommit: %v, %d", err, len(seqset.epochs))
+	}
+	if err := ctrlFirst.step(context.Background()); err != nil || len(first.activated) != 1 || first.activated[0] != 4 {
+		t.Fatalf("first backup not activated: %v, %v", err, first.activated)
+	}
+	if epoch := seqset.epochAt(5); epoch == nil || epoch.Signer != first.signer.Address() {
+		t.Fatalf("unexpected epoch after the takeover: %+v", epoch)
+	}
+	if len(second.activated) != 0 {
+		t.Fatalf("second backup activated: %v", second.activated)
+	}
+}
+
+// newTestChainWithHead returns a test chain with blocks up to the number
+func newTestChainWithHead(t *testing.T, number uint64) *core.BlockChain {
+	bc, _ := newTestChain(t)
+	for nonce := uint64(0); nonce < number; nonce++ {
+		if err := mineTransaction(bc, signTestTransaction(t, testKey, nonce)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bc
+}
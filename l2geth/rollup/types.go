@@ -56,6 +56,15 @@ const (
 	BackendL2
 )
 
+// l1MessageSender returns the L1 message sender of a transaction or the zero
+// address when it is not set
+func l1MessageSender(tx *types.Transaction) common.Address {
+	if sender := tx.L1MessageSender(); sender != nil {
+		return *sender
+	}
+	return common.Address{}
+}
+
 func isCtcTxEqual(a, b *types.Transaction) bool {
 	if a.To() == nil && b.To() != nil {
 		if !bytes.Equal(b.To().Bytes(), common.Address{}.Bytes()) {
@@ -76,17 +85,11 @@ func isCtcTxEqual(a, b *types.Transaction) bool {
 	if !bytes.Equal(a.Data(), b.Data()) {
 		return false
 	}
-	if a.L1MessageSender() == nil && b.L1MessageSender() != nil {
-		return false
-	}
-	if a.L1MessageSender() != nil && b.L1MessageSender() == nil {
+	// Transactions read back from the database have the zero address as the
+	// L1 message sender when it was not set
+	if !bytes.Equal(l1MessageSender(a).Bytes(), l1MessageSender(b).Bytes()) {
 		return false
 	}
-	if a.L1MessageSender() != nil && b.L1MessageSender() != nil {
-		if !bytes.Equal(a.L1MessageSender().Bytes(), b.L1MessageSender().Bytes()) {
-			return false
-		}
-	}
 	if a.Gas() != b.Gas() {
 		return false
 	}
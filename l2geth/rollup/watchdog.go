@@ -0,0 +1,228 @@
+package rollup
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/core/types"
+	"github.com/ethereum-optimism/optimism/l2geth/log"
+	"github.com/ethereum-optimism/optimism/l2geth/metrics"
+)
+
+var (
+	enqueuePendingGauge = metrics.NewRegisteredGauge("rollup/enqueue/pending", nil)
+	enqueueOverdueGauge = metrics.NewRegisteredGauge("rollup/enqueue/overdue", nil)
+	enqueueAlertMeter   = metrics.NewRegisteredMeter("rollup/enqueue/alerts", nil)
+	// Seconds between the L1 timestamp of an enqueue and its inclusion, one
+	// sample per queue index
+	enqueueLatencyHistogram = metrics.NewRegisteredHistogram("rollup/enqueue/latency", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// errWatchdogDisabled is returned for the alerts when no force inclusion
+// window is configured
+var errWatchdogDisabled = errors.New("enqueue watchdog not enabled")
+
+// trackedEnqueue is an enqueue that is not yet included
+type trackedEnqueue struct {
+	tx      *types.Transaction
+	overdue bool
+}
+
+// enqueueWatchdog tracks every enqueue from the time it is enqueued on L1
+// until it is included and raises an alert when it waits longer than the
+// force inclusion window. An enqueue is included once the local chain holds
+// its queue index or the data transport layer reports its CTC index.
+type enqueueWatchdog struct {
+	client   RollupClient
+	window   time.Duration
+	interval time.Duration
+	// included returns the last queue index of the local chain
+	included func() *uint64
+	// skip returns true for queue indexes that are never included
+	skip func(uint64) bool
+	now  func() time.Time
+
+	// next and pending are only modified by the polling goroutine, mu
+	// guards pending against the readers of the alerts
+	next    uint64
+	mu      sync.RWMutex
+	pending map[uint64]*trackedEnqueue
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newEnqueueWatchdog creates a watchdog that starts tracking at the queue
+// index next
+func newEnqueueWatchdog(client RollupClient, window, interval time.Duration, next uint64, included func() *uint64, skip func(uint64) bool) *enqueueWatchdog {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &enqueueWatchdog{
+		client:   client,
+		window:   window,
+		interval: interval,
+		included: included,
+		skip:     skip,
+		now:      time.Now,
+		next:     next,
+		pending:  make(map[uint64]*trackedEnqueue),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// start polls for new enqueues until stop is called
+func (w *enqueueWatchdog) start() {
+	log.Info("Starting enqueue watchdog", "window", w.window, "next-queue-index", w.next)
+	go w.loop()
+}
+
+// stop stops polling
+func (w *enqueueWatchdog) stop() {
+	w.cancel()
+}
+
+func (w *enqueueWatchdog) loop() {
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		if err := w.poll(); err != nil {
+			log.Error("Enqueue watchdog cannot poll", "err", err)
+		}
+		select {
+		case <-t.C:
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// poll tracks the enqueues that were added since the last poll and checks
+// the pending ones
+func (w *enqueueWatchdog) poll() error {
+	if err := w.track(); err != nil {
+		return err
+	}
+	return w.check()
+}
+
+// track fetches the enqueues from the next queue index to the latest one
+func (w *enqueueWatchdog) track() error {
+	latest, err := w.client.GetLatestEnqueueIndex()
+	if errors.Is(err, errElementNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := w.next; i <= *latest; i++ {
+		if w.skip(i) {
+			w.next = i + 1
+			continue
+		}
+		tx, err := w.client.GetEnqueue(i)
+		if err != nil {
+			return err
+		}
+		// Enqueues that are included when they are first seen were not
+		// observed waiting and have no latency sample
+		if tx.GetMeta().Index == nil {
+			w.mu.Lock()
+			w.pending[i] = &trackedEnqueue{tx: tx}
+			w.mu.Unlock()
+		}
+		w.next = i + 1
+	}
+	return nil
+}
+
+// check removes the included enqueues and raises alerts for the ones that
+// exceed the force inclusion window. The data transport layer is only asked
+// about enqueues that are overdue locally.
+func (w *enqueueWatchdog) check() error {
+	now := w.now()
+	included := w.included()
+
+	var overdue int64
+	for index, pending := range w.pending {
+		if included != nil && index <= *included {
+			w.resolve(index, pending, now)
+			continue
+		}
+		age := now.Sub(time.Unix(int64(pending.tx.L1Timestamp()), 0))
+		if age <= w.window {
+			continue
+		}
+		tx, err := w.client.GetEnqueue(index)
+		if err != nil {
+			return err
+		}
+		if tx.GetMeta().Index != nil {
+			w.resolve(index, pending, now)
+			continue
+		}
+		overdue++
+		if !pending.overdue {
+			w.mu.Lock()
+			pending.overdue = true
+			w.mu.Unlock()
+			enqueueAlertMeter.Mark(1)
+			log.Warn("Enqueue not included within the force inclusion window", "queue-index", index, "hash", pending.tx.Hash().Hex(), "l1-timestamp", pending.tx.L1Timestamp(), "age", age.Round(time.Second), "window", w.window)
+		}
+	}
+	enqueuePendingGauge.Update(int64(len(w.pending)))
+	enqueueOverdueGauge.Update(overdue)
+	return nil
+}
+
+// resolve records the inclusion latency of an enqueue and stops tracking it
+func (w *enqueueWatchdog) resolve(index uint64, pending *trackedEnqueue, now time.Time) {
+	latency := now.Sub(time.Unix(int64(pending.tx.L1Timestamp()), 0))
+	enqueueLatencyHistogram.Update(int64(latency / time.Second))
+	if pending.overdue {
+		log.Info("Overdue enqueue included", "queue-index", index, "hash", pending.tx.Hash().Hex(), "latency", latency.Round(time.Second))
+	}
+	w.mu.Lock()
+	delete(w.pending, index)
+	w.mu.Unlock()
+}
+
+// Alerts returns the enqueues that exceed the force inclusion window ordered
+// by queue index
+func (w *enqueueWatchdog) Alerts() []*types.EnqueueAlert {
+	now := w.now()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	alerts := make([]*types.EnqueueAlert, 0)
+	for index, pending := range w.pending {
+		if !pending.overdue {
+			continue
+		}
+		tx := pending.tx
+		alert := &types.EnqueueAlert{
+			QueueIndex:  index,
+			TxHash:      tx.Hash(),
+			L1Timestamp: tx.L1Timestamp(),
+			Age:         uint64(now.Sub(time.Unix(int64(tx.L1Timestamp()), 0)) / time.Second),
+		}
+		if bn := tx.L1BlockNumber(); bn != nil {
+			alert.L1BlockNumber = bn.Uint64()
+		}
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].QueueIndex < alerts[j].QueueIndex })
+	return alerts
+}
+
+// EnqueueAlerts returns the enqueues that were not included within the force
+// inclusion window
+func (s *SyncService) EnqueueAlerts() ([]*types.EnqueueAlert, error) {
+	if s.watchdog == nil {
+		return nil, errWatchdogDisabled
+	}
+	return s.watchdog.Alerts(), nil
+}
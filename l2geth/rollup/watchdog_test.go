@@ -0,0 +1,86 @@
+package rollup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimism/l2geth/common"
+	"github.com/ethereum-optimism/optimism/l2geth/params"
+	"github.com/ethereum-optimism/optimism/l2geth/rollup/dtltest"
+)
+
+func TestEnqueueWatchdog(t *testing.T) {
+	fixture := dtltest.NewFixture(params.TestChainConfig.ChainID)
+	server := dtltest.NewServer(fixture)
+	defer server.Close()
+
+	now := time.Now()
+	origin, target := common.Address{0x01}, common.Address{0x02}
+	fixture.AdvanceL1(2, uint64(now.Add(-time.Hour).Unix()))
+	for i := 0; i < 3; i++ {
+		fixture.AddEnqueue(origin, target, 100000, nil)
+	}
+	// Included before the watchdog started
+	if _, err := fixture.IncludeEnqueue(0); err != nil {
+		t.Fatal(err)
+	}
+	fixture.AdvanceL1(3, uint64(now.Unix()))
+	fixture.AddEnqueue(origin, target, 100000, nil)
+
+	var included *uint64
+	client := NewClient(server.URL, fixture.ChainID())
+	skip := func(index uint64) bool { return index == 2 }
+	w := newEnqueueWatchdog(client, time.Minute, time.Second, 0, func() *uint64 { return included }, skip)
+	w.now = func() time.Time { return now }
+
+	if err := w.poll(); err != nil {
+		t.Fatal(err)
+	}
+	// The recent enqueue is pending without an alert
+	if len(w.pending) != 2 {
+		t.Fatalf("unexpected number of pending enqueues: %d", len(w.pending))
+	}
+	alerts, err := (&SyncService{watchdog: w}).EnqueueAlerts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || alerts[0].QueueIndex != 1 || alerts[0].Age != 3600 || alerts[0].L1BlockNumber != 2 {
+		t.Fatalf("unexpected alerts: %v", alerts)
+	}
+
+	// Overdue enqueues are resolved once the data transport layer reports
+	// them in the canonical transaction chain
+	if _, err := fixture.IncludeEnqueue(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if alerts := w.Alerts(); len(alerts) != 0 {
+		t.Fatalf("unexpected alerts: %v", alerts)
+	}
+
+	// The last enqueue exceeds the window and is then included locally
+	w.now = func() time.Time { return now.Add(2 * time.Minute) }
+	if err := w.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if alerts := w.Alerts(); len(alerts) != 1 || alerts[0].QueueIndex != 3 {
+		t.Fatalf("unexpected alerts: %v", alerts)
+	}
+	queueIndex := uint64(3)
+	included = &queueIndex
+	if err := w.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.pending) != 0 || len(w.Alerts()) != 0 {
+		t.Fatal("included enqueue still tracked")
+	}
+	if requests := fixture.Requests(dtltest.EndpointEnqueue); requests != 10 {
+		t.Fatalf("unexpected number of enqueue requests: %d", requests)
+	}
+
+	if _, err := (&SyncService{}).EnqueueAlerts(); err != errWatchdogDisabled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
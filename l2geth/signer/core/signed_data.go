@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum-optimism/optimism/l2geth/common/hexutil"
 	"github.com/ethereum-optimism/optimism/l2geth/common/math"
 	"github.com/ethereum-optimism/optimism/l2geth/consensus/clique"
+	l2core "github.com/ethereum-optimism/optimism/l2geth/core"
 	"github.com/ethereum-optimism/optimism/l2geth/core/types"
 	"github.com/ethereum-optimism/optimism/l2geth/crypto"
 	"github.com/ethereum-optimism/optimism/l2geth/rlp"
@@ -63,6 +64,10 @@ var (
 		accounts.MimetypeTextPlain,
 		0x45,
 	}
+	ApplicationSequencer = SigFormat{
+		accounts.MimetypeSequencer,
+		0x03,
+	}
 )
 
 type ValidatorData struct {
@@ -259,6 +264,25 @@ func (api *SignerAPI) determineSignatureFormat(ctx context.Context, contentType
 		// Clique uses V on the form 0 or 1
 		useEthereumV = false
 		req = &SignDataRequest{ContentType: mediaType, Rawdata: cliqueRlp, Messages: messages, Hash: sighash}
+	case ApplicationSequencer.Mime:
+		// Rollup sequencers sign either a sequenced transaction or the
+		// EIP-712 payload binding it to its block and epoch, anything else is
+		// rejected
+		stringData, ok := data.(string)
+		if !ok {
+			return nil, useEthereumV, fmt.Errorf("input for %v must be an hex-encoded string", ApplicationSequencer.Mime)
+		}
+		seqData, err := hexutil.Decode(stringData)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		sighash, messages, err := SequencerSignHash(api.chainID, seqData)
+		if err != nil {
+			return nil, useEthereumV, err
+		}
+		// The sequencer signatures use V on the form 0 or 1
+		useEthereumV = false
+		req = &SignDataRequest{ContentType: mediaType, Rawdata: seqData, Messages: messages, Hash: sighash}
 	default: // also case TextPlain.Mime:
 		// Calculates an Ethereum ECDSA signature for:
 		// hash = keccak256("\x19${byteVersion}Ethereum Signed Message:\n${message length}${message}")
@@ -311,6 +335,56 @@ func cliqueHeaderHashAndRlp(header *types.Header) (hash, rlp []byte, err error)
 	return hash, rlp, err
 }
 
+// sequencedTransaction is the RLP encoding of a signed transaction, its
+// keccak256 hash is the transaction hash
+type sequencedTransaction struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	GasLimit uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// SequencerSignHash returns the hash a rollup sequencer signs for the data and
+// the messages shown for it. The data must either be the RLP encoding of a
+// signed transaction, the hash of the transaction is signed, or the EIP-712
+// sequencer signature payload of the chain.
+func SequencerSignHash(chainID *big.Int, data []byte) (hexutil.Bytes, []*NameValueType, error) {
+	sighash := crypto.Keccak256(data)
+	if len(data) == 2+2*common.HashLength && data[0] == 0x19 && data[1] == 0x01 {
+		domain := common.BytesToHash(data[2 : 2+common.HashLength])
+		if chainID == nil || domain != l2core.SeqSignDomainSeparator(chainID) {
+			return nil, nil, fmt.Errorf("sequencer signature payload of domain %x is not for chain %v", domain, chainID)
+		}
+		messages := []*NameValueType{
+			{Name: "Sequencer signature domain", Typ: "hexdata", Value: domain.Hex()},
+			{Name: "Sequencer signature struct hash", Typ: "hexdata", Value: fmt.Sprintf("0x%x", data[2+common.HashLength:])},
+			{Name: "Sequencer signature hash", Typ: "hexdata", Value: fmt.Sprintf("0x%x", sighash)},
+		}
+		return sighash, messages, nil
+	}
+	var tx sequencedTransaction
+	if err := rlp.DecodeBytes(data, &tx); err != nil {
+		return nil, nil, fmt.Errorf("sequencer data is neither a signature payload nor a transaction: %v", err)
+	}
+	if tx.R == nil || tx.S == nil || tx.R.Sign() == 0 || tx.S.Sign() == 0 {
+		return nil, nil, errors.New("sequenced transaction is not signed")
+	}
+	to := "contract creation"
+	if tx.To != nil {
+		to = tx.To.Hex()
+	}
+	messages := []*NameValueType{
+		{Name: "Sequenced transaction to", Typ: "address", Value: to},
+		{Name: "Sequenced transaction value", Typ: "uint256", Value: tx.Value.String()},
+		{Name: "Sequenced transaction nonce", Typ: "uint64", Value: strconv.FormatUint(tx.Nonce, 10)},
+		{Name: "Sequenced transaction hash", Typ: "hexdata", Value: fmt.Sprintf("0x%x", sighash)},
+	}
+	return sighash, messages, nil
+}
+
 // SignTypedData signs EIP-712 conformant typed data
 // hash = keccak256("\x19${byteVersion}${domainSeparator}${hashStruct(message)}")
 func (api *SignerAPI) SignTypedData(ctx context.Context, addr common.MixedcaseAddress, typedData TypedData) (hexutil.Bytes, error) {
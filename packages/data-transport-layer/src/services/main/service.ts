@@ -55,6 +55,9 @@ export interface L1DataTransportServiceOptions {
   batchInboxSender: string
   batchInboxStartIndex: number
   batchInboxL1Height: number
+
+  streamPollingInterval?: number
+  streamKeepAliveInterval?: number
 }
 
 const optionSettings = {
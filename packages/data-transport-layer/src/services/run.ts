@@ -66,6 +66,11 @@ type ethNetwork = 'mainnet' | 'kovan' | 'goerli'
       batchInboxSender: config.str('batch-inbox-sender'),
       batchInboxStartIndex: config.uint('batch-inbox-start-index', 0),
       batchInboxL1Height: config.uint('batch-inbox-l1-height', 0),
+      streamPollingInterval: config.uint('stream-polling-interval', 1000),
+      streamKeepAliveInterval: config.uint(
+        'stream-keep-alive-interval',
+        15000
+      ),
     })
 
     const stop = async (signal) => {
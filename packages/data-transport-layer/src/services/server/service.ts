@@ -32,6 +32,7 @@ import {
 } from '../../types'
 import { validators } from '../../utils'
 import { L1DataTransportServiceOptions } from '../main/service'
+import { IndexStream, StreamIndices } from './stream'
 
 export interface L1TransportServerOptions
   extends L1DataTransportServiceOptions {
@@ -77,6 +78,14 @@ const optionSettings = {
       return val === 'l1' || val === 'l2'
     },
   },
+  streamPollingInterval: {
+    default: 1000,
+    validate: validators.isInteger,
+  },
+  streamKeepAliveInterval: {
+    default: 15000,
+    validate: validators.isInteger,
+  },
 }
 
 export class L1TransportServer extends BaseService<L1TransportServerOptions> {
@@ -90,6 +99,7 @@ export class L1TransportServer extends BaseService<L1TransportServerOptions> {
     db: TransportDB
     l1RpcProvider: JsonRpcProvider
     l2RpcProvider: JsonRpcProvider
+    streams: Map<number, IndexStream>
   } = {} as any
 
   protected async _init(): Promise<void> {
@@ -111,6 +121,7 @@ export class L1TransportServer extends BaseService<L1TransportServerOptions> {
         ? new JsonRpcProvider(this.options.l2RpcProvider)
         : this.options.l2RpcProvider
 
+    this.state.streams = new Map()
     this._initializeApp()
   }
 
@@ -126,6 +137,10 @@ export class L1TransportServer extends BaseService<L1TransportServerOptions> {
   }
 
   protected async _stop(): Promise<void> {
+    // Open streams would keep the server from closing
+    for (const stream of this.state.streams.values()) {
+      stream.close()
+    }
     this.state.server.close()
   }
 
@@ -261,6 +276,46 @@ export class L1TransportServer extends BaseService<L1TransportServerOptions> {
     return db
   }
 
+  /**
+   * Returns the event stream of a chain, creating it on the first
+   * subscription.
+   *
+   * @param chainId L2 chain id of the stream.
+   */
+  private async _getStream(chainId: number): Promise<IndexStream> {
+    let stream = this.state.streams.get(chainId)
+    if (stream) {
+      return stream
+    }
+    const db = await this._getDb(chainId)
+    stream = new IndexStream(
+      async (): Promise<StreamIndices> => {
+        const [enqueue, transaction, block, batch] = await Promise.all([
+          db.getLatestEnqueue(),
+          db.getLatestUnconfirmedTransaction(),
+          db.getLatestUnconfirmedBlock(),
+          db.getLatestTransactionBatch(),
+        ])
+        return {
+          enqueue: enqueue === null ? null : enqueue.index,
+          transaction: transaction === null ? null : transaction.index,
+          block: block === null ? null : block.index,
+          batch: batch === null ? null : batch.index,
+        }
+      },
+      this.options.streamPollingInterval,
+      this.options.streamKeepAliveInterval,
+      (err) => {
+        this.logger.error('Cannot poll the latest indices of the stream', {
+          chainId,
+          msg: err.toString(),
+        })
+      }
+    )
+    this.state.streams.set(chainId, stream)
+    return stream
+  }
+
   private useBatchInbox(batchIndex: number): boolean {
     const inboxAddress = this.options.batchInboxAddress
     const inboxBatchStart = this.options.batchInboxStartIndex
@@ -283,6 +338,27 @@ export class L1TransportServer extends BaseService<L1TransportServerOptions> {
    * TODO: Link to our API spec.
    */
   private _registerAllRoutes(): void {
+    // Server sent events announcing the elements as they are indexed, the
+    // sync service of l2geth subscribes with --rollup.clientstream
+    this.state.app.get('/stream/:chainId', async (req, res) => {
+      try {
+        const stream = await this._getStream(toNumber(req.params.chainId))
+        stream.subscribe(res)
+        this.logger.debug('Stream subscribed', {
+          chainId: req.params.chainId,
+          subscribers: stream.size,
+        })
+      } catch (e) {
+        this.logger.error('Failed stream subscription', {
+          url: req.url,
+          msg: e.toString(),
+        })
+        res.status(400).json({
+          error: e.toString(),
+        })
+      }
+    })
+
     this._registerRoute(
       'get',
       '/eth/syncing/:chainId',
@@ -0,0 +1,159 @@
+/* Imports: External */
+import { Response } from 'express'
+
+export type StreamEventType = 'enqueue' | 'transaction' | 'block' | 'batch'
+
+export const STREAM_EVENT_TYPES: StreamEventType[] = [
+  'enqueue',
+  'transaction',
+  'block',
+  'batch',
+]
+
+/**
+ * Announces that a new element was indexed. Only the type and the index are
+ * sent, subscribers fetch the element itself from the regular routes.
+ */
+export interface StreamEvent {
+  type: StreamEventType
+  index: number
+}
+
+/**
+ * Latest indices of every type of element, null when nothing of the type was
+ * indexed yet.
+ */
+export type StreamIndices = Record<StreamEventType, number | null>
+
+/**
+ * Serves the server sent event stream of one chain. The latest indices are
+ * polled while there are subscribers and an event is pushed to all of them
+ * for every type of element whose latest index changed.
+ */
+export class IndexStream {
+  private subscribers = new Set<Response>()
+  private latest: StreamIndices | null = null
+  private pollTimer: NodeJS.Timeout | null = null
+  private keepAliveTimer: NodeJS.Timeout | null = null
+
+  /**
+   * @param getLatest Returns the latest indices of the database.
+   * @param pollingInterval Milliseconds between two polls of the indices.
+   * @param keepAliveInterval Milliseconds between two keep alive comments.
+   * @param onError Called when the indices cannot be polled.
+   */
+  constructor(
+    private getLatest: () => Promise<StreamIndices>,
+    private pollingInterval: number,
+    private keepAliveInterval: number,
+    private onError: (err: Error) => void
+  ) {}
+
+  /**
+   * Number of connected subscribers.
+   */
+  public get size(): number {
+    return this.subscribers.size
+  }
+
+  /**
+   * Turns a response into an event stream and pushes the events to it until
+   * the connection is closed.
+   *
+   * @param res Response of the subscription request.
+   */
+  public subscribe(res: Response): void {
+    res.status(200)
+    res.set({
+      'Content-Type': 'text/event-stream',
+      'Cache-Control': 'no-cache',
+      Connection: 'keep-alive',
+    })
+    res.flushHeaders()
+    this.subscribers.add(res)
+    res.on('close', () => {
+      this.unsubscribe(res)
+    })
+
+    if (this.pollTimer === null) {
+      // The first poll records the indices the stream starts from
+      this.schedulePoll(0)
+      this.keepAliveTimer = setInterval(() => {
+        this.write(': keep-alive\n\n')
+      }, this.keepAliveInterval)
+    }
+  }
+
+  /**
+   * Polls the latest indices once and pushes the events of the indices that
+   * changed since the previous poll. The first poll only records the
+   * indices.
+   *
+   * @returns The pushed events.
+   */
+  public async poll(): Promise<StreamEvent[]> {
+    const latest = await this.getLatest()
+    const events: StreamEvent[] = []
+    if (this.latest !== null) {
+      for (const type of STREAM_EVENT_TYPES) {
+        const index = latest[type]
+        if (index !== null && index !== this.latest[type]) {
+          events.push({ type, index })
+        }
+      }
+    }
+    this.latest = latest
+    for (const event of events) {
+      this.write(`event: ${event.type}\ndata: ${JSON.stringify(event)}\n\n`)
+    }
+    return events
+  }
+
+  /**
+   * Ends all subscriptions.
+   */
+  public close(): void {
+    for (const res of this.subscribers) {
+      res.end()
+    }
+    this.subscribers.clear()
+    this.stopTimers()
+  }
+
+  private unsubscribe(res: Response): void {
+    this.subscribers.delete(res)
+    if (this.subscribers.size === 0) {
+      this.stopTimers()
+    }
+  }
+
+  private schedulePoll(delay: number): void {
+    const timer = setTimeout(async () => {
+      try {
+        await this.poll()
+      } catch (e) {
+        this.onError(e)
+      }
+      // Unless the stream was stopped while polling
+      if (this.pollTimer === timer) {
+        this.schedulePoll(this.pollingInterval)
+      }
+    }, delay)
+    this.pollTimer = timer
+  }
+
+  private stopTimers(): void {
+    clearTimeout(this.pollTimer)
+    clearInterval(this.keepAliveTimer)
+    this.pollTimer = null
+    this.keepAliveTimer = null
+    // The next subscriber starts from the indices at that time
+    this.latest = null
+  }
+
+  private write(chunk: string): void {
+    for (const res of this.subscribers) {
+      res.write(chunk)
+    }
+  }
+}
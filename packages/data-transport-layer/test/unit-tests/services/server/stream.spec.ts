@@ -0,0 +1,115 @@
+import { expect } from '../../../setup'
+
+/* Imports: External */
+import { EventEmitter } from 'events'
+
+/* Imports: Internal */
+import {
+  IndexStream,
+  StreamIndices,
+} from '../../../../src/services/server/stream'
+
+/**
+ * Stand-in for the response of a subscription, records what is written to it.
+ */
+class FakeResponse extends EventEmitter {
+  public statusCode = 0
+  public headers: Record<string, string> = {}
+  public chunks: string[] = []
+  public ended = false
+
+  public status(code: number): this {
+    this.statusCode = code
+    return this
+  }
+
+  public set(headers: Record<string, string>): this {
+    this.headers = { ...this.headers, ...headers }
+    return this
+  }
+
+  public flushHeaders(): void {
+    return
+  }
+
+  public write(chunk: string): boolean {
+    this.chunks.push(chunk)
+    return true
+  }
+
+  public end(): void {
+    this.ended = true
+  }
+}
+
+describe('IndexStream', () => {
+  let indices: StreamIndices
+  let stream: IndexStream
+  beforeEach(() => {
+    indices = { enqueue: 3, transaction: null, block: null, batch: 1 }
+    // Long intervals, the tests poll by hand
+    stream = new IndexStream(
+      async () => ({ ...indices }),
+      60_000,
+      60_000,
+      () => undefined
+    )
+  })
+  afterEach(() => {
+    stream.close()
+  })
+
+  it('should serve an event stream', () => {
+    const res = new FakeResponse()
+    stream.subscribe(res as any)
+    expect(res.statusCode).to.equal(200)
+    expect(res.headers['Content-Type']).to.equal('text/event-stream')
+    expect(stream.size).to.equal(1)
+  })
+
+  it('should push the indices that changed to every subscriber', async () => {
+    const first = new FakeResponse()
+    const second = new FakeResponse()
+    stream.subscribe(first as any)
+    stream.subscribe(second as any)
+
+    // The first poll records where the stream starts
+    expect(await stream.poll()).to.deep.equal([])
+
+    indices.enqueue = 4
+    indices.transaction = 10
+    expect(await stream.poll()).to.deep.equal([
+      { type: 'enqueue', index: 4 },
+      { type: 'transaction', index: 10 },
+    ])
+    for (const res of [first, second]) {
+      expect(res.chunks).to.deep.equal([
+        'event: enqueue\ndata: {"type":"enqueue","index":4}\n\n',
+        'event: transaction\ndata: {"type":"transaction","index":10}\n\n',
+      ])
+    }
+
+    // Nothing new was indexed
+    expect(await stream.poll()).to.deep.equal([])
+  })
+
+  it('should stop pushing to closed subscriptions', async () => {
+    const res = new FakeResponse()
+    stream.subscribe(res as any)
+    await stream.poll()
+
+    res.emit('close')
+    expect(stream.size).to.equal(0)
+    indices.batch = 2
+    await stream.poll()
+    expect(res.chunks).to.deep.equal([])
+  })
+
+  it('should end the subscriptions when closed', () => {
+    const res = new FakeResponse()
+    stream.subscribe(res as any)
+    stream.close()
+    expect(res.ended).to.equal(true)
+    expect(stream.size).to.equal(0)
+  })
+})